@@ -3,6 +3,7 @@
 package backend
 
 import (
+	"crypto/sha256"
 	"fmt"
 
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
@@ -15,6 +16,8 @@ import (
 //
 // There are different types of account codes:
 // - regular: for unified accounts
+// - multisig: for multisig accounts
+// - imported: for watch-only accounts imported from an xpub or descriptor
 // - erc20: for ERC20 token accounts
 
 // regularAccountCode returns an account code based on a keystore root fingerprint, a coin code and
@@ -23,6 +26,21 @@ func regularAccountCode(rootFingerprint []byte, coinCode coin.Code, accountNumbe
 	return accountsTypes.Code(fmt.Sprintf("v0-%x-%s-%d", rootFingerprint, coinCode, accountNumber))
 }
 
+// multisigAccountCode returns an account code based on a keystore root fingerprint, a coin code and
+// the descriptor of the multisig account. Multisig accounts are not numbered, so the code is
+// derived from the descriptor, which commits to the threshold and all cosigners.
+func multisigAccountCode(rootFingerprint []byte, coinCode coin.Code, descriptor string) accountsTypes.Code {
+	hash := sha256.Sum256([]byte(descriptor))
+	return accountsTypes.Code(fmt.Sprintf("v0-%x-%s-multisig-%x", rootFingerprint, coinCode, hash[:8]))
+}
+
+// importedAccountCode returns an account code based on the root fingerprint of the imported key, a
+// coin code and the descriptor of the account.
+func importedAccountCode(rootFingerprint []byte, coinCode coin.Code, descriptor string) accountsTypes.Code {
+	hash := sha256.Sum256([]byte(descriptor))
+	return accountsTypes.Code(fmt.Sprintf("v0-%x-%s-imported-%x", rootFingerprint, coinCode, hash[:8]))
+}
+
 // Erc20AccountCode returns the account code used for an ERC20 token.
 // It is derived from the account code of the parent ETH account and the token code.
 func Erc20AccountCode(ethereumAccountCode accountsTypes.Code, tokenCode string) accountsTypes.Code {
@@ -8,11 +8,13 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bitsurance"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
@@ -27,7 +29,6 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
-	"github.com/ethereum/go-ethereum/params"
 )
 
 const (
@@ -103,6 +104,28 @@ func (a AccountsList) lookupByTransactionInternalID(internalID string) (accounts
 	return nil, nil
 }
 
+// lookupByAddress finds the Bitcoin-based account which contains this address. The accounts are
+// initialized, and the address is found even if the account is not synced yet, see
+// btc.Account.DerivesAddress. `nil` is returned if not found.
+func (a AccountsList) lookupByAddress(address string) (accounts.Interface, error) {
+	for _, account := range a {
+		if account.FatalError() {
+			continue
+		}
+		btcAccount, ok := account.(*btc.Account)
+		if !ok {
+			continue
+		}
+		if err := account.Initialize(); err != nil {
+			return nil, err
+		}
+		if btcAccount.DerivesAddress(address) {
+			return account, nil
+		}
+	}
+	return nil, nil
+}
+
 func compareAccountCoins(coin1, coin2 coinpkg.Coin) int {
 	getOrder := func(c coinpkg.Coin) (int, bool) {
 		order, ok := map[coinpkg.Code]int{
@@ -118,11 +141,8 @@ func compareAccountCoins(coin1, coin2 coinpkg.Coin) int {
 		// different coin codes, so we use the chain ID.
 		ethCoin, ok := c.(*eth.Coin)
 		if ok {
-			switch ethCoin.ChainID() {
-			case params.MainnetChainConfig.ChainID.Uint64():
-				return 4, true
-			case params.SepoliaChainConfig.ChainID.Uint64():
-				return 5, true
+			if index := evmChainIndex(ethCoin.ChainID()); index != -1 {
+				return 4 + index, true
 			}
 		}
 		return 0, false
@@ -235,6 +255,7 @@ func (backend *Backend) SupportedCoins(keystore keystore.Keystore) []coinpkg.Cod
 		coinpkg.CodeBTC, coinpkg.CodeTBTC, coinpkg.CodeRBTC,
 		coinpkg.CodeLTC, coinpkg.CodeTLTC,
 		coinpkg.CodeETH, coinpkg.CodeSEPETH,
+		coinpkg.CodeARBETH, coinpkg.CodeOPETH, coinpkg.CodeBASEETH, coinpkg.CodePOL,
 	}
 	var availableCoins []coinpkg.Code
 	for _, coinCode := range allCoins {
@@ -649,10 +670,13 @@ func (backend *Backend) createAndPersistAccountConfig(
 			},
 			accountsConfig,
 		)
-	case coinpkg.CodeETH, coinpkg.CodeSEPETH:
-		bip44Coin := "1'"
-		if coinCode == coinpkg.CodeETH {
-			bip44Coin = "60'"
+	case coinpkg.CodeETH, coinpkg.CodeSEPETH,
+		coinpkg.CodeARBETH, coinpkg.CodeOPETH, coinpkg.CodeBASEETH, coinpkg.CodePOL:
+		// Accounts on other EVM chains use the Ethereum keypath, so they have the same address as
+		// the Ethereum account.
+		bip44Coin := "60'"
+		if coinCode == coinpkg.CodeSEPETH {
+			bip44Coin = "1'"
 		}
 		return accountCode, backend.persistETHAccountConfig(
 			keystore, accountCoin, accountCode, hiddenBecauseUnused,
@@ -689,6 +713,10 @@ func findHiddenAccount(
 		if len(account.SigningConfigurations) == 0 {
 			continue
 		}
+		if account.Imported || account.SigningConfigurations.IsMultisig() {
+			// Imported and multisig accounts are not part of the account numbering of the keystore.
+			continue
+		}
 		accountNumber, err := account.SigningConfigurations[0].AccountNumber()
 		if err != nil {
 			continue
@@ -719,6 +747,10 @@ func nextAccountNumber(coinCode coinpkg.Code, keystore keystore.Keystore, accoun
 		if len(account.SigningConfigurations) == 0 {
 			continue
 		}
+		if account.Imported || account.SigningConfigurations.IsMultisig() {
+			// Imported and multisig accounts are not part of the account numbering of the keystore.
+			continue
+		}
 		accountNumber, err := account.SigningConfigurations[0].AccountNumber()
 		if err != nil {
 			continue
@@ -803,6 +835,174 @@ func (backend *Backend) CreateAndPersistAccountConfig(
 	return accountCode, nil
 }
 
+// CreateMultisigAccount adds a wsh(sortedmulti()) multisig account for the given coin to the
+// accounts database, with the keystore as one of the cosigners. The keystore's key is the BIP-48
+// key at m/48'/coin'/0'/2'. `cosigners` are the key infos of the other cosigners. If they already
+// contain the keystore's key, e.g. when the cosigners were copied from a shared descriptor, it is
+// not added a second time.
+//
+// `name` is the account name, shown to the user. If empty, a default name will be set.
+func (backend *Backend) CreateMultisigAccount(
+	coinCode coinpkg.Code,
+	name string,
+	threshold uint32,
+	cosigners []signing.KeyInfo,
+	keystore keystore.Keystore,
+) (accountsTypes.Code, error) {
+	accountCoin, err := backend.Coin(coinCode)
+	if err != nil {
+		return "", err
+	}
+	btcCoin, ok := accountCoin.(*btc.Coin)
+	if !ok {
+		return "", errp.Newf("Multisig accounts are not supported for %s", coinCode)
+	}
+	bip44Coin := 1 + hardenedKeystart
+	switch coinCode {
+	case coinpkg.CodeBTC:
+		bip44Coin = hardenedKeystart
+	case coinpkg.CodeTBTC, coinpkg.CodeRBTC:
+	default:
+		return "", errp.Newf("Multisig accounts are not supported for %s", coinCode)
+	}
+	if !keystore.SupportsAccount(accountCoin, signing.ScriptTypeP2WSH) {
+		return "", errp.New("The keystore does not support multisig accounts")
+	}
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return "", err
+	}
+	keypath := signing.NewAbsoluteKeypathFromUint32(
+		48+hardenedKeystart, bip44Coin, hardenedKeystart, 2+hardenedKeystart)
+	extendedPublicKey, err := keystore.ExtendedPublicKey(accountCoin, keypath)
+	if err != nil {
+		return "", err
+	}
+	ourCosignerIndex := slices.IndexFunc(cosigners, func(cosigner signing.KeyInfo) bool {
+		return cosigner.ExtendedPublicKey.String() == extendedPublicKey.String()
+	})
+	if ourCosignerIndex == -1 {
+		ourCosignerIndex = len(cosigners)
+		cosigners = append(slices.Clone(cosigners), signing.KeyInfo{
+			RootFingerprint:   rootFingerprint,
+			AbsoluteKeypath:   keypath,
+			ExtendedPublicKey: extendedPublicKey,
+		})
+	}
+	signingConfiguration, err := signing.NewBitcoinMultisigConfiguration(
+		threshold, cosigners, ourCosignerIndex)
+	if err != nil {
+		return "", err
+	}
+	descriptor, err := signingConfiguration.BitcoinMultisig.Descriptor(btcCoin.Net())
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s %d-of-%d", accountCoin.Name(), threshold, len(cosigners))
+	}
+	accountCode := multisigAccountCode(rootFingerprint, coinCode, descriptor)
+	backend.log.
+		WithField("accountCode", accountCode).
+		WithField("coinCode", coinCode).
+		Info("Persisting new multisig account config")
+	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		return backend.persistAccount(config.Account{
+			CoinCode:              coinCode,
+			Name:                  name,
+			Code:                  accountCode,
+			SigningConfigurations: signing.Configurations{signingConfiguration},
+		}, accountsConfig)
+	})
+	if err != nil {
+		return "", err
+	}
+	backend.ReinitializeAccounts()
+	return accountCode, nil
+}
+
+// ImportAccount adds a watch-only account for the given Bitcoin-based coin from an extended public
+// key (xpub/ypub/zpub, ...) or an output descriptor, without a keystore. For extended public keys,
+// scriptType determines the type of addresses. If empty, it is inferred from the key version. The
+// account is always loaded. As it can't sign, sending from it produces an unsigned PSBT.
+//
+// `name` is the account name, shown to the user. If empty, a default name will be set.
+func (backend *Backend) ImportAccount(
+	coinCode coinpkg.Code,
+	name string,
+	extendedPublicKeyOrDescriptor string,
+	scriptType signing.ScriptType,
+) (accountsTypes.Code, error) {
+	accountCoin, err := backend.Coin(coinCode)
+	if err != nil {
+		return "", err
+	}
+	btcCoin, ok := accountCoin.(*btc.Coin)
+	if !ok {
+		return "", errp.Newf("Importing accounts is not supported for %s", coinCode)
+	}
+	var signingConfiguration *signing.Configuration
+	if strings.Contains(extendedPublicKeyOrDescriptor, "(") {
+		signingConfiguration, err = signing.NewConfigurationFromDescriptor(
+			extendedPublicKeyOrDescriptor, btcCoin.Net())
+	} else {
+		signingConfiguration, err = signing.NewConfigurationFromExtendedPublicKey(
+			extendedPublicKeyOrDescriptor, scriptType, btcCoin.Net())
+	}
+	if err != nil {
+		return "", err
+	}
+	var descriptor string
+	if signingConfiguration.BitcoinMultisig != nil {
+		descriptor, err = signingConfiguration.BitcoinMultisig.Descriptor(btcCoin.Net())
+	} else {
+		if signingConfiguration.ScriptType() == signing.ScriptTypeP2TR &&
+			(coinCode == coinpkg.CodeLTC || coinCode == coinpkg.CodeTLTC) {
+			return "", errp.New("Taproot is not supported for Litecoin")
+		}
+		descriptor, err = signingConfiguration.BitcoinSimple.Descriptor(btcCoin.Net())
+	}
+	if err != nil {
+		return "", err
+	}
+	rootFingerprint, err := signing.Configurations{signingConfiguration}.RootFingerprint()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s watch-only", accountCoin.Name())
+	}
+	accountCode := importedAccountCode(rootFingerprint, coinCode, descriptor)
+	backend.log.
+		WithField("accountCode", accountCode).
+		WithField("coinCode", coinCode).
+		Info("Persisting imported account config")
+	err = backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		err := backend.persistAccount(config.Account{
+			Imported:              true,
+			CoinCode:              coinCode,
+			Name:                  name,
+			Code:                  accountCode,
+			SigningConfigurations: signing.Configurations{signingConfiguration},
+		}, accountsConfig)
+		if err != nil {
+			return err
+		}
+		// Accounts are grouped by keystore, so we add one for the account if it does not exist yet.
+		if _, err := accountsConfig.LookupKeystore(rootFingerprint); err != nil {
+			keystore := accountsConfig.GetOrAddKeystore(rootFingerprint)
+			keystore.Name = name
+			keystore.Watchonly = true
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	backend.ReinitializeAccounts()
+	return accountCode, nil
+}
+
 // SetAccountActive activates/deactivates an account.
 func (backend *Backend) SetAccountActive(accountCode accountsTypes.Code, active bool) error {
 	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
@@ -1055,6 +1255,9 @@ func (backend *Backend) createAndAddAccount(coin coinpkg.Coin, persistedConfig *
 		SkipInitialSync: backend.skipETHInitialSync,
 		NotesFolder:     backend.arguments.NotesDirectoryPath(),
 		ConnectKeystore: func() (keystore.Keystore, error) {
+			if persistedConfig.Imported {
+				return nil, errp.WithStack(errors.ErrNoKeystore)
+			}
 			accountRootFingerprint, err := persistedConfig.SigningConfigurations.RootFingerprint()
 			if err != nil {
 				return nil, err
@@ -1065,6 +1268,9 @@ func (backend *Backend) createAndAddAccount(coin coinpkg.Coin, persistedConfig *
 		GetMainCurrency: func() string {
 			return backend.config.AppConfig().Backend.MainFiat
 		},
+		ENSReverseLookup: func() bool {
+			return backend.config.AppConfig().Backend.ETH.ENSReverseLookup
+		},
 		GetNotifier: func(configurations signing.Configurations) accounts.Notifier {
 			return backend.notifier.ForAccount(persistedConfig.Code)
 		},
@@ -1079,7 +1285,9 @@ func (backend *Backend) createAndAddAccount(coin coinpkg.Coin, persistedConfig *
 		if err != nil {
 			return nil, err
 		}
-		rootFingerprint, err := backend.keystore.RootFingerprint()
+		// The keystore might not be connected, e.g. for imported watch-only accounts, so we use the
+		// root fingerprint of the account.
+		rootFingerprint, err := persistedConfig.SigningConfigurations.RootFingerprint()
 		if err != nil {
 			return nil, err
 		}
@@ -1166,9 +1374,20 @@ func (backend *Backend) persistAccount(account config.Account, accountsConfig *c
 		}
 		if account.CoinCode == account2.CoinCode {
 			// We detect a duplicate account (subaccount in a unified account) if any of the
-			// configurations is already present.
+			// configurations is already present. The same xpub can be used in several multisig
+			// accounts with different cosigners, so these are compared by their descriptor.
+			isMultisig := account.SigningConfigurations.IsMultisig()
+			if isMultisig != account2.SigningConfigurations.IsMultisig() {
+				continue
+			}
 			for _, config := range account.SigningConfigurations {
 				for _, config2 := range account2.SigningConfigurations {
+					if isMultisig {
+						if sameMultisig(config.BitcoinMultisig, config2.BitcoinMultisig) {
+							return errp.WithStack(errAccountAlreadyExists)
+						}
+						continue
+					}
 					if config.ExtendedPublicKey().String() == config2.ExtendedPublicKey().String() {
 						return errp.WithStack(errAccountAlreadyExists)
 					}
@@ -1181,6 +1400,22 @@ func (backend *Backend) persistAccount(account config.Account, accountsConfig *c
 	return nil
 }
 
+// sameMultisig returns true if both multisig configurations produce the same addresses. The order
+// of the cosigners does not matter, as the keys are sorted in the script.
+func sameMultisig(multisig1, multisig2 *signing.BitcoinMultisig) bool {
+	xpubs := func(multisig *signing.BitcoinMultisig) []string {
+		result := make([]string, len(multisig.Cosigners))
+		for i, cosigner := range multisig.Cosigners {
+			result[i] = cosigner.ExtendedPublicKey.String()
+		}
+		slices.Sort(result)
+		return result
+	}
+	return multisig1.Threshold == multisig2.Threshold &&
+		multisig1.ScriptType == multisig2.ScriptType &&
+		slices.Equal(xpubs(multisig1), xpubs(multisig2))
+}
+
 type scriptTypeWithKeypath struct {
 	scriptType signing.ScriptType
 	keypath    signing.AbsoluteKeypath
@@ -1429,6 +1664,8 @@ func (backend *Backend) maybeAddP2TR(keystore keystore.Keystore, accounts []*con
 				return err
 			}
 			if keystore.SupportsAccount(accountCoin, signing.ScriptTypeP2TR) &&
+				!account.Imported &&
+				!account.SigningConfigurations.IsMultisig() &&
 				account.SigningConfigurations.FindScriptType(signing.ScriptTypeP2TR) == -1 {
 				rootFingerprint, err := backend.keystore.RootFingerprint()
 				if err != nil {
@@ -1587,6 +1824,9 @@ func (backend *Backend) maybeAddHiddenUnusedAccounts() {
 			if !accountConfig.SigningConfigurations.ContainsRootFingerprint(rootFingerprint) {
 				continue
 			}
+			if accountConfig.Imported || accountConfig.SigningConfigurations.IsMultisig() {
+				continue
+			}
 			accountNumber, err := accountConfig.SigningConfigurations[0].AccountNumber()
 			if err != nil {
 				continue
@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/paymentrequest"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
@@ -36,7 +37,10 @@ func FindAddressListByScriptType(addressLists []AddressList, scriptType signing.
 type TxProposalArgs struct {
 	RecipientAddress string
 	Amount           coin.SendAmount
-	FeeTargetCode    FeeTargetCode
+	// Recipients are the recipients of a batch payout. If not empty, RecipientAddress and Amount
+	// are ignored. At most one recipient can receive all remaining funds ("send all").
+	Recipients    []TxRecipient
+	FeeTargetCode FeeTargetCode
 	// Only applies if FeeTargetCode == Custom. It is provided in sat/vB for BTC/LTC and Gwei for ETH.
 	CustomFee string
 	// Option to always use the highest fee rate without specifying FeeTargetCode or CustomFee
@@ -44,6 +48,18 @@ type TxProposalArgs struct {
 	SelectedUTXOs  map[wire.OutPoint]struct{}
 	Note           string
 	PaymentRequest *paymentrequest.Request
+	// CoinSelection is the algorithm selecting the inputs. Only applies to BTC/LTC, and is ignored
+	// if the amount is "send all".
+	CoinSelection maketx.CoinSelectionStrategy
+	// PayjoinURL is the BIP-78 payjoin endpoint of the recipient, e.g. from the `pj` parameter of a
+	// BIP-21 URI. Only applies to BTC/LTC transactions with a single recipient.
+	PayjoinURL string
+}
+
+// TxRecipient is one of multiple recipients of a transaction, see TxProposalArgs.Recipients.
+type TxRecipient struct {
+	Address string
+	Amount  coin.SendAmount
 }
 
 // Interface is the API of a Account.
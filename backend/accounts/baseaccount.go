@@ -45,8 +45,10 @@ type AccountConfig struct {
 	RateUpdater     *rates.RateUpdater
 	// Returns the currency selected by the user in app settings.
 	GetMainCurrency func() string
-	GetNotifier     func(signing.Configurations) Notifier
-	GetSaveFilename func(suggestedFilename string) string
+	// Returns true if the ENS names of the counterparties of ETH transactions should be looked up.
+	ENSReverseLookup func() bool
+	GetNotifier      func(signing.Configurations) Notifier
+	GetSaveFilename  func(suggestedFilename string) string
 	// Opens a file in a default application. The filename is not checked.
 	UnsafeSystemOpen func(filename string) error
 }
@@ -233,13 +235,17 @@ func (account *BaseAccount) SetTxNote(txID string, note string) error {
 	if _, err := account.notes.SetTxNote(txID, note); err != nil {
 		return err
 	}
-	// Prompt refresh.
+	account.notesChanged()
+	return nil
+}
+
+// notesChanged prompts a refresh of the account after notes were modified.
+func (account *BaseAccount) notesChanged() {
 	account.Notify(observable.Event{
 		Subject: string(types.EventStatusChanged),
 		Action:  action.Reload,
 		Object:  nil,
 	})
-	return nil
 }
 
 // TxNote fetches a note for a transaction. Returns the empty string if no note was found.
@@ -247,6 +253,45 @@ func (account *BaseAccount) TxNote(txID string) string {
 	return account.notes.TxNote(txID)
 }
 
+// SetOutputLabel sets the label of an output, identified by its outpoint `<txID>:<output index>`,
+// and refreshes the account.
+func (account *BaseAccount) SetOutputLabel(outPoint string, label string) error {
+	if _, err := account.notes.SetOutputLabel(outPoint, label); err != nil {
+		return err
+	}
+	account.notesChanged()
+	return nil
+}
+
+// SetOutputFrozen freezes or unfreezes an output, identified by its outpoint `<txID>:<output
+// index>`, and refreshes the account. Frozen outputs are not spent unless explicitly selected.
+func (account *BaseAccount) SetOutputFrozen(outPoint string, frozen bool) error {
+	if _, err := account.notes.SetOutputFrozen(outPoint, frozen); err != nil {
+		return err
+	}
+	account.notesChanged()
+	return nil
+}
+
+// SetAddressLabel sets the label of an address and refreshes the account.
+func (account *BaseAccount) SetAddressLabel(address string, label string) error {
+	if _, err := account.notes.SetAddressLabel(address, label); err != nil {
+		return err
+	}
+	account.notesChanged()
+	return nil
+}
+
+// SetInputLabel sets the label of a transaction input, identified by `<txID>:<input index>`, and
+// refreshes the account.
+func (account *BaseAccount) SetInputLabel(input string, label string) error {
+	if _, err := account.notes.SetInputLabel(input, label); err != nil {
+		return err
+	}
+	account.notesChanged()
+	return nil
+}
+
 // ExportCSV implements accounts.Account.
 func (account *BaseAccount) ExportCSV(w io.Writer, transactions []*TransactionData) error {
 	writer := csv.NewWriter(w)
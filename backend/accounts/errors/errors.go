@@ -29,6 +29,13 @@ var (
 	ErrFeeTooLow = TxValidationError("feeTooLow")
 	// ErrAccountNotsynced is used when the account sync has not successfully finished.
 	ErrAccountNotsynced = TxValidationError("accountNotSynced")
+	// ErrTxNotReplaceable is returned when a transaction cannot be replaced by a transaction with a
+	// higher fee, e.g. because it is already confirmed or does not signal replace-by-fee.
+	ErrTxNotReplaceable = TxValidationError("txNotReplaceable")
+	// ErrTxNotAcceleratable is returned when a child-pays-for-parent transaction can't be created
+	// for a transaction, e.g. because it is already confirmed or has no unspent outputs belonging to
+	// the account.
+	ErrTxNotAcceleratable = TxValidationError("txNotAcceleratable")
 
 	// ErrNotAvailable is returned if data required is not available yet. Example: the headers are
 	// not synced yet, which is a prerequisite to making a timeseries of the portfolio.
@@ -36,4 +43,12 @@ var (
 
 	// ErrERC20InsufficientGasFunds is returned when there is not enough ETH to pay the erc20 transaction fee.
 	ErrERC20InsufficientGasFunds = errpkg.New("erc20InsufficientGasFunds")
+
+	// ErrMissingCosignerSignatures is returned when a multisig transaction was signed by the
+	// keystore, but still needs signatures of other cosigners before it can be broadcast.
+	ErrMissingCosignerSignatures = errpkg.New("missingCosignerSignatures")
+
+	// ErrNoKeystore is returned when an action requires a keystore, but the account was imported
+	// from an extended public key or descriptor and has none.
+	ErrNoKeystore = errpkg.New("noKeystore")
 )
@@ -14,12 +14,24 @@ import (
 // MaxNoteLen is the maximum length per note.
 const MaxNoteLen = 1024
 
+// OutputNote contains the user data of a transaction output.
+type OutputNote struct {
+	// Label is the user provided label of the output.
+	Label string `json:"label,omitempty"`
+	// Frozen is true if the output must not be spent unless it is explicitly selected.
+	Frozen bool `json:"frozen,omitempty"`
+}
+
 // Data is the notes JSON data serialized to disk.
 type Data struct {
-	// More fields to be added when we can label more stuff, e.g. receive addresses, utxos, etc.
-
 	// a map of transaction ID to transaction note.
 	TransactionNotes map[string]string `json:"transactions"`
+	// a map of outpoint (`<txID>:<output index>`) to output note.
+	OutputNotes map[string]OutputNote `json:"outputs,omitempty"`
+	// a map of address (encoded as shown to the user) to address label.
+	AddressLabels map[string]string `json:"addresses,omitempty"`
+	// a map of input (`<txID>:<input index>` of the spending transaction) to input label.
+	InputLabels map[string]string `json:"inputs,omitempty"`
 }
 
 // read deserializes the json files into notes. If the file does not exist yet, no error is
@@ -74,10 +86,10 @@ func LoadNotes(filename string) (*Notes, error) {
 	}, nil
 }
 
-// SetTxNote stores a note for a transaction. An empty note will result in the entry being deleted
-// (or not written if it didn't exist), since `TxNote()` returns an empty string anyway if there is
-// no note. Returns whether the note was modified.
-func (notes *Notes) SetTxNote(txID string, note string) (bool, error) {
+// setNote stores a note in the map returned by `getMap`, which is created if needed. An empty note
+// will result in the entry being deleted (or not written if it didn't exist). Returns whether the
+// note was modified.
+func (notes *Notes) setNote(getMap func(*Data) *map[string]string, key string, note string) (bool, error) {
 	notes.dataMu.Lock()
 	defer notes.dataMu.Unlock()
 
@@ -85,20 +97,28 @@ func (notes *Notes) SetTxNote(txID string, note string) (bool, error) {
 		return false, errp.Newf("Length of note must be smaller than %d. Got %d", MaxNoteLen, len(note))
 	}
 
-	if notes.data.TransactionNotes == nil {
-		notes.data.TransactionNotes = map[string]string{}
+	m := getMap(notes.data)
+	if *m == nil {
+		*m = map[string]string{}
 	}
-	changed := notes.data.TransactionNotes[txID] != note
+	changed := (*m)[key] != note
 	if note == "" {
 		// Since not existing entries are returned as `""` anyway, there no need to actually store
 		// them in the JSON file.
-		delete(notes.data.TransactionNotes, txID)
+		delete(*m, key)
 	} else {
-		notes.data.TransactionNotes[txID] = note
+		(*m)[key] = note
 	}
 	return changed, write(notes.data, notes.filename)
 }
 
+// SetTxNote stores a note for a transaction. An empty note will result in the entry being deleted
+// (or not written if it didn't exist), since `TxNote()` returns an empty string anyway if there is
+// no note. Returns whether the note was modified.
+func (notes *Notes) SetTxNote(txID string, note string) (bool, error) {
+	return notes.setNote(func(data *Data) *map[string]string { return &data.TransactionNotes }, txID, note)
+}
+
 // TxNote fetches a note for a transaction. Returns the empty string if no note was found.
 func (notes *Notes) TxNote(txID string) string {
 	notes.dataMu.RLock()
@@ -107,6 +127,81 @@ func (notes *Notes) TxNote(txID string) string {
 	return notes.data.TransactionNotes[txID]
 }
 
+// SetAddressLabel stores a label for an address. An empty label removes the label. Returns whether
+// the label was modified.
+func (notes *Notes) SetAddressLabel(address string, label string) (bool, error) {
+	return notes.setNote(func(data *Data) *map[string]string { return &data.AddressLabels }, address, label)
+}
+
+// AddressLabel fetches the label of an address. Returns the empty string if no label was found.
+func (notes *Notes) AddressLabel(address string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.AddressLabels[address]
+}
+
+// SetInputLabel stores a label for a transaction input, identified by `<txID>:<input index>`. An
+// empty label removes the label. Returns whether the label was modified.
+func (notes *Notes) SetInputLabel(input string, label string) (bool, error) {
+	return notes.setNote(func(data *Data) *map[string]string { return &data.InputLabels }, input, label)
+}
+
+// InputLabel fetches the label of a transaction input. Returns the empty string if no label was
+// found.
+func (notes *Notes) InputLabel(input string) string {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.InputLabels[input]
+}
+
+// setOutputNote updates the note of an output using the modify function and persists it. Empty
+// notes are deleted. Returns whether the note was modified.
+func (notes *Notes) setOutputNote(outPoint string, modify func(*OutputNote)) (bool, error) {
+	notes.dataMu.Lock()
+	defer notes.dataMu.Unlock()
+
+	if notes.data.OutputNotes == nil {
+		notes.data.OutputNotes = map[string]OutputNote{}
+	}
+	note := notes.data.OutputNotes[outPoint]
+	modify(&note)
+	if note == notes.data.OutputNotes[outPoint] {
+		return false, nil
+	}
+	if note == (OutputNote{}) {
+		delete(notes.data.OutputNotes, outPoint)
+	} else {
+		notes.data.OutputNotes[outPoint] = note
+	}
+	return true, write(notes.data, notes.filename)
+}
+
+// SetOutputLabel stores a label for an output, identified by its outpoint `<txID>:<output index>`.
+// An empty label removes the label. Returns whether the label was modified.
+func (notes *Notes) SetOutputLabel(outPoint string, label string) (bool, error) {
+	if len(label) > MaxNoteLen {
+		return false, errp.Newf("Length of note must be smaller than %d. Got %d", MaxNoteLen, len(label))
+	}
+	return notes.setOutputNote(outPoint, func(note *OutputNote) { note.Label = label })
+}
+
+// SetOutputFrozen freezes or unfreezes an output, identified by its outpoint `<txID>:<output
+// index>`. Frozen outputs are not spent unless explicitly selected. Returns whether the state was
+// modified.
+func (notes *Notes) SetOutputFrozen(outPoint string, frozen bool) (bool, error) {
+	return notes.setOutputNote(outPoint, func(note *OutputNote) { note.Frozen = frozen })
+}
+
+// OutputNote fetches the note of an output. Returns the empty note if no note was found.
+func (notes *Notes) OutputNote(outPoint string) OutputNote {
+	notes.dataMu.RLock()
+	defer notes.dataMu.RUnlock()
+
+	return notes.data.OutputNotes[outPoint]
+}
+
 // Data retrieves all stored notes. You must not modify the returned object.
 func (notes *Notes) Data() *Data {
 	notes.dataMu.RLock()
@@ -130,5 +225,25 @@ func (notes *Notes) MergeLegacy(legacy *Notes) error {
 			notes.data.TransactionNotes[txID] = note
 		}
 	}
+	mergeLabels := func(labels *map[string]string, legacyLabels map[string]string) {
+		for key, label := range legacyLabels {
+			if _, ok := (*labels)[key]; !ok {
+				if *labels == nil {
+					*labels = map[string]string{}
+				}
+				(*labels)[key] = label
+			}
+		}
+	}
+	mergeLabels(&notes.data.AddressLabels, legacyData.AddressLabels)
+	mergeLabels(&notes.data.InputLabels, legacyData.InputLabels)
+	for outPoint, note := range legacyData.OutputNotes {
+		if _, ok := notes.data.OutputNotes[outPoint]; !ok {
+			if notes.data.OutputNotes == nil {
+				notes.data.OutputNotes = map[string]OutputNote{}
+			}
+			notes.data.OutputNotes[outPoint] = note
+		}
+	}
 	return write(notes.data, notes.filename)
 }
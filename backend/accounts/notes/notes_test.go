@@ -116,3 +116,76 @@ func TestMergeLegacy(t *testing.T) {
 		},
 		notes.Data())
 }
+
+func TestOutputNotes(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+
+	const outPoint = "tx-id-1:0"
+	require.Equal(t, OutputNote{}, notes.OutputNote(outPoint))
+
+	changed, err := notes.SetOutputLabel(outPoint, "kyc")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetOutputLabel(outPoint, "kyc")
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	changed, err = notes.SetOutputFrozen(outPoint, true)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, OutputNote{Label: "kyc", Frozen: true}, notes.OutputNote(outPoint))
+
+	_, err = notes.SetOutputLabel(outPoint, strings.Repeat("x", 1025))
+	require.Error(t, err)
+
+	// Reload notes.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, OutputNote{Label: "kyc", Frozen: true}, notes.OutputNote(outPoint))
+
+	// Empty notes are removed.
+	_, err = notes.SetOutputLabel(outPoint, "")
+	require.NoError(t, err)
+	_, err = notes.SetOutputFrozen(outPoint, false)
+	require.NoError(t, err)
+	require.Empty(t, notes.Data().OutputNotes)
+}
+
+func TestAddressAndInputLabels(t *testing.T) {
+	filename := test.TstTempFile("account-notes")
+	notes, err := LoadNotes(filename)
+	require.NoError(t, err)
+
+	const address = "bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3"
+	const input = "tx-id-1:1"
+	require.Equal(t, "", notes.AddressLabel(address))
+	require.Equal(t, "", notes.InputLabel(input))
+
+	changed, err := notes.SetAddressLabel(address, "donations")
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = notes.SetAddressLabel(address, "donations")
+	require.NoError(t, err)
+	require.False(t, changed)
+	changed, err = notes.SetInputLabel(input, "consolidation")
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	_, err = notes.SetAddressLabel(address, strings.Repeat("x", 1025))
+	require.Error(t, err)
+
+	// Reload notes.
+	notes, err = LoadNotes(filename)
+	require.NoError(t, err)
+	require.Equal(t, "donations", notes.AddressLabel(address))
+	require.Equal(t, "consolidation", notes.InputLabel(input))
+
+	_, err = notes.SetAddressLabel(address, "")
+	require.NoError(t, err)
+	_, err = notes.SetInputLabel(input, "")
+	require.NoError(t, err)
+	require.Empty(t, notes.Data().AddressLabels)
+	require.Empty(t, notes.Data().InputLabels)
+}
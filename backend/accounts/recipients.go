@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package accounts
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ParseRecipientsCSV parses the recipients of a batch payout from CSV with one `address,amount` row
+// per recipient. The amount is in the unit shown to the user, or "all" to send all remaining funds
+// to the recipient. An optional header row starting with "address" is skipped.
+func ParseRecipientsCSV(r io.Reader) ([]TxRecipient, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	recipients := []TxRecipient{}
+	for i, record := range records {
+		address := strings.TrimSpace(record[0])
+		amount := strings.TrimSpace(record[1])
+		if i == 0 && strings.EqualFold(address, "address") {
+			continue
+		}
+		if address == "" {
+			return nil, errp.Newf("Missing address in line %d", i+1)
+		}
+		recipient := TxRecipient{Address: address}
+		if strings.EqualFold(amount, "all") {
+			recipient.Amount = coin.NewSendAmountAll()
+		} else {
+			recipient.Amount = coin.NewSendAmount(amount)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if len(recipients) == 0 {
+		return nil, errp.New("No recipients")
+	}
+	return recipients, nil
+}
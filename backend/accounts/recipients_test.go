@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package accounts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecipientsCSV(t *testing.T) {
+	recipients, err := ParseRecipientsCSV(strings.NewReader(
+		"address,amount\nbc1qaddress1, 0.001\n bc1qaddress2,ALL\n"))
+	require.NoError(t, err)
+	require.Equal(t, []TxRecipient{
+		{Address: "bc1qaddress1", Amount: coin.NewSendAmount("0.001")},
+		{Address: "bc1qaddress2", Amount: coin.NewSendAmountAll()},
+	}, recipients)
+
+	// Without header.
+	recipients, err = ParseRecipientsCSV(strings.NewReader("bc1qaddress1,1"))
+	require.NoError(t, err)
+	require.Equal(t, []TxRecipient{{Address: "bc1qaddress1", Amount: coin.NewSendAmount("1")}}, recipients)
+
+	_, err = ParseRecipientsCSV(strings.NewReader(""))
+	require.Error(t, err)
+	_, err = ParseRecipientsCSV(strings.NewReader("address,amount\n"))
+	require.Error(t, err)
+	_, err = ParseRecipientsCSV(strings.NewReader("bc1qaddress1,1,extra\n"))
+	require.Error(t, err)
+	_, err = ParseRecipientsCSV(strings.NewReader(",1\n"))
+	require.Error(t, err)
+}
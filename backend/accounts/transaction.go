@@ -40,6 +40,22 @@ const (
 	TxStatusFailed TxStatus = "failed"
 )
 
+// TxVerification is the SPV verification state of a BTC/LTC transaction, i.e. whether it was
+// proven to be included in a block of our header chain using a merkle proof of the server.
+type TxVerification string
+
+const (
+	// TxVerificationUnverified means the tx was not verified yet, e.g. because it is unconfirmed or
+	// the headers are not synced yet.
+	TxVerificationUnverified TxVerification = "unverified"
+	// TxVerificationVerified means the merkle proof of the tx matched the header at the height of
+	// the tx.
+	TxVerificationVerified TxVerification = "verified"
+	// TxVerificationFailed means the merkle proof provided by the server did not match our header.
+	// The server might be lying about the tx.
+	TxVerificationFailed TxVerification = "failed"
+)
+
 // AddressAndAmount holds an address and the corresponding amount.
 type AddressAndAmount struct {
 	Address string
@@ -47,6 +63,8 @@ type AddressAndAmount struct {
 	Amount coin.Amount
 	// Ours is true if the address is one of our receive addresses.
 	Ours bool
+	// ENSName is the primary ENS name of the address, if it was looked up. Only used by ETH.
+	ENSName string
 }
 
 // TransactionData holds transaction data to be shown to the user. It is as coin-agnostic as
@@ -108,6 +126,11 @@ type TransactionData struct {
 	// Weight is the tx weight.
 	Weight           int64
 	CreatedTimestamp *time.Time
+	// Verification is the SPV verification state of the tx.
+	Verification TxVerification
+	// VerifiedHeight is the height of the header the tx was verified against. Only set if
+	// Verification is TxVerificationVerified.
+	VerifiedHeight int
 
 	// --- Fields only used for ETH follow
 
@@ -15,4 +15,8 @@ const (
 
 	// EventSyncedAddressesCount is emitted when the frontend should receives a sync progress update.
 	EventSyncedAddressesCount Event = "synced-addresses-count"
+
+	// EventSPVVerificationFailed is emitted when the server provided a merkle proof for a
+	// transaction which does not match our headers. The object is the transaction ID.
+	EventSPVVerificationFailed Event = "spv-verification-failed"
 )
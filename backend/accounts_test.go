@@ -3,9 +3,12 @@
 package backend
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +22,8 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	keystorepkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
 	keystoremock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
@@ -31,6 +36,9 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -141,6 +149,89 @@ func TestAccounts(t *testing.T) {
 	require.Equal(t, "My ETH Renamed", b.Accounts().lookup("v0-55555555-eth-0").Config().Config.Name)
 }
 
+// TestAddCustomERC20Token tests adding a token by its contract address, which is then loaded like
+// a built-in token.
+func TestAddCustomERC20Token(t *testing.T) {
+	ks := makeBitBox02Multi()
+	ks.RootFingerprintFunc = func() ([]byte, error) {
+		return rootFingerprint1, nil
+	}
+
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	const contractAddress = "0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984"
+	parsed, err := abi.JSON(strings.NewReader(erc20.IERC20MetadataABI))
+	require.NoError(t, err)
+	ethCoin, err := b.Coin(coinpkg.CodeETH)
+	require.NoError(t, err)
+	client := &mocks.InterfaceMock{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, common.HexToAddress(contractAddress), *msg.To)
+			method, err := parsed.MethodById(msg.Data)
+			require.NoError(t, err)
+			switch method.Name {
+			case "name":
+				return method.Outputs.Pack("Uniswap")
+			case "symbol":
+				return method.Outputs.Pack("UNI")
+			default:
+				return method.Outputs.Pack(uint8(18))
+			}
+		},
+	}
+	ethCoin.(*eth.Coin).TstSetClient(client)
+
+	b.registerKeystore(ks)
+	const ethAccountCode = "v0-55555555-eth-0"
+	const tokenCode = coinpkg.Code("eth-erc20-0x1f9840a85d5af5bf1d1762f925bdaddc4201f984")
+
+	_, err = b.AddCustomERC20Token(ethAccountCode, "0x1234")
+	require.Error(t, err)
+	_, err = b.AddCustomERC20Token("v0-55555555-btc-0", contractAddress)
+	require.Error(t, err)
+
+	code, err := b.AddCustomERC20Token(ethAccountCode, contractAddress)
+	require.NoError(t, err)
+	require.Equal(t, tokenCode, code)
+	require.Equal(t,
+		[]config.CustomERC20Token{{
+			Code:            tokenCode,
+			Chain:           coinpkg.CodeETH,
+			ContractAddress: contractAddress,
+			Name:            "Uniswap",
+			Unit:            "UNI",
+			Decimals:        18,
+		}},
+		b.CustomERC20Tokens(),
+	)
+	require.Equal(t, []string{string(tokenCode)}, b.Config().AccountsConfig().Lookup(ethAccountCode).ActiveTokens)
+	tokenAccount := b.Accounts().lookup(Erc20AccountCode(ethAccountCode, string(tokenCode)))
+	require.NotNil(t, tokenAccount)
+	tokenCoin := tokenAccount.Coin().(*eth.Coin)
+	require.Equal(t, "UNI", tokenCoin.Unit(false))
+	require.Equal(t, "ETH", tokenCoin.Unit(true))
+	require.Equal(t, common.HexToAddress(contractAddress), tokenCoin.ERC20Token().ContractAddress())
+	require.Equal(t, uint(18), tokenCoin.ERC20Token().Decimals())
+
+	// Adding it again does not query the contract again or duplicate the token.
+	callsBefore := len(client.CallContractCalls())
+	_, err = b.AddCustomERC20Token(ethAccountCode, strings.ToLower(contractAddress))
+	require.NoError(t, err)
+	require.Len(t, b.CustomERC20Tokens(), 1)
+	require.Len(t, client.CallContractCalls(), callsBefore)
+
+	// Adding a built-in token by its contract address activates the built-in token.
+	code, err = b.AddCustomERC20Token(ethAccountCode, "0xdac17f958d2ee523a2206206994597c13d831ec7")
+	require.NoError(t, err)
+	require.Equal(t, coinpkg.Code("eth-erc20-usdt"), code)
+	require.Len(t, b.CustomERC20Tokens(), 1)
+	require.Equal(t,
+		[]string{string(tokenCode), "eth-erc20-usdt"},
+		b.Config().AccountsConfig().Lookup(ethAccountCode).ActiveTokens,
+	)
+}
+
 func TestSetAccountReceiveScriptType(t *testing.T) {
 	ks := makeBitBox02Multi()
 	ks.RootFingerprintFunc = func() ([]byte, error) {
@@ -433,7 +524,10 @@ func TestSupportedCoins(t *testing.T) {
 		b := newBackend(t, testnetDisabled, regtestDisabled)
 		defer b.Close()
 		require.Equal(t,
-			[]coinpkg.Code{coinpkg.CodeBTC, coinpkg.CodeLTC, coinpkg.CodeETH},
+			[]coinpkg.Code{
+				coinpkg.CodeBTC, coinpkg.CodeLTC, coinpkg.CodeETH,
+				coinpkg.CodeARBETH, coinpkg.CodeOPETH, coinpkg.CodeBASEETH, coinpkg.CodePOL,
+			},
 			b.SupportedCoins(&keystoremock.KeystoreMock{
 				SupportsCoinFunc: func(coin coinpkg.Coin) bool {
 					return true
@@ -636,6 +730,27 @@ func TestCreateAndPersistAccountConfig(t *testing.T) {
 			b.Config().AccountsConfig().Lookup("v0-55555555-eth-2"),
 		)
 
+		// Add an Arbitrum account. It uses the same keypath as the first Ethereum account, so it has
+		// the same address.
+		acctCode, err = b.CreateAndPersistAccountConfig(
+			coinpkg.CodeARBETH,
+			"arbitrum",
+			bitbox02LikeKeystore,
+		)
+		require.NoError(t, err)
+		require.Equal(t, "v0-55555555-arbeth-0", string(acctCode))
+		require.Equal(t,
+			&config.Account{
+				CoinCode: "arbeth",
+				Name:     "arbitrum",
+				Code:     "v0-55555555-arbeth-0",
+				SigningConfigurations: signing.Configurations{
+					signing.NewEthereumConfiguration(rootFingerprint1, mustKeypath("m/44'/60'/0'/0/0"), test.TstMustXKey("xpub6GP83vJASH1kS7dQPWXFjVHDfYajopbG8U3j8peBH67CRCnb8QmDxZJfWpbgCQNHAzCDJ4MyVYjoh7Yv9yo7PQuZ9YyktgrtD9vmeo67Y4E")),
+				},
+			},
+			b.Config().AccountsConfig().Lookup("v0-55555555-arbeth-0"),
+		)
+
 		// Add BTC/LTC hidden accounts for scanning.
 		b.maybeAddHiddenUnusedAccounts()
 		require.Equal(t,
@@ -1729,3 +1844,129 @@ func TestCheckAccountUsed(t *testing.T) {
 	}
 
 }
+
+func TestCreateMultisigAccount(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	ks := makeBitBox02Multi()
+	b.registerKeystore(ks)
+
+	cosigners := make([]signing.KeyInfo, 2)
+	for i := range cosigners {
+		seed := make([]byte, hdkeychain.RecommendedSeedLen)
+		seed[0] = byte(i + 1)
+		xprv, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		cosigners[i] = signing.KeyInfo{
+			RootFingerprint:   []byte{byte(i + 1), 2, 3, 4},
+			AbsoluteKeypath:   mustKeypath("m/48'/0'/0'/2'"),
+			ExtendedPublicKey: xpub,
+		}
+	}
+
+	accountName, ok := b.CanAddAccount(coinpkg.CodeBTC, ks)
+	require.True(t, ok)
+
+	acctCode, err := b.CreateMultisigAccount(coinpkg.CodeBTC, "", 2, cosigners, ks)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(acctCode), "v0-55555555-btc-multisig-"))
+	acct := b.Config().AccountsConfig().Lookup(acctCode)
+	require.NotNil(t, acct)
+	require.Equal(t, "Bitcoin 2-of-3", acct.Name)
+	require.Len(t, acct.SigningConfigurations, 1)
+	multisig := acct.SigningConfigurations[0].BitcoinMultisig
+	require.NotNil(t, multisig)
+	require.Equal(t, uint32(2), multisig.Threshold)
+	require.Len(t, multisig.Cosigners, 3)
+	require.Equal(t, 2, multisig.OurCosignerIndex)
+	require.Equal(t, rootFingerprint1, multisig.OurKeyInfo().RootFingerprint)
+	require.Equal(t, "m/48'/0'/0'/2'", multisig.OurKeyInfo().AbsoluteKeypath.Encode())
+	require.NotNil(t, b.Accounts().lookup(acctCode))
+
+	// Multisig accounts do not affect the account numbering of the keystore.
+	nextAccountName, ok := b.CanAddAccount(coinpkg.CodeBTC, ks)
+	require.True(t, ok)
+	require.Equal(t, accountName, nextAccountName)
+
+	// The same multisig can't be added twice, regardless of the order of the cosigners.
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "", 2, cosigners, ks)
+	require.Equal(t, errAccountAlreadyExists, errp.Cause(err))
+	reordered := []signing.KeyInfo{multisig.OurKeyInfo(), cosigners[1], cosigners[0]}
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "", 2, reordered, ks)
+	require.Equal(t, errAccountAlreadyExists, errp.Cause(err))
+
+	// A different threshold is a different account.
+	acctCode2, err := b.CreateMultisigAccount(coinpkg.CodeBTC, "multisig", 1, cosigners, ks)
+	require.NoError(t, err)
+	require.NotEqual(t, acctCode, acctCode2)
+
+	_, err = b.CreateMultisigAccount(coinpkg.CodeBTC, "", 4, cosigners, ks)
+	require.Error(t, err)
+	_, err = b.CreateMultisigAccount(coinpkg.CodeLTC, "", 2, cosigners, ks)
+	require.Error(t, err)
+}
+
+func TestImportAccount(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	xprv, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	xpub, err := xprv.Neuter()
+	require.NoError(t, err)
+
+	acctCode, err := b.ImportAccount(coinpkg.CodeBTC, "", xpub.String(), signing.ScriptTypeP2WPKH)
+	require.NoError(t, err)
+	require.Contains(t, string(acctCode), "-btc-imported-")
+	acct := b.Config().AccountsConfig().Lookup(acctCode)
+	require.NotNil(t, acct)
+	require.True(t, acct.Imported)
+	watchonly, err := b.Config().AccountsConfig().IsAccountWatchOnly(acct)
+	require.NoError(t, err)
+	require.True(t, watchonly)
+	require.Equal(t, "Bitcoin watch-only", acct.Name)
+	require.Equal(t, signing.ScriptTypeP2WPKH, acct.SigningConfigurations[0].ScriptType())
+
+	rootFingerprint, err := acct.SigningConfigurations.RootFingerprint()
+	require.NoError(t, err)
+	keystore, err := b.Config().AccountsConfig().LookupKeystore(rootFingerprint)
+	require.NoError(t, err)
+	require.True(t, keystore.Watchonly)
+
+	// The account is loaded without a keystore being connected.
+	require.NotNil(t, b.Accounts().lookup(acctCode))
+
+	// The same account can't be imported twice, also not via its descriptor.
+	_, err = b.ImportAccount(coinpkg.CodeBTC, "", xpub.String(), signing.ScriptTypeP2WPKH)
+	require.Equal(t, errAccountAlreadyExists, errp.Cause(err))
+	descriptor, err := acct.SigningConfigurations[0].BitcoinSimple.Descriptor(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	_, err = b.ImportAccount(coinpkg.CodeBTC, "", descriptor, "")
+	require.Equal(t, errAccountAlreadyExists, errp.Cause(err))
+
+	// Duplicates are detected by the xpub, regardless of the script type.
+	_, err = b.ImportAccount(coinpkg.CodeBTC, "", xpub.String(), signing.ScriptTypeP2PKH)
+	require.Equal(t, errAccountAlreadyExists, errp.Cause(err))
+
+	// A descriptor with key origin is grouped under the root fingerprint of the origin.
+	seed := make([]byte, hdkeychain.RecommendedSeedLen)
+	seed[0] = 1
+	xprv2, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	xpub2, err := xprv2.Neuter()
+	require.NoError(t, err)
+	acctCode2, err := b.ImportAccount(
+		coinpkg.CodeBTC, "", "wpkh([01020304/84h/0h/0h]"+xpub2.String()+"/<0;1>/*)", "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(acctCode2), "v0-01020304-btc-imported-"))
+	require.NotNil(t, b.Accounts().lookup(acctCode2))
+
+	_, err = b.ImportAccount(coinpkg.CodeLTC, "", xpub.String(), signing.ScriptTypeP2TR)
+	require.Error(t, err)
+	_, err = b.ImportAccount(coinpkg.CodeBTC, "", xprv.String(), "")
+	require.Error(t, err)
+	_, err = b.ImportAccount(coinpkg.CodeETH, "", xpub.String(), "")
+	require.Error(t, err)
+}
@@ -48,7 +48,6 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
 	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/ethereum/go-ethereum/params"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
 )
@@ -228,6 +227,8 @@ type Backend struct {
 	connectKeystore connectKeystore
 
 	aopp AOPP
+	// paymentURI is the payment request of the last clicked payment URI, nil if there is none.
+	paymentURI *PaymentURI
 
 	// makeBtcAccount creates a BTC account. In production this is `btc.NewAccount`, but can be
 	// overridden in unit tests for mocking.
@@ -252,6 +253,9 @@ type Backend struct {
 	etherScanRateLimiter *rate.Limiter
 	ratesUpdater         *rates.RateUpdater
 	banners              *banners.Banners
+	// blockscoutRateLimiter limits the requests to Blockscout instances used as ETH transactions
+	// sources.
+	blockscoutRateLimiter *rate.Limiter
 
 	// For unit tests, called when `backend.checkAccountUsed()` is called.
 	tstCheckAccountUsed func(accounts.Interface) bool
@@ -313,8 +317,9 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 
 		log: log,
 
-		testing:              backendConfig.AppConfig().Backend.StartInTestnet || arguments.Testing(),
-		etherScanRateLimiter: rate.NewLimiter(rate.Limit(etherscan.CallsPerSec), 1),
+		testing:               backendConfig.AppConfig().Backend.StartInTestnet || arguments.Testing(),
+		etherScanRateLimiter:  rate.NewLimiter(rate.Limit(etherscan.CallsPerSec), 1),
+		blockscoutRateLimiter: rate.NewLimiter(rate.Limit(etherscan.CallsPerSec), 1),
 	}
 	// TODO: remove when connectivity check is present on all platforms
 	backend.isOnline.Store(true)
@@ -333,6 +338,9 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 		log.Errorf("RateUpdater DB cache dir: %v", err)
 	}
 	backend.ratesUpdater = rates.NewRateUpdater(hclient, ratesCache)
+	if providers := backend.ratesProviders(); len(providers) > 0 {
+		backend.ratesUpdater.SetProviders(providers)
+	}
 	backend.ratesUpdater.Observe(func(event observable.Event) {
 		backend.Notify(event)
 		backend.notifyCoinFiatPrices()
@@ -347,6 +355,29 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 	return backend, nil
 }
 
+// ratesProviders returns the sources of exchange rates configured in the app config. Invalid
+// entries are skipped.
+func (backend *Backend) ratesProviders() []rates.Provider {
+	var providers []rates.Provider
+	for _, provider := range backend.config.AppConfig().Backend.RatesProviders {
+		switch provider.Type {
+		case config.RatesProviderCoinGecko:
+			providers = append(providers, rates.NewCoinGeckoProvider(backend.httpClient, provider.URL))
+		case config.RatesProviderBitstamp:
+			providers = append(providers, rates.NewBitstampProvider(backend.httpClient))
+		case config.RatesProviderCSV:
+			if provider.Path == "" {
+				backend.log.Error("rates provider csv: no path configured")
+				continue
+			}
+			providers = append(providers, rates.NewCSVProvider(provider.Path))
+		default:
+			backend.log.Errorf("unknown rates provider %q", provider.Type)
+		}
+	}
+	return providers
+}
+
 // configureHistoryExchangeRates changes backend.ratesUpdater settings.
 // It requires both backend.config to be up-to-date and all accounts initialized.
 //
@@ -506,6 +537,48 @@ mCMuGBNHsbrs6rI1hbI4Qq6GYazLaDRqdCufTA==
 	}
 }
 
+// discoverElectrumPeers returns true if the peers of the Electrum servers of the coin should be
+// discovered. Peer discovery is never used with the dev servers.
+func (backend *Backend) discoverElectrumPeers(code coinpkg.Code) bool {
+	if backend.arguments.DevServers() {
+		return false
+	}
+	backendConfig := backend.config.AppConfig().Backend
+	switch code {
+	case coinpkg.CodeBTC:
+		return backendConfig.BTC.DiscoverPeers
+	case coinpkg.CodeTBTC:
+		return backendConfig.TBTC.DiscoverPeers
+	case coinpkg.CodeLTC:
+		return backendConfig.LTC.DiscoverPeers
+	case coinpkg.CodeTLTC:
+		return backendConfig.TLTC.DiscoverPeers
+	default:
+		return false
+	}
+}
+
+// bitcoindRPC returns the Bitcoin Core node configured to be used instead of the Electrum servers
+// of the coin, or nil. Only Bitcoin is supported.
+func (backend *Backend) bitcoindRPC(code coinpkg.Code) *config.BitcoindRPC {
+	backendConfig := backend.config.AppConfig().Backend
+	var rpcConfig config.BitcoindRPC
+	switch code {
+	case coinpkg.CodeBTC:
+		rpcConfig = backendConfig.BTC.Bitcoind
+	case coinpkg.CodeTBTC:
+		rpcConfig = backendConfig.TBTC.Bitcoind
+	case coinpkg.CodeRBTC:
+		rpcConfig = backendConfig.RBTC.Bitcoind
+	default:
+		return nil
+	}
+	if !rpcConfig.Enabled() {
+		return nil
+	}
+	return &rpcConfig
+}
+
 func (backend *Backend) defaultElectrumXServers(code coinpkg.Code) []*config.ServerInfo {
 	if backend.arguments.DevServers() {
 		return defaultDevServers(code)
@@ -528,7 +601,6 @@ func (backend *Backend) Coin(code coinpkg.Code) (coinpkg.Coin, error) {
 	}
 	dbFolder := backend.arguments.CacheDirectoryPath()
 
-	erc20Token := erc20TokenByCode(code)
 	btcFormatUnit := backend.config.AppConfig().Backend.BtcUnit
 	switch {
 	case code == coinpkg.CodeRBTC:
@@ -550,27 +622,37 @@ func (backend *Backend) Coin(code coinpkg.Code) (coinpkg.Coin, error) {
 		servers := backend.defaultElectrumXServers(code)
 		coin = btc.NewCoin(coinpkg.CodeLTC, "Litecoin", "LTC", coinpkg.BtcUnitDefault, &ltc.MainNetParams, dbFolder, servers,
 			"https://blockchair.com/litecoin/transaction/", backend.socksProxy)
-	case code == coinpkg.CodeETH:
-		etherScan := etherscan.NewEtherScan("1", backend.httpClient, backend.etherScanRateLimiter)
-		coin = eth.NewCoin(etherScan, code, "Ethereum", "ETH", "ETH", params.MainnetChainConfig,
-			"https://etherscan.io/",
-			etherScan,
-			nil)
-	case code == coinpkg.CodeSEPETH:
-		etherScan := etherscan.NewEtherScan("11155111", backend.httpClient, backend.etherScanRateLimiter)
-		coin = eth.NewCoin(etherScan, code, "Ethereum Sepolia", "SEPETH", "SEPETH", params.SepoliaChainConfig,
-			"https://sepolia.etherscan.io/",
-			etherScan,
+	case evmChainByCode(code) != nil:
+		chain := evmChainByCode(code)
+		client, transactionsSource, err := backend.ethSources(chain)
+		if err != nil {
+			return nil, err
+		}
+		coin = eth.NewCoin(client, code, chain.name, chain.unit, chain.unit, chain.chainConfig,
+			chain.blockExplorerURLPrefix,
+			transactionsSource,
 			nil)
-	case erc20Token != nil:
-		etherScan := etherscan.NewEtherScan("1", backend.httpClient, backend.etherScanRateLimiter)
-		coin = eth.NewCoin(etherScan, erc20Token.code, erc20Token.name, erc20Token.unit, "ETH", params.MainnetChainConfig,
-			"https://etherscan.io/",
-			etherScan,
+	default:
+		// Only tokens reach this point. Custom tokens are looked up in the accounts config, so this
+		// must not be reached while the accounts config is being modified.
+		erc20Token := backend.lookupERC20Token(code)
+		if erc20Token == nil {
+			return nil, errp.Newf("unknown coin code %s", code)
+		}
+		chain := evmChainByCode(erc20Token.chain)
+		client, transactionsSource, err := backend.ethSources(chain)
+		if err != nil {
+			return nil, err
+		}
+		coin = eth.NewCoin(client, erc20Token.code, erc20Token.name, erc20Token.unit, chain.unit, chain.chainConfig,
+			chain.blockExplorerURLPrefix,
+			transactionsSource,
 			erc20Token.token,
 		)
-	default:
-		return nil, errp.Newf("unknown coin code %s", code)
+	}
+	if btcCoin, ok := coin.(*btc.Coin); ok {
+		btcCoin.SetDiscoverElectrumPeers(backend.discoverElectrumPeers(code))
+		btcCoin.SetBitcoindRPC(backend.bitcoindRPC(code))
 	}
 	backend.coins[code] = coin
 	coin.Observe(backend.Notify)
@@ -580,19 +662,28 @@ func (backend *Backend) Coin(code coinpkg.Code) (coinpkg.Coin, error) {
 func (backend *Backend) updateETHAccounts() error {
 	backend.log.Debug("Updating ETH accounts balances")
 
-	accountsChainID := map[string][]*eth.Account{}
+	accountsChainID := map[uint64][]*eth.Account{}
 	for _, account := range backend.Accounts() {
 		ethAccount, ok := account.(*eth.Account)
 		if ok {
-			chainID := ethAccount.ETHCoin().ChainIDstr()
+			chainID := ethAccount.ETHCoin().ChainID()
 			accountsChainID[chainID] = append(accountsChainID[chainID], ethAccount)
 		}
 
 	}
 
 	for chainID, ethAccounts := range accountsChainID {
-		etherScanClient := etherscan.NewEtherScan(chainID, backend.httpClient, backend.etherScanRateLimiter)
-		backend.ethupdater.UpdateBalancesAndBlockNumber(ethAccounts, etherScanClient)
+		chainIndex := evmChainIndex(chainID)
+		if chainIndex == -1 {
+			backend.log.Errorf("Unknown chain ID %d", chainID)
+			continue
+		}
+		fetcher, err := backend.ethUpdateFetcher(&evmChains[chainIndex])
+		if err != nil {
+			backend.log.WithError(err).Error("Could not create the ETH update fetcher")
+			continue
+		}
+		backend.ethupdater.UpdateBalancesAndBlockNumber(ethAccounts, fetcher)
 	}
 
 	return nil
@@ -968,6 +1059,20 @@ func (backend *Backend) CheckElectrumServer(serverInfo *config.ServerInfo) error
 		serverInfo, backend.log, backend.socksProxy.GetTCPProxyDialer())
 }
 
+// ElectrumServersHealth returns the health of the Electrum servers of the given coin, to find out
+// why the coin is offline.
+func (backend *Backend) ElectrumServersHealth(code coinpkg.Code) ([]electrum.ServerHealth, error) {
+	coin, err := backend.Coin(code)
+	if err != nil {
+		return nil, err
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return nil, errp.Newf("coin %s does not use Electrum servers", code)
+	}
+	return btcCoin.ElectrumServersHealth()
+}
+
 // RegisterTestKeystore adds a keystore derived deterministically from a PIN, for convenience in
 // devmode.
 func (backend *Backend) RegisterTestKeystore(pin string) {
@@ -1042,9 +1147,9 @@ func (backend *Backend) Banners() *banners.Banners {
 	return backend.banners
 }
 
-// HandleURI handles an external URI click for registered protocols, e.g. 'aopp:?...' URIs.  The uri
-// param can be any string, as it is potentially passed without any validation from the calling
-// platform.
+// HandleURI handles an external URI click for registered protocols, e.g. 'aopp:?...' or
+// 'bitcoin:...' URIs. The uri param can be any string, as it is potentially passed without any
+// validation from the calling platform.
 func (backend *Backend) HandleURI(uri string) {
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -1054,6 +1159,8 @@ func (backend *Backend) HandleURI(uri string) {
 	switch u.Scheme {
 	case "aopp":
 		backend.handleAOPP(*u)
+	case "bitcoin", "litecoin", "ethereum":
+		backend.handlePaymentURI(*u)
 	default:
 		backend.log.Warningf("Unknown URI scheme: %s", uri)
 	}
@@ -21,7 +21,10 @@ import (
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/jsonrpc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/devices/usb"
 	keystoremock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/software"
@@ -454,3 +457,49 @@ func TestRegisterKeystore(t *testing.T) {
 	require.NotNil(t, b.Accounts().lookup("v0-66666666-ltc-0"))
 	require.NotNil(t, b.Accounts().lookup("v0-66666666-eth-0"))
 }
+
+func TestETHSources(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	arbitrum := evmChainByCode(coinpkg.CodeARBETH)
+
+	// EtherScan by default.
+	client, transactionsSource, err := b.ethSources(arbitrum)
+	require.NoError(t, err)
+	require.IsType(t, &etherscan.EtherScan{}, client)
+	require.IsType(t, &etherscan.EtherScan{}, transactionsSource)
+
+	require.NoError(t, b.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.ARBETH = config.ETHSourceConfig{
+			NodeURL:            "http://localhost:8545",
+			TransactionsSource: config.ETHTransactionsSourceBlockscout,
+			BlockscoutURL:      "https://arbitrum.blockscout.com/api",
+		}
+		appConfig.Backend.ETH.TransactionsSource = config.ETHTransactionsSourceNone
+		return nil
+	}))
+	client, transactionsSource, err = b.ethSources(arbitrum)
+	require.NoError(t, err)
+	require.IsType(t, &jsonrpc.Client{}, client)
+	require.IsType(t, &etherscan.EtherScan{}, transactionsSource)
+	fetcher, err := b.ethUpdateFetcher(arbitrum)
+	require.NoError(t, err)
+	require.Implements(t, (*eth.TokenTransactionsFetcher)(nil), fetcher)
+
+	client, transactionsSource, err = b.ethSources(evmChainByCode(coinpkg.CodeETH))
+	require.NoError(t, err)
+	require.IsType(t, &etherscan.EtherScan{}, client)
+	require.Nil(t, transactionsSource)
+	fetcher, err = b.ethUpdateFetcher(evmChainByCode(coinpkg.CodeETH))
+	require.NoError(t, err)
+	_, ok := fetcher.(eth.TokenTransactionsFetcher)
+	require.False(t, ok)
+
+	require.NoError(t, b.config.ModifyAppConfig(func(appConfig *config.AppConfig) error {
+		appConfig.Backend.ARBETH.BlockscoutURL = ""
+		return nil
+	}))
+	_, _, err = b.ethSources(arbitrum)
+	require.Error(t, err)
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/sirupsen/logrus"
 )
@@ -121,6 +122,10 @@ type Account struct {
 
 	httpClient *http.Client
 
+	// derivedAddressIDs caches the addresses derived by DerivesAddress().
+	derivedAddressIDs     map[addresses.AddressID]struct{}
+	derivedAddressIDsLock locker.Locker
+
 	// getAddressFromSameKeystore retrieves an address from any account on the same keystore as this one.
 	getAddressFromSameKeystore func(coin.Code, addresses.AddressID) (*addresses.AccountAddress, error)
 }
@@ -321,10 +326,17 @@ func (account *Account) Initialize() error {
 	account.coin.Initialize()
 	account.SetOffline(account.coin.Blockchain().ConnectionError())
 	account.coin.Blockchain().RegisterOnConnectionErrorChangedEvent(onConnectionStatusChanged)
+	onVerificationFailed := func(txHash chainhash.Hash) {
+		account.Notify(observable.Event{
+			Subject: string(accountsTypes.EventSPVVerificationFailed),
+			Action:  action.Replace,
+			Object:  txHash.String(),
+		})
+	}
 	theHeaders := account.coin.Headers()
 	account.transactions = transactions.NewTransactions(
 		account.coin.Net(), account.db, theHeaders, account.Synchronizer,
-		account.coin.Blockchain(), account.notifier, account.log)
+		account.coin.Blockchain(), account.notifier, onVerificationFailed, account.log)
 
 	for _, signingConfiguration := range signingConfigurations {
 
@@ -395,6 +407,11 @@ func (account *Account) Info() *accounts.Info {
 		if isInsuredAccount && !isNativeSegwit {
 			continue
 		}
+		if subacc.signingConfiguration.BitcoinMultisig != nil {
+			// The cosigner xpubs are exported as part of the multisig descriptor.
+			signingConfigurations = append(signingConfigurations, subacc.signingConfiguration)
+			continue
+		}
 		xpub := subacc.signingConfiguration.ExtendedPublicKey()
 		if xpub.IsPrivate() {
 			panic("xpub can't be private")
@@ -470,9 +487,10 @@ func (account *Account) Notifier() accounts.Notifier {
 
 // feeTargets fetches the available fees. For mainnet BTC it uses mempool.space estimation.
 //
-// For the other coins or in case mempool.space is not available it fallbacks on Bitcoin Core.
-// The minimum relay fee is used as a last resource fallback in case also Bitcoin Core is
-// unavailable.
+// For the other coins or in case mempool.space is not available it estimates the fees from the fee
+// histogram of the mempool of the Electrum server, and if that is not available either, it falls
+// back on Bitcoin Core. The minimum relay fee is used as a last resource fallback in case also
+// Bitcoin Core is unavailable.
 func (account *Account) feeTargets() FeeTargets {
 	// for mainnet BTC we fetch mempool.space fees, as they should be more reliable.
 	var mempoolFees *accounts.MempoolSpaceFees
@@ -485,13 +503,23 @@ func (account *Account) feeTargets() FeeTargets {
 		}
 	}
 
+	var feeHistogram blockchain.FeeHistogram
+	if mempoolFees == nil {
+		histogram, err := account.coin.Blockchain().FeeHistogram()
+		if err != nil {
+			account.log.WithError(err).Info("Fee histogram not available, falling back to fee estimation")
+		} else {
+			feeHistogram = histogram
+		}
+	}
+
 	// feeTargets must be sorted by ascending priority.
 	var feeTargets FeeTargets
 	if mempoolFees != nil {
 		feeTargets = FeeTargets{
-			{blocks: 3, code: accounts.FeeTargetCodeMempoolHour},
-			{blocks: 2, code: accounts.FeeTargetCodeMempoolHalfHour},
-			{blocks: 1, code: accounts.FeeTargetCodeMempoolFastest},
+			{blocks: 3, code: accounts.FeeTargetCodeMempoolHour, expectedConfirmationTime: time.Hour},
+			{blocks: 2, code: accounts.FeeTargetCodeMempoolHalfHour, expectedConfirmationTime: 30 * time.Minute},
+			{blocks: 1, code: accounts.FeeTargetCodeMempoolFastest, expectedConfirmationTime: 10 * time.Minute},
 		}
 	} else {
 		feeTargets = FeeTargets{
@@ -507,6 +535,7 @@ func (account *Account) feeTargets() FeeTargets {
 		minRelayFeeRate = &minRelayFeeRateVal
 	}
 
+	timePerBlock := account.coin.net.TargetTimePerBlock
 	wg := sync.WaitGroup{}
 	for _, feeTarget := range feeTargets {
 		wg.Go(func() {
@@ -514,7 +543,19 @@ func (account *Account) feeTargets() FeeTargets {
 
 			if mempoolFees != nil {
 				feeRatePerKb = mempoolFees.GetFeeRate(feeTarget.code)
+			} else if feeHistogram != nil {
+				// A fee rate of 0 means that the mempool is not full, in which case the min relay
+				// fee applied below suffices.
+				var expectedBlocks int
+				feeRatePerKb, expectedBlocks = feeHistogram.EstimateFee(feeTarget.blocks)
+				if feeRatePerKb == 0 && minRelayFeeRate == nil {
+					account.log.WithField("fee-target", feeTarget.blocks).
+						Warning("Minimum relay fee could not be determined")
+					return
+				}
+				feeTarget.expectedConfirmationTime = time.Duration(expectedBlocks) * timePerBlock
 			} else {
+				feeTarget.expectedConfirmationTime = time.Duration(feeTarget.blocks) * timePerBlock
 				// If mempool.space fees are not available, we fallback on Bitcoin Core estimation.
 				// If even that one is not available, we just offer the min relay fee.
 				estimatedFeeRatePerKb, err := account.coin.Blockchain().EstimateFee(feeTarget.blocks)
@@ -706,6 +747,9 @@ func (account *Account) ensureAddresses() {
 }
 
 func (account *Account) subscribeAddress(address *addresses.AccountAddress) {
+	if watcher, ok := account.coin.Blockchain().(blockchain.ScriptWatcher); ok {
+		watcher.WatchScript(string(account.Config().Config.Code), address.PubkeyScript())
+	}
 	account.coin.Blockchain().ScriptHashSubscribe(
 		account.Synchronizer.IncRequestsCounter,
 		address.PubkeyScriptHashHex(),
@@ -729,6 +773,15 @@ func (account *Account) Transactions() (accounts.OrderedTransactions, error) {
 	return account.transactions.Transactions(account.IsChange)
 }
 
+// SPVVerificationStatus returns how many transactions of the account were verified against our
+// header chain using merkle proofs, and which failed verification.
+func (account *Account) SPVVerificationStatus() (*transactions.VerificationStatus, error) {
+	if !account.isInitialized() {
+		return nil, errp.New("account not initialized")
+	}
+	return account.transactions.VerificationStatus()
+}
+
 // GetUnusedReceiveAddresses returns a number of unused addresses. Returns nil if the account is not initialized.
 func (account *Account) GetUnusedReceiveAddresses() ([]accounts.AddressList, error) {
 	if !account.isInitialized() {
@@ -776,6 +829,8 @@ type UsedAddress struct {
 	AddressType UsedAddressType
 	CanSignMsg  bool
 	LastUsed    *time.Time
+	// Label is the user-defined label of the address.
+	Label string
 }
 
 func (account *Account) canSignMessageForUsedAddress(scriptType signing.ScriptType) bool {
@@ -801,6 +856,59 @@ func (account *Account) lookupAddressByID(
 	return nil, ""
 }
 
+// HasAddress returns true if the address is a receive or change address of the account.
+func (account *Account) HasAddress(address string) bool {
+	if !account.isInitialized() {
+		return false
+	}
+	pkScript, err := account.coin.AddressToPkScript(address)
+	if err != nil {
+		return false
+	}
+	addr, _ := account.lookupAddressByID(addresses.NewAddressID(pkScript))
+	return addr != nil
+}
+
+// derivedAddressesLimit is the number of receive and change addresses per signing configuration
+// derived by DerivesAddress.
+const derivedAddressesLimit = 1000
+
+// DerivesAddress returns true if the address is one of the first derivedAddressesLimit receive or
+// change addresses of the account. Unlike HasAddress, this also works if the account is not synced
+// yet. The derived addresses are cached. The account must be initialized.
+func (account *Account) DerivesAddress(address string) bool {
+	if !account.isInitialized() {
+		return false
+	}
+	pkScript, err := account.coin.AddressToPkScript(address)
+	if err != nil {
+		return false
+	}
+	addressID := addresses.NewAddressID(pkScript)
+	if addr, _ := account.lookupAddressByID(addressID); addr != nil {
+		return true
+	}
+	defer account.derivedAddressIDsLock.Lock()()
+	if account.derivedAddressIDs == nil {
+		account.derivedAddressIDs = map[addresses.AddressID]struct{}{}
+		for _, subacc := range account.subaccounts {
+			for _, change := range []bool{false, true} {
+				for index := uint32(0); index < derivedAddressesLimit; index++ {
+					addr := addresses.NewAccountAddress(
+						subacc.signingConfiguration,
+						types.Derivation{Change: change, AddressIndex: index},
+						account.coin.Net(),
+						account.log,
+					)
+					account.derivedAddressIDs[addr.PubkeyScriptHashHex()] = struct{}{}
+				}
+			}
+		}
+	}
+	_, ok := account.derivedAddressIDs[addressID]
+	return ok
+}
+
 // GetUsedAddresses returns all used wallet addresses from confirmed transaction history.
 // Returns addresses sorted by most recently used first.
 func (account *Account) GetUsedAddresses() ([]UsedAddress, error) {
@@ -848,6 +956,7 @@ func (account *Account) GetUsedAddresses() ([]UsedAddress, error) {
 							AddressID:   addr.ID(),
 							AddressType: addressType,
 							CanSignMsg:  account.canSignMessageForUsedAddress(addr.AccountConfiguration.ScriptType()),
+							Label:       account.Notes().AddressLabel(addr.EncodeForHumans()),
 						},
 					}
 				}
@@ -999,6 +1108,15 @@ type SpendableOutput struct {
 	OutPoint wire.OutPoint
 	Address  *addresses.AccountAddress
 	IsChange bool
+	// Label is the user-defined label of the output.
+	Label string
+	// Frozen outputs are not spent unless explicitly selected.
+	Frozen bool
+}
+
+// isFrozen returns true if the user froze the output.
+func (account *Account) isFrozen(outPoint wire.OutPoint) bool {
+	return account.Notes().OutputNote(outPoint.String()).Frozen
 }
 
 func (account *Account) makeSpendableOutputs(
@@ -1007,6 +1125,7 @@ func (account *Account) makeSpendableOutputs(
 	result := make([]*SpendableOutput, 0, len(utxos))
 	for outPoint, txOut := range utxos {
 		addressID := addresses.NewAddressID(txOut.TxOut.PkScript)
+		outputNote := account.Notes().OutputNote(outPoint.String())
 		result = append(
 			result,
 			&SpendableOutput{
@@ -1014,6 +1133,8 @@ func (account *Account) makeSpendableOutputs(
 				SpendableOutput: txOut,
 				Address:         account.AddressByID(addressID),
 				IsChange:        account.IsChange(addressID),
+				Label:           outputNote.Label,
+				Frozen:          outputNote.Frozen,
 			})
 	}
 	return sortByAddresses(result)
@@ -15,6 +15,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
@@ -368,7 +369,7 @@ func putWalletTransaction(
 			}
 		}
 		if timestamp != nil {
-			return dbTx.MarkTxVerified(txHash, *timestamp)
+			return dbTx.MarkTxVerified(txHash, height, *timestamp)
 		}
 		return nil
 	})
@@ -589,3 +590,21 @@ func TestSignBTCMessageForAddressEdgeCases(t *testing.T) {
 		require.Contains(t, err.Error(), "not found")
 	})
 }
+
+func TestDerivesAddress(t *testing.T) {
+	account := mockAccount(t, nil)
+	// An address beyond the gap limit, which is not known before the account is synced.
+	address := addresses.NewAccountAddress(
+		account.Config().Config.SigningConfigurations[0],
+		types.Derivation{Change: true, AddressIndex: 500},
+		account.coin.Net(),
+		account.log,
+	).EncodeForHumans()
+
+	require.False(t, account.DerivesAddress(address))
+	require.NoError(t, account.Initialize())
+	require.False(t, account.HasAddress(address))
+	require.True(t, account.DerivesAddress(address))
+	require.False(t, account.DerivesAddress("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"))
+	require.False(t, account.DerivesAddress("invalid"))
+}
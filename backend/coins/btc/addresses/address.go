@@ -3,7 +3,10 @@
 package addresses
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"sort"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
@@ -33,13 +36,17 @@ type AccountAddress struct {
 
 	// AccountConfiguration is the account level configuration from which this address was derived.
 	AccountConfiguration *signing.Configuration
-	// PublicKey is the public key of a single-sig address.
+	// PublicKey is the public key of a single-sig address, or the public key of our cosigner in a
+	// multisig address.
 	PublicKey  *btcec.PublicKey
 	Derivation types.Derivation
 
 	// redeemScript stores the redeem script of a BIP16 P2SH output or nil if address type is not
 	// P2SH.
 	RedeemScript []byte
+	// WitnessScript stores the multisig script of a P2WSH output or nil if the address type is not
+	// P2WSH.
+	WitnessScript []byte
 
 	log *logrus.Entry
 }
@@ -61,6 +68,7 @@ func NewAccountAddress(
 
 	var address btcutil.Address
 	var redeemScript []byte
+	var witnessScript []byte
 	relativeKeypath := signing.NewEmptyRelativeKeypath().
 		Child(derivation.SimpleChainIndex(), signing.NonHardened).
 		Child(derivation.AddressIndex, signing.NonHardened)
@@ -105,6 +113,16 @@ func NewAccountAddress(
 		if err != nil {
 			log.WithError(err).Panic("Failed to get p2tr addr")
 		}
+	case signing.ScriptTypeP2WSH:
+		witnessScript, err = sortedMultisigScript(accountConfiguration.BitcoinMultisig, relativeKeypath)
+		if err != nil {
+			log.WithError(err).Panic("Failed to get the multisig witness script.")
+		}
+		witnessScriptHash := sha256.Sum256(witnessScript)
+		address, err = btcutil.NewAddressWitnessScriptHash(witnessScriptHash[:], net)
+		if err != nil {
+			log.WithError(err).Panic("Failed to get p2wsh addr. from witness script.")
+		}
 	default:
 		log.Panic(fmt.Sprintf("Unrecognized script type: %s", accountConfiguration.ScriptType()))
 	}
@@ -115,10 +133,36 @@ func NewAccountAddress(
 		PublicKey:            publicKey,
 		Derivation:           derivation,
 		RedeemScript:         redeemScript,
+		WitnessScript:        witnessScript,
 		log:                  log,
 	}
 }
 
+// sortedMultisigScript returns the multisig script with the public keys of all cosigners derived at
+// the given relative keypath, sorted according to BIP-67.
+func sortedMultisigScript(
+	configuration *signing.BitcoinMultisig, relativeKeypath signing.RelativeKeypath) ([]byte, error) {
+	publicKeys, err := configuration.DerivePublicKeys(relativeKeypath)
+	if err != nil {
+		return nil, err
+	}
+	serializedKeys := make([][]byte, len(publicKeys))
+	for i, publicKey := range publicKeys {
+		serializedKeys[i] = publicKey.SerializeCompressed()
+	}
+	sort.Slice(serializedKeys, func(i, j int) bool {
+		return bytes.Compare(serializedKeys[i], serializedKeys[j]) < 0
+	})
+	builder := txscript.NewScriptBuilder().AddInt64(int64(configuration.Threshold))
+	for _, serializedKey := range serializedKeys {
+		builder.AddData(serializedKey)
+	}
+	return builder.
+		AddInt64(int64(len(serializedKeys))).
+		AddOp(txscript.OP_CHECKMULTISIG).
+		Script()
+}
+
 // ID implements accounts.Address.
 // For BTC/LTC, this value must never change because it is treated interchangeably with the
 // address scriptHashHex.
@@ -164,6 +208,8 @@ func (address *AccountAddress) ScriptForHashToSign() (bool, []byte) {
 		return true, address.RedeemScript
 	case signing.ScriptTypeP2WPKH:
 		return true, address.PubkeyScript()
+	case signing.ScriptTypeP2WSH:
+		return true, address.WitnessScript
 	default:
 		address.log.Panic("Unrecognized address type.")
 	}
@@ -3,8 +3,11 @@
 package addresses_test
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"os"
+	"sort"
 	"testing"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
@@ -14,8 +17,10 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	testlog "github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -117,3 +122,62 @@ func TestAddressP2TR(t *testing.T) {
 		require.Equal(t, test.expectedPkScript, hex.EncodeToString(addr.PubkeyScript()))
 	}
 }
+
+func TestAddressP2WSHMultisig(t *testing.T) {
+	cosigners := make([]signing.KeyInfo, 3)
+	for i := range cosigners {
+		seed := make([]byte, hdkeychain.RecommendedSeedLen)
+		seed[0] = byte(i)
+		xprv, err := hdkeychain.NewMaster(seed, net)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		cosigners[i] = signing.KeyInfo{
+			RootFingerprint:   []byte{byte(i), 2, 3, 4},
+			AbsoluteKeypath:   signing.NewEmptyAbsoluteKeypath(),
+			ExtendedPublicKey: xpub,
+		}
+	}
+	derivation := types.Derivation{Change: true, AddressIndex: 5}
+	configuration, err := signing.NewBitcoinMultisigConfiguration(2, cosigners, 1)
+	require.NoError(t, err)
+	addr := addresses.NewAccountAddress(
+		configuration, derivation, net, logging.Get().WithGroup("addresses_test"))
+
+	// Build the expected script with the BIP-67 sorted keys.
+	var publicKeys []*btcutil.AddressPubKey
+	for _, cosigner := range cosigners {
+		derived, err := signing.NewEmptyRelativeKeypath().Child(1, false).Child(5, false).
+			Derive(cosigner.ExtendedPublicKey)
+		require.NoError(t, err)
+		publicKey, err := derived.ECPubKey()
+		require.NoError(t, err)
+		addressPubKey, err := btcutil.NewAddressPubKey(publicKey.SerializeCompressed(), net)
+		require.NoError(t, err)
+		publicKeys = append(publicKeys, addressPubKey)
+	}
+	// The public key of the address is the one of our cosigner.
+	require.True(t, publicKeys[1].PubKey().IsEqual(addr.PublicKey))
+	sort.Slice(publicKeys, func(i, j int) bool {
+		return bytes.Compare(publicKeys[i].ScriptAddress(), publicKeys[j].ScriptAddress()) < 0
+	})
+	expectedScript, err := txscript.MultiSigScript(publicKeys, 2)
+	require.NoError(t, err)
+	require.Equal(t, expectedScript, addr.WitnessScript)
+	scriptHash := sha256.Sum256(expectedScript)
+	expectedAddress, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], net)
+	require.NoError(t, err)
+	require.Equal(t, expectedAddress.EncodeAddress(), addr.EncodeForHumans())
+
+	isSegwit, script := addr.ScriptForHashToSign()
+	require.True(t, isSegwit)
+	require.Equal(t, expectedScript, script)
+
+	// The order of the cosigners does not change the address.
+	reordered := []signing.KeyInfo{cosigners[2], cosigners[0], cosigners[1]}
+	configuration, err = signing.NewBitcoinMultisigConfiguration(2, reordered, 2)
+	require.NoError(t, err)
+	reorderedAddr := addresses.NewAccountAddress(
+		configuration, derivation, net, logging.Get().WithGroup("addresses_test"))
+	require.Equal(t, addr.EncodeForHumans(), reorderedAddr.EncodeForHumans())
+}
@@ -55,3 +55,36 @@ func GetAddress(scriptType signing.ScriptType) *addresses.AccountAddress {
 		logging.Get().WithGroup("addresses_test"),
 	)
 }
+
+// GetMultisigAddress returns a dummy P2WSH multisig address with the given threshold and number
+// of cosigners.
+func GetMultisigAddress(threshold uint32, numCosigners int) *addresses.AccountAddress {
+	cosigners := make([]signing.KeyInfo, numCosigners)
+	for i := range cosigners {
+		seed := make([]byte, hdkeychain.RecommendedSeedLen)
+		seed[0] = byte(i)
+		xprv, err := hdkeychain.NewMaster(seed, net)
+		if err != nil {
+			panic(err)
+		}
+		xpub, err := xprv.Neuter()
+		if err != nil {
+			panic(err)
+		}
+		cosigners[i] = signing.KeyInfo{
+			RootFingerprint:   []byte{byte(i), 2, 3, 4},
+			AbsoluteKeypath:   absoluteKeypath,
+			ExtendedPublicKey: xpub,
+		}
+	}
+	configuration, err := signing.NewBitcoinMultisigConfiguration(threshold, cosigners, 0)
+	if err != nil {
+		panic(err)
+	}
+	return addresses.NewAccountAddress(
+		configuration,
+		types.Derivation{Change: false, AddressIndex: 0},
+		net,
+		logging.Get().WithGroup("addresses_test"),
+	)
+}
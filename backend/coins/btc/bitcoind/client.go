@@ -0,0 +1,1268 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bitcoind implements blockchain.Interface using the JSON-RPC interface of a Bitcoin Core
+// node, so that users running their own node do not need an Electrum server.
+//
+// Bitcoin Core does not index the blockchain by script. The client scans the blocks for the scripts
+// of the accounts using the compact block filters (BIP-157/158) of the node if its block filter
+// index is enabled (`-blockfilterindex=1`). Otherwise, the scripts are imported into a watch-only
+// wallet on the node, which finds their transactions, and if the wallet of the node is disabled,
+// the full blocks are scanned. The mempool is scanned for unconfirmed transactions.
+//
+// The filters are fetched from the node over RPC (`getblockfilter`). Fetching them from peers over
+// the P2P protocol (BIP-157) is not supported, so a node with RPC access is required.
+//
+// The scan progress is persisted per account, so that after a restart only the blocks containing
+// transactions of the accounts are fetched again. Scripts derived later are scanned for from the
+// first transaction of their account.
+package bitcoind
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/block-client-go/electrum/types"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/gcs"
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// pollInterval is the time between two checks for new blocks and mempool transactions.
+	pollInterval = 10 * time.Second
+	// watchDelay is the time waited after a script was watched, so that scripts watched in quick
+	// succession are scanned together.
+	watchDelay = time.Second
+	// batchSize is the number of calls made in a single request.
+	batchSize = 100
+	// blocksBatchSize is the number of full blocks fetched in a single request.
+	blocksBatchSize = 10
+	// maxHeaders is the maximum number of headers returned by Headers(), as in Electrum.
+	maxHeaders = 2016
+	// stateSaveInterval is the time after which the scan progress is saved during long scans.
+	stateSaveInterval = time.Minute
+	// feeHistogramCacheDuration is the time the fee histogram is reused, as it is computed from the
+	// whole mempool.
+	feeHistogramCacheDuration = time.Minute
+)
+
+// segwitActivationHeights are the heights from which segwit scripts are scanned for by default, as
+// they can't have been used before. Segwit is active from the genesis block on testnet4.
+var segwitActivationHeights = map[wire.BitcoinNet]int{
+	wire.MainNet:  481824,
+	wire.TestNet3: 834624,
+	wire.TestNet4: 0,
+}
+
+var errClosed = errp.New("bitcoind client closed")
+
+// subscription is a script hash subscription made by ScriptHashSubscribe().
+type subscription struct {
+	scriptHash blockchain.ScriptHashHex
+	result     func(string)
+	// teardown is called after the first notification.
+	teardown func()
+	notified bool
+	status   string
+}
+
+// scanMode is how the blocks are scanned for the scripts.
+type scanMode int
+
+const (
+	// scanModeUnknown is the mode until the node was asked what it supports.
+	scanModeUnknown scanMode = iota
+	// scanModeFilters matches the compact block filters of the node and fetches the matching
+	// blocks.
+	scanModeFilters
+	// scanModeWallet imports the scripts into a watch-only wallet of the node, which finds their
+	// transactions.
+	scanModeWallet
+	// scanModeBlocks fetches all blocks.
+	scanModeBlocks
+)
+
+// Client implements blockchain.Interface using a Bitcoin Core node.
+type Client struct {
+	rpc *rpcClient
+	// wallet makes the requests to the watch-only wallet of the app on the node.
+	wallet *rpcClient
+	net    *chaincfg.Params
+	// startHeight is the configured height from which all scripts are scanned, 0 if not
+	// configured.
+	startHeight   int
+	stateFilename string
+	log           *logrus.Entry
+
+	index *index
+	// accounts are the watched accounts by account code.
+	accounts map[string]*account
+	// scriptAccounts are the codes of the accounts of the watched scripts.
+	scriptAccounts map[blockchain.ScriptHashHex]string
+	// savedAccounts are the states loaded from the state file of the accounts not watched yet.
+	savedAccounts map[string]*accountState
+	// dirty is true if the accounts changed since the state file was written.
+	dirty bool
+	// blockHashes are the hashes of the scanned blocks by height, used to detect reorgs.
+	blockHashes map[int]chainhash.Hash
+	// mempoolChecked are the mempool transactions which were already checked for watched scripts.
+	mempoolChecked map[chainhash.Hash]struct{}
+	mode           scanMode
+	// imported are the scripts imported into the wallet, with the time from which the wallet
+	// scanned the blockchain for them.
+	imported         map[blockchain.ScriptHashHex]int64
+	subscriptions    []*subscription
+	headersCallbacks []func(*types.Header)
+	tipHeight        int
+	notifiedTip      int
+	// covers index, accounts, scriptAccounts, savedAccounts, dirty, blockHashes, mempoolChecked,
+	// mode, imported, subscriptions, headersCallbacks, tipHeight and notifiedTip.
+	mu locker.Locker
+
+	// stateSaveTime is the time the state file was last written. Only accessed by sync().
+	stateSaveTime time.Time
+
+	feeHistogram     blockchain.FeeHistogram
+	feeHistogramTime time.Time
+	feeHistogramLock locker.Locker
+
+	connectionError                   error
+	onConnectionErrorChangedCallbacks []func(error)
+	connectionErrorLock               locker.Locker
+
+	wake      chan struct{}
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewClient creates a client of the node configured in rpcConfig and starts scanning the
+// blockchain of the given network. The scan progress of the accounts is persisted in
+// stateFilename.
+func NewClient(
+	rpcConfig *config.BitcoindRPC,
+	net *chaincfg.Params,
+	httpClient *http.Client,
+	stateFilename string,
+	log *logrus.Entry,
+) *Client {
+	client := newClient(rpcConfig, net, httpClient, stateFilename, log)
+	go client.run()
+	return client
+}
+
+func newClient(
+	rpcConfig *config.BitcoindRPC,
+	net *chaincfg.Params,
+	httpClient *http.Client,
+	stateFilename string,
+	log *logrus.Entry,
+) *Client {
+	log = log.WithField("group", "bitcoind")
+	loaded, err := loadState(stateFilename)
+	if err != nil {
+		log.WithError(err).Error("Could not load the scan progress, scanning again")
+		loaded = &state{Accounts: map[string]*accountState{}}
+	}
+	rpc := newRPCClient(rpcConfig, httpClient)
+	return &Client{
+		rpc:            rpc,
+		wallet:         rpc.wallet(walletName),
+		net:            net,
+		startHeight:    max(rpcConfig.StartHeight, 0),
+		stateFilename:  stateFilename,
+		log:            log,
+		index:          newIndex(),
+		accounts:       map[string]*account{},
+		scriptAccounts: map[blockchain.ScriptHashHex]string{},
+		savedAccounts:  loaded.Accounts,
+		blockHashes:    map[int]chainhash.Hash{},
+		mempoolChecked: map[chainhash.Hash]struct{}{},
+		imported:       map[blockchain.ScriptHashHex]int64{},
+		stateSaveTime:  time.Now(),
+		wake:           make(chan struct{}, 1),
+		quit:           make(chan struct{}),
+	}
+}
+
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// trigger makes the client scan for new transactions without waiting for the poll interval.
+func (c *Client) trigger() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Client) run() {
+	for {
+		err := c.sync()
+		if c.isClosed() {
+			return
+		}
+		if err != nil {
+			c.log.WithError(err).Error("Syncing with bitcoind failed")
+		}
+		c.setConnectionError(err)
+		select {
+		case <-c.quit:
+			return
+		case <-c.wake:
+			select {
+			case <-c.quit:
+				return
+			case <-time.After(watchDelay):
+			}
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// sync scans new blocks and the mempool for the watched scripts and notifies the subscribers of
+// changes.
+func (c *Client) sync() error {
+	defer c.saveState()
+	var info struct {
+		Blocks int `json:"blocks"`
+	}
+	if err := c.rpc.call(&info, "getblockchaininfo"); err != nil {
+		return err
+	}
+	if err := c.handleReorg(); err != nil {
+		return err
+	}
+	if err := c.restoreAccounts(); err != nil {
+		return err
+	}
+	if err := c.scanPendingScripts(); err != nil {
+		return err
+	}
+	scannedHeight := func() int {
+		defer c.mu.RLock()()
+		scannedHeight := info.Blocks
+		for _, acc := range c.accounts {
+			scannedHeight = min(scannedHeight, acc.scannedHeight)
+		}
+		return scannedHeight
+	}()
+	if err := c.scanBlocks(scannedHeight+1, info.Blocks, nil, true); err != nil {
+		return err
+	}
+	if err := c.scanMempool(); err != nil {
+		return err
+	}
+	func() {
+		defer c.mu.Lock()()
+		for _, acc := range c.accounts {
+			if !acc.synced && acc.scannedHeight >= info.Blocks {
+				acc.synced = true
+				c.dirty = true
+			}
+		}
+		c.tipHeight = info.Blocks
+	}()
+	c.notify()
+	return nil
+}
+
+// saveState writes the scan progress of the accounts to the state file if it changed.
+func (c *Client) saveState() {
+	saved := func() *state {
+		defer c.mu.Lock()()
+		if !c.dirty {
+			return nil
+		}
+		c.dirty = false
+		saved := &state{Accounts: make(map[string]*accountState, len(c.savedAccounts)+len(c.accounts))}
+		for code, accState := range c.savedAccounts {
+			saved.Accounts[code] = accState
+		}
+		for code, acc := range c.accounts {
+			saved.Accounts[code] = acc.state()
+		}
+		return saved
+	}()
+	c.stateSaveTime = time.Now()
+	if saved == nil {
+		return
+	}
+	if err := saveState(c.stateFilename, saved); err != nil {
+		c.log.WithError(err).Error("Could not save the scan progress")
+	}
+}
+
+// rollback forgets the blocks above the given height after a reorg, so that they are scanned
+// again. The caller must hold the lock.
+func (c *Client) rollback(height int) {
+	c.index.rollback(height + 1)
+	for blockHeight := range c.blockHashes {
+		if blockHeight > height {
+			delete(c.blockHashes, blockHeight)
+		}
+	}
+	for _, acc := range c.accounts {
+		acc.rollback(height)
+	}
+	c.mempoolChecked = map[chainhash.Hash]struct{}{}
+	c.dirty = true
+}
+
+// handleReorg checks that the scanned blocks are still in the active chain, and rolls the index
+// back to the fork point if not.
+func (c *Client) handleReorg() error {
+	scannedHeight, blockHashes := func() (int, map[int]chainhash.Hash) {
+		defer c.mu.RLock()()
+		scannedHeight := c.startHeight - 1
+		blockHashes := make(map[int]chainhash.Hash, len(c.blockHashes))
+		for height, hash := range c.blockHashes {
+			blockHashes[height] = hash
+			scannedHeight = max(scannedHeight, height)
+		}
+		return scannedHeight, blockHashes
+	}()
+	forkHeight := scannedHeight
+	for ; forkHeight >= c.startHeight; forkHeight-- {
+		// In wallet mode, only the blocks with transactions and the last scanned block are
+		// recorded.
+		expected, ok := blockHashes[forkHeight]
+		if !ok {
+			continue
+		}
+		var hash string
+		err := c.rpc.call(&hash, "getblockhash", forkHeight)
+		if err != nil {
+			if _, ok := errp.Cause(err).(*RPCError); !ok {
+				return err
+			}
+			// The height is above the tip of the node, the chain got shorter.
+			continue
+		}
+		if hash == expected.String() {
+			break
+		}
+	}
+	if forkHeight == scannedHeight {
+		return nil
+	}
+	c.log.Infof("Reorg detected, rescanning from height %d", forkHeight+1)
+	defer c.mu.Lock()()
+	c.rollback(forkHeight)
+	return nil
+}
+
+// restoreAccounts restores the transactions of the scripts scanned in a previous session.
+func (c *Client) restoreAccounts() error {
+	restoring := func() []*account {
+		defer c.mu.RLock()()
+		restoring := []*account{}
+		for _, acc := range c.accounts {
+			if len(acc.restoring) > 0 {
+				restoring = append(restoring, acc)
+			}
+		}
+		return restoring
+	}()
+	for _, acc := range restoring {
+		if err := c.restoreAccount(acc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreAccount indexes the transactions of the scripts of the account scanned in a previous
+// session, only fetching the blocks which contained transactions of the account. If one of these
+// blocks or the last scanned block is no longer in the active chain, the blocks are scanned again
+// from the fork point.
+func (c *Client) restoreAccount(acc *account) error {
+	heights, hashes, scannedHeight, scannedHash := func() ([]int, []chainhash.Hash, int, chainhash.Hash) {
+		defer c.mu.RLock()()
+		heights := make([]int, 0, len(acc.matches))
+		for height := range acc.matches {
+			heights = append(heights, height)
+		}
+		sort.Ints(heights)
+		hashes := make([]chainhash.Hash, len(heights))
+		for i, height := range heights {
+			hashes[i] = acc.matches[height]
+		}
+		return heights, hashes, acc.scannedHeight, acc.scannedHash
+	}()
+	verifyHeights := append(append([]int{}, heights...), scannedHeight)
+	activeHashes := make([]string, len(verifyHeights))
+	calls := make([]*rpcCall, len(verifyHeights))
+	for i, height := range verifyHeights {
+		calls[i] = &rpcCall{
+			Method: "getblockhash",
+			Params: []interface{}{height},
+			Result: &activeHashes[i],
+		}
+	}
+	for start := 0; start < len(calls); start += batchSize {
+		if err := c.rpc.batch(calls[start:min(start+batchSize, len(calls))]); err != nil {
+			return err
+		}
+	}
+	for _, call := range calls {
+		// RPC errors mean that the height is above the tip of the node.
+		if call.Err != nil && !isRPCError(call.Err) {
+			return call.Err
+		}
+	}
+	valid := 0
+	for valid < len(heights) && activeHashes[valid] == hashes[valid].String() {
+		valid++
+	}
+	scannedValid := scannedHeight < acc.startHeight ||
+		(scannedHash != chainhash.Hash{} && activeHashes[len(heights)] == scannedHash.String())
+	forkHeight := scannedHeight
+	if valid < len(heights) || !scannedValid {
+		forkHeight = acc.startHeight - 1
+		if valid > 0 {
+			forkHeight = heights[valid-1]
+		}
+	}
+	blocks, err := c.getBlocks(hashes[:valid])
+	if err != nil {
+		return err
+	}
+
+	defer c.mu.Lock()()
+	if forkHeight < scannedHeight {
+		c.log.Infof("Reorg detected while restoring the scan progress, rescanning from height %d",
+			forkHeight+1)
+		c.rollback(forkHeight)
+	}
+	for scriptHash, pkScript := range acc.restoring {
+		c.index.watch(pkScript)
+		acc.scripts[scriptHash] = pkScript
+		delete(acc.restoring, scriptHash)
+	}
+	for i, block := range blocks {
+		for position, tx := range block.Transactions {
+			c.indexTx(tx, heights[i], position, hashes[i])
+		}
+	}
+	if forkHeight == scannedHeight && scannedHeight >= acc.startHeight {
+		c.blockHashes[scannedHeight] = scannedHash
+	}
+	// Mempool transactions checked before could involve the restored scripts.
+	c.mempoolChecked = map[chainhash.Hash]struct{}{}
+	c.dirty = true
+	return nil
+}
+
+// scanPendingScripts scans the blocks scanned before for the scripts watched since, from the
+// birthday of their account.
+func (c *Client) scanPendingScripts() error {
+	type pendingScan struct {
+		acc     *account
+		scripts map[blockchain.ScriptHashHex][]byte
+		from    int
+		to      int
+	}
+	scans := func() []*pendingScan {
+		defer c.mu.Lock()()
+		scans := []*pendingScan{}
+		for _, acc := range c.accounts {
+			if len(acc.pending) == 0 {
+				continue
+			}
+			scans = append(scans, &pendingScan{
+				acc:     acc,
+				scripts: acc.pending,
+				from:    acc.birthday(),
+				to:      acc.scannedHeight,
+			})
+			for scriptHash, pkScript := range acc.pending {
+				c.index.watch(pkScript)
+				acc.scripts[scriptHash] = pkScript
+			}
+			acc.pending = map[blockchain.ScriptHashHex][]byte{}
+		}
+		if len(scans) > 0 {
+			// Mempool transactions checked before could involve the new scripts.
+			c.mempoolChecked = map[chainhash.Hash]struct{}{}
+			c.dirty = true
+		}
+		return scans
+	}()
+	for i, scan := range scans {
+		scripts := make([][]byte, 0, len(scan.scripts))
+		for _, pkScript := range scan.scripts {
+			scripts = append(scripts, pkScript)
+		}
+		if err := c.scanBlocks(scan.from, scan.to, scripts, false); err != nil {
+			// Scan for them again next time.
+			defer c.mu.Lock()()
+			for _, scan := range scans[i:] {
+				for scriptHash, pkScript := range scan.scripts {
+					delete(scan.acc.scripts, scriptHash)
+					scan.acc.pending[scriptHash] = pkScript
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// indexTx indexes the transaction confirmed in the given block, and records the block as a match
+// of the accounts of the scripts involved. The caller must hold the lock.
+func (c *Client) indexTx(tx *wire.MsgTx, height int, position int, blockHash chainhash.Hash) {
+	if !c.index.addTx(tx, height, position) {
+		return
+	}
+	for scriptHash := range c.index.txs[tx.TxHash()].scriptHashes {
+		acc, ok := c.accounts[c.scriptAccounts[scriptHash]]
+		if !ok {
+			continue
+		}
+		if _, ok := acc.matches[height]; !ok {
+			acc.matches[height] = blockHash
+			c.dirty = true
+		}
+	}
+	c.blockHashes[height] = blockHash
+}
+
+// advancingAccounts returns the accounts scanned up to below the given height, and their scripts.
+// The caller must hold the lock.
+func (c *Client) advancingAccounts(height int) ([]*account, [][]byte) {
+	accounts := []*account{}
+	scripts := [][]byte{}
+	for _, acc := range c.accounts {
+		if acc.scannedHeight >= height {
+			continue
+		}
+		accounts = append(accounts, acc)
+		for _, pkScript := range acc.scripts {
+			scripts = append(scripts, pkScript)
+		}
+	}
+	return accounts, scripts
+}
+
+// advance marks the accounts as scanned up to the given block. The caller must hold the lock.
+func (c *Client) advance(accounts []*account, height int, blockHash chainhash.Hash) {
+	for _, acc := range accounts {
+		if acc.scannedHeight >= height {
+			continue
+		}
+		acc.scannedHeight = height
+		acc.scannedHash = blockHash
+		// The saved scripts which were not watched yet were not scanned for in the new blocks.
+		acc.saved = nil
+		for scriptHash, pkScript := range acc.restoring {
+			acc.pending[scriptHash] = pkScript
+			delete(acc.restoring, scriptHash)
+		}
+	}
+	c.blockHashes[height] = blockHash
+	c.dirty = true
+}
+
+// getBlockHashes returns the hashes of the blocks in the active chain from height `from` to `to`
+// (inclusive).
+func (c *Client) getBlockHashes(from int, to int) ([]chainhash.Hash, error) {
+	calls := []*rpcCall{}
+	hashStrings := make([]string, to-from+1)
+	for height := from; height <= to; height++ {
+		calls = append(calls, &rpcCall{
+			Method: "getblockhash",
+			Params: []interface{}{height},
+			Result: &hashStrings[height-from],
+		})
+	}
+	for start := 0; start < len(calls); start += batchSize {
+		if err := c.rpc.batchAll(calls[start:min(start+batchSize, len(calls))]); err != nil {
+			return nil, err
+		}
+	}
+	hashes := make([]chainhash.Hash, len(hashStrings))
+	for i, hashString := range hashStrings {
+		hash, err := chainhash.NewHashFromStr(hashString)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		hashes[i] = *hash
+	}
+	return hashes, nil
+}
+
+// getScanMode returns how the blocks are scanned, checking what the node supports with the block at
+// the given height the first time. Compact block filters are preferred, as the wallet of the node
+// needs to rescan the blockchain for each new script, and are the only way to scan a pruned node.
+func (c *Client) getScanMode(height int) (scanMode, error) {
+	mode := func() scanMode {
+		defer c.mu.RLock()()
+		return c.mode
+	}()
+	if mode != scanModeUnknown {
+		return mode, nil
+	}
+	var blockHash string
+	if err := c.rpc.call(&blockHash, "getblockhash", height); err != nil {
+		return 0, err
+	}
+	var result struct {
+		Filter string `json:"filter"`
+	}
+	err := c.rpc.call(&result, "getblockfilter", blockHash, "basic")
+	switch {
+	case err == nil:
+		mode = scanModeFilters
+	case isRPCError(err):
+		walletAvailable, err := c.setupWallet()
+		if err != nil {
+			return 0, err
+		}
+		if walletAvailable {
+			c.log.Info("Block filters not available, using the wallet of the node. " +
+				"Enable -blockfilterindex on the node to sync faster.")
+			mode = scanModeWallet
+		} else {
+			c.log.Warning("Block filters and the wallet of the node not available, scanning full " +
+				"blocks. Enable -blockfilterindex on the node to sync faster.")
+			mode = scanModeBlocks
+		}
+	default:
+		return 0, err
+	}
+	defer c.mu.Lock()()
+	c.mode = mode
+	return mode, nil
+}
+
+// matchFilters returns the positions of the blocks whose compact block filter matches any of the
+// scripts.
+func (c *Client) matchFilters(hashes []chainhash.Hash, scripts [][]byte) ([]int, error) {
+	results := make([]struct {
+		Filter string `json:"filter"`
+	}, len(hashes))
+	calls := make([]*rpcCall, len(hashes))
+	for i, hash := range hashes {
+		calls[i] = &rpcCall{
+			Method: "getblockfilter",
+			Params: []interface{}{hash.String(), "basic"},
+			Result: &results[i],
+		}
+	}
+	if err := c.rpc.batchAll(calls); err != nil {
+		return nil, err
+	}
+	matches := []int{}
+	for i, result := range results {
+		filterBytes, err := hex.DecodeString(result.Filter)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		filter, err := gcs.FromNBytes(builder.DefaultP, builder.DefaultM, filterBytes)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if filter.N() == 0 {
+			continue
+		}
+		match, err := filter.MatchAny(builder.DeriveKey(&hashes[i]), scripts)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if match {
+			matches = append(matches, i)
+		}
+	}
+	return matches, nil
+}
+
+// getBlocks fetches the full blocks.
+func (c *Client) getBlocks(hashes []chainhash.Hash) ([]*wire.MsgBlock, error) {
+	blocks := make([]*wire.MsgBlock, 0, len(hashes))
+	for start := 0; start < len(hashes); start += blocksBatchSize {
+		batch := hashes[start:min(start+blocksBatchSize, len(hashes))]
+		rawBlocks := make([]string, len(batch))
+		calls := make([]*rpcCall, len(batch))
+		for i, hash := range batch {
+			calls[i] = &rpcCall{
+				Method: "getblock",
+				Params: []interface{}{hash.String(), 0},
+				Result: &rawBlocks[i],
+			}
+		}
+		if err := c.rpc.batchAll(calls); err != nil {
+			return nil, err
+		}
+		for _, rawBlock := range rawBlocks {
+			blockBytes, err := hex.DecodeString(rawBlock)
+			if err != nil {
+				return nil, errp.WithStack(err)
+			}
+			block := &wire.MsgBlock{}
+			if err := block.Deserialize(bytes.NewReader(blockBytes)); err != nil {
+				return nil, errp.WithStack(err)
+			}
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+// scanBlocks indexes the transactions of the blocks from height `from` to `to` (inclusive)
+// involving the given scripts, or the scripts of the accounts not scanned up to there yet if nil.
+// If record is true, the blocks are marked as scanned.
+func (c *Client) scanBlocks(from int, to int, scripts [][]byte, record bool) error {
+	if from > to {
+		return nil
+	}
+	mode, err := c.getScanMode(from)
+	if err != nil {
+		return err
+	}
+	if mode == scanModeWallet {
+		return c.scanWallet(from, to, scripts, record)
+	}
+	for batchStart := from; batchStart <= to; batchStart += batchSize {
+		if c.isClosed() {
+			return errClosed
+		}
+		batchEnd := min(batchStart+batchSize-1, to)
+		hashes, err := c.getBlockHashes(batchStart, batchEnd)
+		if err != nil {
+			return err
+		}
+		var advancing []*account
+		batchScripts := scripts
+		if batchScripts == nil {
+			advancing, batchScripts = func() ([]*account, [][]byte) {
+				defer c.mu.RLock()()
+				return c.advancingAccounts(batchEnd)
+			}()
+		}
+		// Positions of the blocks to fetch in hashes.
+		matches := []int{}
+		if len(batchScripts) > 0 {
+			if mode == scanModeFilters {
+				matches, err = c.matchFilters(hashes, batchScripts)
+				if err != nil {
+					return err
+				}
+			} else {
+				for i := range hashes {
+					matches = append(matches, i)
+				}
+			}
+		}
+		matchingHashes := make([]chainhash.Hash, len(matches))
+		for i, match := range matches {
+			matchingHashes[i] = hashes[match]
+		}
+		blocks, err := c.getBlocks(matchingHashes)
+		if err != nil {
+			return err
+		}
+		func() {
+			defer c.mu.Lock()()
+			for i, block := range blocks {
+				height := batchStart + matches[i]
+				for position, tx := range block.Transactions {
+					c.indexTx(tx, height, position, matchingHashes[i])
+				}
+			}
+			if record {
+				for i, hash := range hashes {
+					c.blockHashes[batchStart+i] = hash
+				}
+				c.advance(advancing, batchEnd, hashes[len(hashes)-1])
+			}
+		}()
+		// Keep the progress of long scans.
+		if record && time.Since(c.stateSaveTime) >= stateSaveInterval {
+			c.saveState()
+		}
+	}
+	return nil
+}
+
+// scanMempool indexes the mempool transactions involving the watched scripts, and removes the
+// unconfirmed transactions which left the mempool, e.g. because they were replaced.
+func (c *Client) scanMempool() error {
+	var txIDs []string
+	if err := c.rpc.call(&txIDs, "getrawmempool"); err != nil {
+		return err
+	}
+	mempool := make(map[chainhash.Hash]struct{}, len(txIDs))
+	for _, txID := range txIDs {
+		txHash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		mempool[*txHash] = struct{}{}
+	}
+	unchecked := func() []chainhash.Hash {
+		defer c.mu.RLock()()
+		unchecked := []chainhash.Hash{}
+		for txHash := range mempool {
+			if _, ok := c.mempoolChecked[txHash]; !ok {
+				unchecked = append(unchecked, txHash)
+			}
+		}
+		return unchecked
+	}()
+
+	newTxs := []*wire.MsgTx{}
+	for start := 0; start < len(unchecked); start += batchSize {
+		batch := unchecked[start:min(start+batchSize, len(unchecked))]
+		rawTxs := make([]string, len(batch))
+		calls := make([]*rpcCall, len(batch))
+		for i, txHash := range batch {
+			calls[i] = &rpcCall{
+				Method: "getrawtransaction",
+				Params: []interface{}{txHash.String(), false},
+				Result: &rawTxs[i],
+			}
+		}
+		if err := c.rpc.batch(calls); err != nil {
+			return err
+		}
+		for i, call := range calls {
+			if call.Err != nil {
+				// The transaction left the mempool in the meantime.
+				continue
+			}
+			tx, err := decodeTx(rawTxs[i])
+			if err != nil {
+				return err
+			}
+			newTxs = append(newTxs, tx)
+		}
+	}
+
+	defer c.mu.Lock()()
+	c.index.removeTxs(func(tx *indexedTx) bool {
+		_, inMempool := mempool[tx.tx.TxHash()]
+		return !tx.confirmed() && !inMempool
+	})
+	unconfirmedHeight := func(tx *wire.MsgTx) int {
+		for _, txIn := range tx.TxIn {
+			if _, ok := mempool[txIn.PreviousOutPoint.Hash]; ok {
+				return -1
+			}
+		}
+		return 0
+	}
+	for _, tx := range c.index.txs {
+		if !tx.confirmed() {
+			tx.height = unconfirmedHeight(tx.tx)
+		}
+	}
+	// Children can be checked before their parents, so repeat until no more transactions are
+	// found.
+	for {
+		found := false
+		remaining := newTxs[:0]
+		for _, tx := range newTxs {
+			if c.index.addTx(tx, unconfirmedHeight(tx), 0) {
+				found = true
+			} else {
+				remaining = append(remaining, tx)
+			}
+		}
+		newTxs = remaining
+		if !found {
+			break
+		}
+	}
+	checked := make(map[chainhash.Hash]struct{}, len(mempool))
+	for txHash := range mempool {
+		checked[txHash] = struct{}{}
+	}
+	c.mempoolChecked = checked
+	return nil
+}
+
+// notify notifies the subscribers whose script status changed, and the headers subscribers if
+// there is a new tip.
+func (c *Client) notify() {
+	var notifications []func()
+	func() {
+		defer c.mu.Lock()()
+		for _, sub := range c.subscriptions {
+			if !c.index.watched(sub.scriptHash) {
+				continue
+			}
+			status := c.index.history(sub.scriptHash).Status()
+			if sub.notified && sub.status == status {
+				continue
+			}
+			firstNotification := !sub.notified
+			sub.notified = true
+			sub.status = status
+			result, teardown := sub.result, sub.teardown
+			notifications = append(notifications, func() {
+				result(status)
+				if firstNotification {
+					teardown()
+				}
+			})
+		}
+		if c.tipHeight != c.notifiedTip {
+			c.notifiedTip = c.tipHeight
+			header := &types.Header{Height: c.tipHeight}
+			for _, callback := range c.headersCallbacks {
+				notifications = append(notifications, func() { callback(header) })
+			}
+		}
+	}()
+	for _, notification := range notifications {
+		notification()
+	}
+}
+
+func decodeTx(rawTx string) (*wire.MsgTx, error) {
+	txBytes, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	tx := &wire.MsgTx{}
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return tx, nil
+}
+
+func (c *Client) setConnectionError(err error) {
+	defer c.connectionErrorLock.Lock()()
+	if err == c.connectionError || (err != nil && c.connectionError != nil) {
+		return
+	}
+	c.connectionError = err
+	for _, callback := range c.onConnectionErrorChangedCallbacks {
+		go callback(err)
+	}
+}
+
+// WatchScript implements blockchain.ScriptWatcher.
+func (c *Client) WatchScript(accountCode string, pkScript []byte) {
+	scriptHash := blockchain.NewScriptHashHex(pkScript)
+	func() {
+		defer c.mu.Lock()()
+		if _, ok := c.scriptAccounts[scriptHash]; ok {
+			return
+		}
+		startHeight := c.scriptStartHeight(pkScript)
+		acc := c.account(accountCode, startHeight)
+		if _, ok := acc.saved[scriptHash]; !ok && acc.saved != nil &&
+			len(acc.scripts) == 0 && len(acc.restoring) == 0 && len(acc.pending) == 0 {
+			// The first script of the account was not scanned before, so the saved state belongs to
+			// a different account with the same code.
+			c.log.WithField("account", accountCode).Info("Discarding the saved scan progress")
+			acc = newAccount(startHeight)
+			c.accounts[accountCode] = acc
+		}
+		acc.lowerStartHeight(startHeight)
+		if _, ok := acc.saved[scriptHash]; ok {
+			acc.restoring[scriptHash] = pkScript
+		} else {
+			acc.pending[scriptHash] = pkScript
+		}
+		c.scriptAccounts[scriptHash] = accountCode
+	}()
+	c.trigger()
+}
+
+// scriptStartHeight returns the height from which the script is scanned for: the configured start
+// height, or the activation of segwit for segwit scripts. Other scripts, and segwit scripts on
+// networks with an unknown activation height, are scanned for from the genesis block.
+func (c *Client) scriptStartHeight(pkScript []byte) int {
+	if c.startHeight > 0 {
+		return c.startHeight
+	}
+	if !txscript.IsWitnessProgram(pkScript) {
+		return 0
+	}
+	if height, ok := segwitActivationHeights[c.net.Net]; ok {
+		return height
+	}
+	return 0
+}
+
+// account returns the account with the given code, restoring it from the state file or creating
+// it with the given start height if it is not watched yet. The caller must hold the lock.
+func (c *Client) account(code string, startHeight int) *account {
+	if acc, ok := c.accounts[code]; ok {
+		return acc
+	}
+	acc := newAccount(startHeight)
+	if accState, ok := c.savedAccounts[code]; ok {
+		delete(c.savedAccounts, code)
+		restored, err := newAccountFromState(accState)
+		if err != nil {
+			c.log.WithError(err).WithField("account", code).Error("Could not restore the scan progress")
+		} else {
+			acc = restored
+		}
+	}
+	c.accounts[code] = acc
+	return acc
+}
+
+// ScriptHashGetHistory implements blockchain.Interface.
+func (c *Client) ScriptHashGetHistory(scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
+	defer c.mu.RLock()()
+	return c.index.history(scriptHashHex), nil
+}
+
+// TransactionGet implements blockchain.Interface. Transactions not involving the watched scripts
+// are fetched from the node, which requires the transaction index (`-txindex`) unless they are in
+// the mempool.
+func (c *Client) TransactionGet(txHash chainhash.Hash) (*wire.MsgTx, error) {
+	tx := func() *wire.MsgTx {
+		defer c.mu.RLock()()
+		if indexed, ok := c.index.txs[txHash]; ok {
+			return indexed.tx
+		}
+		return nil
+	}()
+	if tx != nil {
+		return tx, nil
+	}
+	var rawTx string
+	if err := c.rpc.call(&rawTx, "getrawtransaction", txHash.String(), false); err != nil {
+		return nil, err
+	}
+	return decodeTx(rawTx)
+}
+
+// ScriptHashSubscribe implements blockchain.Interface. The script must have been registered with
+// WatchScript() before. The status is reported once the blocks were scanned for the script.
+func (c *Client) ScriptHashSubscribe(
+	setupAndTeardown func() func(),
+	scriptHashHex blockchain.ScriptHashHex,
+	result func(string)) {
+	teardown := setupAndTeardown()
+	defer c.trigger()
+	defer c.mu.Lock()()
+	if _, ok := c.scriptAccounts[scriptHashHex]; !ok {
+		c.log.WithField("script-hash", scriptHashHex).Error("Subscribed to a script which is not watched")
+	}
+	c.subscriptions = append(c.subscriptions, &subscription{
+		scriptHash: scriptHashHex,
+		result:     result,
+		teardown:   teardown,
+	})
+}
+
+// HeadersSubscribe implements blockchain.Interface.
+func (c *Client) HeadersSubscribe(result func(*types.Header)) {
+	defer c.mu.Lock()()
+	c.headersCallbacks = append(c.headersCallbacks, result)
+	if c.notifiedTip > 0 {
+		header := &types.Header{Height: c.notifiedTip}
+		go result(header)
+	}
+}
+
+// TransactionBroadcast implements blockchain.Interface.
+func (c *Client) TransactionBroadcast(transaction *wire.MsgTx) error {
+	rawTx := &bytes.Buffer{}
+	if err := transaction.Serialize(rawTx); err != nil {
+		return errp.WithStack(err)
+	}
+	var txID string
+	if err := c.rpc.call(&txID, "sendrawtransaction", hex.EncodeToString(rawTx.Bytes())); err != nil {
+		return err
+	}
+	if txID != transaction.TxHash().String() {
+		return errp.New("Response is unexpected (transaction hash mismatch)")
+	}
+	c.trigger()
+	return nil
+}
+
+// RelayFee implements blockchain.Interface.
+func (c *Client) RelayFee() (btcutil.Amount, error) {
+	var info struct {
+		RelayFee float64 `json:"relayfee"`
+	}
+	if err := c.rpc.call(&info, "getnetworkinfo"); err != nil {
+		return 0, err
+	}
+	return btcutil.NewAmount(info.RelayFee)
+}
+
+// EstimateFee implements blockchain.Interface.
+func (c *Client) EstimateFee(number int) (btcutil.Amount, error) {
+	var result struct {
+		FeeRate *float64 `json:"feerate"`
+		Errors  []string `json:"errors"`
+	}
+	if err := c.rpc.call(&result, "estimatesmartfee", number); err != nil {
+		return 0, err
+	}
+	if result.FeeRate == nil {
+		return 0, errp.Newf("fee could not be estimated: %v", result.Errors)
+	}
+	return btcutil.NewAmount(*result.FeeRate)
+}
+
+// FeeHistogram implements blockchain.Interface. It is computed from the mempool of the node and
+// cached for feeHistogramCacheDuration.
+func (c *Client) FeeHistogram() (blockchain.FeeHistogram, error) {
+	defer c.feeHistogramLock.Lock()()
+	if c.feeHistogram != nil && time.Since(c.feeHistogramTime) < feeHistogramCacheDuration {
+		return c.feeHistogram, nil
+	}
+	var mempool map[string]struct {
+		VSize int64 `json:"vsize"`
+		Fees  struct {
+			Base float64 `json:"base"`
+		} `json:"fees"`
+	}
+	if err := c.rpc.call(&mempool, "getrawmempool", true); err != nil {
+		return nil, err
+	}
+	// Transactions are grouped by fee rate rounded down to 0.1 sat/vB.
+	vsizes := map[int64]int64{}
+	for _, entry := range mempool {
+		if entry.VSize <= 0 {
+			continue
+		}
+		fee, err := btcutil.NewAmount(entry.Fees.Base)
+		if err != nil {
+			continue
+		}
+		feeRate := float64(fee) / float64(entry.VSize)
+		vsizes[int64(feeRate*10)] += entry.VSize
+	}
+	histogram := make(blockchain.FeeHistogram, 0, len(vsizes))
+	for feeRate, vsize := range vsizes {
+		histogram = append(histogram, blockchain.FeeHistogramEntry{
+			FeeRate: float64(feeRate) / 10,
+			VSize:   vsize,
+		})
+	}
+	sort.Slice(histogram, func(i, j int) bool {
+		return histogram[i].FeeRate > histogram[j].FeeRate
+	})
+	c.feeHistogram = histogram
+	c.feeHistogramTime = time.Now()
+	return histogram, nil
+}
+
+// Headers implements blockchain.Interface.
+func (c *Client) Headers(startHeight int, count int) (*blockchain.HeadersResult, error) {
+	var tipHeight int
+	if err := c.rpc.call(&tipHeight, "getblockcount"); err != nil {
+		return nil, err
+	}
+	endHeight := min(startHeight+min(count, maxHeaders), tipHeight+1)
+	if endHeight <= startHeight {
+		return &blockchain.HeadersResult{Headers: []*wire.BlockHeader{}, Max: maxHeaders}, nil
+	}
+	hashes, err := c.getBlockHashes(startHeight, endHeight-1)
+	if err != nil {
+		return nil, err
+	}
+	rawHeaders := make([]string, len(hashes))
+	calls := make([]*rpcCall, len(hashes))
+	for i, hash := range hashes {
+		calls[i] = &rpcCall{
+			Method: "getblockheader",
+			Params: []interface{}{hash.String(), false},
+			Result: &rawHeaders[i],
+		}
+	}
+	for start := 0; start < len(calls); start += batchSize {
+		if err := c.rpc.batchAll(calls[start:min(start+batchSize, len(calls))]); err != nil {
+			return nil, err
+		}
+	}
+	headers := make([]*wire.BlockHeader, len(rawHeaders))
+	for i, rawHeader := range rawHeaders {
+		headerBytes, err := hex.DecodeString(rawHeader)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		header := &wire.BlockHeader{}
+		if err := header.Deserialize(bytes.NewReader(headerBytes)); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		headers[i] = header
+	}
+	return &blockchain.HeadersResult{Headers: headers, Max: maxHeaders}, nil
+}
+
+// GetMerkle implements blockchain.Interface.
+func (c *Client) GetMerkle(txHash chainhash.Hash, height int) (*blockchain.GetMerkleResult, error) {
+	var blockHash string
+	if err := c.rpc.call(&blockHash, "getblockhash", height); err != nil {
+		return nil, err
+	}
+	var block struct {
+		Tx []string `json:"tx"`
+	}
+	if err := c.rpc.call(&block, "getblock", blockHash, 1); err != nil {
+		return nil, err
+	}
+	position := -1
+	txHashes := make([]chainhash.Hash, len(block.Tx))
+	for i, txID := range block.Tx {
+		hash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		txHashes[i] = *hash
+		if *hash == txHash {
+			position = i
+		}
+	}
+	if position < 0 {
+		return nil, errp.Newf("transaction %s not in block %d", txHash, height)
+	}
+	return &blockchain.GetMerkleResult{
+		Merkle: merkleBranch(txHashes, position),
+		Pos:    position,
+	}, nil
+}
+
+// Close implements blockchain.Interface.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.quit) })
+}
+
+// ConnectionError implements blockchain.Interface.
+func (c *Client) ConnectionError() error {
+	defer c.connectionErrorLock.RLock()()
+	return c.connectionError
+}
+
+// RegisterOnConnectionErrorChangedEvent implements blockchain.Interface.
+func (c *Client) RegisterOnConnectionErrorChangedEvent(callback func(error)) {
+	defer c.connectionErrorLock.Lock()()
+	c.onConnectionErrorChangedCallbacks = append(c.onConnectionErrorChangedCallbacks, callback)
+}
+
+// ManualReconnect implements blockchain.Interface.
+func (c *Client) ManualReconnect() {
+	c.trigger()
+}
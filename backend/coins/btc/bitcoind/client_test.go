@@ -0,0 +1,714 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package bitcoind
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	btcdblockchain "github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNode serves the subset of the Bitcoin Core JSON-RPC interface used by the client.
+type fakeNode struct {
+	t       *testing.T
+	mu      sync.Mutex
+	filters bool
+	// wallet is true if the wallet of the node is enabled.
+	wallet       bool
+	walletExists bool
+	walletLoaded bool
+	// imported are the timestamps of the scripts imported into the wallet by hex encoded script.
+	imported   map[string]int64
+	blocks     []*wire.MsgBlock
+	mempool    []*wire.MsgTx
+	mempoolFee btcutil.Amount
+	// calls counts the calls by method.
+	calls map[string]int
+}
+
+func (node *fakeNode) txs() map[chainhash.Hash]*wire.MsgTx {
+	txs := map[chainhash.Hash]*wire.MsgTx{}
+	for _, block := range node.blocks {
+		for _, tx := range block.Transactions {
+			txs[tx.TxHash()] = tx
+		}
+	}
+	for _, tx := range node.mempool {
+		txs[tx.TxHash()] = tx
+	}
+	return txs
+}
+
+func (node *fakeNode) block(hashString string) *wire.MsgBlock {
+	if height := node.height(hashString); height >= 0 {
+		return node.blocks[height]
+	}
+	return nil
+}
+
+func (node *fakeNode) height(hashString string) int {
+	for height, block := range node.blocks {
+		if block.BlockHash().String() == hashString {
+			return height
+		}
+	}
+	return -1
+}
+
+// walletEntries returns the entries of listsinceblock for the transaction, one for each output
+// paying to and each input spending from an imported script.
+func (node *fakeNode) walletEntries(tx *wire.MsgTx, txs map[chainhash.Hash]*wire.MsgTx) int {
+	entries := 0
+	for _, txIn := range tx.TxIn {
+		prevTx, ok := txs[txIn.PreviousOutPoint.Hash]
+		if !ok {
+			continue
+		}
+		if _, ok := node.imported[hex.EncodeToString(prevTx.TxOut[txIn.PreviousOutPoint.Index].PkScript)]; ok {
+			entries++
+		}
+	}
+	for _, txOut := range tx.TxOut {
+		if _, ok := node.imported[hex.EncodeToString(txOut.PkScript)]; ok {
+			entries++
+		}
+	}
+	return entries
+}
+
+func serialize(t *testing.T, serializable interface{ Serialize(io.Writer) error }) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	require.NoError(t, serializable.Serialize(buf))
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func (node *fakeNode) handle(method string, params []json.RawMessage, wallet bool) (interface{}, *RPCError) {
+	t := node.t
+	if node.calls == nil {
+		node.calls = map[string]int{}
+	}
+	node.calls[method]++
+	stringParam := func(i int) string {
+		var param string
+		require.NoError(t, json.Unmarshal(params[i], &param))
+		return param
+	}
+	intParam := func(i int) int {
+		var param int
+		require.NoError(t, json.Unmarshal(params[i], &param))
+		return param
+	}
+	notFound := &RPCError{Code: -5, Message: "not found"}
+	switch method {
+	case "getblockchaininfo":
+		return map[string]interface{}{"blocks": len(node.blocks) - 1}, nil
+	case "getblockcount":
+		return len(node.blocks) - 1, nil
+	case "getblockhash":
+		height := intParam(0)
+		if height < 0 || height >= len(node.blocks) {
+			return nil, &RPCError{Code: -8, Message: "Block height out of range"}
+		}
+		return node.blocks[height].BlockHash().String(), nil
+	case "getblockfilter":
+		if !node.filters {
+			return nil, &RPCError{Code: -1, Message: "Index is not enabled for filtertype basic"}
+		}
+		block := node.block(stringParam(0))
+		if block == nil {
+			return nil, notFound
+		}
+		txs := node.txs()
+		prevOutScripts := [][]byte{}
+		for _, tx := range block.Transactions[1:] {
+			for _, txIn := range tx.TxIn {
+				prevTx, ok := txs[txIn.PreviousOutPoint.Hash]
+				if !ok {
+					// Outputs of made up transactions.
+					continue
+				}
+				prevOutScripts = append(prevOutScripts, prevTx.TxOut[txIn.PreviousOutPoint.Index].PkScript)
+			}
+		}
+		filter, err := builder.BuildBasicFilter(block, prevOutScripts)
+		require.NoError(t, err)
+		filterBytes, err := filter.NBytes()
+		require.NoError(t, err)
+		return map[string]string{"filter": hex.EncodeToString(filterBytes)}, nil
+	case "getblock":
+		block := node.block(stringParam(0))
+		if block == nil {
+			return nil, notFound
+		}
+		if intParam(1) == 0 {
+			return serialize(t, block), nil
+		}
+		txIDs := []string{}
+		for _, tx := range block.Transactions {
+			txIDs = append(txIDs, tx.TxHash().String())
+		}
+		return map[string]interface{}{"tx": txIDs}, nil
+	case "getblockheader":
+		height := node.height(stringParam(0))
+		if height < 0 {
+			return nil, notFound
+		}
+		var verbose bool
+		if len(params) > 1 {
+			require.NoError(t, json.Unmarshal(params[1], &verbose))
+		}
+		if verbose {
+			return map[string]interface{}{
+				"height": height,
+				"time":   node.blocks[height].Header.Timestamp.Unix(),
+			}, nil
+		}
+		return serialize(t, &node.blocks[height].Header), nil
+	case "getrawmempool":
+		if len(params) > 0 {
+			entries := map[string]interface{}{}
+			for _, tx := range node.mempool {
+				entries[tx.TxHash().String()] = map[string]interface{}{
+					"vsize": tx.SerializeSize(),
+					"fees":  map[string]float64{"base": node.mempoolFee.ToBTC()},
+				}
+			}
+			return entries, nil
+		}
+		txIDs := []string{}
+		for _, tx := range node.mempool {
+			txIDs = append(txIDs, tx.TxHash().String())
+		}
+		return txIDs, nil
+	case "getrawtransaction":
+		tx, ok := node.txs()[*mustHash(t, stringParam(0))]
+		if !ok {
+			return nil, notFound
+		}
+		return serialize(t, tx), nil
+	case "sendrawtransaction":
+		txBytes, err := hex.DecodeString(stringParam(0))
+		require.NoError(t, err)
+		tx := &wire.MsgTx{}
+		require.NoError(t, tx.Deserialize(bytes.NewReader(txBytes)))
+		node.mempool = append(node.mempool, tx)
+		return tx.TxHash().String(), nil
+	case "getnetworkinfo":
+		return map[string]interface{}{"relayfee": 0.00001}, nil
+	case "estimatesmartfee":
+		if intParam(0) > 100 {
+			return map[string]interface{}{"errors": []string{"Insufficient data or no feerate found"}}, nil
+		}
+		return map[string]interface{}{"feerate": 0.0002}, nil
+	case "loadwallet", "createwallet":
+		if !node.wallet {
+			break
+		}
+		require.Equal(t, walletName, stringParam(0))
+		if method == "createwallet" {
+			node.walletExists = true
+			node.imported = map[string]int64{}
+		} else if node.walletLoaded {
+			return nil, &RPCError{Code: rpcErrorWalletAlreadyLoaded, Message: "Wallet already loaded"}
+		} else if !node.walletExists {
+			return nil, &RPCError{Code: rpcErrorWalletNotFound, Message: "Path does not exist"}
+		}
+		node.walletLoaded = true
+		return map[string]string{"name": walletName}, nil
+	case "getdescriptorinfo":
+		if !node.wallet {
+			break
+		}
+		return map[string]string{"descriptor": stringParam(0) + "#checksum"}, nil
+	case "listdescriptors", "importdescriptors", "listsinceblock", "gettransaction":
+		if !node.wallet {
+			break
+		}
+		require.True(t, wallet && node.walletLoaded)
+		return node.handleWallet(method, params)
+	}
+	return nil, &RPCError{Code: -32601, Message: "Method not found"}
+}
+
+func (node *fakeNode) handleWallet(method string, params []json.RawMessage) (interface{}, *RPCError) {
+	t := node.t
+	switch method {
+	case "listdescriptors":
+		descriptors := []map[string]interface{}{}
+		for script, timestamp := range node.imported {
+			descriptors = append(descriptors, map[string]interface{}{
+				"desc":      "raw(" + script + ")#checksum",
+				"timestamp": timestamp,
+			})
+		}
+		return map[string]interface{}{"descriptors": descriptors}, nil
+	case "importdescriptors":
+		var requests []struct {
+			Desc      string `json:"desc"`
+			Timestamp int64  `json:"timestamp"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &requests))
+		results := []map[string]bool{}
+		for _, request := range requests {
+			require.True(t, strings.HasPrefix(request.Desc, "raw(") && strings.HasSuffix(request.Desc, ")#checksum"))
+			node.imported[strings.TrimSuffix(strings.TrimPrefix(request.Desc, "raw("), ")#checksum")] = request.Timestamp
+			results = append(results, map[string]bool{"success": true})
+		}
+		return results, nil
+	case "listsinceblock":
+		var sinceBlock string
+		require.NoError(t, json.Unmarshal(params[0], &sinceBlock))
+		sinceHeight := -1
+		if sinceBlock != "" {
+			sinceHeight = node.height(sinceBlock)
+			if sinceHeight < 0 {
+				return nil, &RPCError{Code: -5, Message: "Block not found"}
+			}
+		}
+		txs := node.txs()
+		entries := []map[string]interface{}{}
+		for height := sinceHeight + 1; height < len(node.blocks); height++ {
+			block := node.blocks[height]
+			for position, tx := range block.Transactions {
+				for i := 0; i < node.walletEntries(tx, txs); i++ {
+					entries = append(entries, map[string]interface{}{
+						"txid":        tx.TxHash().String(),
+						"blockhash":   block.BlockHash().String(),
+						"blockheight": height,
+						"blockindex":  position,
+					})
+				}
+			}
+		}
+		for _, tx := range node.mempool {
+			if node.walletEntries(tx, txs) > 0 {
+				entries = append(entries, map[string]interface{}{"txid": tx.TxHash().String()})
+			}
+		}
+		return map[string]interface{}{
+			"transactions": entries,
+			"lastblock":    node.blocks[len(node.blocks)-1].BlockHash().String(),
+		}, nil
+	default:
+		var txID string
+		require.NoError(t, json.Unmarshal(params[0], &txID))
+		tx, ok := node.txs()[*mustHash(t, txID)]
+		if !ok {
+			return nil, &RPCError{Code: -5, Message: "Invalid or non-wallet transaction id"}
+		}
+		return map[string]string{"hex": serialize(t, tx)}, nil
+	}
+}
+
+func (node *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, password, ok := r.BasicAuth()
+	if !ok || user != "user" || password != "password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	type request struct {
+		ID     int64             `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	body, err := io.ReadAll(r.Body)
+	require.NoError(node.t, err)
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	respond := func(req request) map[string]interface{} {
+		result, rpcErr := node.handle(req.Method, req.Params, r.URL.Path == "/wallet/"+walletName)
+		return map[string]interface{}{"id": req.ID, "result": result, "error": rpcErr}
+	}
+	var response interface{}
+	var requests []request
+	if json.Unmarshal(body, &requests) == nil {
+		responses := []interface{}{}
+		for _, req := range requests {
+			responses = append(responses, respond(req))
+		}
+		response = responses
+	} else {
+		var req request
+		require.NoError(node.t, json.Unmarshal(body, &req))
+		response = respond(req)
+	}
+	require.NoError(node.t, json.NewEncoder(w).Encode(response))
+}
+
+func mustHash(t *testing.T, hashString string) *chainhash.Hash {
+	t.Helper()
+	hash, err := chainhash.NewHashFromStr(hashString)
+	require.NoError(t, err)
+	return hash
+}
+
+func pkScript(b byte) []byte {
+	return append([]byte{0x00, 0x14}, bytes.Repeat([]byte{b}, 20)...)
+}
+
+func newTx(inputs []wire.OutPoint, outputs ...[]byte) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for i := range inputs {
+		tx.AddTxIn(wire.NewTxIn(&inputs[i], nil, nil))
+	}
+	for _, output := range outputs {
+		tx.AddTxOut(wire.NewTxOut(1000, output))
+	}
+	return tx
+}
+
+// addBlock mines a block containing the given transactions. The extra byte of the coinbase makes
+// blocks at the same height distinct.
+func (node *fakeNode) addBlock(extra byte, txs ...*wire.MsgTx) *wire.MsgBlock {
+	height := len(node.blocks)
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		[]byte{byte(height), byte(height >> 8), extra}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(5000000000, pkScript(0xff)))
+	block := &wire.MsgBlock{Transactions: append([]*wire.MsgTx{coinbase}, txs...)}
+	block.Header.Timestamp = time.Unix(1600000000+int64(height)*600, 0)
+	if height > 0 {
+		block.Header.PrevBlock = node.blocks[height-1].BlockHash()
+	}
+	utilTxs := make([]*btcutil.Tx, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		utilTxs[i] = btcutil.NewTx(tx)
+	}
+	block.Header.MerkleRoot = btcdblockchain.CalcMerkleRoot(utilTxs, false)
+	node.blocks = append(node.blocks, block)
+	return block
+}
+
+func newTestClient(t *testing.T, node *fakeNode) *Client {
+	t.Helper()
+	return newTestClientWithState(t, node, filepath.Join(t.TempDir(), "bitcoind.json"))
+}
+
+func newTestClientWithState(t *testing.T, node *fakeNode, stateFilename string) *Client {
+	t.Helper()
+	node.t = t
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client := newClient(
+		&config.BitcoindRPC{URL: server.URL, User: "user", Password: "password"},
+		&chaincfg.RegressionNetParams,
+		server.Client(),
+		stateFilename,
+		logging.Get().WithGroup("bitcoind_test"),
+	)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func historyHashes(t *testing.T, client *Client, script []byte) map[chainhash.Hash]int {
+	t.Helper()
+	history, err := client.ScriptHashGetHistory(blockchain.NewScriptHashHex(script))
+	require.NoError(t, err)
+	hashes := map[chainhash.Hash]int{}
+	for _, entry := range history {
+		hashes[chainhash.Hash(entry.TXHash)] = entry.Height
+	}
+	return hashes
+}
+
+func TestClientSync(t *testing.T) {
+	nodes := map[string]func() *fakeNode{
+		"filters":     func() *fakeNode { return &fakeNode{filters: true} },
+		"wallet":      func() *fakeNode { return &fakeNode{wallet: true} },
+		"full blocks": func() *fakeNode { return &fakeNode{} },
+	}
+	for name, newNode := range nodes {
+		t.Run(name, func(t *testing.T) {
+			scriptA, scriptB, other := pkScript(0xa), pkScript(0xb), pkScript(0xc)
+			node := newNode()
+			node.addBlock(0)
+			funding := newTx([]wire.OutPoint{{Index: 7}}, other, scriptA)
+			node.addBlock(0, funding)
+			node.addBlock(0, newTx([]wire.OutPoint{{Index: 8}}, other))
+			// Spends from A to B without change, so it only matches A through the spent output.
+			spending := newTx([]wire.OutPoint{{Hash: funding.TxHash(), Index: 1}}, scriptB)
+			node.addBlock(0, spending)
+			// Pays to A, the parent being in the mempool too.
+			unconfirmedParent := newTx([]wire.OutPoint{{Index: 9}}, other)
+			unconfirmed := newTx([]wire.OutPoint{{Hash: unconfirmedParent.TxHash()}}, scriptA)
+			node.mempool = []*wire.MsgTx{unconfirmed, unconfirmedParent}
+
+			client := newTestClient(t, node)
+			client.WatchScript("account", scriptA)
+			statuses := make(chan string, 10)
+			tornDown := false
+			client.ScriptHashSubscribe(
+				func() func() { return func() { tornDown = true } },
+				blockchain.NewScriptHashHex(scriptA),
+				func(status string) { statuses <- status },
+			)
+			require.NoError(t, client.sync())
+			require.Len(t, statuses, 1)
+			status := <-statuses
+			require.True(t, tornDown)
+			require.Equal(t, map[chainhash.Hash]int{
+				funding.TxHash():     1,
+				spending.TxHash():    3,
+				unconfirmed.TxHash(): -1,
+			}, historyHashes(t, client, scriptA))
+			history, err := client.ScriptHashGetHistory(blockchain.NewScriptHashHex(scriptA))
+			require.NoError(t, err)
+			require.Equal(t, history.Status(), status)
+			require.Equal(t, blockchain.TXHash(unconfirmed.TxHash()), history[2].TXHash)
+
+			// Nothing changed, no notification.
+			require.NoError(t, client.sync())
+			require.Empty(t, statuses)
+
+			// Scripts watched later are scanned for in the blocks scanned before.
+			client.WatchScript("account", scriptB)
+			require.NoError(t, client.sync())
+			require.Equal(t, map[chainhash.Hash]int{spending.TxHash(): 3}, historyHashes(t, client, scriptB))
+
+			// The parent gets confirmed, the transaction leaves the mempool.
+			node.mu.Lock()
+			node.addBlock(0, unconfirmedParent)
+			node.mempool = nil
+			node.mu.Unlock()
+			require.NoError(t, client.sync())
+			require.Len(t, statuses, 1)
+			<-statuses
+			require.Equal(t, map[chainhash.Hash]int{
+				funding.TxHash():  1,
+				spending.TxHash(): 3,
+			}, historyHashes(t, client, scriptA))
+
+			// Reorg replacing the blocks from height 3: the spending transaction is confirmed at a
+			// different height.
+			node.mu.Lock()
+			node.blocks = node.blocks[:3]
+			node.addBlock(1)
+			node.addBlock(1)
+			node.addBlock(1, spending)
+			node.mu.Unlock()
+			require.NoError(t, client.sync())
+			require.Len(t, statuses, 1)
+			require.Equal(t, map[chainhash.Hash]int{
+				funding.TxHash():  1,
+				spending.TxHash(): 5,
+			}, historyHashes(t, client, scriptA))
+			require.Equal(t, map[chainhash.Hash]int{spending.TxHash(): 5}, historyHashes(t, client, scriptB))
+			if node.wallet {
+				// The wallet finds the transactions, no blocks are fetched.
+				require.Zero(t, node.calls["getblock"])
+			}
+		})
+	}
+}
+
+func TestClientRestore(t *testing.T) {
+	scriptA, scriptB, other := pkScript(0xa), pkScript(0xb), pkScript(0xc)
+	node := &fakeNode{filters: true}
+	node.addBlock(0)
+	funding := newTx([]wire.OutPoint{{Index: 7}}, other, scriptA)
+	node.addBlock(0, funding)
+	for i := 0; i < 8; i++ {
+		node.addBlock(0)
+	}
+	spending := newTx([]wire.OutPoint{{Hash: funding.TxHash(), Index: 1}}, scriptB)
+	node.addBlock(0, spending)
+	stateFilename := filepath.Join(t.TempDir(), "bitcoind.json")
+
+	client := newTestClientWithState(t, node, stateFilename)
+	client.WatchScript("account", scriptA)
+	client.WatchScript("account", scriptB)
+	require.NoError(t, client.sync())
+	client.Close()
+	saved, err := loadState(stateFilename)
+	require.NoError(t, err)
+	require.Equal(t, 10, saved.Accounts["account"].ScannedHeight)
+	require.True(t, saved.Accounts["account"].Synced)
+	require.Equal(t, map[int]string{
+		1:  node.blocks[1].BlockHash().String(),
+		10: node.blocks[10].BlockHash().String(),
+	}, saved.Accounts["account"].Matches)
+
+	// Only the blocks with transactions of the account are fetched again.
+	node.calls = nil
+	client = newTestClientWithState(t, node, stateFilename)
+	client.WatchScript("account", scriptA)
+	client.WatchScript("account", scriptB)
+	require.NoError(t, client.sync())
+	client.Close()
+	require.Equal(t, 2, node.calls["getblock"])
+	require.Zero(t, node.calls["getblockfilter"])
+	require.Equal(t, map[chainhash.Hash]int{
+		funding.TxHash():  1,
+		spending.TxHash(): 10,
+	}, historyHashes(t, client, scriptA))
+	require.Equal(t, map[chainhash.Hash]int{spending.TxHash(): 10}, historyHashes(t, client, scriptB))
+
+	// The spending transaction was reorged to a later block while the app was not running. The
+	// blocks are scanned again from the last block which is still in the active chain.
+	node.blocks = node.blocks[:10]
+	node.addBlock(1)
+	node.addBlock(1, spending)
+	node.calls = nil
+	client = newTestClientWithState(t, node, stateFilename)
+	client.WatchScript("account", scriptA)
+	client.WatchScript("account", scriptB)
+	require.NoError(t, client.sync())
+	// The filters of the heights 2 to 11, and the check whether the node serves filters.
+	require.Equal(t, 10+1, node.calls["getblockfilter"])
+	require.Equal(t, map[chainhash.Hash]int{
+		funding.TxHash():  1,
+		spending.TxHash(): 11,
+	}, historyHashes(t, client, scriptA))
+	require.Equal(t, map[chainhash.Hash]int{spending.TxHash(): 11}, historyHashes(t, client, scriptB))
+}
+
+func TestClientBirthday(t *testing.T) {
+	scriptA, scriptB, other := pkScript(0xa), pkScript(0xb), pkScript(0xc)
+	node := &fakeNode{filters: true}
+	node.addBlock(0)
+	node.addBlock(0)
+	// Pays to B before the first transaction of the account.
+	early := newTx([]wire.OutPoint{{Index: 7}}, scriptB)
+	node.addBlock(0, early)
+	node.addBlock(0)
+	funding := newTx([]wire.OutPoint{{Index: 8}}, other, scriptA)
+	node.addBlock(0, funding)
+	node.addBlock(0)
+	payment := newTx([]wire.OutPoint{{Index: 9}}, scriptB)
+	node.addBlock(0, payment)
+
+	client := newTestClient(t, node)
+	client.WatchScript("account", scriptA)
+	require.NoError(t, client.sync())
+
+	// Scripts derived later are only scanned for from the first transaction of the account.
+	node.calls = nil
+	client.WatchScript("account", scriptB)
+	require.NoError(t, client.sync())
+	require.Equal(t, 3, node.calls["getblockfilter"])
+	require.Equal(t, map[chainhash.Hash]int{payment.TxHash(): 6}, historyHashes(t, client, scriptB))
+}
+
+func TestClientStartHeight(t *testing.T) {
+	segwitScript := pkScript(0xa)
+	taprootScript := append([]byte{0x51, 0x20}, bytes.Repeat([]byte{0xb}, 32)...)
+	p2shScript := append(append([]byte{0xa9, 0x14}, bytes.Repeat([]byte{0xc}, 20)...), 0x87)
+	p2pkhScript := append(append([]byte{0x76, 0xa9, 0x14}, bytes.Repeat([]byte{0xd}, 20)...), 0x88, 0xac)
+
+	client := newTestClient(t, &fakeNode{})
+	client.net = &chaincfg.MainNetParams
+	require.Equal(t, 481824, client.scriptStartHeight(segwitScript))
+	require.Equal(t, 481824, client.scriptStartHeight(taprootScript))
+	require.Equal(t, 0, client.scriptStartHeight(p2shScript))
+	require.Equal(t, 0, client.scriptStartHeight(p2pkhScript))
+	client.net = &chaincfg.TestNet4Params
+	require.Equal(t, 0, client.scriptStartHeight(segwitScript))
+	client.net = &chaincfg.SigNetParams
+	require.Equal(t, 0, client.scriptStartHeight(segwitScript))
+	client.startHeight = 1000
+	require.Equal(t, 1000, client.scriptStartHeight(segwitScript))
+	require.Equal(t, 1000, client.scriptStartHeight(p2pkhScript))
+
+	// A legacy script lowers the start height of an account not scanned yet.
+	client = newTestClient(t, &fakeNode{})
+	client.net = &chaincfg.MainNetParams
+	client.WatchScript("account", segwitScript)
+	require.Equal(t, 481824, client.accounts["account"].startHeight)
+	require.Equal(t, 481823, client.accounts["account"].scannedHeight)
+	client.WatchScript("account", p2pkhScript)
+	require.Equal(t, 0, client.accounts["account"].startHeight)
+	require.Equal(t, -1, client.accounts["account"].scannedHeight)
+	require.Equal(t, 0, client.accounts["account"].birthday())
+}
+
+func TestClientConnectionError(t *testing.T) {
+	client := newTestClient(t, &fakeNode{})
+	client.rpc.config.Password = "wrong"
+	require.Error(t, client.sync())
+}
+
+func TestClientTransactions(t *testing.T) {
+	node := &fakeNode{}
+	node.addBlock(0)
+	txs := []*wire.MsgTx{}
+	for i := 0; i < 5; i++ {
+		txs = append(txs, newTx([]wire.OutPoint{{Index: uint32(i)}}, pkScript(byte(i))))
+	}
+	block := node.addBlock(0, txs...)
+	client := newTestClient(t, node)
+
+	tx, err := client.TransactionGet(txs[2].TxHash())
+	require.NoError(t, err)
+	require.Equal(t, txs[2].TxHash(), tx.TxHash())
+
+	merkle, err := client.GetMerkle(txs[2].TxHash(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, merkle.Pos)
+	root := txs[2].TxHash()
+	for i, hash := range merkle.Merkle {
+		if (merkle.Pos>>i)&1 == 0 {
+			root = chainhash.DoubleHashH(append(root[:], hash[:]...))
+		} else {
+			root = chainhash.DoubleHashH(append(hash[:], root[:]...))
+		}
+	}
+	require.Equal(t, block.Header.MerkleRoot, root)
+	_, err = client.GetMerkle(txs[2].TxHash(), 0)
+	require.Error(t, err)
+
+	headers, err := client.Headers(0, 10)
+	require.NoError(t, err)
+	require.Len(t, headers.Headers, 2)
+	require.Equal(t, block.BlockHash(), headers.Headers[1].BlockHash())
+	require.Equal(t, maxHeaders, headers.Max)
+
+	broadcast := newTx([]wire.OutPoint{{Index: 10}}, pkScript(0xa))
+	require.NoError(t, client.TransactionBroadcast(broadcast))
+	require.Len(t, node.mempool, 1)
+}
+
+func TestClientFees(t *testing.T) {
+	node := &fakeNode{}
+	node.addBlock(0)
+	for i := 0; i < 3; i++ {
+		node.mempool = append(node.mempool, newTx([]wire.OutPoint{{Index: uint32(i)}}, pkScript(0xa)))
+	}
+	vsize := int64(node.mempool[0].SerializeSize())
+	node.mempoolFee = btcutil.Amount(vsize * 5)
+	client := newTestClient(t, node)
+
+	relayFee, err := client.RelayFee()
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(1000), relayFee)
+
+	fee, err := client.EstimateFee(2)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(20000), fee)
+	_, err = client.EstimateFee(1000)
+	require.Error(t, err)
+
+	histogram, err := client.FeeHistogram()
+	require.NoError(t, err)
+	require.Equal(t, blockchain.FeeHistogram{{FeeRate: 5, VSize: 3 * vsize}}, histogram)
+}
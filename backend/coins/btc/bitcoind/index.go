@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package bitcoind
+
+import (
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// indexedTx is a transaction paying to or spending from a watched script.
+type indexedTx struct {
+	tx *wire.MsgTx
+	// height is the height of the block containing the transaction. As in Electrum, it is 0 if the
+	// transaction is in the mempool and all its inputs are confirmed, and -1 if it spends
+	// unconfirmed outputs.
+	height int
+	// position is the position of the transaction in its block.
+	position     int
+	scriptHashes map[blockchain.ScriptHashHex]struct{}
+}
+
+func (tx *indexedTx) confirmed() bool {
+	return tx.height > 0
+}
+
+// index keeps track of the transactions of the watched scripts, like the index of an Electrum
+// server restricted to the scripts of the accounts. It is not safe for concurrent use.
+type index struct {
+	// scripts are the watched output scripts by their script hash.
+	scripts map[blockchain.ScriptHashHex][]byte
+	txs     map[chainhash.Hash]*indexedTx
+	// outpoints are the outputs paying to a watched script, used to find the transactions spending
+	// them.
+	outpoints map[wire.OutPoint]blockchain.ScriptHashHex
+}
+
+func newIndex() *index {
+	return &index{
+		scripts:   map[blockchain.ScriptHashHex][]byte{},
+		txs:       map[chainhash.Hash]*indexedTx{},
+		outpoints: map[wire.OutPoint]blockchain.ScriptHashHex{},
+	}
+}
+
+// watched returns true if the script hash belongs to a watched script.
+func (idx *index) watched(scriptHash blockchain.ScriptHashHex) bool {
+	_, ok := idx.scripts[scriptHash]
+	return ok
+}
+
+// watch adds the output script to the watched scripts. Transactions are only indexed if they
+// involve a watched script, so the blocks need to be scanned again for new scripts.
+func (idx *index) watch(pkScript []byte) {
+	idx.scripts[blockchain.NewScriptHashHex(pkScript)] = pkScript
+}
+
+// addTx indexes the transaction if it pays to or spends from a watched script. Returns true if the
+// transaction was indexed. The height and position of already indexed transactions are updated,
+// e.g. when a mempool transaction is confirmed.
+func (idx *index) addTx(tx *wire.MsgTx, height int, position int) bool {
+	txHash := tx.TxHash()
+	scriptHashes := map[blockchain.ScriptHashHex]struct{}{}
+	for _, txIn := range tx.TxIn {
+		if scriptHash, ok := idx.outpoints[txIn.PreviousOutPoint]; ok {
+			scriptHashes[scriptHash] = struct{}{}
+		}
+	}
+	for outputIndex, txOut := range tx.TxOut {
+		scriptHash := blockchain.NewScriptHashHex(txOut.PkScript)
+		if !idx.watched(scriptHash) {
+			continue
+		}
+		idx.outpoints[*wire.NewOutPoint(&txHash, uint32(outputIndex))] = scriptHash
+		scriptHashes[scriptHash] = struct{}{}
+	}
+	if len(scriptHashes) == 0 {
+		return false
+	}
+	if existing, ok := idx.txs[txHash]; ok {
+		for scriptHash := range existing.scriptHashes {
+			scriptHashes[scriptHash] = struct{}{}
+		}
+	}
+	idx.txs[txHash] = &indexedTx{
+		tx:           tx,
+		height:       height,
+		position:     position,
+		scriptHashes: scriptHashes,
+	}
+	return true
+}
+
+// removeTxs removes the transactions matching the predicate, along with the outputs they created.
+func (idx *index) removeTxs(remove func(*indexedTx) bool) {
+	removed := false
+	for txHash, tx := range idx.txs {
+		if remove(tx) {
+			delete(idx.txs, txHash)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	idx.outpoints = map[wire.OutPoint]blockchain.ScriptHashHex{}
+	for txHash, tx := range idx.txs {
+		for outputIndex, txOut := range tx.tx.TxOut {
+			scriptHash := blockchain.NewScriptHashHex(txOut.PkScript)
+			if idx.watched(scriptHash) {
+				idx.outpoints[*wire.NewOutPoint(&txHash, uint32(outputIndex))] = scriptHash
+			}
+		}
+	}
+}
+
+// rollback removes the transactions confirmed at or above the given height, after a reorg.
+func (idx *index) rollback(height int) {
+	idx.removeTxs(func(tx *indexedTx) bool {
+		return tx.height >= height
+	})
+}
+
+// history returns the history of the script in the order of Electrum: confirmed transactions in
+// the order of the blockchain, followed by the unconfirmed ones.
+func (idx *index) history(scriptHash blockchain.ScriptHashHex) blockchain.TxHistory {
+	txs := []*indexedTx{}
+	for _, tx := range idx.txs {
+		if _, ok := tx.scriptHashes[scriptHash]; ok {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		a, b := txs[i], txs[j]
+		if a.confirmed() != b.confirmed() {
+			return a.confirmed()
+		}
+		if a.confirmed() && a.height != b.height {
+			return a.height < b.height
+		}
+		if a.confirmed() && a.position != b.position {
+			return a.position < b.position
+		}
+		aHash, bHash := a.tx.TxHash(), b.tx.TxHash()
+		return aHash.String() < bHash.String()
+	})
+	history := make(blockchain.TxHistory, len(txs))
+	for i, tx := range txs {
+		history[i] = &blockchain.TxInfo{
+			Height: tx.height,
+			TXHash: blockchain.TXHash(tx.tx.TxHash()),
+		}
+	}
+	return history
+}
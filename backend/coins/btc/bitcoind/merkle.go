@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package bitcoind
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// merkleBranch returns the merkle branch of the transaction at the given position in the block, in
+// the format of Electrum's `blockchain.transaction.get_merkle`: the sibling hashes from the leaves
+// up to the root.
+func merkleBranch(txHashes []chainhash.Hash, position int) []blockchain.TXHash {
+	branch := []blockchain.TXHash{}
+	level := append([]chainhash.Hash{}, txHashes...)
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		branch = append(branch, blockchain.TXHash(level[position^1]))
+		next := make([]chainhash.Hash, len(level)/2)
+		for i := range next {
+			next[i] = chainhash.DoubleHashH(append(level[2*i][:], level[2*i+1][:]...))
+		}
+		level = next
+		position /= 2
+	}
+	return branch
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package bitcoind
+
+import (
+	"testing"
+
+	btcdblockchain "github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleBranch(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		txs := make([]*btcutil.Tx, count)
+		txHashes := make([]chainhash.Hash, count)
+		for i := range txs {
+			tx := newTx([]wire.OutPoint{{Index: uint32(i)}}, pkScript(byte(i)))
+			txs[i] = btcutil.NewTx(tx)
+			txHashes[i] = tx.TxHash()
+		}
+		merkleRoot := btcdblockchain.CalcMerkleRoot(txs, false)
+		for position := range txHashes {
+			root := txHashes[position]
+			for i, hash := range merkleBranch(txHashes, position) {
+				if (position>>i)&1 == 0 {
+					root = chainhash.DoubleHashH(append(root[:], hash[:]...))
+				} else {
+					root = chainhash.DoubleHashH(append(hash[:], root[:]...))
+				}
+			}
+			require.Equal(t, merkleRoot, root, "count %d, position %d", count, position)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package bitcoind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// RPCError is an error returned by the node, e.g. if a transaction is rejected.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *RPCError) Error() string {
+	return fmt.Sprintf("bitcoind error %d: %s", err.Code, err.Message)
+}
+
+// isRPCError returns true if the error was returned by the node, as opposed to e.g. a connection
+// error.
+func isRPCError(err error) bool {
+	_, ok := errp.Cause(err).(*RPCError)
+	return ok
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// rpcCall is a call made as part of a batch. The result is unmarshalled into Result, the error of
+// the call is stored in Err.
+type rpcCall struct {
+	Method string
+	Params []interface{}
+	Result interface{}
+	Err    error
+}
+
+// rpcClient is a client of the JSON-RPC interface of Bitcoin Core.
+type rpcClient struct {
+	config     *config.BitcoindRPC
+	httpClient *http.Client
+	// path is appended to the URL of the node, e.g. to make requests to a wallet.
+	path   string
+	nextID atomic.Int64
+}
+
+func newRPCClient(config *config.BitcoindRPC, httpClient *http.Client) *rpcClient {
+	return &rpcClient{config: config, httpClient: httpClient}
+}
+
+// wallet returns a client making the requests to the wallet with the given name.
+func (c *rpcClient) wallet(name string) *rpcClient {
+	return &rpcClient{
+		config:     c.config,
+		httpClient: c.httpClient,
+		path:       "/wallet/" + url.PathEscape(name),
+	}
+}
+
+// credentials returns the user and password to authenticate with. The cookie file is read on every
+// request, as the node creates a new cookie each time it starts.
+func (c *rpcClient) credentials() (string, string, error) {
+	if c.config.CookieFile == "" {
+		return c.config.User, c.config.Password, nil
+	}
+	cookie, err := os.ReadFile(c.config.CookieFile)
+	if err != nil {
+		return "", "", errp.WithStack(err)
+	}
+	user, password, ok := strings.Cut(strings.TrimSpace(string(cookie)), ":")
+	if !ok {
+		return "", "", errp.New("invalid cookie file")
+	}
+	return user, password, nil
+}
+
+// post sends the request body and unmarshals the response into response.
+func (c *rpcClient) post(body interface{}, response interface{}) error {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	request, err := http.NewRequest(
+		http.MethodPost, strings.TrimSuffix(c.config.URL, "/")+c.path, bytes.NewReader(requestBody))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	user, password, err := c.credentials()
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(user, password)
+	httpResponse, err := c.httpClient.Do(request)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	defer func() { _ = httpResponse.Body.Close() }()
+	if httpResponse.StatusCode == http.StatusUnauthorized || httpResponse.StatusCode == http.StatusForbidden {
+		return errp.Newf("bitcoind: authentication failed (%s)", httpResponse.Status)
+	}
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	// Bitcoin Core responds with an error status code if the call failed, but the body still
+	// contains the JSON-RPC error.
+	if err := json.Unmarshal(responseBody, response); err != nil {
+		return errp.Newf("bitcoind: unexpected response (%s)", httpResponse.Status)
+	}
+	return nil
+}
+
+func (c *rpcClient) newRequest(method string, params []interface{}) rpcRequest {
+	if params == nil {
+		params = []interface{}{}
+	}
+	return rpcRequest{JSONRPC: "1.0", ID: c.nextID.Add(1), Method: method, Params: params}
+}
+
+func unmarshalResult(response *rpcResponse, result interface{}) error {
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return errp.WithStack(err)
+	}
+	return nil
+}
+
+// call calls the RPC method and unmarshals the result into result.
+func (c *rpcClient) call(result interface{}, method string, params ...interface{}) error {
+	var response rpcResponse
+	if err := c.post(c.newRequest(method, params), &response); err != nil {
+		return err
+	}
+	return unmarshalResult(&response, result)
+}
+
+// batch makes all calls in a single request. Returns an error if the request failed. The errors of
+// the individual calls are stored in the calls.
+func (c *rpcClient) batch(calls []*rpcCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	requests := make([]rpcRequest, len(calls))
+	callsByID := make(map[int64]*rpcCall, len(calls))
+	for i, call := range calls {
+		requests[i] = c.newRequest(call.Method, call.Params)
+		callsByID[requests[i].ID] = call
+		call.Err = errp.New("bitcoind: no response")
+	}
+	var responses []rpcResponse
+	if err := c.post(requests, &responses); err != nil {
+		return err
+	}
+	for i := range responses {
+		call, ok := callsByID[responses[i].ID]
+		if !ok {
+			continue
+		}
+		call.Err = unmarshalResult(&responses[i], call.Result)
+	}
+	return nil
+}
+
+// batchAll is like batch, but returns the first error of the calls.
+func (c *rpcClient) batchAll(calls []*rpcCall) error {
+	if err := c.batch(calls); err != nil {
+		return err
+	}
+	for _, call := range calls {
+		if call.Err != nil {
+			return errp.WithMessage(call.Err, call.Method)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package bitcoind
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// account is the scan progress of the scripts of an account.
+type account struct {
+	// scripts are the scripts scanned up to scannedHeight.
+	scripts map[blockchain.ScriptHashHex][]byte
+	// pending are the scripts watched since the last scan, which still need to be scanned for from
+	// the birthday of the account up to scannedHeight.
+	pending map[blockchain.ScriptHashHex][]byte
+	// restoring are the watched scripts which were scanned up to scannedHeight in a previous session.
+	// Their transactions are restored from the blocks in matches.
+	restoring map[blockchain.ScriptHashHex][]byte
+	// saved are the scripts scanned up to scannedHeight in a previous session. It is nil once the
+	// account was scanned further in this session.
+	saved map[blockchain.ScriptHashHex][]byte
+
+	// startHeight is the height from which the scripts of the account are scanned for.
+	startHeight   int
+	scannedHeight int
+	// scannedHash is the hash of the block at scannedHeight, zero if not known.
+	scannedHash chainhash.Hash
+	// synced is true once the account was scanned up to the tip.
+	synced bool
+	// matches are the hashes of the blocks containing transactions of the account by height.
+	matches map[int]chainhash.Hash
+}
+
+// newAccount creates an account which is not scanned yet, whose scripts are scanned for from the
+// given height.
+func newAccount(startHeight int) *account {
+	return &account{
+		scripts:       map[blockchain.ScriptHashHex][]byte{},
+		pending:       map[blockchain.ScriptHashHex][]byte{},
+		restoring:     map[blockchain.ScriptHashHex][]byte{},
+		startHeight:   startHeight,
+		scannedHeight: startHeight - 1,
+		matches:       map[int]chainhash.Hash{},
+	}
+}
+
+// birthday returns the height from which new scripts of the account are scanned. New scripts are
+// only derived once the previous ones were used (gap limit), so they can't have transactions before
+// the first transaction of the account. Until the account is synced, or if it has no transactions,
+// new scripts are scanned from the start height of the account.
+func (acc *account) birthday() int {
+	if !acc.synced || len(acc.matches) == 0 {
+		return acc.startHeight
+	}
+	birthday := -1
+	for height := range acc.matches {
+		if birthday < 0 || height < birthday {
+			birthday = height
+		}
+	}
+	return birthday
+}
+
+// lowerStartHeight makes the account scanned from the given height if it is below its start height,
+// e.g. for a non-segwit script of an account scanned from the activation of segwit so far. If the
+// account was not scanned yet, the scan starts there, otherwise the new scripts are scanned for
+// from there as pending scripts.
+func (acc *account) lowerStartHeight(height int) {
+	if height >= acc.startHeight {
+		return
+	}
+	if acc.scannedHeight == acc.startHeight-1 {
+		acc.scannedHeight = height - 1
+	}
+	acc.startHeight = height
+}
+
+// rollback forgets the blocks above the given height, after a reorg.
+func (acc *account) rollback(height int) {
+	if acc.scannedHeight <= height {
+		return
+	}
+	acc.scannedHeight = height
+	acc.scannedHash = chainhash.Hash{}
+	for matchHeight := range acc.matches {
+		if matchHeight > height {
+			delete(acc.matches, matchHeight)
+		}
+	}
+}
+
+// accountState is the persisted scan progress of an account, so that the blocks don't need to be
+// scanned again when the app is restarted.
+type accountState struct {
+	// Scripts are the hex encoded scripts scanned up to ScannedHeight.
+	Scripts       []string `json:"scripts"`
+	StartHeight   int      `json:"startHeight"`
+	ScannedHeight int      `json:"scannedHeight"`
+	ScannedHash   string   `json:"scannedHash"`
+	Synced        bool     `json:"synced"`
+	// Matches are the hashes of the blocks containing transactions of the account by height.
+	Matches map[int]string `json:"matches"`
+}
+
+// state is the content of the state file.
+type state struct {
+	Accounts map[string]*accountState `json:"accounts"`
+}
+
+// state returns the state to persist. The saved scripts which were not watched yet are kept, as
+// long as the account was not scanned further.
+func (acc *account) state() *accountState {
+	accState := &accountState{
+		Scripts:       []string{},
+		StartHeight:   acc.startHeight,
+		ScannedHeight: acc.scannedHeight,
+		Synced:        acc.synced,
+		Matches:       make(map[int]string, len(acc.matches)),
+	}
+	for scriptHash, pkScript := range acc.saved {
+		if _, ok := acc.scripts[scriptHash]; !ok {
+			accState.Scripts = append(accState.Scripts, hex.EncodeToString(pkScript))
+		}
+	}
+	for _, pkScript := range acc.scripts {
+		accState.Scripts = append(accState.Scripts, hex.EncodeToString(pkScript))
+	}
+	if acc.scannedHash != (chainhash.Hash{}) {
+		accState.ScannedHash = acc.scannedHash.String()
+	}
+	for height, hash := range acc.matches {
+		accState.Matches[height] = hash.String()
+	}
+	return accState
+}
+
+// newAccountFromState restores an account from its persisted state. The scripts are only restored
+// once they are watched.
+func newAccountFromState(accState *accountState) (*account, error) {
+	acc := newAccount(accState.StartHeight)
+	acc.scannedHeight = accState.ScannedHeight
+	acc.synced = accState.Synced
+	acc.saved = make(map[blockchain.ScriptHashHex][]byte, len(accState.Scripts))
+	for _, scriptHex := range accState.Scripts {
+		pkScript, err := hex.DecodeString(scriptHex)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		acc.saved[blockchain.NewScriptHashHex(pkScript)] = pkScript
+	}
+	if accState.ScannedHash != "" {
+		hash, err := chainhash.NewHashFromStr(accState.ScannedHash)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		acc.scannedHash = *hash
+	}
+	for height, hashString := range accState.Matches {
+		hash, err := chainhash.NewHashFromStr(hashString)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		acc.matches[height] = *hash
+	}
+	return acc, nil
+}
+
+// loadState reads the state file. A missing file results in an empty state.
+func loadState(filename string) (*state, error) {
+	loaded := &state{Accounts: map[string]*accountState{}}
+	if filename == "" {
+		return loaded, nil
+	}
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return loaded, nil
+	}
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := json.Unmarshal(content, loaded); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if loaded.Accounts == nil {
+		loaded.Accounts = map[string]*accountState{}
+	}
+	return loaded, nil
+}
+
+// saveState writes the state file.
+func saveState(filename string, saved *state) error {
+	if filename == "" {
+		return nil
+	}
+	content, err := json.Marshal(saved)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(os.WriteFile(filename, content, 0600))
+}
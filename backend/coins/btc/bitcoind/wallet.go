@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package bitcoind
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// walletName is the name of the watch-only wallet created on the node to find the transactions of
+// the scripts if the node serves no block filters.
+const walletName = "bitboxapp"
+
+// Error codes of Bitcoin Core, see src/rpc/protocol.h.
+const (
+	rpcErrorWalletNotFound      = -18
+	rpcErrorWalletAlreadyLoaded = -35
+)
+
+// setupWallet loads the watch-only wallet of the app, creating it if needed, and the scripts
+// imported into it before. Returns false if the wallet of the node is disabled.
+func (c *Client) setupWallet() (bool, error) {
+	err := c.rpc.call(nil, "loadwallet", walletName)
+	if rpcErr, ok := errp.Cause(err).(*RPCError); ok {
+		switch rpcErr.Code {
+		case rpcErrorWalletAlreadyLoaded:
+			err = nil
+		case rpcErrorWalletNotFound:
+			// wallet_name, disable_private_keys, blank, passphrase, avoid_reuse, descriptors,
+			// load_on_startup
+			err = c.rpc.call(nil, "createwallet", walletName, true, true, "", false, true, true)
+		}
+	}
+	if err != nil {
+		if isRPCError(err) {
+			c.log.WithError(err).Warning("The wallet of the node can't be used")
+			return false, nil
+		}
+		return false, err
+	}
+	var result struct {
+		Descriptors []struct {
+			Desc      string `json:"desc"`
+			Timestamp int64  `json:"timestamp"`
+		} `json:"descriptors"`
+	}
+	if err := c.wallet.call(&result, "listdescriptors"); err != nil {
+		return false, err
+	}
+	imported := map[blockchain.ScriptHashHex]int64{}
+	for _, descriptor := range result.Descriptors {
+		desc, _, _ := strings.Cut(descriptor.Desc, "#")
+		if !strings.HasPrefix(desc, "raw(") || !strings.HasSuffix(desc, ")") {
+			continue
+		}
+		pkScript, err := hex.DecodeString(desc[len("raw(") : len(desc)-1])
+		if err != nil {
+			continue
+		}
+		imported[blockchain.NewScriptHashHex(pkScript)] = descriptor.Timestamp
+	}
+	defer c.mu.Lock()()
+	c.imported = imported
+	return true, nil
+}
+
+// blockTime returns the timestamp of the block at the given height.
+func (c *Client) blockTime(height int) (int64, error) {
+	var blockHash string
+	if err := c.rpc.call(&blockHash, "getblockhash", height); err != nil {
+		return 0, err
+	}
+	var header struct {
+		Time int64 `json:"time"`
+	}
+	if err := c.rpc.call(&header, "getblockheader", blockHash, true); err != nil {
+		return 0, err
+	}
+	return header.Time, nil
+}
+
+// importScripts imports the scripts into the wallet, which rescans the blockchain for them from
+// the block at the given height. Scripts imported from an earlier block before are skipped.
+func (c *Client) importScripts(scripts [][]byte, height int) error {
+	if len(scripts) == 0 {
+		return nil
+	}
+	timestamp, err := c.blockTime(height)
+	if err != nil {
+		return err
+	}
+	toImport := func() [][]byte {
+		defer c.mu.RLock()()
+		toImport := [][]byte{}
+		for _, pkScript := range scripts {
+			importedFrom, ok := c.imported[blockchain.NewScriptHashHex(pkScript)]
+			if !ok || importedFrom > timestamp {
+				toImport = append(toImport, pkScript)
+			}
+		}
+		return toImport
+	}()
+	if len(toImport) == 0 {
+		return nil
+	}
+	// The node computes the checksums of the descriptors.
+	infos := make([]struct {
+		Descriptor string `json:"descriptor"`
+	}, len(toImport))
+	calls := make([]*rpcCall, len(toImport))
+	for i, pkScript := range toImport {
+		calls[i] = &rpcCall{
+			Method: "getdescriptorinfo",
+			Params: []interface{}{"raw(" + hex.EncodeToString(pkScript) + ")"},
+			Result: &infos[i],
+		}
+	}
+	for start := 0; start < len(calls); start += batchSize {
+		if err := c.rpc.batchAll(calls[start:min(start+batchSize, len(calls))]); err != nil {
+			return err
+		}
+	}
+	requests := make([]map[string]interface{}, len(infos))
+	for i, info := range infos {
+		requests[i] = map[string]interface{}{"desc": info.Descriptor, "timestamp": timestamp}
+	}
+	c.log.Infof("Importing %d scripts into the wallet of the node, rescanning from height %d",
+		len(requests), height)
+	var results []struct {
+		Success bool      `json:"success"`
+		Error   *RPCError `json:"error"`
+	}
+	if err := c.wallet.call(&results, "importdescriptors", requests); err != nil {
+		return err
+	}
+	if len(results) != len(requests) {
+		return errp.New("bitcoind: unexpected response to importdescriptors")
+	}
+	for _, result := range results {
+		if !result.Success {
+			return errp.Newf("bitcoind: importing a script failed: %v", result.Error)
+		}
+	}
+	defer c.mu.Lock()()
+	for _, pkScript := range toImport {
+		c.imported[blockchain.NewScriptHashHex(pkScript)] = timestamp
+	}
+	return nil
+}
+
+// scanWallet is scanBlocks using the watch-only wallet of the node. The scripts are imported into
+// the wallet, which lists their transactions, so no blocks need to be fetched.
+func (c *Client) scanWallet(from int, to int, scripts [][]byte, record bool) error {
+	var advancing []*account
+	if scripts == nil {
+		advancing, scripts = func() ([]*account, [][]byte) {
+			defer c.mu.RLock()()
+			return c.advancingAccounts(to)
+		}()
+	}
+	if err := c.importScripts(scripts, from); err != nil {
+		return err
+	}
+	sinceBlock := ""
+	if from > 0 {
+		if err := c.rpc.call(&sinceBlock, "getblockhash", from-1); err != nil {
+			return err
+		}
+	}
+	var result struct {
+		Transactions []struct {
+			TxID        string `json:"txid"`
+			BlockHash   string `json:"blockhash"`
+			BlockHeight int    `json:"blockheight"`
+			BlockIndex  int    `json:"blockindex"`
+		} `json:"transactions"`
+		LastBlock string `json:"lastblock"`
+	}
+	// blockhash, target_confirmations, include_watchonly
+	if err := c.wallet.call(&result, "listsinceblock", sinceBlock, 1, true); err != nil {
+		return err
+	}
+	var lastBlock struct {
+		Height int `json:"height"`
+	}
+	if err := c.rpc.call(&lastBlock, "getblockheader", result.LastBlock, true); err != nil {
+		return err
+	}
+	// The wallet processes new blocks asynchronously, so it can be behind the node.
+	to = min(to, lastBlock.Height)
+	if to < from {
+		return nil
+	}
+
+	type walletTx struct {
+		txID      string
+		height    int
+		position  int
+		blockHash string
+	}
+	// There is an entry for each output and input of a transaction involving the scripts.
+	seen := map[string]struct{}{}
+	walletTxs := []*walletTx{}
+	for _, entry := range result.Transactions {
+		if entry.BlockHash == "" || entry.BlockHeight < from || entry.BlockHeight > to {
+			continue
+		}
+		if _, ok := seen[entry.TxID]; ok {
+			continue
+		}
+		seen[entry.TxID] = struct{}{}
+		walletTxs = append(walletTxs, &walletTx{
+			txID:      entry.TxID,
+			height:    entry.BlockHeight,
+			position:  entry.BlockIndex,
+			blockHash: entry.BlockHash,
+		})
+	}
+	// Spending transactions are only indexed after the outputs they spend.
+	sort.Slice(walletTxs, func(i, j int) bool {
+		if walletTxs[i].height != walletTxs[j].height {
+			return walletTxs[i].height < walletTxs[j].height
+		}
+		return walletTxs[i].position < walletTxs[j].position
+	})
+	txResults := make([]struct {
+		Hex string `json:"hex"`
+	}, len(walletTxs))
+	calls := make([]*rpcCall, len(walletTxs))
+	for i, walletTx := range walletTxs {
+		calls[i] = &rpcCall{
+			Method: "gettransaction",
+			Params: []interface{}{walletTx.txID, true},
+			Result: &txResults[i],
+		}
+	}
+	for start := 0; start < len(calls); start += batchSize {
+		if err := c.wallet.batchAll(calls[start:min(start+batchSize, len(calls))]); err != nil {
+			return err
+		}
+	}
+	txs := make([]*wire.MsgTx, len(txResults))
+	blockHashes := make([]*chainhash.Hash, len(walletTxs))
+	for i, txResult := range txResults {
+		tx, err := decodeTx(txResult.Hex)
+		if err != nil {
+			return err
+		}
+		txs[i] = tx
+		blockHashes[i], err = chainhash.NewHashFromStr(walletTxs[i].blockHash)
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	toHashString := result.LastBlock
+	if to != lastBlock.Height {
+		if err := c.rpc.call(&toHashString, "getblockhash", to); err != nil {
+			return err
+		}
+	}
+	toHash, err := chainhash.NewHashFromStr(toHashString)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+
+	defer c.mu.Lock()()
+	for i, tx := range txs {
+		c.indexTx(tx, walletTxs[i].height, walletTxs[i].position, *blockHashes[i])
+	}
+	if record {
+		c.advance(advancing, to, *toHash)
+	}
+	return nil
+}
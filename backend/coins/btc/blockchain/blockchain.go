@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
@@ -89,6 +90,42 @@ type GetMerkleResult struct {
 	Pos    int
 }
 
+// blockVSize is the maximum virtual size of a block in vbytes.
+const blockVSize = 1000000
+
+// FeeHistogramEntry is an entry of the fee histogram of the mempool.
+type FeeHistogramEntry struct {
+	// FeeRate is the fee rate in sat/vB.
+	FeeRate float64
+	// VSize is the total virtual size in vbytes of the mempool transactions paying at least FeeRate,
+	// but less than the fee rate of the previous entry.
+	VSize int64
+}
+
+// FeeHistogram is the fee histogram of the mempool, sorted by descending fee rate. It is returned
+// by FeeHistogram().
+type FeeHistogram []FeeHistogramEntry
+
+// EstimateFee returns the fee rate per kB a transaction needs to pay to be included in one of the
+// next `blocks` blocks, assuming that miners pick the transactions with the highest fee rate and
+// ignoring transactions arriving in the meantime. It also returns the number of blocks after which
+// a transaction paying this fee rate is expected to confirm. A fee rate of 0 means that the whole
+// mempool fits into the blocks, so that any fee rate above the minimum relay fee suffices.
+func (histogram FeeHistogram) EstimateFee(blocks int) (btcutil.Amount, int) {
+	targetDepth := int64(blocks) * blockVSize
+	var depth int64
+	for _, entry := range histogram {
+		if depth+entry.VSize > targetDepth {
+			// Paying slightly more than the transactions of this entry puts the transaction right
+			// behind the ones of the previous entries.
+			feeRatePerKb := btcutil.Amount(math.Ceil((entry.FeeRate + 1) * 1000))
+			return feeRatePerKb, min(int(depth/blockVSize)+1, blocks)
+		}
+		depth += entry.VSize
+	}
+	return 0, int(depth/blockVSize) + 1
+}
+
 // Interface is the interface to a blockchain index backend. Currently geared to Electrum, though
 // other backends can implement the same interface.
 //
@@ -101,6 +138,7 @@ type Interface interface {
 	TransactionBroadcast(*wire.MsgTx) error
 	RelayFee() (btcutil.Amount, error)
 	EstimateFee(int) (btcutil.Amount, error)
+	FeeHistogram() (FeeHistogram, error)
 	Headers(int, int) (*HeadersResult, error)
 	GetMerkle(chainhash.Hash, int) (*GetMerkleResult, error)
 	Close()
@@ -108,3 +146,11 @@ type Interface interface {
 	RegisterOnConnectionErrorChangedEvent(func(error))
 	ManualReconnect()
 }
+
+// ScriptWatcher is implemented by backends which do not index all scripts, like a Bitcoin Core
+// node. The scripts need to be watched before subscribing to them, so that the backend scans the
+// blockchain for them. The account code identifies the account the script belongs to, so that the
+// backend can keep track of the scan progress per account.
+type ScriptWatcher interface {
+	WatchScript(accountCode string, pkScript []byte)
+}
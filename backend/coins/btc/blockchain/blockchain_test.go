@@ -5,6 +5,7 @@ package blockchain
 import (
 	"testing"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/stretchr/testify/require"
 )
@@ -32,3 +33,31 @@ func TestStatus(t *testing.T) {
 		"9783fa8a2f1c89652022e0bb435f302ee8b856961dd979ee083435c65384f314",
 		history.Status())
 }
+
+func TestFeeHistogramEstimateFee(t *testing.T) {
+	histogram := FeeHistogram{
+		{FeeRate: 50, VSize: 1500000},
+		{FeeRate: 20, VSize: 3000000},
+		{FeeRate: 5.5, VSize: 4000000},
+	}
+	feeRate, blocks := histogram.EstimateFee(1)
+	require.Equal(t, btcutil.Amount(51000), feeRate)
+	require.Equal(t, 1, blocks)
+
+	feeRate, blocks = histogram.EstimateFee(2)
+	require.Equal(t, btcutil.Amount(21000), feeRate)
+	require.Equal(t, 2, blocks)
+
+	feeRate, blocks = histogram.EstimateFee(6)
+	require.Equal(t, btcutil.Amount(6500), feeRate)
+	require.Equal(t, 5, blocks)
+
+	// The whole mempool fits into the blocks.
+	feeRate, blocks = histogram.EstimateFee(9)
+	require.Equal(t, btcutil.Amount(0), feeRate)
+	require.Equal(t, 9, blocks)
+
+	feeRate, blocks = FeeHistogram{}.EstimateFee(6)
+	require.Equal(t, btcutil.Amount(0), feeRate)
+	require.Equal(t, 1, blocks)
+}
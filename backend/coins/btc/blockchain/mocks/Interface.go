@@ -71,6 +71,36 @@ func (_m *Interface) EstimateFee(_a0 int) (btcutil.Amount, error) {
 	return r0, r1
 }
 
+// FeeHistogram provides a mock function with given fields:
+func (_m *Interface) FeeHistogram() (blockchain.FeeHistogram, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for FeeHistogram")
+	}
+
+	var r0 blockchain.FeeHistogram
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (blockchain.FeeHistogram, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() blockchain.FeeHistogram); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(blockchain.FeeHistogram)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMerkle provides a mock function with given fields: _a0, _a1
 func (_m *Interface) GetMerkle(_a0 chainhash.Hash, _a1 int) (*blockchain.GetMerkleResult, error) {
 	ret := _m.Called(_a0, _a1)
@@ -21,6 +21,7 @@ type BlockchainMock struct {
 	MockTransactionBroadcast func(*wire.MsgTx) error
 	MockRelayFee             func() (btcutil.Amount, error)
 	MockEstimateFee          func(int) (btcutil.Amount, error)
+	MockFeeHistogram         func() (blockchain.FeeHistogram, error)
 	MockHeaders              func(int, int) (*blockchain.HeadersResult, error)
 	MockGetMerkle            func(chainhash.Hash, int) (*blockchain.GetMerkleResult, error)
 	MockClose                func()
@@ -84,6 +85,14 @@ func (b *BlockchainMock) EstimateFee(i int) (btcutil.Amount, error) {
 	panic("not implemented")
 }
 
+// FeeHistogram implements Interface.
+func (b *BlockchainMock) FeeHistogram() (blockchain.FeeHistogram, error) {
+	if b.MockFeeHistogram != nil {
+		return b.MockFeeHistogram()
+	}
+	return nil, errors.New("fee histogram not available")
+}
+
 // Headers implements Interface.
 func (b *BlockchainMock) Headers(i1 int, i2 int) (*blockchain.HeadersResult, error) {
 	if b.MockHeaders != nil {
@@ -5,11 +5,13 @@ package btc
 import (
 	"fmt"
 	"math/big"
+	"net/http"
 	"os"
 	"path"
 	"sync"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/bitcoind"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/db/headersdb"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
@@ -41,6 +43,10 @@ type Coin struct {
 	dbFolder              string
 	makeBlockchain        func() blockchain.Interface
 	blockExplorerTxPrefix string
+	// discoverElectrumPeers enables the discovery of peers of the Electrum servers.
+	discoverElectrumPeers bool
+	// bitcoindRPC, if enabled, is the Bitcoin Core node used instead of the Electrum servers.
+	bitcoindRPC *config.BitcoindRPC
 
 	observable.Implementation
 
@@ -63,7 +69,8 @@ func NewCoin(
 	socksProxy socksproxy.SocksProxy,
 ) *Coin {
 	log := logging.Get().WithGroup("coin").WithField("code", code)
-	coin := &Coin{
+	var coin *Coin
+	coin = &Coin{
 		code:                  code,
 		name:                  name,
 		unit:                  unit,
@@ -72,10 +79,26 @@ func NewCoin(
 		dbFolder:              dbFolder,
 		blockExplorerTxPrefix: blockExplorerTxPrefix,
 		makeBlockchain: func() blockchain.Interface {
+			if coin.bitcoindRPC != nil && coin.bitcoindRPC.Enabled() {
+				httpClient, err := socksProxy.GetHTTPClient()
+				if err != nil {
+					log.WithError(err).Error("Could not use the proxy to connect to bitcoind")
+					httpClient = &http.Client{}
+				}
+				return bitcoind.NewClient(
+					coin.bitcoindRPC,
+					net,
+					httpClient,
+					path.Join(coin.dbFolder, fmt.Sprintf("bitcoind-%s.json", coin.code)),
+					log,
+				)
+			}
 			return electrum.NewElectrumConnection(
 				servers,
+				net,
 				log,
 				socksProxy.GetTCPProxyDialer(),
+				coin.discoverElectrumPeers,
 			)
 		},
 		log: log,
@@ -83,6 +106,18 @@ func NewCoin(
 	return coin
 }
 
+// SetDiscoverElectrumPeers enables or disables the discovery of peers of the Electrum servers,
+// which are shown next to the configured servers for comparison. Must be called before Initialize().
+func (coin *Coin) SetDiscoverElectrumPeers(enabled bool) {
+	coin.discoverElectrumPeers = enabled
+}
+
+// SetBitcoindRPC makes the coin use the given Bitcoin Core node instead of the Electrum servers, if
+// it is enabled. Must be called before Initialize().
+func (coin *Coin) SetBitcoindRPC(rpcConfig *config.BitcoindRPC) {
+	coin.bitcoindRPC = rpcConfig
+}
+
 // TstSetMakeBlockchain must only be used in unit tests to provide a mock instance for the
 // blockchain interface.
 func (coin *Coin) TstSetMakeBlockchain(f func() blockchain.Interface) {
@@ -214,6 +249,18 @@ func (coin *Coin) Blockchain() blockchain.Interface {
 	return coin.blockchain
 }
 
+// ElectrumServersHealth returns the health of the Electrum servers. Returns an error if the coin is
+// not initialized or not backed by Electrum servers.
+func (coin *Coin) ElectrumServersHealth() ([]electrum.ServerHealth, error) {
+	healthReporter, ok := coin.blockchain.(interface {
+		ServersHealth() []electrum.ServerHealth
+	})
+	if !ok {
+		return nil, errp.New("coin not connected to Electrum servers")
+	}
+	return healthReporter.ServersHealth(), nil
+}
+
 // Headers returns the coin headers.
 func (coin *Coin) Headers() *headers.Headers {
 	return coin.headers
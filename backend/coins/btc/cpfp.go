@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// cpfpParent holds the data of an unconfirmed tx paying to the account needed to accelerate it
+// using a child-pays-for-parent tx.
+type cpfpParent struct {
+	parent *maketx.UnconfirmedParent
+	// ourInputsSum is the sum of the inputs of the parent belonging to the account.
+	ourInputsSum btcutil.Amount
+	// foreignInputs are the inputs of the parent not belonging to the account. Their value has to
+	// be looked up to compute the fee of the parent.
+	foreignInputs []wire.OutPoint
+	outputsSum    btcutil.Amount
+	// confirmedUTXOs are the confirmed spendable outputs of the account which can be used to pay
+	// for the fee of the child if the parent outputs are not sufficient.
+	confirmedUTXOs map[wire.OutPoint]maketx.UTXO
+}
+
+func (account *Account) getCPFPParent(txID string) (*cpfpParent, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if !account.Synced() {
+		return nil, accounts.ErrSyncInProgress
+	}
+	spendableOutputs, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return nil, err
+	}
+	return transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) (*cpfpParent, error) {
+		txInfo, err := dbTx.TxInfo(*txHash)
+		if err != nil {
+			return nil, err
+		}
+		if txInfo == nil {
+			return nil, errp.Newf("transaction %s not found", txID)
+		}
+		if txInfo.Height > 0 {
+			account.log.Info("Cannot accelerate a confirmed transaction")
+			return nil, errp.WithStack(errors.ErrTxNotAcceleratable)
+		}
+		result := &cpfpParent{
+			parent: &maketx.UnconfirmedParent{
+				TxHash:  *txHash,
+				Outputs: map[wire.OutPoint]maketx.UTXO{},
+				VSize:   mempool.GetTxVirtualSize(btcutil.NewTx(txInfo.Tx)),
+			},
+		}
+		for _, txIn := range txInfo.Tx.TxIn {
+			spentOutput, err := dbTx.Output(txIn.PreviousOutPoint)
+			if err != nil {
+				return nil, err
+			}
+			if spentOutput == nil {
+				result.foreignInputs = append(result.foreignInputs, txIn.PreviousOutPoint)
+				continue
+			}
+			result.ourInputsSum += btcutil.Amount(spentOutput.Value)
+		}
+		for index, txOut := range txInfo.Tx.TxOut {
+			result.outputsSum += btcutil.Amount(txOut.Value)
+			outPoint := wire.OutPoint{Hash: *txHash, Index: uint32(index)}
+			output, err := dbTx.Output(outPoint)
+			if err != nil {
+				return nil, err
+			}
+			if output == nil {
+				continue
+			}
+			spendingTx, err := dbTx.Input(outPoint)
+			if err != nil {
+				return nil, err
+			}
+			if spendingTx != nil {
+				continue
+			}
+			result.parent.Outputs[outPoint] = maketx.UTXO{
+				TxOut:   output,
+				Address: account.AddressByID(addresses.NewAddressID(output.PkScript)),
+			}
+		}
+		if len(result.parent.Outputs) == 0 {
+			account.log.Info("Cannot accelerate a transaction without unspent outputs of ours")
+			return nil, errp.WithStack(errors.ErrTxNotAcceleratable)
+		}
+		result.confirmedUTXOs, err = account.confirmedUTXOs(dbTx, spendableOutputs)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// newCPFPTx creates a child-pays-for-parent tx accelerating the unconfirmed tx with the given ID.
+func (account *Account) newCPFPTx(txID string, args *accounts.TxProposalArgs) (*maketx.TxProposal, error) {
+	cpfp, err := account.getCPFPParent(txID)
+	if err != nil {
+		return nil, err
+	}
+	// The parent fee can only be computed if we know the values of all its inputs. Inputs not
+	// belonging to the account are looked up from the previous transactions.
+	inputsSum := cpfp.ourInputsSum
+	for _, outPoint := range cpfp.foreignInputs {
+		prevTx, err := account.coin.Blockchain().TransactionGet(outPoint.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if int(outPoint.Index) >= len(prevTx.TxOut) {
+			return nil, errp.Newf("invalid input %s of the parent transaction", outPoint)
+		}
+		inputsSum += btcutil.Amount(prevTx.TxOut[outPoint.Index].Value)
+	}
+	cpfp.parent.Fee = inputsSum - cpfp.outputsSum
+
+	feeRatePerKb, err := account.getFeePerKb(args)
+	if err != nil {
+		return nil, err
+	}
+	minRelayFeeRatePerKb, err := account.getMinRelayFeeRate()
+	if err != nil {
+		return nil, err
+	}
+	changeAddress, err := account.pickChangeAddress(cpfp.parent.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	return maketx.NewTxCPFP(
+		account.coin,
+		cpfp.parent,
+		cpfp.confirmedUTXOs,
+		feeRatePerKb,
+		minRelayFeeRatePerKb,
+		changeAddress,
+		account.log,
+	)
+}
+
+// CPFPTxProposal creates a child-pays-for-parent tx accelerating the unconfirmed tx with the given
+// ID, which must have at least one unspent output belonging to the account. The child spends these
+// outputs back to the account, paying a fee such that the combined fee rate of the parent and the
+// child reaches the fee rate given by args. Only the fee related fields of args are used.
+//
+// The proposal is stored internally and can be signed and sent with SendTx().
+func (account *Account) CPFPTxProposal(txID string, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	defer account.activeTxProposalLock.Lock()()
+
+	account.log.Debug("Proposing child-pays-for-parent transaction")
+	txProposal, err := account.newCPFPTx(txID, args)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+
+	account.activeTxProposal = txProposal
+
+	account.log.WithField("fee", txProposal.Fee).Debug("Returning fee")
+	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		coin.NewAmountFromInt64(int64(txProposal.Total())), nil
+}
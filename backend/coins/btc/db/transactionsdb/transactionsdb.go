@@ -130,10 +130,16 @@ func (tx *Tx) TxInfo(txHash chainhash.Hash) (
 	return walletTx, nil
 }
 
-// PutTx implements transactions.DBTxInterface.
+// PutTx implements transactions.DBTxInterface. A verified tx is marked as unverified if its height
+// changed, e.g. because of a reorg.
 func (tx *Tx) PutTx(txHash chainhash.Hash, msgTx *wire.MsgTx, height int, headerTimestamp *time.Time) error {
 	var verified *bool
 	err := tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
+		if walletTx.Height != height {
+			walletTx.Verified = nil
+			walletTx.VerifiedHeight = 0
+			walletTx.VerificationFailed = false
+		}
 		verified = walletTx.Verified
 		walletTx.Tx = msgTx
 		walletTx.Height = height
@@ -145,16 +151,19 @@ func (tx *Tx) PutTx(txHash chainhash.Hash, msgTx *wire.MsgTx, height int, header
 		return err
 	}
 	if verified == nil {
-		bucketUnverifiedTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketUnverifiedTransactionsKey))
-		if err != nil {
-			return errp.WithStack(err)
-		}
-
-		return bucketUnverifiedTransactions.Put(txHash[:], nil)
+		return tx.putUnverifiedTx(txHash)
 	}
 	return nil
 }
 
+func (tx *Tx) putUnverifiedTx(txHash chainhash.Hash) error {
+	bucketUnverifiedTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketUnverifiedTransactionsKey))
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(bucketUnverifiedTransactions.Put(txHash[:], nil))
+}
+
 // DeleteTx implements transactions.DBTxInterface. It panics if called from a read-only db
 // transaction.
 func (tx *Tx) DeleteTx(txHash chainhash.Hash) {
@@ -212,7 +221,7 @@ func (tx *Tx) UnverifiedTransactions() ([]chainhash.Hash, error) {
 }
 
 // MarkTxVerified implements transactions.DBTxInterface.
-func (tx *Tx) MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) error {
+func (tx *Tx) MarkTxVerified(txHash chainhash.Hash, height int, headerTimestamp time.Time) error {
 	bucketUnverifiedTransactions, err := tx.tx.CreateBucketIfNotExists([]byte(bucketUnverifiedTransactionsKey))
 	if err != nil {
 		panic(errp.WithStack(err))
@@ -223,10 +232,31 @@ func (tx *Tx) MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) e
 	return tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
 		truth := true
 		walletTx.Verified = &truth
+		walletTx.VerifiedHeight = height
+		walletTx.VerificationFailed = false
 		walletTx.HeaderTimestamp = &headerTimestamp
 	})
 }
 
+// MarkTxVerificationFailed implements transactions.DBTxInterface.
+func (tx *Tx) MarkTxVerificationFailed(txHash chainhash.Hash) error {
+	return tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
+		walletTx.VerificationFailed = true
+	})
+}
+
+// MarkTxUnverified implements transactions.DBTxInterface.
+func (tx *Tx) MarkTxUnverified(txHash chainhash.Hash) error {
+	err := tx.modifyTx(txHash[:], func(walletTx *transactions.DBTxInfo) {
+		walletTx.Verified = nil
+		walletTx.VerifiedHeight = 0
+	})
+	if err != nil {
+		return err
+	}
+	return tx.putUnverifiedTx(txHash)
+}
+
 // PutInput implements transactions.DBTxInterface.
 func (tx *Tx) PutInput(outPoint wire.OutPoint, txHash chainhash.Hash) error {
 	bucketInputs, err := tx.tx.CreateBucketIfNotExists([]byte(bucketInputsKey))
@@ -252,7 +252,7 @@ func TestTxQuick(t *testing.T) {
 		for txHash := range allUnverifiedTxHashes {
 			t.Run("", func(t *testing.T) {
 				expectedHeaderTimestamp := time.Unix(time.Now().Unix(), 123)
-				require.NoError(t, tx.MarkTxVerified(txHash, expectedHeaderTimestamp))
+				require.NoError(t, tx.MarkTxVerified(txHash, 10, expectedHeaderTimestamp))
 				delete(allUnverifiedTxHashes, txHash)
 				require.True(t, checkTxHashes())
 				txInfo, err := tx.TxInfo(txHash)
@@ -297,6 +297,67 @@ func TestPutTxHeaderTimestampNotOverwrittenWithNil(t *testing.T) {
 	})
 }
 
+func TestTxVerification(t *testing.T) {
+	testTx(func(tx *Tx) {
+		msgTx := &wire.MsgTx{
+			Version: wire.TxVersion,
+			TxIn: []*wire.TxIn{
+				wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH(nil), Index: 0}, nil, nil),
+			},
+			TxOut:    []*wire.TxOut{wire.NewTxOut(123, []byte("dummyPubKeyScript"))},
+			LockTime: 0,
+		}
+		txHash := msgTx.TxHash()
+		requireUnverified := func(expected bool) {
+			t.Helper()
+			unverified, err := tx.UnverifiedTransactions()
+			require.NoError(t, err)
+			if expected {
+				require.Equal(t, []chainhash.Hash{txHash}, unverified)
+			} else {
+				require.Empty(t, unverified)
+			}
+		}
+
+		require.NoError(t, tx.PutTx(txHash, msgTx, 100, nil))
+		requireUnverified(true)
+
+		require.NoError(t, tx.MarkTxVerificationFailed(txHash))
+		txInfo, err := tx.TxInfo(txHash)
+		require.NoError(t, err)
+		require.True(t, txInfo.VerificationFailed)
+		require.Nil(t, txInfo.Verified)
+		requireUnverified(true)
+
+		require.NoError(t, tx.MarkTxVerified(txHash, 100, time.Unix(123456, 0)))
+		txInfo, err = tx.TxInfo(txHash)
+		require.NoError(t, err)
+		require.False(t, txInfo.VerificationFailed)
+		require.True(t, *txInfo.Verified)
+		require.Equal(t, 100, txInfo.VerifiedHeight)
+		requireUnverified(false)
+
+		// Same height, stays verified.
+		require.NoError(t, tx.PutTx(txHash, msgTx, 100, nil))
+		requireUnverified(false)
+
+		// The tx moved to another block, it needs to be verified again.
+		require.NoError(t, tx.PutTx(txHash, msgTx, 101, nil))
+		txInfo, err = tx.TxInfo(txHash)
+		require.NoError(t, err)
+		require.Nil(t, txInfo.Verified)
+		require.Equal(t, 0, txInfo.VerifiedHeight)
+		requireUnverified(true)
+
+		require.NoError(t, tx.MarkTxVerified(txHash, 101, time.Unix(123456, 0)))
+		require.NoError(t, tx.MarkTxUnverified(txHash))
+		txInfo, err = tx.TxInfo(txHash)
+		require.NoError(t, err)
+		require.Nil(t, txInfo.Verified)
+		requireUnverified(true)
+	})
+}
+
 func TestInput(t *testing.T) {
 	testTx(func(tx *Tx) {
 		outpoint1 := wire.OutPoint{
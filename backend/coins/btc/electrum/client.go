@@ -11,18 +11,37 @@ import (
 	"strings"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 	"github.com/BitBoxSwiss/block-client-go/electrum"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
+	"github.com/BitBoxSwiss/block-client-go/jsonrpc"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+	"golang.org/x/net/proxy"
 )
 
+// errClientClosed is returned by requests made after the client was closed.
+var errClientClosed = errors.New("client closed")
+
 // client wraps electrum.Client to convert some method inputs and outputs to btcd/btcutil types. It
 // also implements blockchain.Interface.
 type client struct {
 	client *electrum.Client
+	// server is the address of the server, used to attribute measurements to it.
+	server string
+
+	// serverInfo and dialer are used to open rpc.
+	serverInfo *config.ServerInfo
+	dialer     proxy.Dialer
+	// rpc is a second connection to the server for requests not supported by the Electrum client. It
+	// is nil until it is needed.
+	rpc    *jsonrpc.Client
+	closed bool
+	// covers rpc and closed.
+	rpcLock locker.Locker
 }
 
 func (c *client) EstimateFee(number int) (btcutil.Amount, error) {
@@ -139,4 +158,10 @@ func (c *client) SetOnError(f func(error)) {
 
 func (c *client) Close() {
 	c.client.Close()
+	defer c.rpcLock.Lock()()
+	c.closed = true
+	if c.rpc != nil {
+		c.rpc.Close()
+		c.rpc = nil
+	}
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox02-api-go/util/semver"
 	"github.com/BitBoxSwiss/block-client-go/electrum"
 	"github.com/BitBoxSwiss/block-client-go/failover"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
 )
@@ -124,8 +125,16 @@ func newTCPConnection(address string, dialer proxy.Dialer) (net.Conn, error) {
 }
 
 // NewElectrumConnection connects to an Electrum server and returns a ElectrumClient instance to
-// communicate with it.
-func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry, dialer proxy.Dialer) blockchain.Interface {
+// communicate with it. The health of the servers is monitored, and unhealthy servers are avoided.
+// The tips reported by the servers are checked against the proof of work limit of chainParams. If
+// discoverPeers is true, the peers of the servers are discovered and shown for comparison.
+func NewElectrumConnection(
+	serverInfos []*config.ServerInfo,
+	chainParams *chaincfg.Params,
+	log *logrus.Entry,
+	dialer proxy.Dialer,
+	discoverPeers bool,
+) blockchain.Interface {
 	var serverList string
 	for _, serverInfo := range serverInfos {
 		if serverList != "" {
@@ -138,13 +147,19 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 
 	servers := []*failover.Server[*client]{}
 	retryTimeout := 30 * time.Second
+	pool := newServerPool(serverInfos, discoverPeers, chainParams, dialer, log)
 
 	for _, serverInfo := range serverInfos {
 		servers = append(servers, &failover.Server[*client]{
 			Name: serverInfo.Server,
 			Connect: func() (*client, error) {
 				log := log.WithField("server", serverInfo.String())
+				if !pool.shouldConnect(serverInfo.Server) {
+					log.Info("Skipping unhealthy backend")
+					return nil, errServerUnhealthy
+				}
 				log.Info("Trying to connect to backend")
+				start := time.Now()
 				c, err := electrum.Connect(&electrum.Options{
 					SoftwareVersion: softwareVersion,
 					// Slightly less than PingInterval according to the `electrum.Options` docs - a
@@ -155,6 +170,7 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 						return establishConnection(serverInfo, dialer)
 					},
 				})
+				pool.recordConnect(serverInfo.Server, time.Since(start), err)
 				if err != nil {
 					log.WithError(err).Error("Failover: backend is down")
 					return nil, err
@@ -162,7 +178,12 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 				log.
 					WithField("server-version", c.ServerVersion().String()).
 					Infof("Successfully connected to backend %s", serverInfo.Server)
-				return &client{client: c}, nil
+				return &client{
+					client:     c,
+					server:     serverInfo.Server,
+					serverInfo: serverInfo,
+					dialer:     dialer,
+				}, nil
 			},
 		})
 	}
@@ -171,9 +192,11 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 		Servers:      servers,
 		RetryTimeout: retryTimeout,
 		OnConnect: func(server *failover.Server[*client]) {
+			pool.setConnected(server.Name)
 			fclient.setConnectionError(nil)
 		},
 		OnDisconnect: func(server *failover.Server[*client], err error) {
+			pool.setDisconnected(server.Name)
 			log.
 				WithError(err).
 				WithField("server", server.String()).
@@ -188,7 +211,8 @@ func NewElectrumConnection(serverInfos []*config.ServerInfo, log *logrus.Entry,
 				fclient.setConnectionError(errors.New("Servers unreachable"))
 			}
 		},
-	})
+	}, pool)
+	go pool.run(fclient.failoverFrom)
 	return fclient
 }
 
@@ -3,9 +3,12 @@
 package electrum
 
 import (
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
 	"github.com/BitBoxSwiss/block-client-go/failover"
 	"github.com/btcsuite/btcd/btcutil"
@@ -18,21 +21,39 @@ import (
 // servers are tried again. Subscriptions are automatically re-subscribed on new servers.
 type failoverClient struct {
 	failover *failover.Failover[*client]
+	pool     *serverPool
 
 	connectionError                   error
 	onConnectionErrorChangedCallbacks []func(error)
 	// covers connectionError and onConnectionErrorChangedCallbacks.
 	mu sync.RWMutex
+
+	feeHistogram     blockchain.FeeHistogram
+	feeHistogramErr  error
+	feeHistogramTime time.Time
+	// covers feeHistogram, feeHistogramErr and feeHistogramTime.
+	feeHistogramLock locker.Locker
 }
 
-// newFailoverClient creates a new failover client.
-func newFailoverClient(opts *failover.Options[*client]) *failoverClient {
+// newFailoverClient creates a new failover client. The requests are recorded in the pool.
+func newFailoverClient(opts *failover.Options[*client], pool *serverPool) *failoverClient {
 	return &failoverClient{
 		failover:                          failover.New[*client](opts),
+		pool:                              pool,
 		onConnectionErrorChangedCallbacks: []func(error){},
 	}
 }
 
+// call is like failover.Call, but records the latency and the errors of the requests in the pool.
+func call[R any](f *failoverClient, method func(c *client) (R, error)) (R, error) {
+	return failover.Call(f.failover, func(c *client) (R, error) {
+		start := time.Now()
+		result, err := method(c)
+		f.pool.recordRequest(c.server, time.Since(start), err)
+		return result, err
+	})
+}
+
 func (f *failoverClient) setConnectionError(err error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -57,19 +78,40 @@ func (f *failoverClient) RegisterOnConnectionErrorChangedEvent(callback func(err
 }
 
 func (f *failoverClient) EstimateFee(number int) (btcutil.Amount, error) {
-	return failover.Call(f.failover, func(c *client) (btcutil.Amount, error) {
+	return call(f, func(c *client) (btcutil.Amount, error) {
 		return c.EstimateFee(number)
 	})
 }
 
+// FeeHistogram returns the fee histogram of the mempool of the connected server. The result is
+// cached for feeHistogramCacheDuration. Errors are cached as well, so that servers not supporting
+// the method are not asked over and over again.
+func (f *failoverClient) FeeHistogram() (blockchain.FeeHistogram, error) {
+	defer f.feeHistogramLock.Lock()()
+	if !f.feeHistogramTime.IsZero() && time.Since(f.feeHistogramTime) < feeHistogramCacheDuration {
+		return f.feeHistogram, f.feeHistogramErr
+	}
+	// Not recorded in the pool, as servers not supporting the method would look unhealthy.
+	histogram, err := failover.Call(f.failover, func(c *client) (blockchain.FeeHistogram, error) {
+		return c.FeeHistogram()
+	})
+	if errors.Is(err, failover.ErrClosed) {
+		return nil, err
+	}
+	f.feeHistogram = histogram
+	f.feeHistogramErr = err
+	f.feeHistogramTime = time.Now()
+	return histogram, err
+}
+
 func (f *failoverClient) GetMerkle(txHash chainhash.Hash, height int) (*blockchain.GetMerkleResult, error) {
-	return failover.Call(f.failover, func(c *client) (*blockchain.GetMerkleResult, error) {
+	return call(f, func(c *client) (*blockchain.GetMerkleResult, error) {
 		return c.GetMerkle(txHash, height)
 	})
 }
 
 func (f *failoverClient) Headers(startHeight int, count int) (*blockchain.HeadersResult, error) {
-	return failover.Call(f.failover, func(c *client) (*blockchain.HeadersResult, error) {
+	return call(f, func(c *client) (*blockchain.HeadersResult, error) {
 		return c.Headers(startHeight, count)
 	})
 }
@@ -92,13 +134,13 @@ func (f *failoverClient) HeadersSubscribe(result func(header *types.Header)) {
 }
 
 func (f *failoverClient) RelayFee() (btcutil.Amount, error) {
-	return failover.Call(f.failover, func(c *client) (btcutil.Amount, error) {
+	return call(f, func(c *client) (btcutil.Amount, error) {
 		return c.RelayFee()
 	})
 }
 
 func (f *failoverClient) ScriptHashGetHistory(scriptHashHex blockchain.ScriptHashHex) (blockchain.TxHistory, error) {
-	return failover.Call(f.failover, func(c *client) (blockchain.TxHistory, error) {
+	return call(f, func(c *client) (blockchain.TxHistory, error) {
 		return c.ScriptHashGetHistory(scriptHashHex)
 	})
 }
@@ -132,6 +174,7 @@ func (f *failoverClient) ScriptHashSubscribe(
 }
 
 func (f *failoverClient) TransactionBroadcast(transaction *wire.MsgTx) error {
+	// Not recorded in the pool, as errors are mostly transactions rejected by the server.
 	_, err := failover.Call(f.failover, func(c *client) (struct{}, error) {
 		return struct{}{}, c.TransactionBroadcast(transaction)
 	})
@@ -139,7 +182,7 @@ func (f *failoverClient) TransactionBroadcast(transaction *wire.MsgTx) error {
 }
 
 func (f *failoverClient) TransactionGet(txHash chainhash.Hash) (*wire.MsgTx, error) {
-	return failover.Call(f.failover, func(c *client) (*wire.MsgTx, error) {
+	return call(f, func(c *client) (*wire.MsgTx, error) {
 		return c.TransactionGet(txHash)
 	})
 }
@@ -148,6 +191,24 @@ func (f *failoverClient) ManualReconnect() {
 	f.failover.ManualReconnect()
 }
 
+// ServersHealth returns the health of the servers.
+func (f *failoverClient) ServersHealth() []ServerHealth {
+	return f.pool.Health()
+}
+
+// failoverFrom triggers a failover if the given server is the one currently connected.
+func (f *failoverClient) failoverFrom(server string) {
+	failedOver := false
+	_, _ = failover.Call(f.failover, func(c *client) (struct{}, error) {
+		if !failedOver && c.server == server {
+			failedOver = true
+			return struct{}{}, failover.NewFailoverError(errServerUnhealthy)
+		}
+		return struct{}{}, nil
+	})
+}
+
 func (f *failoverClient) Close() {
+	f.pool.close()
 	f.failover.Close()
 }
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package electrum
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/block-client-go/jsonrpc"
+)
+
+// feeHistogramCacheDuration is the time a fetched fee histogram, or the error fetching it, is
+// reused.
+const feeHistogramCacheDuration = time.Minute
+
+// FeeHistogram fetches the fee histogram of the mempool of the server. The Electrum client does not
+// support `mempool.get_fee_histogram`, so the request is made over a second connection to the
+// server, which is opened on first use and kept until the client is closed. If the connection
+// broke in the meantime, it is reopened once.
+func (c *client) FeeHistogram() (blockchain.FeeHistogram, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	var result [][2]float64
+	err := c.rpcMethod(ctx, &result, "mempool.get_fee_histogram")
+	if isTransportError(err) {
+		err = c.rpcMethod(ctx, &result, "mempool.get_fee_histogram")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newFeeHistogram(result), nil
+}
+
+// rpcMethod makes a request over the second connection to the server. The connection is dropped
+// after a transport error, so that the next request reconnects.
+func (c *client) rpcMethod(ctx context.Context, response interface{}, method string) error {
+	rpc, err := c.getRPC(ctx)
+	if err != nil {
+		return err
+	}
+	if err := rpc.MethodBlocking(ctx, response, method); err != nil {
+		if isTransportError(err) {
+			c.dropRPC(rpc)
+		}
+		return errp.WithStack(err)
+	}
+	return nil
+}
+
+// getRPC returns the second connection to the server, opening it if needed. The lock is not held
+// while connecting, so that closing the client is not delayed.
+func (c *client) getRPC(ctx context.Context) (*jsonrpc.Client, error) {
+	rpc, closed := func() (*jsonrpc.Client, bool) {
+		defer c.rpcLock.RLock()()
+		return c.rpc, c.closed
+	}()
+	switch {
+	case closed:
+		return nil, errClientClosed
+	case rpc != nil:
+		return rpc, nil
+	}
+	rpc, err := connectRPC(ctx, c.serverInfo, c.dialer)
+	if err != nil {
+		return nil, err
+	}
+	defer c.rpcLock.Lock()()
+	switch {
+	case c.closed:
+		rpc.Close()
+		return nil, errClientClosed
+	case c.rpc != nil:
+		// Opened concurrently.
+		rpc.Close()
+		return c.rpc, nil
+	}
+	c.rpc = rpc
+	return rpc, nil
+}
+
+// dropRPC closes the given connection and forgets it if it is still the current one.
+func (c *client) dropRPC(rpc *jsonrpc.Client) {
+	rpc.Close()
+	defer c.rpcLock.Lock()()
+	if c.rpc == rpc {
+		c.rpc = nil
+	}
+}
+
+// newFeeHistogram converts the result of `mempool.get_fee_histogram`, a list of `[fee rate, vsize]`
+// pairs, to a fee histogram sorted by descending fee rate.
+func newFeeHistogram(result [][2]float64) blockchain.FeeHistogram {
+	histogram := make(blockchain.FeeHistogram, 0, len(result))
+	for _, entry := range result {
+		if entry[0] < 0 || entry[1] <= 0 {
+			continue
+		}
+		histogram = append(histogram, blockchain.FeeHistogramEntry{
+			FeeRate: entry[0],
+			VSize:   int64(entry[1]),
+		})
+	}
+	sort.SliceStable(histogram, func(i, j int) bool {
+		return histogram[i].FeeRate > histogram[j].FeeRate
+	})
+	return histogram
+}
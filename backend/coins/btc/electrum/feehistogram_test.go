@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package electrum
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/BitBoxSwiss/block-client-go/electrum"
+	"github.com/BitBoxSwiss/block-client-go/failover"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+)
+
+// fakeServer is an in-memory Electrum server. respond returns the result or the error of a method.
+type fakeServer struct {
+	respond func(method string) (interface{}, error)
+	dials   atomic.Int32
+	// histogramRequests counts the `mempool.get_fee_histogram` requests.
+	histogramRequests atomic.Int32
+
+	conns   []net.Conn
+	connsMu sync.Mutex
+}
+
+func newFakeServer(respond func(method string) (interface{}, error)) *fakeServer {
+	return &fakeServer{respond: func(method string) (interface{}, error) {
+		if method == "server.version" {
+			return []string{"fake", protocolVersion}, nil
+		}
+		return respond(method)
+	}}
+}
+
+func (s *fakeServer) dialer() proxy.Dialer {
+	return &test.Dialer{DialFn: func(network, addr string) (net.Conn, error) {
+		s.dials.Add(1)
+		clientConn, serverConn := net.Pipe()
+		s.connsMu.Lock()
+		s.conns = append(s.conns, serverConn)
+		s.connsMu.Unlock()
+		go s.serve(serverConn)
+		return clientConn, nil
+	}}
+}
+
+// closeConns breaks all connections to the server.
+func (s *fakeServer) closeConns() {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var request struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(line, &request); err != nil {
+			return
+		}
+		if request.Method == "mempool.get_fee_histogram" {
+			s.histogramRequests.Add(1)
+		}
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+		result, err := s.respond(request.Method)
+		if err != nil {
+			response["error"] = map[string]interface{}{"code": 1, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		responseBytes, err := json.Marshal(response)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write(append(responseBytes, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) connect(t *testing.T) *client {
+	t.Helper()
+	serverInfo := &config.ServerInfo{Server: "fake:50001"}
+	dialer := s.dialer()
+	c, err := electrum.Connect(&electrum.Options{
+		SoftwareVersion: softwareVersion,
+		MethodTimeout:   time.Second,
+		PingInterval:    -1,
+		Dial: func() (net.Conn, error) {
+			return establishConnection(serverInfo, dialer)
+		},
+	})
+	require.NoError(t, err)
+	return &client{client: c, server: serverInfo.Server, serverInfo: serverInfo, dialer: dialer}
+}
+
+func TestClientFeeHistogram(t *testing.T) {
+	histogramErr := errors.New("unknown method")
+	var failing atomic.Bool
+	server := newFakeServer(func(method string) (interface{}, error) {
+		if failing.Load() {
+			return nil, histogramErr
+		}
+		return [][2]float64{{12, 50000}, {53.5, 102000}}, nil
+	})
+	c := server.connect(t)
+	expected := blockchain.FeeHistogram{{FeeRate: 53.5, VSize: 102000}, {FeeRate: 12, VSize: 50000}}
+
+	// The second connection is opened once and reused.
+	for i := 0; i < 2; i++ {
+		histogram, err := c.FeeHistogram()
+		require.NoError(t, err)
+		require.Equal(t, expected, histogram)
+	}
+	require.Equal(t, int32(2), server.dials.Load())
+
+	// Errors returned by the server don't close the connection.
+	failing.Store(true)
+	_, err := c.FeeHistogram()
+	require.EqualError(t, err, histogramErr.Error())
+	require.Equal(t, int32(2), server.dials.Load())
+	failing.Store(false)
+
+	// A broken connection is reopened.
+	server.closeConns()
+	histogram, err := c.FeeHistogram()
+	require.NoError(t, err)
+	require.Equal(t, expected, histogram)
+	require.Equal(t, int32(3), server.dials.Load())
+
+	c.Close()
+	_, err = c.FeeHistogram()
+	require.Error(t, err)
+	require.Equal(t, int32(3), server.dials.Load())
+}
+
+func TestFailoverClientFeeHistogramCachesErrors(t *testing.T) {
+	server := newFakeServer(func(method string) (interface{}, error) {
+		return nil, errors.New("unknown method")
+	})
+	pool := newServerPool(nil, false, &chaincfg.MainNetParams, server.dialer(), logging.Get().WithGroup("electrum_test"))
+	fclient := newFailoverClient(&failover.Options[*client]{
+		Servers: []*failover.Server[*client]{{
+			Name: "fake:50001",
+			Connect: func() (*client, error) {
+				return server.connect(t), nil
+			},
+		}},
+		RetryTimeout: time.Second,
+	}, pool)
+	defer fclient.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := fclient.FeeHistogram()
+		require.EqualError(t, err, "unknown method")
+	}
+	require.Equal(t, int32(1), server.histogramRequests.Load())
+}
+
+func TestNewFeeHistogram(t *testing.T) {
+	require.Equal(t,
+		blockchain.FeeHistogram{
+			{FeeRate: 53.5, VSize: 102000},
+			{FeeRate: 12, VSize: 50000},
+			{FeeRate: 1, VSize: 300000},
+		},
+		newFeeHistogram([][2]float64{{12, 50000}, {53.5, 102000}, {1, 300000}, {2, 0}}))
+	require.Equal(t, blockchain.FeeHistogram{}, newFeeHistogram(nil))
+}
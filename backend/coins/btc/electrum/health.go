@@ -0,0 +1,598 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package electrum
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+	"github.com/BitBoxSwiss/block-client-go/failover"
+	"github.com/BitBoxSwiss/block-client-go/jsonrpc"
+	btcdBlockchain "github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// healthCheckInterval is the time between two probes of the servers.
+	healthCheckInterval = 5 * time.Minute
+	// healthCheckDelay is the time after startup before the servers are probed the first time, so
+	// that probing does not compete with the initial sync.
+	healthCheckDelay = 30 * time.Second
+	// probeTimeout is the timeout of each request made when probing a server.
+	probeTimeout = 30 * time.Second
+	// maxTipLag is the number of blocks the tip of a server may differ from the tip of the majority
+	// of the servers before the server is considered unhealthy.
+	maxTipLag = 2
+	// maxErrorRate is the share of requests failing with a transport error, e.g. a timeout, above
+	// which a server is considered unhealthy. Errors returned by the server itself don't count.
+	maxErrorRate = 0.5
+	// minRequestsForErrorRate is the number of requests needed before the error rate of a server is
+	// taken into account.
+	minRequestsForErrorRate = 10
+	// latencySmoothing is the weight of a new measurement in the moving average of the latency.
+	latencySmoothing = 0.2
+	// maxDiscoveredServers limits the number of servers added by peer discovery in total, and
+	// maxDiscoveredPerServer the number of servers taken from the peers of a single server.
+	// Discovered servers are announced by the servers themselves, so they are only shown for
+	// comparison and never count towards the tip of the majority.
+	maxDiscoveredServers   = 10
+	maxDiscoveredPerServer = 3
+	// protocolVersion is the Electrum protocol version negotiated when probing a server.
+	protocolVersion = "1.4"
+	// defaultTLSPort is the port of a peer announcing TLS support without a port.
+	defaultTLSPort = "50002"
+)
+
+// errServerUnhealthy is used to refuse connecting to unhealthy servers and to fail over from them.
+var errServerUnhealthy = errors.New("server is unhealthy")
+
+// TipStatus describes how the tip of a server compares to the tip of the majority of the servers.
+type TipStatus string
+
+const (
+	// TipStatusUnknown means that the tip of the server is not known yet.
+	TipStatusUnknown TipStatus = "unknown"
+	// TipStatusOK means that the tip of the server agrees with the majority.
+	TipStatusOK TipStatus = "ok"
+	// TipStatusBehind means that the server lags behind the majority.
+	TipStatusBehind TipStatus = "behind"
+	// TipStatusAhead means that the server reports a tip the majority does not know about. As the
+	// proof of work of the tips is checked, the server is more likely up to date than the majority,
+	// so it is not considered unhealthy.
+	TipStatusAhead TipStatus = "ahead"
+)
+
+// ServerHealth is the health of an Electrum server as measured by the app.
+type ServerHealth struct {
+	Server string `json:"server"`
+	TLS    bool   `json:"tls"`
+	// Discovered is true if the server was found through peer discovery. Discovered servers are only
+	// probed to compare their tip, they are not used to make requests and don't count towards the tip
+	// of the majority.
+	Discovered bool `json:"discovered"`
+	// Connected is true if the server is the one currently used for requests.
+	Connected bool `json:"connected"`
+	// Reachable is false if the last connection attempt failed or if no connection was attempted
+	// yet.
+	Reachable bool `json:"reachable"`
+	// LatencyMs is the moving average of the response time in milliseconds, 0 if not measured yet.
+	LatencyMs int64     `json:"latencyMs"`
+	Requests  int       `json:"requests"`
+	Errors    int       `json:"errors"`
+	TipHeight int       `json:"tipHeight"`
+	TipStatus TipStatus `json:"tipStatus"`
+	LastError string    `json:"lastError,omitempty"`
+	Healthy   bool      `json:"healthy"`
+}
+
+// serverStats holds the measurements of one server.
+type serverStats struct {
+	info       *config.ServerInfo
+	discovered bool
+
+	attempted bool
+	reachable bool
+	latency   time.Duration
+	requests  int
+	errors    int
+	tipHeight int
+	lastError error
+}
+
+// serverPool keeps track of the health of the configured servers. Servers lagging behind the
+// majority, unreachable servers and servers failing too many requests are considered unhealthy and are avoided as long as there is a healthy server.
+type serverPool struct {
+	// servers contains the configured servers first, followed by the discovered ones.
+	servers   []*serverStats
+	connected string
+	// covers servers and connected.
+	mu locker.Locker
+
+	discoverPeers bool
+	net           *chaincfg.Params
+	dialer        proxy.Dialer
+	log           *logrus.Entry
+
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+func newServerPool(
+	serverInfos []*config.ServerInfo,
+	discoverPeers bool,
+	chainParams *chaincfg.Params,
+	dialer proxy.Dialer,
+	log *logrus.Entry,
+) *serverPool {
+	servers := make([]*serverStats, len(serverInfos))
+	for i, serverInfo := range serverInfos {
+		servers[i] = &serverStats{info: serverInfo}
+	}
+	return &serverPool{
+		servers:       servers,
+		discoverPeers: discoverPeers,
+		net:           chainParams,
+		dialer:        dialer,
+		log:           log,
+		quit:          make(chan struct{}),
+	}
+}
+
+// get returns the stats of the server, or nil if the server is unknown. The caller must hold the
+// lock.
+func (p *serverPool) get(server string) *serverStats {
+	for _, stats := range p.servers {
+		if stats.info.Server == server {
+			return stats
+		}
+	}
+	return nil
+}
+
+func (stats *serverStats) addLatency(latency time.Duration) {
+	if stats.latency == 0 {
+		stats.latency = latency
+		return
+	}
+	stats.latency = time.Duration(
+		(1-latencySmoothing)*float64(stats.latency) + latencySmoothing*float64(latency))
+}
+
+// recordConnect records the result of a connection attempt.
+func (p *serverPool) recordConnect(server string, latency time.Duration, err error) {
+	defer p.mu.Lock()()
+	stats := p.get(server)
+	if stats == nil {
+		return
+	}
+	stats.attempted = true
+	stats.reachable = err == nil
+	if err != nil {
+		stats.lastError = err
+		return
+	}
+	stats.addLatency(latency)
+}
+
+// isTransportError returns true if the request failed because the server did not respond in time or
+// the connection broke, as opposed to an error returned by the server, e.g. when broadcasting an
+// invalid transaction.
+func isTransportError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.As(err, &netErr)
+}
+
+// recordRequest records the result of a request made to the server. Only transport errors count as
+// failed requests, errors returned by the server are answers like any other.
+func (p *serverPool) recordRequest(server string, latency time.Duration, err error) {
+	if errors.Is(err, failover.ErrClosed) {
+		return
+	}
+	defer p.mu.Lock()()
+	stats := p.get(server)
+	if stats == nil {
+		return
+	}
+	stats.requests++
+	if isTransportError(err) {
+		stats.errors++
+		stats.lastError = err
+		return
+	}
+	stats.addLatency(latency)
+}
+
+// recordTip records the tip height reported by the server.
+func (p *serverPool) recordTip(server string, height int) {
+	defer p.mu.Lock()()
+	if stats := p.get(server); stats != nil {
+		stats.tipHeight = height
+	}
+}
+
+// setPEMCert sets the certificate of the server which was downloaded when probing it. The server
+// info is replaced instead of modified, as it is shared with the connections to the server.
+func (p *serverPool) setPEMCert(server string, pemCert string) {
+	defer p.mu.Lock()()
+	if stats := p.get(server); stats != nil {
+		info := *stats.info
+		info.PEMCert = pemCert
+		stats.info = &info
+	}
+}
+
+func (p *serverPool) setConnected(server string) {
+	defer p.mu.Lock()()
+	p.connected = server
+}
+
+func (p *serverPool) setDisconnected(server string) {
+	defer p.mu.Lock()()
+	if p.connected == server {
+		p.connected = ""
+	}
+}
+
+// referenceTip returns the tip of the majority of the servers: the highest tip reached by a strict
+// majority of the configured servers, which is the lower median if the tips of all of them are
+// known. Discovered servers are not taken into account. Returns 0 if the tips of less than a
+// majority are known. The caller must hold the lock.
+func (p *serverPool) referenceTip() int {
+	numConfigured := 0
+	tips := []int{}
+	for _, stats := range p.servers {
+		if stats.discovered {
+			continue
+		}
+		numConfigured++
+		if stats.tipHeight > 0 {
+			tips = append(tips, stats.tipHeight)
+		}
+	}
+	// Index of the highest tip reached by numConfigured/2+1 servers.
+	index := len(tips) - numConfigured/2 - 1
+	if index < 0 {
+		return 0
+	}
+	sort.Ints(tips)
+	return tips[index]
+}
+
+// tipStatus compares the tip of the server to the reference tip. The caller must hold the lock.
+func (p *serverPool) tipStatus(stats *serverStats, referenceTip int) TipStatus {
+	switch {
+	case stats.tipHeight == 0 || referenceTip == 0:
+		return TipStatusUnknown
+	case stats.tipHeight < referenceTip-maxTipLag:
+		return TipStatusBehind
+	case stats.tipHeight > referenceTip+maxTipLag:
+		return TipStatusAhead
+	default:
+		return TipStatusOK
+	}
+}
+
+// healthy returns true if the server is reachable, is not behind the majority and does not fail
+// too many requests. Servers not measured yet are considered healthy. The caller must hold
+// the lock.
+func (p *serverPool) healthy(stats *serverStats, referenceTip int) bool {
+	if stats.attempted && !stats.reachable {
+		return false
+	}
+	if p.tipStatus(stats, referenceTip) == TipStatusBehind {
+		return false
+	}
+	if stats.requests >= minRequestsForErrorRate &&
+		float64(stats.errors)/float64(stats.requests) > maxErrorRate {
+		return false
+	}
+	return true
+}
+
+// otherHealthy returns true if there is a healthy configured server other than the given one. The
+// caller must hold the lock.
+func (p *serverPool) otherHealthy(server string, referenceTip int) bool {
+	for _, stats := range p.servers {
+		if !stats.discovered && stats.info.Server != server && p.healthy(stats, referenceTip) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldConnect returns false if the server is unhealthy and there is another server which is
+// healthy. If all servers are unhealthy, connecting to any of them is preferred to being offline.
+func (p *serverPool) shouldConnect(server string) bool {
+	defer p.mu.RLock()()
+	stats := p.get(server)
+	if stats == nil {
+		return true
+	}
+	referenceTip := p.referenceTip()
+	return p.healthy(stats, referenceTip) || !p.otherHealthy(server, referenceTip)
+}
+
+// connectedUnhealthy returns the connected server if it is unhealthy while another server is
+// healthy, and an empty string otherwise.
+func (p *serverPool) connectedUnhealthy() string {
+	defer p.mu.RLock()()
+	stats := p.get(p.connected)
+	if stats == nil {
+		return ""
+	}
+	referenceTip := p.referenceTip()
+	if p.healthy(stats, referenceTip) || !p.otherHealthy(stats.info.Server, referenceTip) {
+		return ""
+	}
+	return p.connected
+}
+
+// addDiscovered adds servers found through peer discovery, skipping the known ones. Returns the
+// number of servers added.
+func (p *serverPool) addDiscovered(serverInfos []*config.ServerInfo) int {
+	defer p.mu.Lock()()
+	numDiscovered := 0
+	for _, stats := range p.servers {
+		if stats.discovered {
+			numDiscovered++
+		}
+	}
+	added := 0
+	for _, serverInfo := range serverInfos {
+		if numDiscovered >= maxDiscoveredServers || added >= maxDiscoveredPerServer {
+			break
+		}
+		if p.get(serverInfo.Server) != nil {
+			continue
+		}
+		p.servers = append(p.servers, &serverStats{info: serverInfo, discovered: true})
+		numDiscovered++
+		added++
+	}
+	return added
+}
+
+// Health returns the health of all servers, the configured ones first.
+func (p *serverPool) Health() []ServerHealth {
+	defer p.mu.RLock()()
+	referenceTip := p.referenceTip()
+	result := make([]ServerHealth, len(p.servers))
+	for i, stats := range p.servers {
+		var lastError string
+		if stats.lastError != nil {
+			lastError = stats.lastError.Error()
+		}
+		result[i] = ServerHealth{
+			Server:     stats.info.Server,
+			TLS:        stats.info.TLS,
+			Discovered: stats.discovered,
+			Connected:  stats.info.Server == p.connected,
+			Reachable:  stats.attempted && stats.reachable,
+			LatencyMs:  stats.latency.Milliseconds(),
+			Requests:   stats.requests,
+			Errors:     stats.errors,
+			TipHeight:  stats.tipHeight,
+			TipStatus:  p.tipStatus(stats, referenceTip),
+			LastError:  lastError,
+			Healthy:    p.healthy(stats, referenceTip),
+		}
+	}
+	return result
+}
+
+// run probes the servers periodically until the pool is closed. onConnectedUnhealthy is called
+// with the connected server if it turned out to be unhealthy.
+func (p *serverPool) run(onConnectedUnhealthy func(server string)) {
+	delay := healthCheckDelay
+	for {
+		select {
+		case <-p.quit:
+			return
+		case <-time.After(delay):
+		}
+		delay = healthCheckInterval
+		p.probeAll()
+		if server := p.connectedUnhealthy(); server != "" {
+			p.log.WithField("server", server).Warning("Connected server is unhealthy, failing over")
+			onConnectedUnhealthy(server)
+		}
+	}
+}
+
+func (p *serverPool) close() {
+	p.closeOnce.Do(func() { close(p.quit) })
+}
+
+// probeAll connects to all servers, including the connected one, to fetch their tip. The tips are
+// only recorded after their proof of work was checked. If peer discovery is enabled, the peers of
+// the configured servers are added to the pool.
+func (p *serverPool) probeAll() {
+	// Copies, so that a downloaded certificate can be set without holding the lock.
+	serverInfos, discovered := func() ([]config.ServerInfo, []bool) {
+		defer p.mu.RLock()()
+		serverInfos := make([]config.ServerInfo, len(p.servers))
+		discovered := make([]bool, len(p.servers))
+		for i, stats := range p.servers {
+			serverInfos[i] = *stats.info
+			discovered[i] = stats.discovered
+		}
+		return serverInfos, discovered
+	}()
+
+	var wg sync.WaitGroup
+	for i := range serverInfos {
+		wg.Add(1)
+		go func(serverInfo config.ServerInfo, discovered bool) {
+			defer wg.Done()
+			if serverInfo.TLS && serverInfo.PEMCert == "" {
+				// Discovered servers are trusted on first use, as their tip is only compared to the
+				// tip of the other servers.
+				pemCert, err := DownloadCert(serverInfo.Server, p.dialer)
+				if err != nil {
+					p.recordConnect(serverInfo.Server, 0, err)
+					p.log.WithError(err).WithField("server", serverInfo.Server).Info("Probing server failed")
+					return
+				}
+				serverInfo.PEMCert = pemCert
+				p.setPEMCert(serverInfo.Server, pemCert)
+			}
+			discover := p.discoverPeers && !discovered
+			start := time.Now()
+			tipHeight, peers, err := probeServer(&serverInfo, discover, p.net, p.dialer)
+			p.recordConnect(serverInfo.Server, time.Since(start), err)
+			if err != nil {
+				p.log.WithError(err).WithField("server", serverInfo.Server).Info("Probing server failed")
+				return
+			}
+			p.recordTip(serverInfo.Server, tipHeight)
+			if added := p.addDiscovered(peers); added > 0 {
+				p.log.WithField("server", serverInfo.Server).Infof("Discovered %d servers", added)
+			}
+		}(serverInfos[i], discovered[i])
+	}
+	wg.Wait()
+}
+
+// probeServer connects to the server and returns its tip height. The tip header must have a valid
+// proof of work. If discover is true, the TLS servers among its peers are returned as well.
+func probeServer(
+	serverInfo *config.ServerInfo,
+	discover bool,
+	chainParams *chaincfg.Params,
+	dialer proxy.Dialer,
+) (int, []*config.ServerInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	rpc, err := connectRPC(ctx, serverInfo, dialer)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rpc.Close()
+	var header struct {
+		Height int    `json:"height"`
+		Hex    string `json:"hex"`
+	}
+	if err := rpc.MethodBlocking(ctx, &header, "blockchain.headers.subscribe"); err != nil {
+		return 0, nil, errp.WithStack(err)
+	}
+	if err := checkProofOfWork(header.Hex, chainParams); err != nil {
+		return 0, nil, errp.WithMessage(err, fmt.Sprintf("invalid tip header at height %d", header.Height))
+	}
+	if !discover {
+		return header.Height, nil, nil
+	}
+	var peers json.RawMessage
+	if err := rpc.MethodBlocking(ctx, &peers, "server.peers.subscribe"); err != nil {
+		// Peer discovery is optional, the tip is still valid.
+		return header.Height, nil, nil
+	}
+	return header.Height, parsePeers(peers), nil
+}
+
+// checkProofOfWork checks that the hash of the hex encoded header meets the target of the header,
+// and that the target is not easier than the proof of work limit of the chain. A server therefore
+// can't report a made up tip without mining it.
+func checkProofOfWork(headerHex string, chainParams *chaincfg.Params) error {
+	rawHeader, err := hex.DecodeString(headerHex)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if len(rawHeader) != wire.MaxBlockHeaderPayload {
+		return errp.Newf("unexpected header length %d", len(rawHeader))
+	}
+	header := &wire.BlockHeader{}
+	if err := header.Deserialize(bytes.NewReader(rawHeader)); err != nil {
+		return errp.WithStack(err)
+	}
+	target := btcdBlockchain.CompactToBig(header.Bits)
+	if target.Sign() <= 0 || target.Cmp(chainParams.PowLimit) > 0 {
+		return errp.Newf("header %s has an invalid target", header.BlockHash())
+	}
+	powHash := headers.PowHash(chainParams, rawHeader)
+	if btcdBlockchain.HashToBig(&powHash).Cmp(target) > 0 {
+		return errp.Newf("header %s has insufficient proof of work", header.BlockHash())
+	}
+	return nil
+}
+
+// connectRPC opens a JSON-RPC connection to the server and negotiates the protocol version. It is
+// used for short-lived connections and for requests not supported by the Electrum client.
+func connectRPC(ctx context.Context, serverInfo *config.ServerInfo, dialer proxy.Dialer) (
+	*jsonrpc.Client, error) {
+	rpc, err := jsonrpc.Connect(&jsonrpc.Options{
+		Dial: func() (net.Conn, error) {
+			return establishConnection(serverInfo, dialer)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var version [2]string
+	if err := rpc.MethodBlocking(ctx, &version, "server.version", softwareVersion, protocolVersion); err != nil {
+		rpc.Close()
+		return nil, errp.WithStack(err)
+	}
+	return rpc, nil
+}
+
+// parsePeers parses the result of `server.peers.subscribe`, e.g.
+// `[["107.150.45.210", "e.anonyhost.org", ["v1.4", "p10000", "t", "s995"]]]`, and returns the peers
+// reachable over TLS. Onion peers and invalid entries are skipped.
+func parsePeers(result []byte) []*config.ServerInfo {
+	var peers [][]json.RawMessage
+	if err := json.Unmarshal(result, &peers); err != nil {
+		return nil
+	}
+	servers := []*config.ServerInfo{}
+	for _, peer := range peers {
+		if len(peer) != 3 {
+			continue
+		}
+		var host string
+		var features []string
+		if json.Unmarshal(peer[1], &host) != nil || json.Unmarshal(peer[2], &features) != nil {
+			continue
+		}
+		if host == "" || strings.HasSuffix(host, ".onion") {
+			continue
+		}
+		for _, feature := range features {
+			if !strings.HasPrefix(feature, "s") {
+				continue
+			}
+			port := strings.TrimPrefix(feature, "s")
+			if port == "" {
+				port = defaultTLSPort
+			}
+			if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+				break
+			}
+			servers = append(servers, &config.ServerInfo{Server: net.JoinHostPort(host, port), TLS: true})
+			break
+		}
+	}
+	return servers
+}
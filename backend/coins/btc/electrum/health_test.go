@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package electrum
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/block-client-go/failover"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/proxy"
+)
+
+func newTestServerPool(servers ...string) *serverPool {
+	serverInfos := make([]*config.ServerInfo, len(servers))
+	for i, server := range servers {
+		serverInfos[i] = &config.ServerInfo{Server: server, TLS: true}
+	}
+	return newServerPool(
+		serverInfos, false, &chaincfg.MainNetParams, proxy.Direct, logging.Get().WithGroup("electrum_test"))
+}
+
+func healthOf(t *testing.T, pool *serverPool, server string) ServerHealth {
+	t.Helper()
+	for _, health := range pool.Health() {
+		if health.Server == server {
+			return health
+		}
+	}
+	require.Fail(t, "server not found", server)
+	return ServerHealth{}
+}
+
+func TestServerPoolTipStatus(t *testing.T) {
+	pool := newTestServerPool("a:1", "b:1", "c:1", "d:1")
+	// Nothing measured yet.
+	for _, health := range pool.Health() {
+		require.Equal(t, TipStatusUnknown, health.TipStatus)
+		require.True(t, health.Healthy)
+		require.False(t, health.Reachable)
+	}
+
+	pool.recordTip("a:1", 800000)
+	pool.recordTip("b:1", 800001)
+	pool.recordTip("c:1", 799990)
+	pool.recordTip("d:1", 800100)
+	require.Equal(t, TipStatusOK, healthOf(t, pool, "a:1").TipStatus)
+	require.Equal(t, TipStatusOK, healthOf(t, pool, "b:1").TipStatus)
+	require.Equal(t, TipStatusBehind, healthOf(t, pool, "c:1").TipStatus)
+	require.False(t, healthOf(t, pool, "c:1").Healthy)
+	require.Equal(t, TipStatusAhead, healthOf(t, pool, "d:1").TipStatus)
+	// The proof of work of the tips is checked, so a server ahead is not penalized.
+	require.True(t, healthOf(t, pool, "d:1").Healthy)
+
+	// With an even number of servers, the lower median is the reference, as it is reached by a
+	// strict majority. The up-to-date server stays healthy and is not failed over from.
+	pool = newTestServerPool("a:1", "b:1")
+	pool.recordTip("a:1", 800000)
+	pool.recordTip("b:1", 800010)
+	pool.setConnected("b:1")
+	require.Equal(t, TipStatusOK, healthOf(t, pool, "a:1").TipStatus)
+	require.Equal(t, TipStatusAhead, healthOf(t, pool, "b:1").TipStatus)
+	require.True(t, healthOf(t, pool, "b:1").Healthy)
+	require.True(t, pool.shouldConnect("b:1"))
+	require.Equal(t, "", pool.connectedUnhealthy())
+
+	// The tips of a majority of the configured servers must be known.
+	pool = newTestServerPool("a:1", "b:1", "c:1")
+	pool.recordTip("a:1", 800000)
+	require.Equal(t, TipStatusUnknown, healthOf(t, pool, "a:1").TipStatus)
+	pool.recordTip("b:1", 800010)
+	require.Equal(t, TipStatusOK, healthOf(t, pool, "a:1").TipStatus)
+	require.Equal(t, TipStatusAhead, healthOf(t, pool, "b:1").TipStatus)
+	require.True(t, healthOf(t, pool, "b:1").Healthy)
+}
+
+func TestServerPoolRequests(t *testing.T) {
+	pool := newTestServerPool("a:1")
+	pool.recordRequest("a:1", 100*time.Millisecond, nil)
+	require.Equal(t, int64(100), healthOf(t, pool, "a:1").LatencyMs)
+	pool.recordRequest("a:1", 200*time.Millisecond, nil)
+	require.Equal(t, int64(120), healthOf(t, pool, "a:1").LatencyMs)
+
+	// Requests made after the client was closed are not the fault of the server.
+	pool.recordRequest("a:1", time.Second, failover.ErrClosed)
+	require.Equal(t, 2, healthOf(t, pool, "a:1").Requests)
+
+	// Errors returned by the server are answers, not failures of the server.
+	for i := 0; i < 8; i++ {
+		pool.recordRequest("a:1", 100*time.Millisecond, errors.New("transaction already in block chain"))
+	}
+	health := healthOf(t, pool, "a:1")
+	require.Equal(t, 10, health.Requests)
+	require.Equal(t, 0, health.Errors)
+	require.Equal(t, "", health.LastError)
+	require.True(t, health.Healthy)
+
+	for i := 0; i < 12; i++ {
+		pool.recordRequest("a:1", time.Second, context.DeadlineExceeded)
+	}
+	health = healthOf(t, pool, "a:1")
+	require.Equal(t, 22, health.Requests)
+	require.Equal(t, 12, health.Errors)
+	require.Equal(t, context.DeadlineExceeded.Error(), health.LastError)
+	require.False(t, health.Healthy)
+
+	pool.recordConnect("a:1", time.Second, errors.New("connection refused"))
+	health = healthOf(t, pool, "a:1")
+	require.False(t, health.Reachable)
+	require.Equal(t, "connection refused", health.LastError)
+}
+
+func TestServerPoolPreferHealthy(t *testing.T) {
+	pool := newTestServerPool("a:1", "b:1", "c:1")
+	pool.recordTip("a:1", 800000)
+	pool.recordTip("b:1", 800000)
+	pool.recordTip("c:1", 799000)
+	pool.setConnected("c:1")
+	require.True(t, pool.shouldConnect("a:1"))
+	require.False(t, pool.shouldConnect("c:1"))
+	require.Equal(t, "c:1", pool.connectedUnhealthy())
+	require.True(t, healthOf(t, pool, "c:1").Connected)
+
+	pool.setDisconnected("c:1")
+	require.Equal(t, "", pool.connectedUnhealthy())
+	pool.setConnected("a:1")
+	require.Equal(t, "", pool.connectedUnhealthy())
+
+	// If no other server is healthy, connecting to an unhealthy server is better than being offline.
+	pool.recordConnect("a:1", 0, errors.New("connection refused"))
+	pool.recordConnect("b:1", 0, errors.New("connection refused"))
+	require.True(t, pool.shouldConnect("c:1"))
+}
+
+func TestServerPoolAddDiscovered(t *testing.T) {
+	pool := newTestServerPool("a:1")
+	peers := func(host string) []*config.ServerInfo {
+		serverInfos := []*config.ServerInfo{}
+		for i := 0; i < 5; i++ {
+			serverInfos = append(serverInfos,
+				&config.ServerInfo{Server: fmt.Sprintf("%s%d:50002", host, i), TLS: true})
+		}
+		return serverInfos
+	}
+	require.Equal(t, maxDiscoveredPerServer, pool.addDiscovered(peers("x")))
+	// Known servers are skipped.
+	require.Equal(t, 2, pool.addDiscovered(peers("x")))
+	require.Equal(t, 3, pool.addDiscovered(peers("y")))
+	require.Equal(t, 2, pool.addDiscovered(peers("z")))
+	require.Equal(t, 0, pool.addDiscovered(peers("w")))
+	health := pool.Health()
+	require.Len(t, health, 1+maxDiscoveredServers)
+	require.False(t, health[0].Discovered)
+	require.True(t, health[1].Discovered)
+
+	// Discovered servers don't count towards the majority and are never connected to.
+	pool.recordTip("a:1", 799000)
+	for _, server := range []string{"x0:50002", "x1:50002", "y0:50002"} {
+		pool.recordTip(server, 800000)
+	}
+	pool.setConnected("a:1")
+	require.Equal(t, TipStatusOK, healthOf(t, pool, "a:1").TipStatus)
+	require.Equal(t, TipStatusAhead, healthOf(t, pool, "x0:50002").TipStatus)
+	require.Equal(t, "", pool.connectedUnhealthy())
+	require.True(t, pool.shouldConnect("a:1"))
+}
+
+func TestServerPoolSetPEMCert(t *testing.T) {
+	pool := newTestServerPool("a:1")
+	serverInfo := pool.servers[0].info
+	pool.setPEMCert("a:1", "cert")
+	require.Equal(t, "cert", pool.servers[0].info.PEMCert)
+	// The server info handed out before is not modified.
+	require.Equal(t, "", serverInfo.PEMCert)
+}
+
+func TestTransportError(t *testing.T) {
+	require.True(t, isTransportError(context.DeadlineExceeded))
+	require.True(t, isTransportError(fmt.Errorf("failed to read from socket: %w", io.EOF)))
+	require.True(t, isTransportError(&net.OpError{Op: "write", Err: errors.New("broken pipe")}))
+	require.False(t, isTransportError(nil))
+	require.False(t, isTransportError(errors.New("unknown method")))
+}
+
+func serializeHeader(t *testing.T, header wire.BlockHeader) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	require.NoError(t, header.Serialize(buf))
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func TestCheckProofOfWork(t *testing.T) {
+	genesis := chaincfg.MainNetParams.GenesisBlock.Header
+	require.NoError(t, checkProofOfWork(serializeHeader(t, genesis), &chaincfg.MainNetParams))
+
+	ltcGenesis := ltc.MainNetParams.GenesisBlock.Header
+	require.NoError(t, checkProofOfWork(serializeHeader(t, ltcGenesis), &ltc.MainNetParams))
+	// Litecoin uses scrypt, which the Bitcoin genesis block was not mined with.
+	require.Error(t, checkProofOfWork(serializeHeader(t, genesis), &ltc.MainNetParams))
+
+	invalidNonce := genesis
+	invalidNonce.Nonce++
+	require.Error(t, checkProofOfWork(serializeHeader(t, invalidNonce), &chaincfg.MainNetParams))
+
+	// A target easier than the proof of work limit is never valid.
+	easyTarget := genesis
+	easyTarget.Bits = chaincfg.RegressionNetParams.GenesisBlock.Header.Bits
+	require.ErrorContains(t,
+		checkProofOfWork(serializeHeader(t, easyTarget), &chaincfg.MainNetParams), "invalid target")
+
+	require.Error(t, checkProofOfWork("", &chaincfg.MainNetParams))
+	require.Error(t, checkProofOfWork("zz", &chaincfg.MainNetParams))
+}
+
+func TestParsePeers(t *testing.T) {
+	peers := parsePeers([]byte(`[
+		["107.150.45.210", "e.anonyhost.org", ["v1.4", "p10000", "t50001", "s995"]],
+		["1.2.3.4", "default.example.org", ["v1.4", "s"]],
+		["1.2.3.5", "tcp.example.org", ["v1.4", "t"]],
+		["", "abcdef.onion", ["v1.4", "s50002"]],
+		["1.2.3.6", "invalid.example.org", ["v1.4", "s99999"]],
+		["1.2.3.7", "short.example.org"]
+	]`))
+	require.Equal(t, []*config.ServerInfo{
+		{Server: "e.anonyhost.org:995", TLS: true},
+		{Server: "default.example.org:50002", TLS: true},
+	}, peers)
+	require.Nil(t, parsePeers([]byte(`{}`)))
+}
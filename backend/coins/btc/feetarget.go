@@ -5,6 +5,7 @@ package btc
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/btcsuite/btcd/btcutil"
@@ -20,6 +21,10 @@ type FeeTarget struct {
 
 	// FeeRatePerKb is the fee rate needed for this target. Can be nil until populated.
 	feeRatePerKb *btcutil.Amount
+
+	// expectedConfirmationTime is the expected time until a transaction paying feeRatePerKb
+	// confirms. Zero if unknown.
+	expectedConfirmationTime time.Duration
 }
 
 // Code returns the btc fee target.
@@ -27,6 +32,12 @@ func (feeTarget *FeeTarget) Code() accounts.FeeTargetCode {
 	return feeTarget.code
 }
 
+// ExpectedConfirmationTime returns the expected time until a transaction paying the fee rate of
+// this target confirms, or zero if unknown.
+func (feeTarget *FeeTarget) ExpectedConfirmationTime() time.Duration {
+	return feeTarget.expectedConfirmationTime
+}
+
 // FormattedFeeRate returns a string showing the fee rate.
 func (feeTarget *FeeTarget) FormattedFeeRate() string {
 	if feeTarget.feeRatePerKb == nil {
@@ -3,8 +3,10 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"net/http"
 	"os"
@@ -15,6 +17,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
@@ -54,10 +57,17 @@ func NewHandlers(
 	handleFunc("/export", handlers.ensureAccountInitialized(handlers.postExportTransactions)).Methods("POST")
 	handleFunc("/info", handlers.ensureAccountInitialized(handlers.getAccountInfo)).Methods("GET")
 	handleFunc("/utxos", handlers.ensureAccountInitialized(handlers.getUTXOs)).Methods("GET")
+	handleFunc("/spv-verification", handlers.ensureAccountInitialized(handlers.getSPVVerification)).Methods("GET")
 	handleFunc("/balance", handlers.ensureAccountInitialized(handlers.getAccountBalance)).Methods("GET")
 	handleFunc("/sendtx", handlers.ensureAccountInitialized(handlers.postAccountSendTx)).Methods("POST")
 	handleFunc("/fee-targets", handlers.ensureAccountInitialized(handlers.getAccountFeeTargets)).Methods("GET")
 	handleFunc("/tx-proposal", handlers.ensureAccountInitialized(handlers.postAccountTxProposal)).Methods("POST")
+	handleFunc("/bump-fee", handlers.ensureAccountInitialized(handlers.postBumpFee)).Methods("POST")
+	handleFunc("/cancel-tx", handlers.ensureAccountInitialized(handlers.postCancelTx)).Methods("POST")
+	handleFunc("/cpfp-tx-proposal", handlers.ensureAccountInitialized(handlers.postCPFPTxProposal)).Methods("POST")
+	handleFunc("/tx-proposal/psbt", handlers.ensureAccountInitialized(handlers.getTxProposalPSBT)).Methods("GET")
+	handleFunc("/tx-proposal/psbt/export", handlers.ensureAccountInitialized(handlers.postExportTxProposalPSBT)).Methods("POST")
+	handleFunc("/psbt-import", handlers.ensureAccountInitialized(handlers.postImportPSBT)).Methods("POST")
 	handleFunc("/receive-addresses", handlers.ensureAccountInitialized(handlers.getReceiveAddresses)).Methods("GET")
 	handleFunc("/used-addresses", handlers.ensureAccountInitialized(handlers.getUsedAddresses)).Methods("GET")
 	handleFunc("/verify-address", handlers.ensureAccountInitialized(handlers.postVerifyAddress)).Methods("POST")
@@ -69,6 +79,10 @@ func NewHandlers(
 	handleFunc("/has-payment-request", handlers.ensureAccountInitialized(handlers.getHasPaymentRequest)).Methods("GET")
 	handleFunc("/has-swap-payment-request", handlers.ensureAccountInitialized(handlers.getHasSwapPaymentRequest)).Methods("GET")
 	handleFunc("/notes/tx", handlers.ensureAccountInitialized(handlers.postSetTxNote)).Methods("POST")
+	handleFunc("/notes/output", handlers.ensureAccountInitialized(handlers.postSetOutputLabel)).Methods("POST")
+	handleFunc("/notes/address", handlers.ensureAccountInitialized(handlers.postSetAddressLabel)).Methods("POST")
+	handleFunc("/notes/input", handlers.ensureAccountInitialized(handlers.postSetInputLabel)).Methods("POST")
+	handleFunc("/utxos/freeze", handlers.ensureAccountInitialized(handlers.postFreezeUTXO)).Methods("POST")
 	handleFunc("/eth-sign-msg", handlers.ensureAccountInitialized(handlers.postEthSignMsg)).Methods("POST")
 	handleFunc("/eth-sign-typed-msg", handlers.ensureAccountInitialized(handlers.postEthSignTypedMsg)).Methods("POST")
 	handleFunc("/eth-sign-wallet-connect-tx", handlers.ensureAccountInitialized(handlers.postEthSignWalletConnectTx)).Methods("POST")
@@ -107,10 +121,16 @@ type Transaction struct {
 	Size         int64                               `json:"size"`
 	Weight       int64                               `json:"weight"`
 	FeeRatePerKb coin.FormattedAmountWithConversions `json:"feeRatePerKb"`
+	// Verification is the SPV verification state, see accounts.TxVerification.
+	Verification   accounts.TxVerification `json:"verification,omitempty"`
+	VerifiedHeight int                     `json:"verifiedHeight,omitempty"`
 
 	// ETH specific fields
 	Gas   uint64  `json:"gas"`
 	Nonce *uint64 `json:"nonce"`
+	// ENSNames are the ENS names of the addresses, in the same order, or empty strings for
+	// addresses without a name. Only set if at least one address has a name.
+	ENSNames []string `json:"ensNames,omitempty"`
 }
 
 func (handlers *Handlers) ensureAccountInitialized(h func(*http.Request) (interface{}, error)) func(*http.Request) (interface{}, error) {
@@ -143,8 +163,14 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 	}
 
 	addresses := []string{}
+	ensNames := []string{}
+	hasENSNames := false
 	for _, addressAndAmount := range txInfo.Addresses {
 		addresses = append(addresses, addressAndAmount.Address)
+		ensNames = append(ensNames, addressAndAmount.ENSName)
+		if addressAndAmount.ENSName != "" {
+			hasENSNames = true
+		}
 	}
 	txInfoJSON := Transaction{
 		TxID:                     txInfo.TxID,
@@ -165,6 +191,13 @@ func (handlers *Handlers) getTxInfoJSON(txInfo *accounts.TransactionData, detail
 		Note:                 handlers.account.TxNote(txInfo.InternalID),
 		Fee:                  feeString,
 	}
+	if hasENSNames {
+		txInfoJSON.ENSNames = ensNames
+	}
+	if _, ok := handlers.account.Coin().(*btc.Coin); ok {
+		txInfoJSON.Verification = txInfo.Verification
+		txInfoJSON.VerifiedHeight = txInfo.VerifiedHeight
+	}
 
 	if detail {
 		switch handlers.account.Coin().(type) {
@@ -272,9 +305,17 @@ func (handlers *Handlers) getAccountInfo(*http.Request) (interface{}, error) {
 		ScriptType signing.ScriptType `json:"scriptType"`
 		Descriptor string             `json:"descriptor"`
 	}
+	type bitcoinMultisigInfo struct {
+		Threshold        uint32             `json:"threshold"`
+		Cosigners        []signing.KeyInfo  `json:"cosigners"`
+		OurCosignerIndex int                `json:"ourCosignerIndex"`
+		ScriptType       signing.ScriptType `json:"scriptType"`
+		Descriptor       string             `json:"descriptor"`
+	}
 	type signingConfigurationInfo struct {
-		BitcoinSimple  *bitcoinSimpleInfo      `json:"bitcoinSimple,omitempty"`
-		EthereumSimple *signing.EthereumSimple `json:"ethereumSimple,omitempty"`
+		BitcoinSimple   *bitcoinSimpleInfo      `json:"bitcoinSimple,omitempty"`
+		BitcoinMultisig *bitcoinMultisigInfo    `json:"bitcoinMultisig,omitempty"`
+		EthereumSimple  *signing.EthereumSimple `json:"ethereumSimple,omitempty"`
 	}
 	type accountInfo struct {
 		SigningConfigurations []signingConfigurationInfo `json:"signingConfigurations"`
@@ -309,6 +350,22 @@ func (handlers *Handlers) getAccountInfo(*http.Request) (interface{}, error) {
 				Descriptor: descriptor,
 			}
 		}
+		if cfg.BitcoinMultisig != nil {
+			if btcNet == nil {
+				return nil, errp.New("bitcoin network unavailable for bitcoin signing config")
+			}
+			descriptor, err := cfg.BitcoinMultisig.Descriptor(btcNet)
+			if err != nil {
+				return nil, err
+			}
+			signingConfig.BitcoinMultisig = &bitcoinMultisigInfo{
+				Threshold:        cfg.BitcoinMultisig.Threshold,
+				Cosigners:        cfg.BitcoinMultisig.Cosigners,
+				OurCosignerIndex: cfg.BitcoinMultisig.OurCosignerIndex,
+				ScriptType:       cfg.BitcoinMultisig.ScriptType,
+				Descriptor:       descriptor,
+			}
+		}
 		if cfg.EthereumSimple != nil {
 			signingConfig.EthereumSimple = cfg.EthereumSimple
 		}
@@ -317,6 +374,30 @@ func (handlers *Handlers) getAccountInfo(*http.Request) (interface{}, error) {
 	return result, nil
 }
 
+func (handlers *Handlers) getSPVVerification(*http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	status, err := btcAccount.SPVVerificationStatus()
+	if err != nil {
+		return nil, err
+	}
+	failedTxIDs := make([]string, len(status.Failed))
+	for i, txHash := range status.Failed {
+		failedTxIDs[i] = txHash.String()
+	}
+	return struct {
+		Verified    int      `json:"verified"`
+		Unverified  int      `json:"unverified"`
+		FailedTxIDs []string `json:"failedTxIDs"`
+	}{
+		Verified:    status.Verified,
+		Unverified:  status.Unverified,
+		FailedTxIDs: failedTxIDs,
+	}, nil
+}
+
 func (handlers *Handlers) getUTXOs(*http.Request) (interface{}, error) {
 	accountConfig := handlers.account.Config()
 	type utxoResponse struct {
@@ -327,6 +408,8 @@ func (handlers *Handlers) getUTXOs(*http.Request) (interface{}, error) {
 		Address         string                              `json:"address"`
 		ScriptType      signing.ScriptType                  `json:"scriptType"`
 		Note            string                              `json:"note"`
+		Label           string                              `json:"label"`
+		Frozen          bool                                `json:"frozen"`
 		AddressReused   bool                                `json:"addressReused"`
 		IsChange        bool                                `json:"isChange"`
 		HeaderTimestamp *string                             `json:"headerTimestamp"`
@@ -366,6 +449,8 @@ func (handlers *Handlers) getUTXOs(*http.Request) (interface{}, error) {
 				Address:         address,
 				ScriptType:      output.Address.AccountConfiguration.ScriptType(),
 				Note:            handlers.account.TxNote(output.OutPoint.Hash.String()),
+				Label:           output.Label,
+				Frozen:          output.Frozen,
 				AddressReused:   addressReused,
 				IsChange:        output.IsChange,
 				HeaderTimestamp: formattedTime,
@@ -420,11 +505,42 @@ func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
 		Counter        int                    `json:"counter"`
 		PaymentRequest *paymentrequest.Slip24 `json:"paymentRequest"`
 		UseHighestFee  bool                   `json:"useHighestFee"`
+		CoinSelection  string                 `json:"coinSelection"`
+		// Recipients of a batch payout. If set, address, amount and sendAll are ignored.
+		Recipients []struct {
+			Address string `json:"address"`
+			Amount  string `json:"amount"`
+			SendAll bool   `json:"sendAll"`
+		} `json:"recipients"`
+		// RecipientsCSV are the recipients of a batch payout as CSV, see
+		// accounts.ParseRecipientsCSV. Can be used instead of recipients.
+		RecipientsCSV string `json:"recipientsCSV"`
+		// PayjoinURL is the BIP-78 payjoin endpoint of the recipient.
+		PayjoinURL string `json:"payjoinURL"`
 	}{}
 	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
 		return errp.WithStack(err)
 	}
 	input.RecipientAddress = jsonBody.Address
+	for _, recipient := range jsonBody.Recipients {
+		txRecipient := accounts.TxRecipient{Address: recipient.Address}
+		if recipient.SendAll {
+			txRecipient.Amount = coin.NewSendAmountAll()
+		} else {
+			txRecipient.Amount = coin.NewSendAmount(recipient.Amount)
+		}
+		input.Recipients = append(input.Recipients, txRecipient)
+	}
+	if jsonBody.RecipientsCSV != "" {
+		if len(input.Recipients) != 0 {
+			return errp.New("recipients and recipientsCSV cannot both be set")
+		}
+		recipients, err := accounts.ParseRecipientsCSV(strings.NewReader(jsonBody.RecipientsCSV))
+		if err != nil {
+			return err
+		}
+		input.Recipients = recipients
+	}
 	var err error
 	input.FeeTargetCode, err = accounts.NewFeeTargetCode(jsonBody.FeeTarget)
 	if err != nil {
@@ -455,6 +571,11 @@ func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
 		input.PaymentRequest = paymentRequest
 	}
 	input.UseHighestFee = jsonBody.UseHighestFee
+	input.PayjoinURL = jsonBody.PayjoinURL
+	input.CoinSelection, err = maketx.NewCoinSelectionStrategy(jsonBody.CoinSelection)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -465,6 +586,9 @@ func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error
 		ErrorMessage string `json:"errorMessage,omitempty"`
 		ErrorCode    string `json:"errorCode,omitempty"`
 		TxID         string `json:"txId,omitempty"`
+		// PSBT is the base64 encoded PSBT if the transaction could not be fully signed, so it can be
+		// signed externally.
+		PSBT string `json:"psbt,omitempty"`
 	}
 
 	var txNote string
@@ -478,6 +602,20 @@ func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error
 	if errp.Cause(err) == keystore.ErrSigningAborted || errp.Cause(err) == errp.ErrUserAbort {
 		return response{Success: false, Aborted: true}, nil
 	}
+	if errp.Cause(err) == errors.ErrMissingCosignerSignatures || errp.Cause(err) == errors.ErrNoKeystore {
+		// The partially signed PSBT of a multisig account, or the unsigned PSBT of a watch-only
+		// account, is returned so it can be signed externally.
+		result := response{Success: false, ErrorCode: errp.Cause(err).Error()}
+		if btcAccount, ok := handlers.account.(*btc.Account); ok {
+			psbtBytes, err := btcAccount.TxProposalPSBT()
+			if err != nil {
+				handlers.log.WithError(err).Error("Failed to export PSBT")
+				return response{Success: false, ErrorMessage: err.Error()}, nil
+			}
+			result.PSBT = base64.StdEncoding.EncodeToString(psbtBytes)
+		}
+		return result, nil
+	}
 	if err != nil {
 		handlers.log.WithError(err).Error("Failed to send transaction")
 		result := response{Success: false, ErrorMessage: err.Error()}
@@ -496,6 +634,20 @@ type txProposalResponse struct {
 	Fee                     *coin.FormattedAmountWithConversions `json:"fee,omitempty"`
 	Total                   *coin.FormattedAmountWithConversions `json:"total,omitempty"`
 	RecipientDisplayAddress string                               `json:"recipientDisplayAddress,omitempty"`
+	// RecipientENSName is the ENS name entered by the user, in which case RecipientDisplayAddress is
+	// the address it resolved to. Only set for ETH.
+	RecipientENSName string `json:"recipientENSName,omitempty"`
+	// Recipients are the recipients of a batch payout with the amount each of them receives.
+	Recipients []txProposalRecipient `json:"recipients,omitempty"`
+	// CoinSelection is the strategy that selected the inputs. Only set for BTC/LTC.
+	CoinSelection maketx.CoinSelectionStrategy `json:"coinSelection,omitempty"`
+}
+
+type txProposalRecipient struct {
+	DisplayAddress string                              `json:"displayAddress"`
+	Amount         coin.FormattedAmountWithConversions `json:"amount"`
+	// ENSName is the ENS name entered by the user, see txProposalResponse.RecipientENSName.
+	ENSName string `json:"ensName,omitempty"`
 }
 
 func txProposalError(err error) (interface{}, error) {
@@ -518,12 +670,248 @@ func (handlers *Handlers) postAccountTxProposal(r *http.Request) (interface{}, e
 	amountResponse := outputAmount.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
 	feeResponse := fee.FormatWithConversions(handlers.account.Coin(), true, accountConfig.RateUpdater)
 	totalResponse := total.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
-	return txProposalResponse{
+	response := txProposalResponse{
 		Success:                 true,
 		Amount:                  &amountResponse,
 		Fee:                     &feeResponse,
 		Total:                   &totalResponse,
 		RecipientDisplayAddress: formatAddressForDisplay(handlers.account, input.RecipientAddress),
+	}
+	if len(input.Recipients) != 0 {
+		response.RecipientDisplayAddress = ""
+		// Implemented by BTC/LTC and ETH accounts.
+		if account, ok := handlers.account.(interface {
+			TxProposalRecipientAmounts() []coin.Amount
+		}); ok {
+			amounts := account.TxProposalRecipientAmounts()
+			for i, recipient := range input.Recipients {
+				if i >= len(amounts) {
+					break
+				}
+				response.Recipients = append(response.Recipients, txProposalRecipient{
+					DisplayAddress: formatAddressForDisplay(handlers.account, recipient.Address),
+					Amount: amounts[i].FormatWithConversions(
+						handlers.account.Coin(), false, accountConfig.RateUpdater),
+				})
+			}
+		}
+	}
+	if btcAccount, ok := handlers.account.(*btc.Account); ok {
+		response.CoinSelection = btcAccount.TxProposalCoinSelection()
+	}
+	if ethAccount, ok := handlers.account.(*eth.Account); ok {
+		// Show the addresses ENS names resolved to, so they can be compared to the address shown
+		// on the device.
+		recipients := ethAccount.TxProposalRecipients()
+		if len(input.Recipients) == 0 && len(recipients) == 1 && recipients[0].ENSName != "" {
+			response.RecipientDisplayAddress = formatAddressForDisplay(handlers.account, recipients[0].Address)
+			response.RecipientENSName = recipients[0].ENSName
+		}
+		for i := range response.Recipients {
+			if i < len(recipients) && recipients[i].ENSName != "" {
+				response.Recipients[i].DisplayAddress = formatAddressForDisplay(
+					handlers.account, recipients[i].Address)
+				response.Recipients[i].ENSName = recipients[i].ENSName
+			}
+		}
+	}
+	return response, nil
+}
+
+type replaceTxInput struct {
+	TxID string
+	accounts.TxProposalArgs
+}
+
+func (input *replaceTxInput) UnmarshalJSON(jsonBytes []byte) error {
+	jsonBody := struct {
+		TxID      string `json:"txID"`
+		FeeTarget string `json:"feeTarget"`
+		// Provided in Sat/vByte for BTC/LTC and in Gwei for ETH.
+		CustomFee     string `json:"customFee"`
+		UseHighestFee bool   `json:"useHighestFee"`
+	}{}
+	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
+		return errp.WithStack(err)
+	}
+	input.TxID = jsonBody.TxID
+	var err error
+	input.FeeTargetCode, err = accounts.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to retrieve fee target code")
+	}
+	if input.FeeTargetCode == accounts.FeeTargetCodeCustom {
+		input.CustomFee = jsonBody.CustomFee
+	}
+	input.UseHighestFee = jsonBody.UseHighestFee
+	return nil
+}
+
+// txReplacer is implemented by accounts which can replace their pending outgoing transactions (BTC
+// based accounts using replace-by-fee, ETH based accounts by reusing the nonce).
+type txReplacer interface {
+	BumpFeeProposal(txID string, args *accounts.TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error)
+	CancelTxProposal(txID string, args *accounts.TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error)
+}
+
+// postReplaceTx creates a proposal replacing a pending outgoing transaction (speed up or cancel).
+// The proposal is signed and broadcast using /sendtx.
+func (handlers *Handlers) postReplaceTx(
+	r *http.Request,
+	propose func(string, *accounts.TxProposalArgs) (coin.Amount, coin.Amount, coin.Amount, error),
+) (interface{}, error) {
+	accountConfig := handlers.account.Config()
+	var input replaceTxInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
+	}
+	outputAmount, fee, total, err := propose(input.TxID, &input.TxProposalArgs)
+	if err != nil {
+		return txProposalError(err)
+	}
+	amountResponse := outputAmount.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
+	feeResponse := fee.FormatWithConversions(handlers.account.Coin(), true, accountConfig.RateUpdater)
+	totalResponse := total.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
+	return txProposalResponse{
+		Success: true,
+		Amount:  &amountResponse,
+		Fee:     &feeResponse,
+		Total:   &totalResponse,
+	}, nil
+}
+
+// postBumpFee creates a proposal replacing a pending outgoing transaction with one paying a higher
+// fee. The proposal is signed and broadcast using /sendtx.
+func (handlers *Handlers) postBumpFee(r *http.Request) (interface{}, error) {
+	replacer, ok := handlers.account.(txReplacer)
+	if !ok {
+		return nil, errp.New("Account does not support replacing transactions")
+	}
+	return handlers.postReplaceTx(r, replacer.BumpFeeProposal)
+}
+
+// postCancelTx creates a proposal replacing a pending outgoing transaction with one sending the
+// funds back to the account. The proposal is signed and broadcast using /sendtx.
+func (handlers *Handlers) postCancelTx(r *http.Request) (interface{}, error) {
+	replacer, ok := handlers.account.(txReplacer)
+	if !ok {
+		return nil, errp.New("Account does not support replacing transactions")
+	}
+	return handlers.postReplaceTx(r, replacer.CancelTxProposal)
+}
+
+// getTxProposalPSBT returns the unsigned PSBT of the active tx proposal in base64.
+func (handlers *Handlers) getTxProposalPSBT(*http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
+		PSBT         string `json:"psbt,omitempty"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Must be a BTC based account"}, nil
+	}
+	serializedPSBT, err := btcAccount.TxProposalPSBT()
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting the PSBT")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true, PSBT: base64.StdEncoding.EncodeToString(serializedPSBT)}, nil
+}
+
+// postExportTxProposalPSBT writes the unsigned PSBT of the active tx proposal to a binary .psbt
+// file in the exports folder.
+func (handlers *Handlers) postExportTxProposalPSBT(*http.Request) (interface{}, error) {
+	type result struct {
+		Success      bool   `json:"success"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return result{Success: false, ErrorMessage: "Must be a BTC based account"}, nil
+	}
+	serializedPSBT, err := btcAccount.TxProposalPSBT()
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting the PSBT")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	name := fmt.Sprintf("%s-%s.psbt", time.Now().Format("2006-01-02-at-15-04-05"), handlers.account.Config().Config.Code)
+	exportsDir, err := config.ExportsDir()
+	if err != nil {
+		handlers.log.WithError(err).Error("error exporting the PSBT")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := handlers.account.Config().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return nil, nil
+	}
+	handlers.log.Infof("Export PSBT to %s.", path)
+	if err := os.WriteFile(path, serializedPSBT, 0600); err != nil {
+		handlers.log.WithError(err).Error("error writing file")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	if err := handlers.account.Config().UnsafeSystemOpen(filepath.Dir(path)); err != nil {
+		handlers.log.WithError(err).Error("error opening the exports folder")
+		return result{Success: false, ErrorMessage: err.Error()}, nil
+	}
+	return result{Success: true}, nil
+}
+
+// postImportPSBT creates a tx proposal from a base64 encoded PSBT. The proposal is finalized,
+// signed if needed, and broadcast using /sendtx.
+func (handlers *Handlers) postImportPSBT(r *http.Request) (interface{}, error) {
+	accountConfig := handlers.account.Config()
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	var input struct {
+		PSBT string `json:"psbt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
+	}
+	outputAmount, fee, total, err := btcAccount.ImportPSBT([]byte(input.PSBT))
+	if err != nil {
+		return txProposalError(err)
+	}
+	amountResponse := outputAmount.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
+	feeResponse := fee.FormatWithConversions(handlers.account.Coin(), true, accountConfig.RateUpdater)
+	totalResponse := total.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
+	return txProposalResponse{
+		Success: true,
+		Amount:  &amountResponse,
+		Fee:     &feeResponse,
+		Total:   &totalResponse,
+	}, nil
+}
+
+// postCPFPTxProposal creates a child-pays-for-parent proposal for a pending transaction. The
+// proposal is signed and broadcast using /sendtx.
+func (handlers *Handlers) postCPFPTxProposal(r *http.Request) (interface{}, error) {
+	accountConfig := handlers.account.Config()
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	// The parent transaction and the fee are passed like when replacing a transaction.
+	var input replaceTxInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return txProposalError(errp.WithStack(err))
+	}
+	outputAmount, fee, total, err := btcAccount.CPFPTxProposal(input.TxID, &input.TxProposalArgs)
+	if err != nil {
+		return txProposalError(err)
+	}
+	amountResponse := outputAmount.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
+	feeResponse := fee.FormatWithConversions(handlers.account.Coin(), true, accountConfig.RateUpdater)
+	totalResponse := total.FormatWithConversions(handlers.account.Coin(), false, accountConfig.RateUpdater)
+	return txProposalResponse{
+		Success: true,
+		Amount:  &amountResponse,
+		Fee:     &feeResponse,
+		Total:   &totalResponse,
 	}, nil
 }
 
@@ -531,6 +919,8 @@ func (handlers *Handlers) getAccountFeeTargets(*http.Request) (interface{}, erro
 	type jsonFeeTarget struct {
 		Code        accounts.FeeTargetCode `json:"code"`
 		FeeRateInfo string                 `json:"feeRateInfo"`
+		// ExpectedConfirmationMinutes is the expected time until confirmation, 0 if unknown.
+		ExpectedConfirmationMinutes int `json:"expectedConfirmationMinutes,omitempty"`
 	}
 	type response struct {
 		FeeTargets       []jsonFeeTarget        `json:"feeTargets"`
@@ -540,10 +930,14 @@ func (handlers *Handlers) getAccountFeeTargets(*http.Request) (interface{}, erro
 	feeTargets, defaultFeeTarget := handlers.account.FeeTargets()
 	result := []jsonFeeTarget{}
 	for _, feeTarget := range feeTargets {
-		result = append(result, jsonFeeTarget{
+		jsonTarget := jsonFeeTarget{
 			Code:        feeTarget.Code(),
 			FeeRateInfo: feeTarget.FormattedFeeRate(),
-		})
+		}
+		if btcFeeTarget, ok := feeTarget.(*btc.FeeTarget); ok {
+			jsonTarget.ExpectedConfirmationMinutes = int(math.Ceil(btcFeeTarget.ExpectedConfirmationTime().Minutes()))
+		}
+		result = append(result, jsonTarget)
 	}
 	return response{
 		FeeTargets:       result,
@@ -593,6 +987,7 @@ func (handlers *Handlers) getReceiveAddresses(*http.Request) (interface{}, error
 		Address        string `json:"address"`
 		DisplayAddress string `json:"displayAddress"`
 		AddressID      string `json:"addressID"`
+		Label          string `json:"label"`
 	}
 	type jsonAddressList struct {
 		ScriptType *signing.ScriptType `json:"scriptType"`
@@ -610,6 +1005,7 @@ func (handlers *Handlers) getReceiveAddresses(*http.Request) (interface{}, error
 				Address:        address.EncodeForHumans(),
 				DisplayAddress: formatAddressForDisplay(handlers.account, address.EncodeForHumans()),
 				AddressID:      address.ID(),
+				Label:          handlers.account.Notes().AddressLabel(address.EncodeForHumans()),
 			})
 		}
 		addressList = append(addressList, jsonAddressList{
@@ -633,6 +1029,7 @@ func (handlers *Handlers) getUsedAddresses(*http.Request) (interface{}, error) {
 		AddressType    btc.UsedAddressType `json:"addressType"`
 		CanSignMsg     bool                `json:"canSignMsg"`
 		LastUsed       *string             `json:"lastUsed"`
+		Label          string              `json:"label"`
 	}
 	type response struct {
 		Success   bool              `json:"success"`
@@ -673,6 +1070,7 @@ func (handlers *Handlers) getUsedAddresses(*http.Request) (interface{}, error) {
 			AddressType:    addr.AddressType,
 			CanSignMsg:     addr.CanSignMsg,
 			LastUsed:       lastUsed,
+			Label:          addr.Label,
 		}
 	}
 
@@ -750,6 +1148,82 @@ func (handlers *Handlers) postSetTxNote(r *http.Request) (interface{}, error) {
 	return nil, handlers.account.SetTxNote(args.InternalTxID, args.Note)
 }
 
+func (handlers *Handlers) postSetOutputLabel(r *http.Request) (interface{}, error) {
+	var args struct {
+		OutPoint string `json:"outPoint"`
+		Label    string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	outPoint, err := wire.NewOutPointFromString(args.OutPoint)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, btcAccount.SetOutputLabel(outPoint.String(), args.Label)
+}
+
+func (handlers *Handlers) postSetAddressLabel(r *http.Request) (interface{}, error) {
+	var args struct {
+		Address string `json:"address"`
+		Label   string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	if !btcAccount.HasAddress(args.Address) {
+		return nil, errp.Newf("Address %s does not belong to the account", args.Address)
+	}
+	return nil, btcAccount.SetAddressLabel(args.Address, args.Label)
+}
+
+func (handlers *Handlers) postSetInputLabel(r *http.Request) (interface{}, error) {
+	var args struct {
+		Input string `json:"input"`
+		Label string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	// Inputs are identified by `<txID>:<input index>`, which has the same format as an outpoint.
+	input, err := wire.NewOutPointFromString(args.Input)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, btcAccount.SetInputLabel(input.String(), args.Label)
+}
+
+func (handlers *Handlers) postFreezeUTXO(r *http.Request) (interface{}, error) {
+	var args struct {
+		OutPoint string `json:"outPoint"`
+		Frozen   bool   `json:"frozen"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	outPoint, err := wire.NewOutPointFromString(args.OutPoint)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, btcAccount.SetOutputFrozen(outPoint.String(), args.Frozen)
+}
+
 type signingResponse struct {
 	Success      bool   `json:"success"`
 	Signature    string `json:"signature"`
@@ -25,7 +25,8 @@ import (
 	"golang.org/x/crypto/scrypt"
 )
 
-const reorgLimit = 100
+// ReorgLimit is the maximum number of blocks reverted when a reorg is detected.
+const ReorgLimit = 100
 
 //go:embed checkpoints.json
 var checkpointsJSONRaw []byte
@@ -78,6 +79,9 @@ const (
 	EventSynced Event = "synced"
 	// EventNewTip is fired when a new tip is known.
 	EventNewTip Event = "newTip"
+	// EventReorg is fired when a reorg was detected and the last ReorgLimit headers are fetched
+	// again.
+	EventReorg Event = "reorg"
 )
 
 // Interface represents the public API of this package.
@@ -291,11 +295,11 @@ func (headers *Headers) getTarget(db DBInterface, index int) (*big.Int, error) {
 	return newTarget, nil
 }
 
-func (headers *Headers) powHash(msg []byte) chainhash.Hash {
-	switch headers.net.Net {
-	case chaincfg.MainNetParams.Net:
-		return chainhash.DoubleHashH(msg)
-	case ltc.MainNetParams.Net:
+// PowHash returns the hash of the serialized header msg which must not exceed the target of the
+// header. Litecoin uses scrypt, Bitcoin a double SHA256.
+func PowHash(net *chaincfg.Params, msg []byte) chainhash.Hash {
+	switch net.Net {
+	case ltc.MainNetParams.Net, ltc.TestNet4Params.Net:
 		const (
 			N = 1024
 			r = 1
@@ -311,7 +315,7 @@ func (headers *Headers) powHash(msg []byte) chainhash.Hash {
 		}
 		return hash
 	default:
-		panic("unsupported coin")
+		return chainhash.DoubleHashH(msg)
 	}
 }
 
@@ -359,7 +363,7 @@ func (headers *Headers) canConnect(db DBInterface, tip int, header *wire.BlockHe
 			}
 			// Skip PoW check before the checkpoint for performance.
 			if lastCheckpoint != nil && tip > int(lastCheckpoint.Height) {
-				powHash := headers.powHash(headerSerialized.Bytes())
+				powHash := PowHash(headers.net, headerSerialized.Bytes())
 				proofOfWork := btcdBlockchain.HashToBig(&powHash)
 				if proofOfWork.Cmp(newTarget) > 0 {
 					return errp.Newf("header %d, %s has insufficient proof of work.", tip, powHash)
@@ -372,12 +376,13 @@ func (headers *Headers) canConnect(db DBInterface, tip int, header *wire.BlockHe
 
 func (headers *Headers) reorg(db DBInterface, tip int) {
 	// Simple reorg method: re-fetch headers up to the maximum reorg limit. The server can shorten
-	// our chain by sending a fake header and set us back by `reorgLimit` blocks, but it needs to
+	// our chain by sending a fake header and set us back by `ReorgLimit` blocks, but it needs to
 	// contain the correct PoW to do so.
-	newTip := max(tip-reorgLimit, -1)
+	newTip := max(tip-ReorgLimit, -1)
 	if err := db.RevertTo(newTip); err != nil {
 		panic(err)
 	}
+	headers.notifyEvent(EventReorg)
 	headers.kick()
 }
 
@@ -0,0 +1,287 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package maketx
+
+import (
+	"math"
+	mrand "math/rand"
+	"sort"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CoinSelectionStrategy is the algorithm used to select the inputs of a transaction. See the
+// constants below.
+type CoinSelectionStrategy string
+
+const (
+	// CoinSelectionLargestFirst selects the largest coins first until the amount is covered. This is
+	// the default.
+	CoinSelectionLargestFirst CoinSelectionStrategy = "largestFirst"
+	// CoinSelectionBranchAndBound searches for a set of coins matching the amount closely enough so
+	// that no change output is needed. If there is none, it falls back to
+	// CoinSelectionLargestFirst.
+	CoinSelectionBranchAndBound CoinSelectionStrategy = "branchAndBound"
+	// CoinSelectionSingleRandomDraw selects coins in random order until the amount is covered.
+	CoinSelectionSingleRandomDraw CoinSelectionStrategy = "singleRandomDraw"
+	// CoinSelectionPrivacy avoids spending coins of different addresses together, as this reveals
+	// to observers that the addresses belong to the same wallet. All coins of an address are spent
+	// together, so that no coins remain on an address that was already linked to the transaction.
+	// If no single address covers the amount, it falls back to CoinSelectionLargestFirst.
+	CoinSelectionPrivacy CoinSelectionStrategy = "privacy"
+)
+
+// NewCoinSelectionStrategy checks if the code is valid and returns a CoinSelectionStrategy in that
+// case. The empty code results in the default strategy.
+func NewCoinSelectionStrategy(code string) (CoinSelectionStrategy, error) {
+	switch CoinSelectionStrategy(code) {
+	case "":
+		return CoinSelectionLargestFirst, nil
+	case CoinSelectionLargestFirst,
+		CoinSelectionBranchAndBound,
+		CoinSelectionSingleRandomDraw,
+		CoinSelectionPrivacy:
+		return CoinSelectionStrategy(code), nil
+	default:
+		return "", errp.Newf("Unrecognized coin selection strategy %s", code)
+	}
+}
+
+// coinSelector selects a subset of the outputs with a sum of at least minAmount. It returns the sum
+// of the selected outputs and the selected outpoints, or ErrInsufficientFunds.
+//
+// Selectors must be deterministic for the same input, as NewTx calls them repeatedly with increasing
+// minAmount until the fee of the selected inputs is covered.
+type coinSelector interface {
+	selectCoins(minAmount btcutil.Amount, outputs map[wire.OutPoint]UTXO) (btcutil.Amount, []wire.OutPoint, error)
+}
+
+// newCoinSelector returns the selector for the strategy. The seed is used by randomized strategies.
+// CoinSelectionBranchAndBound is not a coinSelector, as it needs the fee rate, and falls back to
+// the largest first selector.
+func newCoinSelector(strategy CoinSelectionStrategy, seed int64) coinSelector {
+	switch strategy {
+	case CoinSelectionSingleRandomDraw:
+		return singleRandomDraw{seed: seed}
+	case CoinSelectionPrivacy:
+		return addressClusters{}
+	default:
+		return largestFirst{}
+	}
+}
+
+// sortedOutPoints returns the outpoints of the outputs ordered by value, from the largest to the
+// smallest.
+func sortedOutPoints(outputs map[wire.OutPoint]UTXO) []wire.OutPoint {
+	outPoints := []wire.OutPoint{}
+	for outPoint := range outputs {
+		outPoints = append(outPoints, outPoint)
+	}
+	sort.Sort(sort.Reverse(&byValue{outPoints, outputs}))
+	return outPoints
+}
+
+// selectInOrder selects outpoints in the given order until their sum is at least minAmount.
+func selectInOrder(
+	minAmount btcutil.Amount,
+	outPoints []wire.OutPoint,
+	outputs map[wire.OutPoint]UTXO,
+) (btcutil.Amount, []wire.OutPoint, error) {
+	selectedOutPoints := []wire.OutPoint{}
+	outputsSum := btcutil.Amount(0)
+	for _, outPoint := range outPoints {
+		if outputsSum >= minAmount {
+			break
+		}
+		selectedOutPoints = append(selectedOutPoints, outPoint)
+		outputsSum += btcutil.Amount(outputs[outPoint].TxOut.Value)
+	}
+	if outputsSum < minAmount {
+		return 0, nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	return outputsSum, selectedOutPoints, nil
+}
+
+type largestFirst struct{}
+
+func (largestFirst) selectCoins(
+	minAmount btcutil.Amount,
+	outputs map[wire.OutPoint]UTXO,
+) (btcutil.Amount, []wire.OutPoint, error) {
+	return selectInOrder(minAmount, sortedOutPoints(outputs), outputs)
+}
+
+// singleRandomDraw selects the coins in a random order. The order only depends on the seed, so
+// repeated calls with a higher minAmount select a superset of the previous selection.
+type singleRandomDraw struct {
+	seed int64
+}
+
+func (selector singleRandomDraw) selectCoins(
+	minAmount btcutil.Amount,
+	outputs map[wire.OutPoint]UTXO,
+) (btcutil.Amount, []wire.OutPoint, error) {
+	outPoints := sortedOutPoints(outputs)
+	random := mrand.New(mrand.NewSource(selector.seed))
+	random.Shuffle(len(outPoints), func(i, j int) {
+		outPoints[i], outPoints[j] = outPoints[j], outPoints[i]
+	})
+	return selectInOrder(minAmount, outPoints, outputs)
+}
+
+// errNoSingleAddress is returned by addressClusters if the coins of no single address cover the
+// amount, but the coins of all addresses together do.
+var errNoSingleAddress = errp.New("no single address covers the amount")
+
+// addressClusters treats all coins of an address as one unit. It selects the smallest single
+// address covering the amount, or returns errNoSingleAddress if there is none.
+type addressClusters struct{}
+
+func (addressClusters) selectCoins(
+	minAmount btcutil.Amount,
+	outputs map[wire.OutPoint]UTXO,
+) (btcutil.Amount, []wire.OutPoint, error) {
+	type cluster struct {
+		pkScriptHash string
+		sum          btcutil.Amount
+		outPoints    []wire.OutPoint
+	}
+	clustersByPkScript := map[string]*cluster{}
+	for _, outPoint := range sortedOutPoints(outputs) {
+		pkScript := string(outputs[outPoint].TxOut.PkScript)
+		c, ok := clustersByPkScript[pkScript]
+		if !ok {
+			c = &cluster{pkScriptHash: chainhash.HashH([]byte(pkScript)).String()}
+			clustersByPkScript[pkScript] = c
+		}
+		c.sum += btcutil.Amount(outputs[outPoint].TxOut.Value)
+		c.outPoints = append(c.outPoints, outPoint)
+	}
+	clusters := make([]*cluster, 0, len(clustersByPkScript))
+	for _, c := range clustersByPkScript {
+		clusters = append(clusters, c)
+	}
+	// Smallest first, with a secondary sort to make the selection deterministic.
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].sum == clusters[j].sum {
+			return clusters[i].pkScriptHash < clusters[j].pkScriptHash
+		}
+		return clusters[i].sum < clusters[j].sum
+	})
+	for _, c := range clusters {
+		if c.sum >= minAmount {
+			return c.sum, c.outPoints, nil
+		}
+	}
+	outputsSum := btcutil.Amount(0)
+	for _, c := range clusters {
+		outputsSum += c.sum
+	}
+	if outputsSum < minAmount {
+		return 0, nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	return 0, nil, errp.WithStack(errNoSingleAddress)
+}
+
+// branchAndBoundMaxTries limits the number of steps of the branch and bound search.
+const branchAndBoundMaxTries = 100000
+
+// inputFee returns the fee to add an input of the given configuration to a transaction.
+func inputFee(feePerKb btcutil.Amount, configuration *signing.Configuration) btcutil.Amount {
+	sigScriptSize, witnessSize := sigScriptWitnessSize(configuration)
+	const nonWitness = 4
+	inputWeight := nonWitness*calcInputSize(sigScriptSize) + witnessSize
+	return feePerKb * btcutil.Amount((inputWeight+3)/4) / 1000
+}
+
+// branchAndBound searches for a set of outputs whose effective values (value minus the fee to spend
+// it) sum up to at least target, but exceed it by no more than costOfChange, which is the cost of
+// creating and later spending a change output. Among the solutions found, the one with the least
+// excess is returned. nil is returned if there is no solution.
+//
+// This is the algorithm of Bitcoin Core, see https://murch.one/erhardt2016coinselection.pdf.
+func branchAndBound(
+	outputs map[wire.OutPoint]UTXO,
+	feePerKb btcutil.Amount,
+	target btcutil.Amount,
+	costOfChange btcutil.Amount,
+) []wire.OutPoint {
+	type pooledOutput struct {
+		outPoint       wire.OutPoint
+		effectiveValue btcutil.Amount
+	}
+	pool := []pooledOutput{}
+	availableValue := btcutil.Amount(0)
+	// sortedOutPoints orders by value. Effective values could be ordered differently with mixed
+	// input types, so we sort by effective value afterwards, keeping the order of equal values.
+	for _, outPoint := range sortedOutPoints(outputs) {
+		output := outputs[outPoint]
+		effectiveValue := btcutil.Amount(output.TxOut.Value) -
+			inputFee(feePerKb, output.Address.AccountConfiguration)
+		if effectiveValue <= 0 {
+			continue
+		}
+		pool = append(pool, pooledOutput{outPoint: outPoint, effectiveValue: effectiveValue})
+		availableValue += effectiveValue
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].effectiveValue > pool[j].effectiveValue
+	})
+	if availableValue < target {
+		return nil
+	}
+
+	var selection []int
+	var bestSelection []int
+	bestExcess := btcutil.Amount(math.MaxInt64)
+	value := btcutil.Amount(0)
+	index := 0
+	for try := 0; try < branchAndBoundMaxTries; try, index = try+1, index+1 {
+		backtrack := false
+		if value+availableValue < target || value > target+costOfChange {
+			// This branch can't reach the target anymore or already exceeds it too much.
+			backtrack = true
+		} else if value >= target {
+			if excess := value - target; excess < bestExcess {
+				bestExcess = excess
+				bestSelection = append([]int{}, selection...)
+			}
+			backtrack = true
+		}
+		if backtrack {
+			if len(selection) == 0 {
+				// The whole tree was searched.
+				break
+			}
+			// Add the omitted outputs back before trying to omit the last included output.
+			for index--; index > selection[len(selection)-1]; index-- {
+				availableValue += pool[index].effectiveValue
+			}
+			value -= pool[index].effectiveValue
+			selection = selection[:len(selection)-1]
+			continue
+		}
+		availableValue -= pool[index].effectiveValue
+		// If the previous output was omitted and has the same value, including this one would
+		// result in a combination that was already tried.
+		if len(selection) == 0 ||
+			index-1 == selection[len(selection)-1] ||
+			pool[index].effectiveValue != pool[index-1].effectiveValue {
+			selection = append(selection, index)
+			value += pool[index].effectiveValue
+		}
+	}
+	if bestSelection == nil {
+		return nil
+	}
+	outPoints := make([]wire.OutPoint, len(bestSelection))
+	for i, index := range bestSelection {
+		outPoints[i] = pool[index].outPoint
+	}
+	return outPoints
+}
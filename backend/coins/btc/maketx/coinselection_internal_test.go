@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package maketx
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func testOutPoint(i int) wire.OutPoint {
+	return wire.OutPoint{Hash: chainhash.HashH([]byte("coin-selection")), Index: uint32(i)}
+}
+
+// testUTXOs builds an utxo set with the given values. The outpoint index is the index of the value.
+// Each utxo is on its own address unless pkScripts are given.
+func testUTXOs(values []int64, pkScripts ...string) map[wire.OutPoint]UTXO {
+	address := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	utxos := map[wire.OutPoint]UTXO{}
+	for i, value := range values {
+		pkScript := []byte{byte(i)}
+		if len(pkScripts) != 0 {
+			pkScript = []byte(pkScripts[i])
+		}
+		utxos[testOutPoint(i)] = UTXO{
+			TxOut:   wire.NewTxOut(value, pkScript),
+			Address: address,
+		}
+	}
+	return utxos
+}
+
+func testOutPoints(indices ...int) []wire.OutPoint {
+	outPoints := make([]wire.OutPoint, len(indices))
+	for i, index := range indices {
+		outPoints[i] = testOutPoint(index)
+	}
+	return outPoints
+}
+
+func TestNewCoinSelectionStrategy(t *testing.T) {
+	strategy, err := NewCoinSelectionStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, CoinSelectionLargestFirst, strategy)
+	for _, expected := range []CoinSelectionStrategy{
+		CoinSelectionLargestFirst,
+		CoinSelectionBranchAndBound,
+		CoinSelectionSingleRandomDraw,
+		CoinSelectionPrivacy,
+	} {
+		strategy, err := NewCoinSelectionStrategy(string(expected))
+		require.NoError(t, err)
+		require.Equal(t, expected, strategy)
+	}
+	_, err = NewCoinSelectionStrategy("knapsack")
+	require.Error(t, err)
+}
+
+func TestLargestFirst(t *testing.T) {
+	utxos := testUTXOs([]int64{1, 5, 3})
+	sum, outPoints, err := largestFirst{}.selectCoins(4, utxos)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(5), sum)
+	require.Equal(t, testOutPoints(1), outPoints)
+
+	sum, outPoints, err = largestFirst{}.selectCoins(6, utxos)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(8), sum)
+	require.Equal(t, testOutPoints(1, 2), outPoints)
+
+	_, _, err = largestFirst{}.selectCoins(10, utxos)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func TestSingleRandomDraw(t *testing.T) {
+	utxos := testUTXOs([]int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	selector := singleRandomDraw{seed: 1}
+
+	sum, outPoints, err := selector.selectCoins(20, utxos)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, sum, btcutil.Amount(20))
+	// The result only depends on the seed.
+	sum2, outPoints2, err := selector.selectCoins(20, utxos)
+	require.NoError(t, err)
+	require.Equal(t, sum, sum2)
+	require.Equal(t, outPoints, outPoints2)
+
+	// A higher amount extends the previous selection.
+	_, morePoints, err := selector.selectCoins(sum+1, utxos)
+	require.NoError(t, err)
+	require.Equal(t, outPoints, morePoints[:len(outPoints)])
+
+	// Different seeds result in different orders.
+	var differs bool
+	for seed := int64(2); seed < 10 && !differs; seed++ {
+		_, otherPoints, err := singleRandomDraw{seed: seed}.selectCoins(55, utxos)
+		require.NoError(t, err)
+		_, allPoints, err := selector.selectCoins(55, utxos)
+		require.NoError(t, err)
+		require.ElementsMatch(t, allPoints, otherPoints)
+		differs = !slices.Equal(allPoints, otherPoints)
+	}
+	require.True(t, differs)
+
+	_, _, err = selector.selectCoins(56, utxos)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func TestAddressClusters(t *testing.T) {
+	// Address a has two coins of 3, b one coin of 5 and c one coin of 10.
+	utxos := testUTXOs([]int64{3, 3, 5, 10}, "a", "a", "b", "c")
+	selector := addressClusters{}
+
+	// The smallest address covering the amount is used.
+	sum, outPoints, err := selector.selectCoins(5, utxos)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(5), sum)
+	require.Equal(t, testOutPoints(2), outPoints)
+
+	// All coins of an address are spent together.
+	sum, outPoints, err = selector.selectCoins(6, utxos)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(6), sum)
+	require.ElementsMatch(t, testOutPoints(0, 1), outPoints)
+
+	sum, outPoints, err = selector.selectCoins(4, utxos)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(5), sum)
+	require.Equal(t, testOutPoints(2), outPoints)
+
+	// Addresses are not combined.
+	_, _, err = selector.selectCoins(12, utxos)
+	require.Equal(t, errNoSingleAddress, errp.Cause(err))
+
+	_, _, err = selector.selectCoins(22, utxos)
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func TestBranchAndBound(t *testing.T) {
+	utxos := testUTXOs([]int64{1, 2, 5, 10})
+	tests := []struct {
+		target       btcutil.Amount
+		costOfChange btcutil.Amount
+		expected     []wire.OutPoint
+	}{
+		{3, 0, testOutPoints(0, 1)},
+		{8, 0, testOutPoints(0, 1, 2)},
+		{18, 0, testOutPoints(0, 1, 2, 3)},
+		{4, 0, nil},
+		{4, 1, testOutPoints(2)},
+		// An exact match is preferred over a match with excess.
+		{6, 1, testOutPoints(0, 2)},
+		{19, 0, nil},
+	}
+	for _, test := range tests {
+		require.ElementsMatch(t, test.expected, branchAndBound(utxos, 0, test.target, test.costOfChange),
+			"target %d, cost of change %d", test.target, test.costOfChange)
+	}
+
+	// Combinations of coins with equal values are only tried once, the result is still found.
+	require.Len(t, branchAndBound(testUTXOs([]int64{2, 2, 2, 2}), 0, 6, 0), 3)
+
+	// The fee to spend an input is deducted from its value. A P2WPKH input has 68 vbytes.
+	feePerKb := btcutil.Amount(1000)
+	utxos = testUTXOs([]int64{1068, 2068})
+	require.Equal(t, testOutPoints(0), branchAndBound(utxos, feePerKb, 1000, 0))
+	require.ElementsMatch(t, testOutPoints(0, 1), branchAndBound(utxos, feePerKb, 3000, 0))
+	require.Nil(t, branchAndBound(utxos, feePerKb, 1068, 0))
+}
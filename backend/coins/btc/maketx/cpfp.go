@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package maketx
+
+import (
+	mrand "math/rand"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+// UnconfirmedParent describes an unconfirmed transaction that is accelerated by a child spending
+// one or more of its outputs (child-pays-for-parent).
+type UnconfirmedParent struct {
+	// TxHash is the hash of the parent transaction.
+	TxHash chainhash.Hash
+	// Outputs are the unspent outputs of the parent transaction belonging to the account. All of
+	// them are spent by the child.
+	Outputs map[wire.OutPoint]UTXO
+	// Fee is the absolute fee paid by the parent transaction.
+	Fee btcutil.Amount
+	// VSize is the virtual size of the parent transaction.
+	VSize int64
+}
+
+// NewTxCPFP creates a transaction spending the outputs of an unconfirmed parent transaction back to
+// changeAddress. The fee of the child is chosen such that the fee rate of the package (parent and
+// child together) reaches feePerKb. The child always pays at least minRelayFeePerKb for its own
+// size. If the parent outputs are not enough to cover the fee, additional inputs are selected from
+// spendableOutputs.
+func NewTxCPFP(
+	coin coinpkg.Coin,
+	parent *UnconfirmedParent,
+	spendableOutputs map[wire.OutPoint]UTXO,
+	feePerKb btcutil.Amount,
+	minRelayFeePerKb btcutil.Amount,
+	changeAddress *addresses.AccountAddress,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if len(parent.Outputs) == 0 {
+		return nil, errp.New("the parent transaction has no outputs to spend")
+	}
+	parentFeeRatePerKb := parent.Fee * 1000 / btcutil.Amount(parent.VSize)
+	if feePerKb <= parentFeeRatePerKb {
+		return nil, errp.WithStack(errors.ErrFeeTooLow)
+	}
+	changePKScript := changeAddress.PubkeyScript()
+
+	parentOutPoints := make([]wire.OutPoint, 0, len(parent.Outputs))
+	parentSum := btcutil.Amount(0)
+	for outPoint, utxo := range parent.Outputs {
+		parentOutPoints = append(parentOutPoints, outPoint)
+		parentSum += btcutil.Amount(utxo.TxOut.Value)
+	}
+
+	additionalAmount := btcutil.Amount(0)
+	for {
+		additionalSum, additionalOutPoints, err := coinSelection(additionalAmount, spendableOutputs)
+		if err != nil {
+			return nil, err
+		}
+		previousOutputs := make(PreviousOutputs, len(parentOutPoints)+len(additionalOutPoints))
+		for outPoint, utxo := range parent.Outputs {
+			previousOutputs[outPoint] = utxo
+		}
+		for _, outPoint := range additionalOutPoints {
+			previousOutputs[outPoint] = spendableOutputs[outPoint]
+		}
+		selectedOutPoints := append(append([]wire.OutPoint{}, parentOutPoints...), additionalOutPoints...)
+
+		childSize := estimateTxSizeOutputs(
+			toInputConfigurations(previousOutputs, selectedOutPoints),
+			len(changePKScript))
+		childFee := feeForSerializeSize(feePerKb, int(parent.VSize)+childSize, log) - parent.Fee
+		if minChildFee := feeForSerializeSize(minRelayFeePerKb, childSize, log); childFee < minChildFee {
+			childFee = minChildFee
+		}
+		selectedSum := parentSum + additionalSum
+		changeAmount := selectedSum - childFee
+		if changeAmount <= 0 || isDustAmount(
+			changeAmount, len(changePKScript), changeAddress.AccountConfiguration, feePerKb) {
+			// Select at least one more coin.
+			additionalAmount = additionalSum + 1
+			continue
+		}
+
+		inputs := make([]*wire.TxIn, len(selectedOutPoints))
+		for i, outPoint := range selectedOutPoints {
+			inputs[i] = wire.NewTxIn(&outPoint, nil, nil)
+		}
+		unsignedTransaction := &wire.MsgTx{
+			Version:  wire.TxVersion,
+			TxIn:     inputs,
+			TxOut:    []*wire.TxOut{wire.NewTxOut(int64(changeAmount), changePKScript)},
+			LockTime: 0,
+		}
+
+		secureRand := mrand.New(mrand.NewSource(secureSeed()))
+		shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
+
+		log.WithFields(logrus.Fields{"fee": childFee, "parent-fee": parent.Fee}).
+			Debug("Preparing child-pays-for-parent transaction")
+
+		setRBF(coin, unsignedTransaction)
+		psbt, err := psbt.NewFromUnsignedTx(unsignedTransaction)
+		if err != nil {
+			return nil, err
+		}
+		parentTxHash := parent.TxHash
+		return &TxProposal{
+			Coin:            coin,
+			Amount:          changeAmount,
+			Fee:             childFee,
+			ChangeAddress:   changeAddress,
+			PreviousOutputs: previousOutputs,
+			// Only one output in a CPFP tx.
+			OutIndex:     0,
+			Psbt:         psbt,
+			CPFPParentTx: &parentTxHash,
+		}, nil
+	}
+}
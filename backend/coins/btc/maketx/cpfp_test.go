@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package maketx_test
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// unconfirmedParent builds a parent tx with the given outputs belonging to us.
+func (s *newTxSuite) unconfirmedParent(fee btcutil.Amount, vsize int64, satoshis ...int64) *maketx.UnconfirmedParent {
+	txHash := chainhash.HashH([]byte(`parent-tx`))
+	outputs := map[wire.OutPoint]maketx.UTXO{}
+	for i, satoshi := range satoshis {
+		outputs[wire.OutPoint{Hash: txHash, Index: uint32(i)}] = maketx.UTXO{
+			TxOut:   wire.NewTxOut(satoshi, s.someAddresses[0].PubkeyScript()),
+			Address: s.someAddresses[0],
+		}
+	}
+	return &maketx.UnconfirmedParent{TxHash: txHash, Outputs: outputs, Fee: fee, VSize: vsize}
+}
+
+func (s *newTxSuite) TestNewTxCPFP() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(10000)        // 10 sat/vbyte
+	minRelayFeePerKb := btcutil.Amount(1000) // 1 sat/vbyte
+
+	// The parent pays 1 sat/vbyte.
+	parent := s.unconfirmedParent(200, 200, 10*mBTC)
+	txProposal, err := maketx.NewTxCPFP(
+		s.coin, parent, s.buildUTXO(), feePerKb, minRelayFeePerKb, s.changeAddress, s.log)
+	s.Require().NoError(err)
+
+	tx := txProposal.Psbt.UnsignedTx
+	s.Require().Len(tx.TxIn, 1)
+	s.Require().Equal(parent.TxHash, tx.TxIn[0].PreviousOutPoint.Hash)
+	s.Require().Len(tx.TxOut, 1)
+	s.Require().Equal(s.changeAddress.PubkeyScript(), tx.TxOut[0].PkScript)
+	s.Require().Equal(parent.TxHash, *txProposal.CPFPParentTx)
+
+	childSize := maketx.TstEstimateTxSize(s.inputConfigurations(1), len(s.changeAddress.PubkeyScript()), 0)
+	// The package of parent and child pays 10 sat/vbyte.
+	expectedFee := btcutil.Amount(10*(200+childSize)) - parent.Fee
+	s.Require().Equal(expectedFee, txProposal.Fee)
+	s.Require().Equal(int64(10*mBTC)-int64(expectedFee), tx.TxOut[0].Value)
+}
+
+func (s *newTxSuite) TestNewTxCPFPAddsInputs() {
+	feePerKb := btcutil.Amount(100000)       // 100 sat/vbyte
+	minRelayFeePerKb := btcutil.Amount(1000) // 1 sat/vbyte
+
+	// The parent output is too small to pay for the fee on its own.
+	parent := s.unconfirmedParent(200, 200, 10000)
+	_, err := maketx.NewTxCPFP(
+		s.coin, parent, s.buildUTXO(), feePerKb, minRelayFeePerKb, s.changeAddress, s.log)
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+
+	txProposal, err := maketx.NewTxCPFP(
+		s.coin, parent, s.buildUTXO(1000000), feePerKb, minRelayFeePerKb, s.changeAddress, s.log)
+	s.Require().NoError(err)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 2)
+	s.Require().Len(txProposal.PreviousOutputs, 2)
+	_, ok := txProposal.PreviousOutputs[s.outpoint(0)]
+	s.Require().True(ok)
+	s.Require().Equal(btcutil.Amount(1010000-txProposal.Psbt.UnsignedTx.TxOut[0].Value), txProposal.Fee)
+}
+
+func (s *newTxSuite) TestNewTxCPFPFeeTooLow() {
+	// The parent already pays more than the requested fee rate.
+	parent := s.unconfirmedParent(2000, 200, 100000)
+	_, err := maketx.NewTxCPFP(
+		s.coin, parent, s.buildUTXO(), btcutil.Amount(5000), btcutil.Amount(1000), s.changeAddress, s.log)
+	s.Require().Equal(errors.ErrFeeTooLow, errp.Cause(err))
+}
@@ -6,7 +6,6 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	mrand "math/rand"
-	"sort"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
@@ -35,7 +34,8 @@ func (p PreviousOutputs) FetchPrevOutput(op wire.OutPoint) *wire.TxOut {
 type TxProposal struct {
 	// Coin is the coin this tx was made for.
 	Coin coinpkg.Coin
-	// Amount is the amount that is sent out. The fee is not included and is deducted on top.
+	// Amount is the amount that is sent out, summed over all recipients. The fee is not included and
+	// is deducted on top.
 	Amount btcutil.Amount
 	// Fee is the mining fee used.
 	Fee btcutil.Amount
@@ -46,9 +46,22 @@ type TxProposal struct {
 	// If not empty, we are sending to a silent payment recipient. The keystore needs access to this
 	// to be able to generate the silent payment output. See BIP-352.
 	SilentPaymentAddress string
-	// OutIndex is the index of the output we send to.
+	// OutIndex is the index of the output we send to. If there are multiple recipients, it is the
+	// output of the first recipient.
 	OutIndex int
-	Psbt     *psbt.Packet
+	// OutIndices are the indices of the outputs of all recipients, in the order of the recipients.
+	// Only set for transactions created by NewTx and NewTxBatch.
+	OutIndices []int
+	Psbt       *psbt.Packet
+	// CPFPParentTx is the hash of the unconfirmed parent tx if this is a child-pays-for-parent tx
+	// created by NewTxCPFP, nil otherwise.
+	CPFPParentTx *chainhash.Hash
+	// CoinSelection is the strategy that selected the inputs. It can differ from the requested
+	// strategy if it had to fall back to another one. Empty if all inputs are spent.
+	CoinSelection CoinSelectionStrategy
+	// PayjoinURL is the BIP-78 endpoint of the recipient. If set, the signed transaction is first
+	// sent to the recipient, which can add its own inputs before the transaction is broadcast.
+	PayjoinURL string
 }
 
 // SigHashes computes the hashes cache to speed up per-input sighash computations.
@@ -65,6 +78,19 @@ func (txProposal *TxProposal) Total() btcutil.Amount {
 type UTXO struct {
 	TxOut   *wire.TxOut
 	Address *addresses.AccountAddress
+	// Frozen outputs are skipped when selecting the inputs of a new transaction.
+	Frozen bool
+}
+
+// unfrozenOutputs returns the outputs which are not frozen.
+func unfrozenOutputs(outputs map[wire.OutPoint]UTXO) map[wire.OutPoint]UTXO {
+	result := make(map[wire.OutPoint]UTXO, len(outputs))
+	for outPoint, output := range outputs {
+		if !output.Frozen {
+			result[outPoint] = output
+		}
+	}
+	return result
 }
 
 type byValue struct {
@@ -82,29 +108,13 @@ func (p *byValue) Less(i, j int) bool {
 }
 func (p *byValue) Swap(i, j int) { p.outPoints[i], p.outPoints[j] = p.outPoints[j], p.outPoints[i] }
 
+// coinSelection selects the largest outputs first until their sum is at least minAmount. Frozen
+// outputs are skipped.
 func coinSelection(
 	minAmount btcutil.Amount,
 	outputs map[wire.OutPoint]UTXO,
 ) (btcutil.Amount, []wire.OutPoint, error) {
-	outPoints := []wire.OutPoint{}
-	for outPoint := range outputs {
-		outPoints = append(outPoints, outPoint)
-	}
-	sort.Sort(sort.Reverse(&byValue{outPoints, outputs}))
-	selectedOutPoints := []wire.OutPoint{}
-	outputsSum := btcutil.Amount(0)
-
-	for _, outPoint := range outPoints {
-		if outputsSum >= minAmount {
-			break
-		}
-		selectedOutPoints = append(selectedOutPoints, outPoint)
-		outputsSum += btcutil.Amount(outputs[outPoint].TxOut.Value)
-	}
-	if outputsSum < minAmount {
-		return 0, nil, errp.WithStack(errors.ErrInsufficientFunds)
-	}
-	return outputsSum, selectedOutPoints, nil
+	return largestFirst{}.selectCoins(minAmount, unfrozenOutputs(outputs))
 }
 
 // toInputConfigurations converts selected inputs to input configurations.
@@ -160,7 +170,8 @@ func NewOutputInfo(pkScript []byte) *OutputInfo {
 	return &OutputInfo{pkScript: pkScript}
 }
 
-// NewTxSpendAll creates a transaction which spends all available unspent outputs.
+// NewTxSpendAll creates a transaction which spends all available unspent outputs, except for frozen
+// outputs.
 func NewTxSpendAll(
 	coin coinpkg.Coin,
 	spendableOutputs map[wire.OutPoint]UTXO,
@@ -168,6 +179,7 @@ func NewTxSpendAll(
 	feePerKb btcutil.Amount,
 	log *logrus.Entry,
 ) (*TxProposal, error) {
+	spendableOutputs = unfrozenOutputs(spendableOutputs)
 	selectedOutPoints := []wire.OutPoint{}
 	inputs := []*wire.TxIn{}
 	outputsSum := btcutil.Amount(0)
@@ -214,8 +226,19 @@ func NewTxSpendAll(
 	}, nil
 }
 
+// Recipient is an output of a transaction paying multiple recipients, see NewTxBatch.
+type Recipient struct {
+	OutputInfo *OutputInfo
+	// Amount is the amount sent to the recipient. It is ignored if SendAll is true.
+	Amount int64
+	// SendAll sends all funds remaining after paying the other recipients and the fee to this
+	// recipient.
+	SendAll bool
+}
+
 // NewTx creates a transaction from a set of unspent outputs, targeting an output value. A subset of
-// the unspent outputs is selected to cover the needed amount.
+// the unspent outputs is selected to cover the needed amount, using the given coin selection
+// strategy. Frozen outputs are not selected.
 //
 // changeAddress: a change output to this address is added if needed.
 func NewTx(
@@ -225,49 +248,145 @@ func NewTx(
 	outputAmount int64,
 	feePerKb btcutil.Amount,
 	changeAddress *addresses.AccountAddress,
+	coinSelection CoinSelectionStrategy,
 	log *logrus.Entry,
 ) (*TxProposal, error) {
-	output := wire.NewTxOut(outputAmount, outputInfo.pkScript)
-
-	targetAmount := btcutil.Amount(output.Value)
-	if targetAmount <= 0 {
+	if outputAmount <= 0 {
 		panic("amount must be positive")
 	}
-	outputs := []*wire.TxOut{output}
+	return newTx(coin, spendableOutputs, []Recipient{{OutputInfo: outputInfo, Amount: outputAmount}},
+		feePerKb, changeAddress, coinSelection, log)
+}
+
+// NewTxBatch creates a transaction paying multiple recipients at once, so that the fee has to be
+// paid only once. If one of the recipients has SendAll set, all unfrozen outputs are spent and
+// there is no change output. Otherwise, the inputs are selected like in NewTx.
+//
+// Silent payment addresses are only supported when paying a single recipient.
+func NewTxBatch(
+	coin coinpkg.Coin,
+	spendableOutputs map[wire.OutPoint]UTXO,
+	recipients []Recipient,
+	feePerKb btcutil.Amount,
+	changeAddress *addresses.AccountAddress,
+	coinSelection CoinSelectionStrategy,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if len(recipients) == 0 {
+		return nil, errp.New("at least one recipient is required")
+	}
+	sendAllIndex := -1
+	for i, recipient := range recipients {
+		if recipient.OutputInfo.silentPaymentAddress != "" && len(recipients) > 1 {
+			return nil, errp.New("silent payment addresses are not supported when paying multiple recipients")
+		}
+		if recipient.SendAll {
+			if sendAllIndex != -1 {
+				return nil, errp.New("only one recipient can receive all remaining funds")
+			}
+			sendAllIndex = i
+			continue
+		}
+		if recipient.Amount <= 0 {
+			return nil, errp.WithStack(errors.ErrInvalidAmount)
+		}
+	}
+	if sendAllIndex == -1 {
+		return newTx(coin, spendableOutputs, recipients, feePerKb, changeAddress, coinSelection, log)
+	}
+
+	spendableOutputs = unfrozenOutputs(spendableOutputs)
+	selectedOutPoints := make([]wire.OutPoint, 0, len(spendableOutputs))
+	outputsSum := btcutil.Amount(0)
+	for outPoint, output := range spendableOutputs {
+		selectedOutPoints = append(selectedOutPoints, outPoint)
+		outputsSum += btcutil.Amount(output.TxOut.Value)
+	}
+	recipientOutputs, pkScriptLens, recipientsSum := recipientOutputs(recipients)
+	txSize := estimateTxSizeOutputs(
+		toInputConfigurations(spendableOutputs, selectedOutPoints), pkScriptLens...)
+	fee := feeForSerializeSize(feePerKb, txSize, log)
+	sendAllAmount := outputsSum - recipientsSum - fee
+	if sendAllAmount <= 0 {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	recipientOutputs[sendAllIndex].Value = int64(sendAllAmount)
+	log.WithField("fee", fee).Debug("Preparing transaction to spend all outputs to multiple recipients")
+	return newTxProposal(coin, spendableOutputs, selectedOutPoints, recipientOutputs,
+		recipients[0].OutputInfo.silentPaymentAddress, fee, nil, 0, "")
+}
+
+// recipientOutputs returns the outputs paying the recipients, the sizes of their pkScripts and the
+// sum of their amounts.
+func recipientOutputs(recipients []Recipient) ([]*wire.TxOut, []int, btcutil.Amount) {
+	outputs := make([]*wire.TxOut, len(recipients))
+	pkScriptLens := make([]int, len(recipients))
+	sum := btcutil.Amount(0)
+	for i, recipient := range recipients {
+		outputs[i] = wire.NewTxOut(recipient.Amount, recipient.OutputInfo.pkScript)
+		pkScriptLens[i] = recipient.OutputInfo.pkScriptLen()
+		sum += btcutil.Amount(recipient.Amount)
+	}
+	return outputs, pkScriptLens, sum
+}
+
+// newTx selects the inputs to pay the recipients, none of which has SendAll set, and adds a change
+// output if needed.
+func newTx(
+	coin coinpkg.Coin,
+	spendableOutputs map[wire.OutPoint]UTXO,
+	recipients []Recipient,
+	feePerKb btcutil.Amount,
+	changeAddress *addresses.AccountAddress,
+	coinSelection CoinSelectionStrategy,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	outputs, pkScriptLens, targetAmount := recipientOutputs(recipients)
+	silentPaymentAddress := recipients[0].OutputInfo.silentPaymentAddress
 	changePKScript := changeAddress.PubkeyScript()
+	spendableOutputs = unfrozenOutputs(spendableOutputs)
+
+	if coinSelection == CoinSelectionBranchAndBound {
+		selectedOutPoints, fee := changelessCoinSelection(
+			spendableOutputs, pkScriptLens, targetAmount, feePerKb, changeAddress, log)
+		if selectedOutPoints != nil {
+			log.WithField("fee", fee).Info("Found a coin selection without change")
+			return newTxProposal(coin, spendableOutputs, selectedOutPoints, outputs,
+				silentPaymentAddress, fee, nil, 0, coinSelection)
+		}
+		log.Info("Found no coin selection without change, falling back to largest first")
+		coinSelection = CoinSelectionLargestFirst
+	}
+	if coinSelection == "" {
+		coinSelection = CoinSelectionLargestFirst
+	}
+	selector := newCoinSelector(coinSelection, secureSeed())
 
 	targetFee := btcutil.Amount(0)
 	for {
-		selectedOutputsSum, selectedOutPoints, err := coinSelection(
+		selectedOutputsSum, selectedOutPoints, err := selector.selectCoins(
 			targetAmount+targetFee,
 			spendableOutputs,
 		)
+		if coinSelection == CoinSelectionPrivacy && errp.Cause(err) == errNoSingleAddress {
+			log.Info("No single address covers the amount, falling back to largest first")
+			coinSelection = CoinSelectionLargestFirst
+			selector = largestFirst{}
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		txSize := estimateTxSize(
+		txSize := estimateTxSizeOutputs(
 			toInputConfigurations(spendableOutputs, selectedOutPoints),
-			outputInfo.pkScriptLen(),
-			len(changePKScript))
+			append(pkScriptLens, len(changePKScript))...)
 		maxRequiredFee := feeForSerializeSize(feePerKb, txSize, log)
 		if selectedOutputsSum-targetAmount < maxRequiredFee {
 			targetFee = maxRequiredFee
 			continue
 		}
 
-		inputs := make([]*wire.TxIn, len(selectedOutPoints))
-		previousOutputs := make(PreviousOutputs, len(selectedOutPoints))
-		for i, outPoint := range selectedOutPoints {
-			inputs[i] = wire.NewTxIn(&outPoint, nil, nil)
-			previousOutputs[outPoint] = spendableOutputs[outPoint]
-		}
-		unsignedTransaction := &wire.MsgTx{
-			Version:  wire.TxVersion,
-			TxIn:     inputs,
-			TxOut:    outputs,
-			LockTime: 0,
-		}
 		changeAmount := selectedOutputsSum - targetAmount - maxRequiredFee
 		changeIsDust := isDustAmount(
 			changeAmount, len(changePKScript), changeAddress.AccountConfiguration, feePerKb)
@@ -276,46 +395,120 @@ func NewTx(
 			log.Info("change is dust")
 			finalFee = selectedOutputsSum - targetAmount
 		}
-		if changeAmount != 0 && !changeIsDust {
-			unsignedTransaction.TxOut = append(unsignedTransaction.TxOut,
-				wire.NewTxOut(int64(changeAmount), changePKScript))
-		} else {
+		if changeAmount == 0 || changeIsDust {
 			changeAddress = nil
+			changeAmount = 0
 		}
 
-		secureRand := mrand.New(mrand.NewSource(secureSeed()))
-		shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
-
 		log.WithField("fee", finalFee).Debug("Preparing transaction")
+		return newTxProposal(coin, spendableOutputs, selectedOutPoints, outputs,
+			silentPaymentAddress, finalFee, changeAddress, changeAmount, coinSelection)
+	}
+}
+
+// changelessCoinSelection uses branch and bound to find inputs so that no change output is needed.
+// The excess is added to the fee, but it is at most the cost of creating and spending a change
+// output. It returns the selected outpoints and the fee, or nil if there is no such selection.
+func changelessCoinSelection(
+	spendableOutputs map[wire.OutPoint]UTXO,
+	pkScriptLens []int,
+	targetAmount btcutil.Amount,
+	feePerKb btcutil.Amount,
+	changeAddress *addresses.AccountAddress,
+	log *logrus.Entry,
+) ([]wire.OutPoint, btcutil.Amount) {
+	// The fee of the transaction without inputs. The fee of the inputs is deducted from their value
+	// in the search.
+	baseFee := feePerKb * btcutil.Amount(estimateTxSizeOutputs(nil, pkScriptLens...)) / 1000
+	costOfChange := feePerKb*btcutil.Amount(outputSize(len(changeAddress.PubkeyScript())))/1000 +
+		inputFee(feePerKb, changeAddress.AccountConfiguration)
+	selectedOutPoints := branchAndBound(spendableOutputs, feePerKb, targetAmount+baseFee, costOfChange)
+	if selectedOutPoints == nil {
+		return nil, 0
+	}
+	selectedOutputsSum := btcutil.Amount(0)
+	for _, outPoint := range selectedOutPoints {
+		selectedOutputsSum += btcutil.Amount(spendableOutputs[outPoint].TxOut.Value)
+	}
+	// The search works with per-input fees, which can be slightly off from the fee of the whole
+	// transaction due to rounding and the segwit marker, so we check the result again.
+	txSize := estimateTxSizeOutputs(
+		toInputConfigurations(spendableOutputs, selectedOutPoints), pkScriptLens...)
+	requiredFee := feeForSerializeSize(feePerKb, txSize, log)
+	excess := selectedOutputsSum - targetAmount - requiredFee
+	if excess < 0 || excess > costOfChange {
+		return nil, 0
+	}
+	return selectedOutPoints, selectedOutputsSum - targetAmount
+}
+
+// newTxProposal creates the tx proposal spending the selected outpoints to the recipient outputs. A
+// change output is added if changeAddress is not nil.
+func newTxProposal(
+	coin coinpkg.Coin,
+	spendableOutputs map[wire.OutPoint]UTXO,
+	selectedOutPoints []wire.OutPoint,
+	outputs []*wire.TxOut,
+	silentPaymentAddress string,
+	fee btcutil.Amount,
+	changeAddress *addresses.AccountAddress,
+	changeAmount btcutil.Amount,
+	coinSelection CoinSelectionStrategy,
+) (*TxProposal, error) {
+	inputs := make([]*wire.TxIn, len(selectedOutPoints))
+	previousOutputs := make(PreviousOutputs, len(selectedOutPoints))
+	for i, outPoint := range selectedOutPoints {
+		inputs[i] = wire.NewTxIn(&outPoint, nil, nil)
+		previousOutputs[outPoint] = spendableOutputs[outPoint]
+	}
+	unsignedTransaction := &wire.MsgTx{
+		Version:  wire.TxVersion,
+		TxIn:     inputs,
+		TxOut:    append([]*wire.TxOut{}, outputs...),
+		LockTime: 0,
+	}
+	if changeAddress != nil {
+		unsignedTransaction.TxOut = append(unsignedTransaction.TxOut,
+			wire.NewTxOut(int64(changeAmount), changeAddress.PubkeyScript()))
+	}
+
+	secureRand := mrand.New(mrand.NewSource(secureSeed()))
+	shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
 
-		outIndex := -1
-		for i, txOut := range unsignedTransaction.TxOut {
+	amount := btcutil.Amount(0)
+	outIndices := make([]int, len(outputs))
+	for i, output := range outputs {
+		amount += btcutil.Amount(output.Value)
+		outIndices[i] = -1
+		for j, txOut := range unsignedTransaction.TxOut {
 			if txOut == output {
-				outIndex = i
+				outIndices[i] = j
 				break
 			}
 		}
-		if outIndex == -1 {
+		if outIndices[i] == -1 {
 			return nil, errp.New("could not identify output")
 		}
+	}
 
-		setRBF(coin, unsignedTransaction)
-		psbt, err := psbt.NewFromUnsignedTx(unsignedTransaction)
-		if err != nil {
-			return nil, err
-		}
-
-		return &TxProposal{
-			Coin:                 coin,
-			Amount:               targetAmount,
-			Fee:                  finalFee,
-			ChangeAddress:        changeAddress,
-			PreviousOutputs:      previousOutputs,
-			SilentPaymentAddress: outputInfo.silentPaymentAddress,
-			OutIndex:             outIndex,
-			Psbt:                 psbt,
-		}, nil
+	setRBF(coin, unsignedTransaction)
+	psbt, err := psbt.NewFromUnsignedTx(unsignedTransaction)
+	if err != nil {
+		return nil, err
 	}
+
+	return &TxProposal{
+		Coin:                 coin,
+		Amount:               amount,
+		Fee:                  fee,
+		ChangeAddress:        changeAddress,
+		PreviousOutputs:      previousOutputs,
+		SilentPaymentAddress: silentPaymentAddress,
+		OutIndex:             outIndices[0],
+		OutIndices:           outIndices,
+		Psbt:                 psbt,
+		CoinSelection:        coinSelection,
+	}, nil
 }
 
 // shuffleTxInputsAndOutputs shuffles both the TxIn and TxOut slices of a wire.MsgTx.
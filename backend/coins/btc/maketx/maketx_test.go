@@ -88,6 +88,14 @@ func (s *newTxSuite) newTx(
 	amount btcutil.Amount,
 	feePerKb btcutil.Amount,
 	utxo map[wire.OutPoint]maketx.UTXO) (*maketx.TxProposal, error) {
+	return s.newTxWithCoinSelection(amount, feePerKb, utxo, "")
+}
+
+func (s *newTxSuite) newTxWithCoinSelection(
+	amount btcutil.Amount,
+	feePerKb btcutil.Amount,
+	utxo map[wire.OutPoint]maketx.UTXO,
+	coinSelection maketx.CoinSelectionStrategy) (*maketx.TxProposal, error) {
 	return maketx.NewTx(
 		s.coin,
 		utxo,
@@ -95,6 +103,7 @@ func (s *newTxSuite) newTx(
 		int64(amount),
 		feePerKb,
 		s.changeAddress,
+		coinSelection,
 		s.log,
 	)
 }
@@ -334,3 +343,181 @@ func (s *newTxSuite) TestNewTxCoinSelection() {
 	s.check(true, btcutil.Amount(100299738), feePerKb, s.buildUTXO(mBTC, 2*mBTC, 1000*mBTC+txSizeOneInput), s.change(0), noDust, s.selectCoins(0, 1, 2))
 
 }
+
+func (s *newTxSuite) TestNewTxFrozen() {
+	const mBTC = 100000
+	amount := btcutil.Amount(1000 * mBTC) // 1 BTC
+	feePerKb := btcutil.Amount(1000)      // 1 sat / vbyte
+
+	// The largest coin is frozen, so the two others are selected.
+	utxo := s.buildUTXO(1000*mBTC, 2000*mBTC, 1000*mBTC)
+	frozen := utxo[s.outpoint(1)]
+	frozen.Frozen = true
+	utxo[s.outpoint(1)] = frozen
+	s.check(false, amount, feePerKb, utxo, s.change(1000*mBTC-txSizeTwoInputs), noDust, s.selectCoins(0, 2))
+	s.check(true, btcutil.Amount(199999660), feePerKb, utxo, s.change(0), noDust, s.selectCoins(0, 2))
+
+	// Frozen coins are not used even if the amount can't be covered otherwise.
+	_, err := s.newTx(2500*mBTC, feePerKb, utxo)
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+	_, err = s.newTxSpendAll(feePerKb, map[wire.OutPoint]maketx.UTXO{s.outpoint(1): frozen})
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxCoinSelectionStrategies() {
+	feePerKb := btcutil.Amount(0)
+	utxo := s.buildUTXO(1, 2, 5)
+
+	txProposal, err := s.newTx(3, feePerKb, utxo)
+	s.Require().NoError(err)
+	s.Require().Equal(maketx.CoinSelectionLargestFirst, txProposal.CoinSelection)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 1)
+	s.Require().NotNil(txProposal.ChangeAddress)
+
+	// Branch and bound finds the inputs matching the amount exactly, so no change is needed.
+	txProposal, err = s.newTxWithCoinSelection(3, feePerKb, utxo, maketx.CoinSelectionBranchAndBound)
+	s.Require().NoError(err)
+	s.Require().Equal(maketx.CoinSelectionBranchAndBound, txProposal.CoinSelection)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 2)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxOut, 1)
+	s.Require().Nil(txProposal.ChangeAddress)
+	s.Require().Equal(btcutil.Amount(0), txProposal.Fee)
+
+	// Without a match, it falls back to largest first.
+	txProposal, err = s.newTxWithCoinSelection(4, feePerKb, utxo, maketx.CoinSelectionBranchAndBound)
+	s.Require().NoError(err)
+	s.Require().Equal(maketx.CoinSelectionLargestFirst, txProposal.CoinSelection)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 1)
+	s.Require().NotNil(txProposal.ChangeAddress)
+
+	for _, coinSelection := range []maketx.CoinSelectionStrategy{
+		maketx.CoinSelectionSingleRandomDraw,
+		maketx.CoinSelectionPrivacy,
+	} {
+		txProposal, err = s.newTxWithCoinSelection(6, feePerKb, utxo, coinSelection)
+		s.Require().NoError(err)
+		s.Require().Equal(coinSelection, txProposal.CoinSelection)
+		s.Require().Equal(btcutil.Amount(6), txProposal.Amount)
+		_, err = s.newTxWithCoinSelection(9, feePerKb, utxo, coinSelection)
+		s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+	}
+
+	// If no single address covers the amount, the privacy strategy falls back to largest first.
+	for i, address := range s.someAddresses[:3] {
+		output := utxo[s.outpoint(i)]
+		output.TxOut = wire.NewTxOut(output.TxOut.Value, address.PubkeyScript())
+		output.Address = address
+		utxo[s.outpoint(i)] = output
+	}
+	txProposal, err = s.newTxWithCoinSelection(6, feePerKb, utxo, maketx.CoinSelectionPrivacy)
+	s.Require().NoError(err)
+	s.Require().Equal(maketx.CoinSelectionLargestFirst, txProposal.CoinSelection)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 2)
+}
+
+func (s *newTxSuite) TestNewTxBranchAndBoundFee() {
+	const mBTC = 100000
+	amount := btcutil.Amount(1000 * mBTC)
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	changelessFee := maketx.TstFeeForSerializeSize(
+		feePerKb,
+		maketx.TstEstimateTxSize(
+			[]*signing.Configuration{s.inputConfiguration}, len(s.outputPkScript), 0),
+		s.log)
+
+	// The excess of 10 sats is less than the cost of change, so it is added to the fee.
+	utxo := s.buildUTXO(mBTC, int64(amount+changelessFee+10), 2000*mBTC)
+	txProposal, err := s.newTxWithCoinSelection(amount, feePerKb, utxo, maketx.CoinSelectionBranchAndBound)
+	s.Require().NoError(err)
+	s.Require().Equal(maketx.CoinSelectionBranchAndBound, txProposal.CoinSelection)
+	s.Require().Nil(txProposal.ChangeAddress)
+	s.Require().Equal(changelessFee+10, txProposal.Fee)
+	s.Require().Equal(amount, txProposal.Amount)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 1)
+	s.Require().Equal(s.outpoint(1), txProposal.Psbt.UnsignedTx.TxIn[0].PreviousOutPoint)
+}
+
+func (s *newTxSuite) TestNewTxBatch() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	inputConfigurations := []*signing.Configuration{s.inputConfiguration, s.inputConfiguration}
+	recipientPkScripts := [][]byte{
+		s.outputPkScript,
+		s.someAddresses[1].PubkeyScript(),
+		s.someAddresses[2].PubkeyScript(),
+	}
+	newRecipients := func(amounts ...int64) []maketx.Recipient {
+		recipients := make([]maketx.Recipient, len(amounts))
+		for i, amount := range amounts {
+			recipients[i] = maketx.Recipient{
+				OutputInfo: maketx.NewOutputInfo(recipientPkScripts[i]),
+				Amount:     amount,
+			}
+		}
+		return recipients
+	}
+	utxo := s.buildUTXO(1000*mBTC, 1000*mBTC, 10*mBTC)
+
+	checkOutputs := func(txProposal *maketx.TxProposal, recipients []maketx.Recipient) {
+		s.Require().Len(txProposal.OutIndices, len(recipients))
+		s.Require().Equal(txProposal.OutIndices[0], txProposal.OutIndex)
+		sum := btcutil.Amount(0)
+		for i, outIndex := range txProposal.OutIndices {
+			txOut := txProposal.Psbt.UnsignedTx.TxOut[outIndex]
+			s.Require().Equal(recipientPkScripts[i], txOut.PkScript)
+			if !recipients[i].SendAll {
+				s.Require().Equal(recipients[i].Amount, txOut.Value)
+			}
+			sum += btcutil.Amount(txOut.Value)
+		}
+		s.Require().Equal(sum, txProposal.Amount)
+	}
+
+	// The fee is paid once for all recipients and the change output.
+	recipients := newRecipients(500*mBTC, 700*mBTC, 300*mBTC)
+	txProposal, err := maketx.NewTxBatch(
+		s.coin, utxo, recipients, feePerKb, s.changeAddress, maketx.CoinSelectionLargestFirst, s.log)
+	s.Require().NoError(err)
+	checkOutputs(txProposal, recipients)
+	expectedFee := btcutil.Amount(maketx.TstEstimateTxSizeOutputs(
+		inputConfigurations, 25, 25, 25, len(s.changeAddress.PubkeyScript())))
+	s.Require().Equal(expectedFee, txProposal.Fee)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 2)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxOut, 4)
+	s.Require().Equal(s.changeAddress, txProposal.ChangeAddress)
+
+	// One recipient receives the remaining funds, all coins are spent.
+	recipients = newRecipients(500*mBTC, 700*mBTC, 0)
+	recipients[2].SendAll = true
+	txProposal, err = maketx.NewTxBatch(
+		s.coin, utxo, recipients, feePerKb, s.changeAddress, maketx.CoinSelectionLargestFirst, s.log)
+	s.Require().NoError(err)
+	checkOutputs(txProposal, recipients)
+	expectedFee = btcutil.Amount(maketx.TstEstimateTxSizeOutputs(
+		append(inputConfigurations, s.inputConfiguration), 25, 25, 25))
+	s.Require().Equal(expectedFee, txProposal.Fee)
+	s.Require().Equal(btcutil.Amount(2010*mBTC)-expectedFee, txProposal.Amount)
+	s.Require().Nil(txProposal.ChangeAddress)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxIn, 3)
+	s.Require().Len(txProposal.Psbt.UnsignedTx.TxOut, 3)
+
+	// Errors.
+	_, err = maketx.NewTxBatch(
+		s.coin, utxo, nil, feePerKb, s.changeAddress, maketx.CoinSelectionLargestFirst, s.log)
+	s.Require().Error(err)
+	_, err = maketx.NewTxBatch(
+		s.coin, utxo, newRecipients(500*mBTC, 0), feePerKb, s.changeAddress, maketx.CoinSelectionLargestFirst, s.log)
+	s.Require().Equal(errors.ErrInvalidAmount, errp.Cause(err))
+	_, err = maketx.NewTxBatch(
+		s.coin, utxo, newRecipients(1005*mBTC, 1005*mBTC), feePerKb, s.changeAddress, maketx.CoinSelectionLargestFirst, s.log)
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+	recipients = newRecipients(2010*mBTC, 0)
+	recipients[1].SendAll = true
+	_, err = maketx.NewTxBatch(
+		s.coin, utxo, recipients, feePerKb, s.changeAddress, maketx.CoinSelectionLargestFirst, s.log)
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+	recipients[0].SendAll = true
+	_, err = maketx.NewTxBatch(
+		s.coin, utxo, recipients, feePerKb, s.changeAddress, maketx.CoinSelectionLargestFirst, s.log)
+	s.Require().Error(err)
+}
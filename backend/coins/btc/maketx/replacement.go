@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package maketx
+
+import (
+	mrand "math/rand"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+// ReplacedTx describes an unconfirmed transaction that is replaced using BIP-125 replace-by-fee.
+type ReplacedTx struct {
+	// Inputs are the outputs spent by the replaced transaction. All of them are spent again by the
+	// replacement, so that the replaced transaction becomes invalid.
+	Inputs map[wire.OutPoint]UTXO
+	// Fee is the absolute fee paid by the replaced transaction.
+	Fee btcutil.Amount
+}
+
+// replacementFee returns the fee needed for a replacement transaction of the given size. BIP-125
+// requires the replacement to pay at least the fee of the replaced transaction plus the relay fee
+// for its own size, on top of the requested fee rate.
+func replacementFee(
+	replaced *ReplacedTx,
+	feePerKb btcutil.Amount,
+	incrementalFeePerKb btcutil.Amount,
+	txSize int,
+	log *logrus.Entry,
+) btcutil.Amount {
+	fee := feeForSerializeSize(feePerKb, txSize, log)
+	minFee := replaced.Fee + feeForSerializeSize(incrementalFeePerKb, txSize, log)
+	if fee < minFee {
+		return minFee
+	}
+	return fee
+}
+
+// NewTxReplacement creates a transaction replacing an unconfirmed transaction with a higher fee.
+// All inputs of the replaced transaction are spent again, and the recipient outputs are kept as
+// they are. If the inputs of the replaced transaction cannot cover the higher fee, additional
+// inputs are selected from spendableOutputs, which must only contain confirmed outputs.
+//
+// changeAddress: a change output to this address is added if needed.
+func NewTxReplacement(
+	coin coinpkg.Coin,
+	replaced *ReplacedTx,
+	spendableOutputs map[wire.OutPoint]UTXO,
+	recipientOutputs []*wire.TxOut,
+	feePerKb btcutil.Amount,
+	incrementalFeePerKb btcutil.Amount,
+	changeAddress *addresses.AccountAddress,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if len(recipientOutputs) == 0 {
+		return nil, errp.New("a replacement needs at least one recipient output")
+	}
+	targetAmount := btcutil.Amount(0)
+	outputPkScriptSizes := make([]int, 0, len(recipientOutputs)+1)
+	for _, output := range recipientOutputs {
+		targetAmount += btcutil.Amount(output.Value)
+		outputPkScriptSizes = append(outputPkScriptSizes, len(output.PkScript))
+	}
+	changePKScript := changeAddress.PubkeyScript()
+	outputPkScriptSizes = append(outputPkScriptSizes, len(changePKScript))
+
+	replacedOutPoints := make([]wire.OutPoint, 0, len(replaced.Inputs))
+	replacedSum := btcutil.Amount(0)
+	for outPoint, utxo := range replaced.Inputs {
+		replacedOutPoints = append(replacedOutPoints, outPoint)
+		replacedSum += btcutil.Amount(utxo.TxOut.Value)
+	}
+
+	additionalAmount := btcutil.Amount(0)
+	for {
+		additionalSum, additionalOutPoints, err := coinSelection(additionalAmount, spendableOutputs)
+		if err != nil {
+			return nil, err
+		}
+		previousOutputs := make(PreviousOutputs, len(replacedOutPoints)+len(additionalOutPoints))
+		for outPoint, utxo := range replaced.Inputs {
+			previousOutputs[outPoint] = utxo
+		}
+		for _, outPoint := range additionalOutPoints {
+			previousOutputs[outPoint] = spendableOutputs[outPoint]
+		}
+		selectedOutPoints := append(append([]wire.OutPoint{}, replacedOutPoints...), additionalOutPoints...)
+
+		txSize := estimateTxSizeOutputs(
+			toInputConfigurations(previousOutputs, selectedOutPoints),
+			outputPkScriptSizes...)
+		requiredFee := replacementFee(replaced, feePerKb, incrementalFeePerKb, txSize, log)
+		selectedSum := replacedSum + additionalSum
+		if selectedSum-targetAmount < requiredFee {
+			additionalAmount = targetAmount + requiredFee - replacedSum
+			continue
+		}
+
+		inputs := make([]*wire.TxIn, len(selectedOutPoints))
+		for i, outPoint := range selectedOutPoints {
+			inputs[i] = wire.NewTxIn(&outPoint, nil, nil)
+		}
+		outputs := make([]*wire.TxOut, len(recipientOutputs))
+		for i, output := range recipientOutputs {
+			outputs[i] = wire.NewTxOut(output.Value, output.PkScript)
+		}
+		unsignedTransaction := &wire.MsgTx{
+			Version:  wire.TxVersion,
+			TxIn:     inputs,
+			TxOut:    outputs,
+			LockTime: 0,
+		}
+		changeAmount := selectedSum - targetAmount - requiredFee
+		changeIsDust := isDustAmount(
+			changeAmount, len(changePKScript), changeAddress.AccountConfiguration, feePerKb)
+		finalFee := requiredFee
+		if changeIsDust {
+			log.Info("change is dust")
+			finalFee = selectedSum - targetAmount
+		}
+		if changeAmount != 0 && !changeIsDust {
+			unsignedTransaction.TxOut = append(unsignedTransaction.TxOut,
+				wire.NewTxOut(int64(changeAmount), changePKScript))
+		} else {
+			changeAddress = nil
+		}
+
+		secureRand := mrand.New(mrand.NewSource(secureSeed()))
+		shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
+
+		log.WithFields(logrus.Fields{"fee": finalFee, "replaced-fee": replaced.Fee}).
+			Debug("Preparing replacement transaction")
+
+		outIndex := -1
+		for i, txOut := range unsignedTransaction.TxOut {
+			if txOut == outputs[0] {
+				outIndex = i
+				break
+			}
+		}
+		if outIndex == -1 {
+			return nil, errp.New("could not identify output")
+		}
+
+		setRBF(coin, unsignedTransaction)
+		psbt, err := psbt.NewFromUnsignedTx(unsignedTransaction)
+		if err != nil {
+			return nil, err
+		}
+
+		return &TxProposal{
+			Coin:            coin,
+			Amount:          targetAmount,
+			Fee:             finalFee,
+			ChangeAddress:   changeAddress,
+			PreviousOutputs: previousOutputs,
+			OutIndex:        outIndex,
+			Psbt:            psbt,
+		}, nil
+	}
+}
+
+// NewTxCancel creates a transaction replacing an unconfirmed transaction by one that spends the
+// same inputs, but sends all funds back to changeAddress. Once it confirms, the replaced
+// transaction can never be confirmed.
+func NewTxCancel(
+	coin coinpkg.Coin,
+	replaced *ReplacedTx,
+	feePerKb btcutil.Amount,
+	incrementalFeePerKb btcutil.Amount,
+	changeAddress *addresses.AccountAddress,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	selectedOutPoints := []wire.OutPoint{}
+	inputs := []*wire.TxIn{}
+	inputsSum := btcutil.Amount(0)
+	for outPoint, utxo := range replaced.Inputs {
+		selectedOutPoints = append(selectedOutPoints, outPoint)
+		inputsSum += btcutil.Amount(utxo.TxOut.Value)
+		inputs = append(inputs, wire.NewTxIn(&outPoint, nil, nil))
+	}
+	changePKScript := changeAddress.PubkeyScript()
+	txSize := estimateTxSizeOutputs(
+		toInputConfigurations(replaced.Inputs, selectedOutPoints),
+		len(changePKScript))
+	requiredFee := replacementFee(replaced, feePerKb, incrementalFeePerKb, txSize, log)
+	if inputsSum < requiredFee {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	changeAmount := inputsSum - requiredFee
+	if isDustAmount(changeAmount, len(changePKScript), changeAddress.AccountConfiguration, feePerKb) {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	unsignedTransaction := &wire.MsgTx{
+		Version:  wire.TxVersion,
+		TxIn:     inputs,
+		TxOut:    []*wire.TxOut{wire.NewTxOut(int64(changeAmount), changePKScript)},
+		LockTime: 0,
+	}
+
+	secureRand := mrand.New(mrand.NewSource(secureSeed()))
+	shuffleTxInputsAndOutputs(unsignedTransaction, secureRand)
+
+	log.WithFields(logrus.Fields{"fee": requiredFee, "replaced-fee": replaced.Fee}).
+		Debug("Preparing cancel transaction")
+
+	setRBF(coin, unsignedTransaction)
+	psbt, err := psbt.NewFromUnsignedTx(unsignedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	return &TxProposal{
+		Coin:            coin,
+		Amount:          changeAmount,
+		Fee:             requiredFee,
+		ChangeAddress:   changeAddress,
+		PreviousOutputs: replaced.Inputs,
+		// Only one output when cancelling.
+		OutIndex: 0,
+		Psbt:     psbt,
+	}, nil
+}
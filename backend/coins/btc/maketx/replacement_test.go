@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package maketx_test
+
+import (
+	"bytes"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// replacedTx builds a replaced tx spending the given amounts and paying the given fee.
+func (s *newTxSuite) replacedTx(fee btcutil.Amount, satoshis ...int64) *maketx.ReplacedTx {
+	inputs := map[wire.OutPoint]maketx.UTXO{}
+	for i, satoshi := range satoshis {
+		outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte(`replaced-tx`)), Index: uint32(i)}
+		inputs[outPoint] = maketx.UTXO{
+			TxOut:   wire.NewTxOut(satoshi, s.someAddresses[0].PubkeyScript()),
+			Address: s.someAddresses[0],
+		}
+	}
+	return &maketx.ReplacedTx{Inputs: inputs, Fee: fee}
+}
+
+func (s *newTxSuite) inputConfigurations(count int) []*signing.Configuration {
+	inputConfigurations := make([]*signing.Configuration, count)
+	for i := range inputConfigurations {
+		inputConfigurations[i] = s.inputConfiguration
+	}
+	return inputConfigurations
+}
+
+func (s *newTxSuite) TestNewTxReplacement() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(10000)           // 10 sat/vbyte
+	incrementalFeePerKb := btcutil.Amount(1000) // 1 sat/vbyte
+
+	recipient := s.output(500 * mBTC)
+	replaced := s.replacedTx(txSizeOneInput, 1000*mBTC)
+	txProposal, err := maketx.NewTxReplacement(
+		s.coin, replaced, s.buildUTXO(), []*wire.TxOut{recipient},
+		feePerKb, incrementalFeePerKb, s.changeAddress, s.log)
+	s.Require().NoError(err)
+
+	tx := txProposal.Psbt.UnsignedTx
+	s.Require().Len(tx.TxIn, 1)
+	for outPoint := range replaced.Inputs {
+		s.Require().Equal(outPoint, tx.TxIn[0].PreviousOutPoint)
+	}
+	s.Require().Len(tx.TxOut, 2)
+	s.Require().Equal(recipient, tx.TxOut[txProposal.OutIndex])
+	expectedFee := maketx.TstFeeForSerializeSize(
+		feePerKb,
+		maketx.TstEstimateTxSize(s.inputConfigurations(1), len(recipient.PkScript), len(s.changeAddress.PubkeyScript())),
+		s.log)
+	s.Require().Equal(expectedFee, txProposal.Fee)
+	s.Require().Equal(btcutil.Amount(500*mBTC), txProposal.Amount)
+	s.Require().Equal(s.changeAddress, txProposal.ChangeAddress)
+	changeOutput := tx.TxOut[1-txProposal.OutIndex]
+	s.Require().True(bytes.Equal(s.changeAddress.PubkeyScript(), changeOutput.PkScript))
+	s.Require().Equal(int64(500*mBTC)-int64(expectedFee), changeOutput.Value)
+	if s.coin == tbtc {
+		for _, txIn := range tx.TxIn {
+			s.Require().Equal(wire.MaxTxInSequenceNum-2, txIn.Sequence)
+		}
+	}
+}
+
+func (s *newTxSuite) TestNewTxReplacementMinFee() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000)            // 1 sat/vbyte
+	incrementalFeePerKb := btcutil.Amount(1000) // 1 sat/vbyte
+
+	// The replaced tx paid a high absolute fee, so the replacement has to pay at least that plus
+	// the incremental relay fee for its own size, even if the requested fee rate is lower.
+	recipient := s.output(500 * mBTC)
+	replacedFee := btcutil.Amount(5000)
+	replaced := s.replacedTx(replacedFee, 1000*mBTC)
+	txProposal, err := maketx.NewTxReplacement(
+		s.coin, replaced, s.buildUTXO(), []*wire.TxOut{recipient},
+		feePerKb, incrementalFeePerKb, s.changeAddress, s.log)
+	s.Require().NoError(err)
+	s.Require().Equal(replacedFee+txSizeOneInput, txProposal.Fee)
+}
+
+func (s *newTxSuite) TestNewTxReplacementAddsInputs() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000)            // 1 sat/vbyte
+	incrementalFeePerKb := btcutil.Amount(1000) // 1 sat/vbyte
+
+	// The replaced tx had no change, so the fee increase must be paid by an additional input.
+	recipient := s.output(1000 * mBTC)
+	replaced := s.replacedTx(100, 1000*mBTC+100)
+	utxo := s.buildUTXO(mBTC)
+	txProposal, err := maketx.NewTxReplacement(
+		s.coin, replaced, utxo, []*wire.TxOut{recipient},
+		feePerKb, incrementalFeePerKb, s.changeAddress, s.log)
+	s.Require().NoError(err)
+	tx := txProposal.Psbt.UnsignedTx
+	s.Require().Len(tx.TxIn, 2)
+	s.Require().Len(txProposal.PreviousOutputs, 2)
+	_, ok := txProposal.PreviousOutputs[s.outpoint(0)]
+	s.Require().True(ok)
+	s.Require().Equal(recipient, tx.TxOut[txProposal.OutIndex])
+
+	inputSum := int64(0)
+	for _, prevOut := range txProposal.PreviousOutputs {
+		inputSum += prevOut.TxOut.Value
+	}
+	outputSum := int64(0)
+	for _, txOut := range tx.TxOut {
+		outputSum += txOut.Value
+	}
+	s.Require().Equal(btcutil.Amount(inputSum-outputSum), txProposal.Fee)
+
+	// Not enough funds to pay for the higher fee.
+	_, err = maketx.NewTxReplacement(
+		s.coin, replaced, s.buildUTXO(), []*wire.TxOut{recipient},
+		feePerKb, incrementalFeePerKb, s.changeAddress, s.log)
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxCancel() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(10000)           // 10 sat/vbyte
+	incrementalFeePerKb := btcutil.Amount(1000) // 1 sat/vbyte
+
+	replaced := s.replacedTx(txSizeTwoInputs, 400*mBTC, 600*mBTC)
+	txProposal, err := maketx.NewTxCancel(
+		s.coin, replaced, feePerKb, incrementalFeePerKb, s.changeAddress, s.log)
+	s.Require().NoError(err)
+	tx := txProposal.Psbt.UnsignedTx
+	s.Require().Len(tx.TxIn, 2)
+	s.Require().Len(tx.TxOut, 1)
+	s.Require().Equal(s.changeAddress.PubkeyScript(), tx.TxOut[0].PkScript)
+	expectedFee := maketx.TstFeeForSerializeSize(
+		feePerKb,
+		maketx.TstEstimateTxSize(s.inputConfigurations(2), len(s.changeAddress.PubkeyScript()), 0),
+		s.log)
+	s.Require().Equal(expectedFee, txProposal.Fee)
+	s.Require().Equal(int64(1000*mBTC)-int64(expectedFee), tx.TxOut[0].Value)
+	s.Require().Equal(btcutil.Amount(tx.TxOut[0].Value), txProposal.Amount)
+
+	// The inputs can't even cover the fee.
+	_, err = maketx.NewTxCancel(
+		s.coin, s.replacedTx(100, 1000), feePerKb, incrementalFeePerKb, s.changeAddress, s.log)
+	s.Require().Equal(errors.ErrInsufficientFunds, errp.Cause(err))
+}
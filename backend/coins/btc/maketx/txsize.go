@@ -53,11 +53,28 @@ func sigScriptWitnessSize(configuration *signing.Configuration) (int, int) {
 	case signing.ScriptTypeP2TR:
 		// Taproot key spend: <64 byte sig>
 		return 0, wire.VarIntSerializeSize(1) + wire.VarIntSerializeSize(64) + 64
+	case signing.ScriptTypeP2WSH:
+		// Multisig: <empty> <sig1> ... <sigM> <witnessScript>
+		threshold := int(configuration.BitcoinMultisig.Threshold)
+		// OP_M <pubkey1> ... <pubkeyN> OP_N OP_CHECKMULTISIG
+		witnessScriptSize := 1 + len(configuration.BitcoinMultisig.Cosigners)*(1+pubkeySize) + 1 + 1
+		return 0, wire.VarIntSerializeSize(uint64(threshold+2)) +
+			wire.VarIntSerializeSize(0) +
+			threshold*(wire.VarIntSerializeSize(signatureSize)+signatureSize) +
+			wire.VarIntSerializeSize(uint64(witnessScriptSize)) + witnessScriptSize
 	default:
 		panic("unknown address type")
 	}
 }
 
+// EstimateInputSize returns the worst case size of an input spending an output of the given
+// configuration, in vbytes.
+func EstimateInputSize(configuration *signing.Configuration) int {
+	sigScriptSize, witnessSize := sigScriptWitnessSize(configuration)
+	weight := 4*calcInputSize(sigScriptSize) + witnessSize
+	return (weight + 3) / 4
+}
+
 // estimateTxSize gives the worst case tx size estimate. The unit of the result is vbyte (virtual
 // bytes), for the purpose of fee calculation.
 // https://en.bitcoin.it/wiki/Weight_units
@@ -73,9 +90,22 @@ func estimateTxSize(
 	inputConfigurations []*signing.Configuration,
 	outputPkScriptSize int,
 	changePkScriptSize int) int {
-	outputCount := 2 // 1 output + 1 change output
-	if changePkScriptSize == 0 {
-		outputCount = 1
+	return estimateTxSizeOutputs(inputConfigurations, outputPkScriptSize, changePkScriptSize)
+}
+
+// estimateTxSizeOutputs is like estimateTxSize, but allows an arbitrary number of outputs. A
+// pkScript size of 0 means that the output is not present.
+func estimateTxSizeOutputs(
+	inputConfigurations []*signing.Configuration,
+	outputPkScriptSizes ...int) int {
+	outputCount := 0
+	outputsSize := 0
+	for _, pkScriptSize := range outputPkScriptSizes {
+		if pkScriptSize == 0 {
+			continue
+		}
+		outputCount++
+		outputsSize += outputSize(pkScriptSize)
 	}
 
 	const (
@@ -87,8 +117,7 @@ func estimateTxSize(
 
 	txWeight := nonWitness * (versionSize + lockTimeSize + wire.VarIntSerializeSize(uint64(len(inputConfigurations))) +
 		wire.VarIntSerializeSize(uint64(outputCount)) +
-		outputSize(outputPkScriptSize) +
-		outputSize(changePkScriptSize))
+		outputsSize)
 
 	isSegwitTx := false
 	for _, inputConfiguration := range inputConfigurations {
@@ -87,6 +87,13 @@ func signatureScript(
 			signature.SerializeCompact(),
 		}
 		return []byte{}, txWitness
+	case signing.ScriptTypeP2WSH:
+		txWitness := wire.TxWitness{[]byte{}}
+		for range address.AccountConfiguration.BitcoinMultisig.Threshold {
+			txWitness = append(txWitness, append(signature.SerializeDER(), byte(txscript.SigHashAll)))
+		}
+		txWitness = append(txWitness, address.WitnessScript)
+		return []byte{}, txWitness
 	default:
 		panic("Unrecognized address type.")
 	}
@@ -151,9 +158,16 @@ func testEstimateTxSize(
 func TestSigScriptWitnessSize(t *testing.T) {
 	sig := makeSig()
 
-	// Test all singlesig configurations.
+	// Test all singlesig configurations and some multisig configurations.
+	testAddresses := []*addresses.AccountAddress{
+		addressesTest.GetMultisigAddress(1, 2),
+		addressesTest.GetMultisigAddress(2, 3),
+		addressesTest.GetMultisigAddress(11, 15),
+	}
 	for _, scriptType := range scriptTypes {
-		address := addressesTest.GetAddress(scriptType)
+		testAddresses = append(testAddresses, addressesTest.GetAddress(scriptType))
+	}
+	for _, address := range testAddresses {
 		t.Run(address.AccountConfiguration.String(), func(t *testing.T) {
 			sigScriptSize, witnessSize := sigScriptWitnessSize(address.AccountConfiguration)
 			sigScript, witness := signatureScript(t, address, sig)
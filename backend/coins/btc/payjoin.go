@@ -0,0 +1,460 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// payjoinTimeout is the time the receiver has to respond with its proposal. BIP-78 recommends
+	// waiting at most one minute.
+	payjoinTimeout = time.Minute
+	// maxPayjoinResponseSize limits the size of the response of the receiver.
+	maxPayjoinResponseSize = 4 << 20
+	// maxWitnessItemSize limits the size of a witness item in a proposal of the receiver.
+	maxWitnessItemSize = 4000000
+)
+
+// parsePayjoinEndpoint parses the payjoin endpoint of a BIP-21 URI (`pj` parameter). As required by
+// BIP-78, the endpoint must use https unless it is an onion service. Endpoints of BIP-77 (async
+// payjoin), which carry the keys of the receiver in the fragment, are not supported.
+func parsePayjoinEndpoint(rawURL string) (*url.URL, error) {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if endpoint.Fragment != "" {
+		return nil, errp.New("BIP-77 payjoin endpoints are not supported")
+	}
+	isOnion := strings.HasSuffix(endpoint.Hostname(), ".onion")
+	if endpoint.Scheme != "https" && !(endpoint.Scheme == "http" && isOnion) {
+		return nil, errp.Newf("payjoin endpoint %q must use https", rawURL)
+	}
+	return endpoint, nil
+}
+
+// payjoinParams are the parameters sent to the receiver along with the original transaction, see
+// BIP-78.
+type payjoinParams struct {
+	// additionalFeeOutputIndex is the index of our change output, from which the receiver can deduct
+	// the fee for its additional inputs. It is -1 if there is no change.
+	additionalFeeOutputIndex int
+	// maxAdditionalFeeContribution is the maximum amount deducted from the change output.
+	maxAdditionalFeeContribution btcutil.Amount
+	// minFeeRate is the minimum fee rate of the proposal in sat/vB.
+	minFeeRate float64
+}
+
+// requestURL returns the endpoint with the parameters added to its query. Output substitution is
+// always disabled, so the receiver can not redirect the payment to a different address than the one
+// confirmed by the user.
+func (params payjoinParams) requestURL(endpoint *url.URL) string {
+	query := endpoint.Query()
+	query.Set("v", "1")
+	query.Set("disableoutputsubstitution", "true")
+	if params.additionalFeeOutputIndex >= 0 {
+		query.Set("additionalfeeoutputindex", strconv.Itoa(params.additionalFeeOutputIndex))
+		query.Set("maxadditionalfeecontribution", strconv.FormatInt(int64(params.maxAdditionalFeeContribution), 10))
+	}
+	if params.minFeeRate > 0 {
+		query.Set("minfeerate", strconv.FormatFloat(params.minFeeRate, 'f', -1, 64))
+	}
+	requestURL := *endpoint
+	requestURL.RawQuery = query.Encode()
+	return requestURL.String()
+}
+
+// payjoinOriginal is the original transaction of a payjoin, fully signed by us, so that it can be
+// broadcast if the payjoin fails.
+type payjoinOriginal struct {
+	tx              *wire.MsgTx
+	previousOutputs maketx.PreviousOutputs
+	// payeeIndex is the index of the output paying the receiver.
+	payeeIndex int
+	params     payjoinParams
+}
+
+func newPayjoinOriginal(txProposal *maketx.TxProposal, signedTx *wire.MsgTx) *payjoinOriginal {
+	original := &payjoinOriginal{
+		tx:              signedTx,
+		previousOutputs: txProposal.PreviousOutputs,
+		payeeIndex:      txProposal.OutIndex,
+		params:          payjoinParams{additionalFeeOutputIndex: -1},
+	}
+	feeRate := float64(original.fee()) / float64(mempool.GetTxVirtualSize(btcutil.NewTx(signedTx)))
+	original.params.minFeeRate = math.Floor(feeRate*100) / 100
+	if txProposal.ChangeAddress == nil {
+		return original
+	}
+	changeScript := txProposal.ChangeAddress.PubkeyScript()
+	for index, txOut := range signedTx.TxOut {
+		if bytes.Equal(txOut.PkScript, changeScript) {
+			original.params.additionalFeeOutputIndex = index
+			break
+		}
+	}
+	if original.params.additionalFeeOutputIndex >= 0 {
+		// The receiver is expected to add one input of the same type as ours.
+		inputAddress := txProposal.PreviousOutputs[signedTx.TxIn[0].PreviousOutPoint].Address
+		inputSize := maketx.EstimateInputSize(inputAddress.AccountConfiguration)
+		original.params.maxAdditionalFeeContribution = btcutil.Amount(math.Ceil(feeRate * float64(inputSize)))
+	}
+	return original
+}
+
+// fee returns the fee paid by the original transaction.
+func (original *payjoinOriginal) fee() btcutil.Amount {
+	var fee btcutil.Amount
+	for _, txIn := range original.tx.TxIn {
+		fee += btcutil.Amount(original.previousOutputs[txIn.PreviousOutPoint].TxOut.Value)
+	}
+	for _, txOut := range original.tx.TxOut {
+		fee -= btcutil.Amount(txOut.Value)
+	}
+	return fee
+}
+
+// psbt returns the original transaction as a finalized PSBT as sent to the receiver. Key origins
+// are not included.
+func (original *payjoinOriginal) psbt() (*psbt.Packet, error) {
+	unsignedTx := original.tx.Copy()
+	for _, txIn := range unsignedTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	packet, err := psbt.NewFromUnsignedTx(unsignedTx)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	for index, txIn := range original.tx.TxIn {
+		input := &packet.Inputs[index]
+		input.WitnessUtxo = original.previousOutputs[txIn.PreviousOutPoint].TxOut
+		input.FinalScriptSig = txIn.SignatureScript
+		if len(txIn.Witness) != 0 {
+			input.FinalScriptWitness, err = serializeWitness(txIn.Witness)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return packet, nil
+}
+
+// payjoinProposal is a proposal of the receiver which passed all checks.
+type payjoinProposal struct {
+	packet *psbt.Packet
+	// previousOutputs are the outputs spent by all inputs, including those of the receiver, which
+	// have no address.
+	previousOutputs maketx.PreviousOutputs
+	payeeIndex      int
+	// contribution is the amount deducted from our change to pay for the inputs of the receiver.
+	contribution btcutil.Amount
+}
+
+// check validates the proposal of the receiver following the sender checklist of BIP-78: our inputs
+// and outputs are unchanged except for the allowed fee contribution, the receiver's inputs are
+// finalized and of the same type as ours, and the fee and fee rate did not decrease. The fields of
+// the PSBT describing our inputs and outputs are cleared, so they can be filled in by us.
+func (original *payjoinOriginal) check(proposal *psbt.Packet) (*payjoinProposal, error) {
+	if err := proposal.SanityCheck(); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	tx := proposal.UnsignedTx
+	if tx.Version != original.tx.Version || tx.LockTime != original.tx.LockTime {
+		return nil, errp.New("payjoin proposal changed the transaction version or lock time")
+	}
+	originalInputs := make(map[wire.OutPoint]*wire.TxIn, len(original.tx.TxIn))
+	inputScriptClass := txscript.NonStandardTy
+	for index, txIn := range original.tx.TxIn {
+		originalInputs[txIn.PreviousOutPoint] = txIn
+		class := txscript.GetScriptClass(original.previousOutputs[txIn.PreviousOutPoint].TxOut.PkScript)
+		if index == 0 {
+			inputScriptClass = class
+		} else if class != inputScriptClass {
+			// Our inputs are of mixed types, so any type is fine for the receiver.
+			inputScriptClass = txscript.NonStandardTy
+		}
+	}
+
+	previousOutputs := make(maketx.PreviousOutputs, len(tx.TxIn))
+	// finalTx has the signatures of the original transaction for our inputs, to estimate the size.
+	finalTx := tx.Copy()
+	ourInputs := 0
+	var inputsSum btcutil.Amount
+	for index, txIn := range tx.TxIn {
+		if _, ok := previousOutputs[txIn.PreviousOutPoint]; ok {
+			return nil, errp.Newf("payjoin proposal input %d is spent twice", index)
+		}
+		input := &proposal.Inputs[index]
+		if originalIn, ok := originalInputs[txIn.PreviousOutPoint]; ok {
+			if txIn.Sequence != originalIn.Sequence {
+				return nil, errp.Newf("payjoin proposal changed the sequence of our input %d", index)
+			}
+			if len(input.FinalScriptSig) != 0 || len(input.FinalScriptWitness) != 0 ||
+				len(input.PartialSigs) != 0 || len(input.TaprootKeySpendSig) != 0 {
+				return nil, errp.Newf("payjoin proposal input %d must not be signed", index)
+			}
+			previousOutput := original.previousOutputs[txIn.PreviousOutPoint]
+			previousOutputs[txIn.PreviousOutPoint] = previousOutput
+			inputsSum += btcutil.Amount(previousOutput.TxOut.Value)
+			finalTx.TxIn[index].SignatureScript = originalIn.SignatureScript
+			finalTx.TxIn[index].Witness = originalIn.Witness
+			*input = psbt.PInput{}
+			ourInputs++
+			continue
+		}
+
+		// Input of the receiver.
+		if len(input.FinalScriptSig) == 0 && len(input.FinalScriptWitness) == 0 {
+			return nil, errp.Newf("payjoin proposal input %d of the receiver is not finalized", index)
+		}
+		txOut := input.WitnessUtxo
+		if input.NonWitnessUtxo != nil {
+			outPoint := txIn.PreviousOutPoint
+			if input.NonWitnessUtxo.TxHash() != outPoint.Hash ||
+				int(outPoint.Index) >= len(input.NonWitnessUtxo.TxOut) {
+				return nil, errp.Newf("payjoin proposal input %d has an unexpected previous transaction", index)
+			}
+			txOut = input.NonWitnessUtxo.TxOut[outPoint.Index]
+		}
+		if txOut == nil {
+			return nil, errp.Newf("payjoin proposal input %d lacks the previous output", index)
+		}
+		if inputScriptClass != txscript.NonStandardTy &&
+			txscript.GetScriptClass(txOut.PkScript) != inputScriptClass {
+			return nil, errp.Newf("payjoin proposal input %d is of a different type than ours", index)
+		}
+		previousOutputs[txIn.PreviousOutPoint] = maketx.UTXO{TxOut: txOut}
+		inputsSum += btcutil.Amount(txOut.Value)
+		finalTx.TxIn[index].SignatureScript = input.FinalScriptSig
+		if len(input.FinalScriptWitness) != 0 {
+			witness, err := parseWitness(input.FinalScriptWitness)
+			if err != nil {
+				return nil, err
+			}
+			finalTx.TxIn[index].Witness = witness
+		}
+	}
+	if ourInputs != len(original.tx.TxIn) {
+		return nil, errp.New("payjoin proposal is missing some of our inputs")
+	}
+	if ourInputs == len(tx.TxIn) {
+		return nil, errp.New("payjoin proposal does not contain inputs of the receiver")
+	}
+
+	// Our outputs can be reordered, but must all be present. Additional outputs belong to the
+	// receiver.
+	used := make([]bool, len(tx.TxOut))
+	result := &payjoinProposal{
+		packet:          proposal,
+		previousOutputs: previousOutputs,
+		payeeIndex:      -1,
+	}
+	for index, originalOut := range original.tx.TxOut {
+		found := -1
+		for proposalIndex, txOut := range tx.TxOut {
+			if !used[proposalIndex] && bytes.Equal(txOut.PkScript, originalOut.PkScript) {
+				found = proposalIndex
+				break
+			}
+		}
+		if found < 0 {
+			return nil, errp.Newf("payjoin proposal is missing output %d", index)
+		}
+		used[found] = true
+		value := tx.TxOut[found].Value
+		switch index {
+		case original.payeeIndex:
+			if value < originalOut.Value {
+				return nil, errp.New("payjoin proposal decreased the payment")
+			}
+			result.payeeIndex = found
+		case original.params.additionalFeeOutputIndex:
+			if value > originalOut.Value {
+				return nil, errp.New("payjoin proposal increased our change")
+			}
+			result.contribution = btcutil.Amount(originalOut.Value - value)
+		default:
+			if value != originalOut.Value {
+				return nil, errp.Newf("payjoin proposal changed output %d", index)
+			}
+		}
+	}
+	for index := range proposal.Outputs {
+		proposal.Outputs[index] = psbt.POutput{}
+	}
+	proposal.XPubs = nil
+
+	var outputsSum btcutil.Amount
+	for _, txOut := range tx.TxOut {
+		outputsSum += btcutil.Amount(txOut.Value)
+	}
+	fee := inputsSum - outputsSum
+	originalFee := original.fee()
+	if fee < originalFee {
+		return nil, errp.New("payjoin proposal decreased the fee")
+	}
+	if result.contribution > original.params.maxAdditionalFeeContribution ||
+		result.contribution > fee-originalFee {
+		return nil, errp.New("payjoin proposal takes too much of our change")
+	}
+	vsize := mempool.GetTxVirtualSize(btcutil.NewTx(finalTx))
+	if float64(fee)/float64(vsize) < original.params.minFeeRate {
+		return nil, errp.New("payjoin proposal fee rate is too low")
+	}
+	return result, nil
+}
+
+// requestPayjoin sends the original PSBT to the receiver and returns its proposal.
+func requestPayjoin(
+	httpClient *http.Client,
+	endpoint *url.URL,
+	original *psbt.Packet,
+	params payjoinParams,
+) (*psbt.Packet, error) {
+	body, err := original.B64Encode()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), payjoinTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, params.requestURL(endpoint), strings.NewReader(body))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	request.Header.Set("Content-Type", "text/plain")
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, maxPayjoinResponseSize))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		var receiverError struct {
+			ErrorCode string `json:"errorCode"`
+			Message   string `json:"message"`
+		}
+		if json.Unmarshal(responseBody, &receiverError) == nil && receiverError.ErrorCode != "" {
+			return nil, errp.Newf("payjoin receiver error %s: %s", receiverError.ErrorCode, receiverError.Message)
+		}
+		return nil, errp.Newf("payjoin receiver responded with %s", response.Status)
+	}
+	proposal, err := psbt.NewFromRawBytes(bytes.NewReader(bytes.TrimSpace(responseBody)), true)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return proposal, nil
+}
+
+// payjoin sends the signed original transaction to the payjoin endpoint of the tx proposal, checks
+// the proposal of the receiver and signs it. The returned transaction is ready to be broadcast.
+func (account *Account) payjoin(txProposal *maketx.TxProposal, signedTx *wire.MsgTx) (*wire.MsgTx, error) {
+	endpoint, err := parsePayjoinEndpoint(txProposal.PayjoinURL)
+	if err != nil {
+		return nil, err
+	}
+	if account.httpClient == nil {
+		return nil, errp.New("no HTTP client to reach the payjoin receiver")
+	}
+	original := newPayjoinOriginal(txProposal, signedTx)
+	originalPSBT, err := original.psbt()
+	if err != nil {
+		return nil, err
+	}
+	account.log.Info("Sending the original transaction to the payjoin receiver")
+	proposalPSBT, err := requestPayjoin(account.httpClient, endpoint, originalPSBT, original.params)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := original.check(proposalPSBT)
+	if err != nil {
+		return nil, err
+	}
+	payjoinTxProposal := *txProposal
+	payjoinTxProposal.Psbt = proposal.packet
+	payjoinTxProposal.PreviousOutputs = proposal.previousOutputs
+	payjoinTxProposal.Fee = txProposal.Fee + proposal.contribution
+	payjoinTxProposal.OutIndex = proposal.payeeIndex
+	payjoinTxProposal.OutIndices = []int{proposal.payeeIndex}
+	payjoinTxProposal.PayjoinURL = ""
+	account.log.WithField("contribution", proposal.contribution).Info("Signing the payjoin proposal")
+	return account.signTransaction(&payjoinTxProposal, account.coin.Blockchain().TransactionGet)
+}
+
+// payjoinOrOriginal returns the signed payjoin transaction if the receiver responds with a valid
+// proposal, and the original transaction otherwise. As required by BIP-78, the original transaction
+// is broadcast if the payjoin fails. This includes the user aborting to sign the proposal, as the
+// receiver has the signed original transaction and may broadcast it anyway.
+func (account *Account) payjoinOrOriginal(txProposal *maketx.TxProposal, signedTx *wire.MsgTx) *wire.MsgTx {
+	keystore, err := account.Config().ConnectKeystore()
+	if err != nil {
+		account.log.WithError(err).Warning("Payjoin not used")
+		return signedTx
+	}
+	if !keystore.Features().SupportsForeignInputs {
+		account.log.Info("Payjoin not used, the keystore can't sign inputs of the receiver")
+		return signedTx
+	}
+	payjoinTx, err := account.payjoin(txProposal, signedTx)
+	if err != nil {
+		account.log.WithError(err).Warning("Payjoin failed, broadcasting the original transaction")
+		return signedTx
+	}
+	return payjoinTx
+}
+
+// serializeWitness serializes the witness as in the PSBT_IN_FINAL_SCRIPTWITNESS field.
+func serializeWitness(witness wire.TxWitness) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// parseWitness parses the PSBT_IN_FINAL_SCRIPTWITNESS field.
+func parseWitness(serialized []byte) (wire.TxWitness, error) {
+	reader := bytes.NewReader(serialized)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if count > uint64(len(serialized)) {
+		return nil, errp.New("invalid witness")
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(reader, 0, maxWitnessItemSize, "witness item")
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return witness, nil
+}
@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package btc
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
+	keystoremock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayjoinEndpoint(t *testing.T) {
+	_, err := parsePayjoinEndpoint("https://example.com/pj?foo=bar")
+	require.NoError(t, err)
+	_, err = parsePayjoinEndpoint("http://abcdef.onion/pj")
+	require.NoError(t, err)
+	_, err = parsePayjoinEndpoint("http://example.com/pj")
+	require.Error(t, err)
+	// BIP-77
+	_, err = parsePayjoinEndpoint("https://payjo.in/TXJCGKTKXLUUZ#RK1Q0DRC4WQ9+OH1QYPM59NK2LXXS4890SUAXXYT25Z2VAPHP0X7YEYCJXGWAG6UG9ZU6NQ")
+	require.Error(t, err)
+}
+
+func TestPayjoinParamsRequestURL(t *testing.T) {
+	endpoint, err := url.Parse("https://example.com/pj?foo=bar")
+	require.NoError(t, err)
+	params := payjoinParams{
+		additionalFeeOutputIndex:     1,
+		maxAdditionalFeeContribution: 680,
+		minFeeRate:                   10.5,
+	}
+	require.Equal(t,
+		"https://example.com/pj?additionalfeeoutputindex=1&disableoutputsubstitution=true&foo=bar&maxadditionalfeecontribution=680&minfeerate=10.5&v=1",
+		params.requestURL(endpoint))
+	params.additionalFeeOutputIndex = -1
+	params.minFeeRate = 0
+	require.Equal(t,
+		"https://example.com/pj?disableoutputsubstitution=true&foo=bar&v=1",
+		params.requestURL(endpoint))
+}
+
+// p2wpkhWitness is a witness of the size of a P2WPKH spend.
+var p2wpkhWitness = wire.TxWitness{bytes.Repeat([]byte{1}, 72), bytes.Repeat([]byte{2}, 33)}
+
+// testPayjoinOriginal returns a signed original transaction paying 100000 sat, with change.
+func testPayjoinOriginal(t *testing.T) (*maketx.TxProposal, *wire.MsgTx) {
+	t.Helper()
+	address := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	payee := addressesTest.GetAddress(signing.ScriptTypeP2TR)
+	outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("ours")), Index: 0}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&outPoint, nil, p2wpkhWitness))
+	tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 2
+	tx.AddTxOut(wire.NewTxOut(100000, payee.PubkeyScript()))
+	tx.AddTxOut(wire.NewTxOut(397000, address.PubkeyScript()))
+	txProposal := &maketx.TxProposal{
+		Fee:           3000,
+		ChangeAddress: address,
+		PreviousOutputs: maketx.PreviousOutputs{
+			outPoint: {TxOut: wire.NewTxOut(500000, address.PubkeyScript()), Address: address},
+		},
+		OutIndex: 0,
+	}
+	return txProposal, tx
+}
+
+// testPayjoinProposal creates the proposal of a receiver adding an input of 50000 sat to the
+// payment, deducting the given fee contribution from the change.
+func testPayjoinProposal(t *testing.T, original *psbt.Packet, contribution int64) *psbt.Packet {
+	t.Helper()
+	tx := original.UnsignedTx.Copy()
+	receiverOutPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("receiver")), Index: 3}
+	tx.TxIn = append([]*wire.TxIn{wire.NewTxIn(&receiverOutPoint, nil, nil)}, tx.TxIn...)
+	tx.TxOut[0].Value += 50000
+	tx.TxOut[1].Value -= contribution
+	// Outputs are shuffled.
+	tx.TxOut[0], tx.TxOut[1] = tx.TxOut[1], tx.TxOut[0]
+	proposal, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+	receiverInput := &proposal.Inputs[0]
+	receiverInput.WitnessUtxo = wire.NewTxOut(50000, append([]byte{0x00, 0x14}, bytes.Repeat([]byte{3}, 20)...))
+	receiverInput.FinalScriptWitness, err = serializeWitness(p2wpkhWitness)
+	require.NoError(t, err)
+	// The receiver keeps the previous outputs of our inputs.
+	proposal.Inputs[1].WitnessUtxo = original.Inputs[0].WitnessUtxo
+	return proposal
+}
+
+func TestPayjoin(t *testing.T) {
+	txProposal, signedTx := testPayjoinOriginal(t)
+	original := newPayjoinOriginal(txProposal, signedTx)
+	require.Equal(t, btcutil.Amount(3000), original.fee())
+	require.Equal(t, 1, original.params.additionalFeeOutputIndex)
+	// 3000 sat for 153 vB.
+	require.Equal(t, 19.6, original.params.minFeeRate)
+	// 68 vB for the additional P2WPKH input.
+	require.Equal(t, btcutil.Amount(1334), original.params.maxAdditionalFeeContribution)
+
+	originalPSBT, err := original.psbt()
+	require.NoError(t, err)
+	require.NoError(t, originalPSBT.SanityCheck())
+	extracted, err := psbt.Extract(originalPSBT)
+	require.NoError(t, err)
+	require.Equal(t, signedTx.TxHash(), extracted.TxHash())
+	require.Equal(t, signedTx.WitnessHash(), extracted.WitnessHash())
+
+	var receiverError bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "1", r.URL.Query().Get("v"))
+		require.Equal(t, "true", r.URL.Query().Get("disableoutputsubstitution"))
+		require.Equal(t, "1334", r.URL.Query().Get("maxadditionalfeecontribution"))
+		if receiverError {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errorCode": "unavailable", "message": "The payjoin endpoint is not available for now."}`))
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		originalPSBT, err := psbt.NewFromRawBytes(bytes.NewReader(body), true)
+		require.NoError(t, err)
+		proposal := testPayjoinProposal(t, originalPSBT, 1334)
+		encoded, err := proposal.B64Encode()
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(encoded))
+	}))
+	defer server.Close()
+	endpoint, err := parsePayjoinEndpoint(server.URL + "/pj")
+	require.NoError(t, err)
+
+	proposalPSBT, err := requestPayjoin(server.Client(), endpoint, originalPSBT, original.params)
+	require.NoError(t, err)
+	proposal, err := original.check(proposalPSBT)
+	require.NoError(t, err)
+	require.Equal(t, 1, proposal.payeeIndex)
+	require.Equal(t, btcutil.Amount(1334), proposal.contribution)
+	require.Len(t, proposal.previousOutputs, 2)
+	// Our input is left to be filled in and signed by us.
+	require.Equal(t, psbt.PInput{}, proposal.packet.Inputs[1])
+
+	receiverError = true
+	_, err = requestPayjoin(server.Client(), endpoint, originalPSBT, original.params)
+	require.ErrorContains(t, err, "unavailable")
+}
+
+func TestPayjoinOrOriginal(t *testing.T) {
+	txProposal, signedTx := testPayjoinOriginal(t)
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	txProposal.PayjoinURL = server.URL + "/pj"
+
+	account := mockAccount(t, nil)
+	account.httpClient = server.Client()
+	features := &keystore.Features{}
+	account.Config().ConnectKeystore = func() (keystore.Keystore, error) {
+		return &keystoremock.KeystoreMock{
+			FeaturesFunc: func() *keystore.Features { return features },
+		}, nil
+	}
+
+	// Keystores like the BitBox02 can't sign the proposal, so the receiver is not contacted.
+	require.Equal(t, signedTx, account.payjoinOrOriginal(txProposal, signedTx))
+	require.Zero(t, requests)
+
+	// The original transaction is broadcast if the payjoin fails.
+	features.SupportsForeignInputs = true
+	require.Equal(t, signedTx, account.payjoinOrOriginal(txProposal, signedTx))
+	require.Equal(t, 1, requests)
+}
+
+func TestPayjoinCheck(t *testing.T) {
+	txProposal, signedTx := testPayjoinOriginal(t)
+	original := newPayjoinOriginal(txProposal, signedTx)
+	originalPSBT, err := original.psbt()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		modify func(*psbt.Packet)
+	}{
+		{"payment decreased", func(p *psbt.Packet) { p.UnsignedTx.TxOut[1].Value -= 50001 }},
+		{"change increased", func(p *psbt.Packet) { p.UnsignedTx.TxOut[0].Value += 2000 }},
+		{"output substituted", func(p *psbt.Packet) {
+			p.UnsignedTx.TxOut[1].PkScript = p.Inputs[0].WitnessUtxo.PkScript
+		}},
+		{"sequence changed", func(p *psbt.Packet) { p.UnsignedTx.TxIn[1].Sequence = wire.MaxTxInSequenceNum }},
+		{"lock time changed", func(p *psbt.Packet) { p.UnsignedTx.LockTime = 800000 }},
+		{"our input signed", func(p *psbt.Packet) { p.Inputs[1].FinalScriptWitness = p.Inputs[0].FinalScriptWitness }},
+		{"receiver input not finalized", func(p *psbt.Packet) { p.Inputs[0].FinalScriptWitness = nil }},
+		{"receiver input of another type", func(p *psbt.Packet) {
+			p.Inputs[0].WitnessUtxo.PkScript = addressesTest.GetAddress(signing.ScriptTypeP2TR).PubkeyScript()
+		}},
+		{"no receiver input", func(p *psbt.Packet) {
+			p.UnsignedTx.TxIn = p.UnsignedTx.TxIn[1:]
+			p.Inputs = p.Inputs[1:]
+			p.UnsignedTx.TxOut[1].Value -= 50000
+		}},
+		{"our input missing", func(p *psbt.Packet) {
+			p.UnsignedTx.TxIn = p.UnsignedTx.TxIn[:1]
+			p.Inputs = p.Inputs[:1]
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proposal := testPayjoinProposal(t, originalPSBT, 1334)
+			test.modify(proposal)
+			_, err := original.check(proposal)
+			require.Error(t, err)
+		})
+	}
+
+	// Taking more than the allowed contribution from our change.
+	_, err = original.check(testPayjoinProposal(t, originalPSBT, 1335))
+	require.Error(t, err)
+	// The receiver not paying for its input lowers the fee rate.
+	_, err = original.check(testPayjoinProposal(t, originalPSBT, 0))
+	require.Error(t, err)
+	// The receiver paying for its input itself is fine.
+	proposal := testPayjoinProposal(t, originalPSBT, 0)
+	proposal.UnsignedTx.TxOut[1].Value -= 1334
+	checked, err := original.check(proposal)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(0), checked.contribution)
+}
+
+func TestPayjoinWitnessSerialization(t *testing.T) {
+	serialized, err := serializeWitness(p2wpkhWitness)
+	require.NoError(t, err)
+	witness, err := parseWitness(serialized)
+	require.NoError(t, err)
+	require.Equal(t, p2wpkhWitness, witness)
+	_, err = parseWitness([]byte{0x05, 0x01})
+	require.Error(t, err)
+}
@@ -0,0 +1,373 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package btc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// psbtMagic is the prefix of a binary serialized PSBT, see BIP-174.
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// ParsePSBT decodes a PSBT serialized in binary or in base64.
+func ParsePSBT(data []byte) (*psbt.Packet, error) {
+	if bytes.HasPrefix(data, psbtMagic) {
+		packet, err := psbt.NewFromRawBytes(bytes.NewReader(data), false)
+		return packet, errp.WithStack(err)
+	}
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(bytes.TrimSpace(data)), true)
+	return packet, errp.WithStack(err)
+}
+
+// psbtInputsSigned returns true if all inputs of the PSBT are finalized or carry a signature, in
+// which case no more signatures from the keystore are needed. Multisig inputs need as many
+// signatures as the threshold of their witness script.
+func psbtInputsSigned(packet *psbt.Packet) bool {
+	for _, input := range packet.Inputs {
+		if len(input.FinalScriptSig) != 0 || len(input.FinalScriptWitness) != 0 ||
+			len(input.TaprootKeySpendSig) != 0 {
+			continue
+		}
+		if len(input.PartialSigs) < requiredPSBTSignatures(input) {
+			return false
+		}
+	}
+	return true
+}
+
+// requiredPSBTSignatures returns the number of signatures needed to spend the input, which is the
+// threshold for multisig inputs and 1 otherwise.
+func requiredPSBTSignatures(input psbt.PInput) int {
+	if len(input.WitnessScript) != 0 &&
+		txscript.GetScriptClass(input.WitnessScript) == txscript.MultiSigTy {
+		_, threshold, err := txscript.CalcMultiSigStats(input.WitnessScript)
+		if err == nil {
+			return threshold
+		}
+	}
+	return 1
+}
+
+// psbtSigHashes returns the sighash midstate of the PSBT, or nil if a previous output is missing.
+func psbtSigHashes(packet *psbt.Packet) *txscript.TxSigHashes {
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(packet.Inputs))
+	for index, input := range packet.Inputs {
+		outPoint := packet.UnsignedTx.TxIn[index].PreviousOutPoint
+		switch {
+		case input.WitnessUtxo != nil:
+			prevOuts[outPoint] = input.WitnessUtxo
+		case input.NonWitnessUtxo != nil && int(outPoint.Index) < len(input.NonWitnessUtxo.TxOut):
+			prevOuts[outPoint] = input.NonWitnessUtxo.TxOut[outPoint.Index]
+		default:
+			return nil
+		}
+	}
+	return txscript.NewTxSigHashes(packet.UnsignedTx, txscript.NewMultiPrevOutFetcher(prevOuts))
+}
+
+// validMultisigSignatures returns the partial signatures of a multisig input which belong to a key
+// of its witness script and verify, ordered by the position of the key in the script.
+func validMultisigSignatures(
+	packet *psbt.Packet, index int, sigHashes *txscript.TxSigHashes) []*psbt.PartialSig {
+	input := packet.Inputs[index]
+	pubKeys, err := txscript.PushedData(input.WitnessScript)
+	if err != nil || input.WitnessUtxo == nil {
+		return nil
+	}
+	var result []*psbt.PartialSig
+	for _, pubKey := range pubKeys {
+		for _, partialSig := range input.PartialSigs {
+			if !bytes.Equal(partialSig.PubKey, pubKey) || len(partialSig.Signature) == 0 {
+				continue
+			}
+			publicKey, err := btcec.ParsePubKey(pubKey)
+			if err != nil {
+				continue
+			}
+			derSignature := partialSig.Signature[:len(partialSig.Signature)-1]
+			hashType := txscript.SigHashType(partialSig.Signature[len(partialSig.Signature)-1])
+			signature, err := ecdsa.ParseDERSignature(derSignature)
+			if err != nil {
+				continue
+			}
+			sigHash, err := txscript.CalcWitnessSigHash(input.WitnessScript, sigHashes, hashType,
+				packet.UnsignedTx, index, input.WitnessUtxo.Value)
+			if err != nil || !signature.Verify(sigHash, publicKey) {
+				continue
+			}
+			result = append(result, partialSig)
+			break
+		}
+	}
+	return result
+}
+
+// trimMultisigSignatures drops superfluous partial signatures of multisig inputs, as an input can
+// only be finalized with exactly as many signatures as the threshold. Signatures of keys not in the
+// witness script and invalid signatures are dropped first.
+func trimMultisigSignatures(packet *psbt.Packet) {
+	var sigHashes *txscript.TxSigHashes
+	for index := range packet.Inputs {
+		input := &packet.Inputs[index]
+		if len(input.WitnessScript) == 0 ||
+			txscript.GetScriptClass(input.WitnessScript) != txscript.MultiSigTy {
+			continue
+		}
+		required := requiredPSBTSignatures(*input)
+		if len(input.PartialSigs) <= required {
+			continue
+		}
+		if sigHashes == nil {
+			if sigHashes = psbtSigHashes(packet); sigHashes == nil {
+				return
+			}
+		}
+		valid := validMultisigSignatures(packet, index, sigHashes)
+		if len(valid) > required {
+			valid = valid[:required]
+		}
+		input.PartialSigs = valid
+	}
+}
+
+// checkPSBTKeyOrigins verifies that all key origins in the PSBT input or output claiming to come
+// from our root fingerprint match the given address. This prevents a PSBT from tricking us into
+// treating a foreign output as our change, or into signing with unexpected keys.
+func checkPSBTKeyOrigins(
+	rootFingerprint uint32,
+	bip32Derivations []*psbt.Bip32Derivation,
+	taprootBip32Derivations []*psbt.TaprootBip32Derivation,
+	address *addresses.AccountAddress,
+) error {
+	for _, derivation := range bip32Derivations {
+		if derivation.MasterKeyFingerprint != rootFingerprint {
+			continue
+		}
+		if address == nil ||
+			!bytes.Equal(derivation.PubKey, address.PublicKey.SerializeCompressed()) ||
+			!slices.Equal(derivation.Bip32Path, address.AbsoluteKeypath().ToUInt32()) {
+			return errp.New("PSBT key origin does not match the account")
+		}
+	}
+	for _, derivation := range taprootBip32Derivations {
+		if derivation.MasterKeyFingerprint != rootFingerprint {
+			continue
+		}
+		if address == nil ||
+			!bytes.Equal(derivation.XOnlyPubKey, schnorr.SerializePubKey(address.PublicKey)) ||
+			!slices.Equal(derivation.Bip32Path, address.AbsoluteKeypath().ToUInt32()) {
+			return errp.New("PSBT taproot key origin does not match the account")
+		}
+	}
+	return nil
+}
+
+// newProposedTransaction wraps the tx proposal with everything needed to fill in and sign its PSBT.
+func (account *Account) newProposedTransaction(txProposal *maketx.TxProposal) *ProposedTransaction {
+	return &ProposedTransaction{
+		TXProposal:                   txProposal,
+		AccountSigningConfigurations: account.subaccounts.signingConfigurations(),
+		GetKeystoreAddress:           account.getAddressFromSameKeystore,
+		GetPrevTx:                    account.coin.Blockchain().TransactionGet,
+		FormatUnit:                   account.coin.formatUnit,
+	}
+}
+
+// TxProposalPSBT returns the unsigned PSBT of the active tx proposal in binary serialization, so it
+// can be signed by an external signer. Key origins and the previous outputs are included. For
+// non-taproot inputs, the previous transactions are included as well, as many signers require
+// them.
+func (account *Account) TxProposalPSBT() ([]byte, error) {
+	defer account.activeTxProposalLock.Lock()()
+	txProposal := account.activeTxProposal
+	if txProposal == nil {
+		return nil, errp.New("No active tx proposal")
+	}
+	if txProposal.SilentPaymentAddress != "" {
+		// The silent payment output is only computed by the keystore when signing.
+		return nil, errp.New("Cannot export a silent payment transaction as a PSBT")
+	}
+	proposedTransaction := account.newProposedTransaction(txProposal)
+	if err := proposedTransaction.Update(); err != nil {
+		return nil, err
+	}
+	updater, err := psbt.NewUpdater(txProposal.Psbt)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	for index, txIn := range txProposal.Psbt.UnsignedTx.TxIn {
+		prevOut := txProposal.PreviousOutputs[txIn.PreviousOutPoint]
+		if !psbtInputNeedsPrevTx(prevOut, &txProposal.Psbt.Inputs[index]) {
+			continue
+		}
+		prevTx, err := proposedTransaction.GetPrevTx(txIn.PreviousOutPoint.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if err := updater.AddInNonWitnessUtxo(prevTx, index); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	var result bytes.Buffer
+	if err := txProposal.Psbt.Serialize(&result); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return result.Bytes(), nil
+}
+
+// psbtInputNeedsPrevTx returns true if the previous transaction needs to be added to the PSBT
+// input, which is the case for all inputs except taproot inputs and inputs which already include
+// it. The previous output is looked up in the PSBT input if it does not belong to the account, e.g.
+// for the inputs of the receiver of a payjoin transaction.
+func psbtInputNeedsPrevTx(prevOut maketx.UTXO, input *psbt.PInput) bool {
+	if input.NonWitnessUtxo != nil {
+		return false
+	}
+	if prevOut.Address != nil {
+		return prevOut.Address.AccountConfiguration.ScriptType() != signing.ScriptTypeP2TR
+	}
+	switch {
+	case prevOut.TxOut != nil:
+		return !txscript.IsPayToTaproot(prevOut.TxOut.PkScript)
+	case input.WitnessUtxo != nil:
+		return !txscript.IsPayToTaproot(input.WitnessUtxo.PkScript)
+	default:
+		return true
+	}
+}
+
+// newTxFromPSBT creates a tx proposal from a PSBT. All inputs must spend unspent outputs of the
+// account. Key origins in the PSBT belonging to our keystore are validated against our signing
+// configurations.
+func (account *Account) newTxFromPSBT(packet *psbt.Packet) (*maketx.TxProposal, error) {
+	if err := packet.SanityCheck(); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if !account.Synced() {
+		return nil, accounts.ErrSyncInProgress
+	}
+	rootFingerprint, err := account.subaccounts.signingConfigurations().RootFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	rootFingerprintUint32 := binary.LittleEndian.Uint32(rootFingerprint)
+	spendableOutputs, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return nil, err
+	}
+
+	unsignedTx := packet.UnsignedTx
+	previousOutputs := make(maketx.PreviousOutputs, len(unsignedTx.TxIn))
+	inputsSum := btcutil.Amount(0)
+	for index, txIn := range unsignedTx.TxIn {
+		spendableOutput, ok := spendableOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.Newf("PSBT input %d does not spend an unspent output of the account", index)
+		}
+		if _, ok := previousOutputs[txIn.PreviousOutPoint]; ok {
+			return nil, errp.Newf("PSBT input %d is spent twice", index)
+		}
+		input := packet.Inputs[index]
+		if input.WitnessUtxo != nil && !psbt.TxOutsEqual(input.WitnessUtxo, spendableOutput.TxOut) {
+			return nil, errp.Newf("PSBT input %d has an unexpected previous output", index)
+		}
+		if input.NonWitnessUtxo != nil && input.NonWitnessUtxo.TxHash() != txIn.PreviousOutPoint.Hash {
+			return nil, errp.Newf("PSBT input %d has an unexpected previous transaction", index)
+		}
+		if input.SighashType != 0 &&
+			input.SighashType != txscript.SigHashAll &&
+			input.SighashType != txscript.SigHashDefault {
+			return nil, errp.Newf("PSBT input %d uses an unsupported sighash type", index)
+		}
+		address := account.AddressByID(addresses.NewAddressID(spendableOutput.TxOut.PkScript))
+		if err := checkPSBTKeyOrigins(
+			rootFingerprintUint32, input.Bip32Derivation, input.TaprootBip32Derivation, address); err != nil {
+			return nil, err
+		}
+		previousOutputs[txIn.PreviousOutPoint] = maketx.UTXO{
+			TxOut:   spendableOutput.TxOut,
+			Address: address,
+		}
+		inputsSum += btcutil.Amount(spendableOutput.TxOut.Value)
+	}
+
+	txProposal := &maketx.TxProposal{
+		Coin:            account.coin,
+		PreviousOutputs: previousOutputs,
+		OutIndex:        -1,
+		Psbt:            packet,
+	}
+	outputsSum := btcutil.Amount(0)
+	for index, txOut := range unsignedTx.TxOut {
+		outputsSum += btcutil.Amount(txOut.Value)
+		addressID := addresses.NewAddressID(txOut.PkScript)
+		keystoreAddress, err := account.getAddressFromSameKeystore(account.coin.Code(), addressID)
+		if err != nil {
+			return nil, err
+		}
+		output := packet.Outputs[index]
+		if err := checkPSBTKeyOrigins(
+			rootFingerprintUint32, output.Bip32Derivation, output.TaprootBip32Derivation, keystoreAddress); err != nil {
+			return nil, err
+		}
+		if txProposal.ChangeAddress == nil && account.IsChange(addressID) {
+			txProposal.ChangeAddress = account.AddressByID(addressID)
+			continue
+		}
+		if txProposal.OutIndex == -1 {
+			txProposal.OutIndex = index
+		}
+		txProposal.Amount += btcutil.Amount(txOut.Value)
+	}
+	if txProposal.OutIndex == -1 {
+		// Only change outputs, e.g. when consolidating coins.
+		txProposal.OutIndex = 0
+	}
+	if outputsSum > inputsSum {
+		return nil, errp.New("PSBT outputs exceed its inputs")
+	}
+	txProposal.Fee = inputsSum - outputsSum
+	return txProposal, nil
+}
+
+// ImportPSBT creates a tx proposal from a PSBT given in binary or base64 serialization, for
+// example one exported with TxProposalPSBT() and signed externally, or one created by another
+// wallet spending the coins of this account. If all inputs of the PSBT are already signed, the
+// keystore is not asked to sign. Like TxProposal(), the proposal is stored and can be finalized and
+// sent with SendTx().
+func (account *Account) ImportPSBT(data []byte) (coin.Amount, coin.Amount, coin.Amount, error) {
+	defer account.activeTxProposalLock.Lock()()
+
+	account.log.Debug("Importing PSBT")
+	packet, err := ParsePSBT(data)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+	txProposal, err := account.newTxFromPSBT(packet)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+
+	account.activeTxProposal = txProposal
+
+	account.log.WithField("fee", txProposal.Fee).Debug("Returning fee")
+	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		coin.NewAmountFromInt64(int64(txProposal.Total())), nil
+}
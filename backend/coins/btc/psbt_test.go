@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	addressesTest "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func testPSBT(t *testing.T) *psbt.Packet {
+	t.Helper()
+	address := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("prev")), Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, address.PubkeyScript()))
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+	return packet
+}
+
+func TestParsePSBT(t *testing.T) {
+	packet := testPSBT(t)
+
+	var serialized bytes.Buffer
+	require.NoError(t, packet.Serialize(&serialized))
+	parsed, err := ParsePSBT(serialized.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, packet.UnsignedTx.TxHash(), parsed.UnsignedTx.TxHash())
+
+	encoded, err := packet.B64Encode()
+	require.NoError(t, err)
+	parsed, err = ParsePSBT([]byte(" " + encoded + "\n"))
+	require.NoError(t, err)
+	require.Equal(t, packet.UnsignedTx.TxHash(), parsed.UnsignedTx.TxHash())
+
+	_, err = ParsePSBT([]byte("not a psbt"))
+	require.Error(t, err)
+}
+
+func TestPSBTInputsSigned(t *testing.T) {
+	packet := testPSBT(t)
+	require.False(t, psbtInputsSigned(packet))
+	packet.Inputs[0].PartialSigs = []*psbt.PartialSig{{PubKey: []byte{1}, Signature: []byte{2}}}
+	require.True(t, psbtInputsSigned(packet))
+	packet.Inputs[0].PartialSigs = nil
+	packet.Inputs[0].TaprootKeySpendSig = []byte{1}
+	require.True(t, psbtInputsSigned(packet))
+	packet.Inputs[0].TaprootKeySpendSig = nil
+	packet.Inputs[0].FinalScriptWitness = []byte{1}
+	require.True(t, psbtInputsSigned(packet))
+
+	// A 2-of-3 multisig input needs two signatures.
+	packet.Inputs[0].FinalScriptWitness = nil
+	packet.Inputs[0].WitnessScript = addressesTest.GetMultisigAddress(2, 3).WitnessScript
+	packet.Inputs[0].PartialSigs = []*psbt.PartialSig{{PubKey: []byte{1}, Signature: []byte{2}}}
+	require.False(t, psbtInputsSigned(packet))
+	packet.Inputs[0].PartialSigs = append(
+		packet.Inputs[0].PartialSigs, &psbt.PartialSig{PubKey: []byte{3}, Signature: []byte{4}})
+	require.True(t, psbtInputsSigned(packet))
+}
+
+func TestPSBTInputNeedsPrevTx(t *testing.T) {
+	p2wpkh := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	p2tr := addressesTest.GetAddress(signing.ScriptTypeP2TR)
+	ownOutput := func(address *addresses.AccountAddress) maketx.UTXO {
+		return maketx.UTXO{TxOut: wire.NewTxOut(1000, address.PubkeyScript()), Address: address}
+	}
+	require.True(t, psbtInputNeedsPrevTx(ownOutput(p2wpkh), &psbt.PInput{}))
+	require.False(t, psbtInputNeedsPrevTx(ownOutput(p2tr), &psbt.PInput{}))
+	require.False(t, psbtInputNeedsPrevTx(ownOutput(p2wpkh), &psbt.PInput{NonWitnessUtxo: wire.NewMsgTx(2)}))
+
+	// Foreign inputs, e.g. of the receiver of a payjoin transaction, have no address.
+	require.True(t, psbtInputNeedsPrevTx(
+		maketx.UTXO{TxOut: wire.NewTxOut(1000, p2wpkh.PubkeyScript())}, &psbt.PInput{}))
+	require.False(t, psbtInputNeedsPrevTx(
+		maketx.UTXO{TxOut: wire.NewTxOut(1000, p2tr.PubkeyScript())}, &psbt.PInput{}))
+	require.False(t, psbtInputNeedsPrevTx(
+		maketx.UTXO{}, &psbt.PInput{WitnessUtxo: wire.NewTxOut(1000, p2tr.PubkeyScript())}))
+	require.True(t, psbtInputNeedsPrevTx(maketx.UTXO{}, &psbt.PInput{}))
+}
+
+func TestCheckPSBTKeyOrigins(t *testing.T) {
+	const rootFingerprint = 0x04030201
+	address := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	keypath := address.AbsoluteKeypath().ToUInt32()
+	derivation := &psbt.Bip32Derivation{
+		PubKey:               address.PublicKey.SerializeCompressed(),
+		MasterKeyFingerprint: rootFingerprint,
+		Bip32Path:            keypath,
+	}
+	taprootDerivation := &psbt.TaprootBip32Derivation{
+		XOnlyPubKey:          schnorr.SerializePubKey(address.PublicKey),
+		MasterKeyFingerprint: rootFingerprint,
+		Bip32Path:            keypath,
+	}
+	require.NoError(t, checkPSBTKeyOrigins(
+		rootFingerprint, []*psbt.Bip32Derivation{derivation}, nil, address))
+	require.NoError(t, checkPSBTKeyOrigins(
+		rootFingerprint, nil, []*psbt.TaprootBip32Derivation{taprootDerivation}, address))
+
+	// Key origins of other keystores are ignored.
+	require.NoError(t, checkPSBTKeyOrigins(
+		rootFingerprint+1, []*psbt.Bip32Derivation{derivation}, nil, nil))
+
+	// Our key origin on an output which is not ours.
+	require.Error(t, checkPSBTKeyOrigins(
+		rootFingerprint, []*psbt.Bip32Derivation{derivation}, nil, nil))
+
+	// Wrong keypath.
+	wrongDerivation := *derivation
+	wrongDerivation.Bip32Path = append(append([]uint32{}, keypath[:len(keypath)-1]...), 99)
+	require.Error(t, checkPSBTKeyOrigins(
+		rootFingerprint, []*psbt.Bip32Derivation{&wrongDerivation}, nil, address))
+
+	// Wrong pubkey.
+	wrongTaprootDerivation := *taprootDerivation
+	wrongTaprootDerivation.XOnlyPubKey = make([]byte, 32)
+	require.Error(t, checkPSBTKeyOrigins(
+		rootFingerprint, nil, []*psbt.TaprootBip32Derivation{&wrongTaprootDerivation}, address))
+}
+
+func TestTrimMultisigSignatures(t *testing.T) {
+	privateKeys := make([]*btcec.PrivateKey, 4)
+	for i := range privateKeys {
+		privateKeys[i], _ = btcec.PrivKeyFromBytes(chainhash.HashB([]byte{byte(i)}))
+	}
+	// 2-of-3 with the first three keys. The fourth key is not a cosigner.
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	for _, privateKey := range privateKeys[:3] {
+		builder.AddData(privateKey.PubKey().SerializeCompressed())
+	}
+	witnessScript, err := builder.AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(witnessScript)
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(scriptHash[:]).Script()
+	require.NoError(t, err)
+
+	packet := testPSBT(t)
+	prevOut := wire.NewTxOut(2000, pkScript)
+	packet.Inputs[0].WitnessUtxo = prevOut
+	packet.Inputs[0].WitnessScript = witnessScript
+	sigHashes := psbtSigHashes(packet)
+	require.NotNil(t, sigHashes)
+	sign := func(privateKey *btcec.PrivateKey) *psbt.PartialSig {
+		signature, err := txscript.RawTxInWitnessSignature(packet.UnsignedTx, sigHashes, 0,
+			prevOut.Value, witnessScript, txscript.SigHashAll, privateKey)
+		require.NoError(t, err)
+		return &psbt.PartialSig{
+			PubKey: privateKey.PubKey().SerializeCompressed(), Signature: signature,
+		}
+	}
+	invalid := sign(privateKeys[0])
+	invalid.PubKey = privateKeys[1].PubKey().SerializeCompressed()
+	packet.Inputs[0].PartialSigs = []*psbt.PartialSig{
+		sign(privateKeys[3]), // not a cosigner
+		invalid,              // signature does not match the key
+		sign(privateKeys[2]),
+		sign(privateKeys[0]),
+	}
+
+	trimMultisigSignatures(packet)
+	require.Len(t, packet.Inputs[0].PartialSigs, 2)
+	// Ordered by the keys in the witness script.
+	require.Equal(t, privateKeys[0].PubKey().SerializeCompressed(), packet.Inputs[0].PartialSigs[0].PubKey)
+	require.Equal(t, privateKeys[2].PubKey().SerializeCompressed(), packet.Inputs[0].PartialSigs[1].PubKey)
+	require.NoError(t, psbt.MaybeFinalizeAll(packet))
+	signedTx, err := psbt.Extract(packet)
+	require.NoError(t, err)
+	engine, err := txscript.NewEngine(pkScript, signedTx, 0, txscript.StandardVerifyFlags, nil,
+		sigHashes, prevOut.Value, txscript.NewCannedPrevOutputFetcher(pkScript, prevOut.Value))
+	require.NoError(t, err)
+	require.NoError(t, engine.Execute())
+}
@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package btc
+
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// replaceableTx holds the data of one of our unconfirmed outgoing transactions needed to replace it
+// (BIP-125 replace-by-fee).
+type replaceableTx struct {
+	replaced *maketx.ReplacedTx
+	// recipientOutputs are all outputs that do not go to our change addresses.
+	recipientOutputs []*wire.TxOut
+	// changeAddress is the change address used by the replaced tx, or nil if it has no change.
+	changeAddress *addresses.AccountAddress
+	// feeRatePerKb is the fee rate of the replaced tx.
+	feeRatePerKb btcutil.Amount
+	// confirmedUTXOs are the confirmed spendable outputs of the account which can be used to pay
+	// for the higher fee of the replacement. BIP-125 does not allow adding unconfirmed inputs.
+	confirmedUTXOs map[wire.OutPoint]maketx.UTXO
+}
+
+// signalsRBF returns true if the transaction opted in to replace-by-fee. See
+// https://github.com/bitcoin/bips/blob/master/bip-0125.mediawiki#summary
+func signalsRBF(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// getReplaceableTx collects all the data needed to replace the tx with the given ID. Returns
+// ErrTxNotReplaceable if the tx is not one of our unconfirmed outgoing RBF transactions.
+func (account *Account) getReplaceableTx(txID string) (*replaceableTx, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if !account.Synced() {
+		return nil, accounts.ErrSyncInProgress
+	}
+	spendableOutputs, err := account.transactions.SpendableOutputs()
+	if err != nil {
+		return nil, err
+	}
+	return transactions.DBView(account.db, func(dbTx transactions.DBTxInterface) (*replaceableTx, error) {
+		txInfo, err := dbTx.TxInfo(*txHash)
+		if err != nil {
+			return nil, err
+		}
+		if txInfo == nil {
+			return nil, errp.Newf("transaction %s not found", txID)
+		}
+		if txInfo.Height > 0 {
+			account.log.Info("Cannot replace a confirmed transaction")
+			return nil, errp.WithStack(errors.ErrTxNotReplaceable)
+		}
+		if !signalsRBF(txInfo.Tx) {
+			account.log.Info("Cannot replace a transaction that does not signal RBF")
+			return nil, errp.WithStack(errors.ErrTxNotReplaceable)
+		}
+
+		result := &replaceableTx{
+			replaced: &maketx.ReplacedTx{
+				Inputs: make(map[wire.OutPoint]maketx.UTXO, len(txInfo.Tx.TxIn)),
+			},
+		}
+		inputsSum := btcutil.Amount(0)
+		for _, txIn := range txInfo.Tx.TxIn {
+			spentOutput, err := dbTx.Output(txIn.PreviousOutPoint)
+			if err != nil {
+				return nil, err
+			}
+			if spentOutput == nil {
+				// Not all inputs are ours, so we can't sign a replacement.
+				account.log.Info("Cannot replace a transaction with foreign inputs")
+				return nil, errp.WithStack(errors.ErrTxNotReplaceable)
+			}
+			inputsSum += btcutil.Amount(spentOutput.Value)
+			result.replaced.Inputs[txIn.PreviousOutPoint] = maketx.UTXO{
+				TxOut:   spentOutput,
+				Address: account.AddressByID(addresses.NewAddressID(spentOutput.PkScript)),
+			}
+		}
+		outputsSum := btcutil.Amount(0)
+		for index, txOut := range txInfo.Tx.TxOut {
+			outputsSum += btcutil.Amount(txOut.Value)
+			outPoint := wire.OutPoint{Hash: *txHash, Index: uint32(index)}
+			spendingTx, err := dbTx.Input(outPoint)
+			if err != nil {
+				return nil, err
+			}
+			if spendingTx != nil {
+				// Replacing the tx would also evict the child spending this output.
+				account.log.Info("Cannot replace a transaction whose outputs are already spent")
+				return nil, errp.WithStack(errors.ErrTxNotReplaceable)
+			}
+			addressID := addresses.NewAddressID(txOut.PkScript)
+			if account.IsChange(addressID) {
+				result.changeAddress = account.AddressByID(addressID)
+				continue
+			}
+			result.recipientOutputs = append(result.recipientOutputs, txOut)
+		}
+		result.replaced.Fee = inputsSum - outputsSum
+		vsize := mempool.GetTxVirtualSize(btcutil.NewTx(txInfo.Tx))
+		result.feeRatePerKb = result.replaced.Fee * 1000 / btcutil.Amount(vsize)
+
+		result.confirmedUTXOs, err = account.confirmedUTXOs(dbTx, spendableOutputs)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// confirmedUTXOs filters the given spendable outputs, keeping only outputs of confirmed
+// transactions.
+func (account *Account) confirmedUTXOs(
+	dbTx transactions.DBTxInterface,
+	spendableOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+) (map[wire.OutPoint]maketx.UTXO, error) {
+	result := map[wire.OutPoint]maketx.UTXO{}
+	for outPoint, spendableOutput := range spendableOutputs {
+		txInfo, err := dbTx.TxInfo(outPoint.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if txInfo == nil || txInfo.Height <= 0 {
+			continue
+		}
+		result[outPoint] = maketx.UTXO{
+			TxOut:   spendableOutput.TxOut,
+			Address: account.AddressByID(addresses.NewAddressID(spendableOutput.TxOut.PkScript)),
+			Frozen:  account.isFrozen(outPoint),
+		}
+	}
+	return result, nil
+}
+
+// newReplacementTx creates a transaction replacing the unconfirmed outgoing tx with the given ID.
+// If cancel is false, the recipients are kept and the fee is increased (speed up). If cancel is
+// true, all funds are sent back to a change address of the account.
+func (account *Account) newReplacementTx(
+	txID string, cancel bool, args *accounts.TxProposalArgs) (*maketx.TxProposal, error) {
+	replaceable, err := account.getReplaceableTx(txID)
+	if err != nil {
+		return nil, err
+	}
+	feeRatePerKb, err := account.getFeePerKb(args)
+	if err != nil {
+		return nil, err
+	}
+	if feeRatePerKb <= replaceable.feeRatePerKb {
+		return nil, errp.WithStack(errors.ErrFeeTooLow)
+	}
+	// Bitcoin Core uses the incremental relay fee, which defaults to the min relay fee.
+	incrementalFeeRatePerKb, err := account.getMinRelayFeeRate()
+	if err != nil {
+		return nil, err
+	}
+	changeAddress := replaceable.changeAddress
+	if changeAddress == nil {
+		changeAddress, err = account.pickChangeAddress(replaceable.replaced.Inputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cancel {
+		return maketx.NewTxCancel(
+			account.coin,
+			replaceable.replaced,
+			feeRatePerKb,
+			incrementalFeeRatePerKb,
+			changeAddress,
+			account.log,
+		)
+	}
+	if len(replaceable.recipientOutputs) == 0 {
+		// Nothing to speed up in a tx that only sends to our own change.
+		return nil, errp.WithStack(errors.ErrTxNotReplaceable)
+	}
+	return maketx.NewTxReplacement(
+		account.coin,
+		replaceable.replaced,
+		replaceable.confirmedUTXOs,
+		replaceable.recipientOutputs,
+		feeRatePerKb,
+		incrementalFeeRatePerKb,
+		changeAddress,
+		account.log,
+	)
+}
+
+func (account *Account) replacementTxProposal(txID string, cancel bool, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	defer account.activeTxProposalLock.Lock()()
+
+	account.log.WithField("cancel", cancel).Debug("Proposing replacement transaction")
+	txProposal, err := account.newReplacementTx(txID, cancel, args)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+
+	account.activeTxProposal = txProposal
+
+	account.log.WithField("fee", txProposal.Fee).Debug("Returning fee")
+	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		coin.NewAmountFromInt64(int64(txProposal.Total())), nil
+}
+
+// BumpFeeProposal creates a tx replacing the pending outgoing tx with the given ID with one paying
+// the fee rate given by args (replace-by-fee). Only the fee related fields of args are used. The
+// recipients of the original tx are kept; more inputs are added if the change does not cover the
+// higher fee. Like TxProposal(), the proposal is stored and can be signed and sent with SendTx().
+func (account *Account) BumpFeeProposal(txID string, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	return account.replacementTxProposal(txID, false, args)
+}
+
+// CancelTxProposal creates a tx replacing the pending outgoing tx with the given ID with one that
+// spends the same inputs to our own change address at the fee rate given by args, effectively
+// cancelling the original payment. The proposal can be signed and sent with SendTx().
+func (account *Account) CancelTxProposal(txID string, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	return account.replacementTxProposal(txID, true, args)
+}
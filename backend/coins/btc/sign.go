@@ -5,6 +5,7 @@ package btc
 import (
 	"encoding/binary"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
@@ -53,6 +54,10 @@ func (p *ProposedTransaction) Update() error {
 			return errp.New("There needs to be exactly one output being spent per input.")
 		}
 		inputAddress := prevOut.Address
+		if inputAddress == nil {
+			// Input of another party, e.g. of the receiver of a payjoin, which is signed already.
+			continue
+		}
 		if err := updater.AddInWitnessUtxo(prevOut.TxOut, index); err != nil {
 			return err
 		}
@@ -75,6 +80,23 @@ func (p *ProposedTransaction) Update() error {
 				// so we can ignore the duplicate key error.
 				return err
 			}
+		case signing.ScriptTypeP2WSH:
+			if err := updater.AddInWitnessScript(inputAddress.WitnessScript, index); err != nil {
+				return err
+			}
+			derivations, err := multisigBip32Derivations(inputAddress)
+			if err != nil {
+				return err
+			}
+			for _, derivation := range derivations {
+				if err := updater.AddInBip32Derivation(
+					derivation.MasterKeyFingerprint,
+					derivation.Bip32Path,
+					derivation.PubKey,
+					index); err != nil && err != psbt.ErrDuplicateKey {
+					return err
+				}
+			}
 		case signing.ScriptTypeP2TR:
 			internalKey := schnorr.SerializePubKey(inputAddress.PublicKey)
 			txProposal.Psbt.Inputs[index].TaprootInternalKey = internalKey
@@ -114,6 +136,23 @@ func (p *ProposedTransaction) Update() error {
 					// so we can ignore the duplicate key error.
 					return err
 				}
+			case signing.ScriptTypeP2WSH:
+				if err := updater.AddOutWitnessScript(outputAddress.WitnessScript, index); err != nil {
+					return err
+				}
+				derivations, err := multisigBip32Derivations(outputAddress)
+				if err != nil {
+					return err
+				}
+				for _, derivation := range derivations {
+					if err := updater.AddOutBip32Derivation(
+						derivation.MasterKeyFingerprint,
+						derivation.Bip32Path,
+						derivation.PubKey,
+						index); err != nil && err != psbt.ErrDuplicateKey {
+						return err
+					}
+				}
 			case signing.ScriptTypeP2TR:
 				internalKey := schnorr.SerializePubKey(outputAddress.PublicKey)
 				txProposal.Psbt.Outputs[index].TaprootInternalKey = internalKey
@@ -132,10 +171,35 @@ func (p *ProposedTransaction) Update() error {
 	return nil
 }
 
+// multisigBip32Derivations returns the key infos of all cosigners of a multisig address.
+func multisigBip32Derivations(address *addresses.AccountAddress) ([]*psbt.Bip32Derivation, error) {
+	multisig := address.AccountConfiguration.BitcoinMultisig
+	relativeKeypath := signing.NewEmptyRelativeKeypath().
+		Child(address.Derivation.SimpleChainIndex(), signing.NonHardened).
+		Child(address.Derivation.AddressIndex, signing.NonHardened)
+	publicKeys, err := multisig.DerivePublicKeys(relativeKeypath)
+	if err != nil {
+		return nil, err
+	}
+	derivations := make([]*psbt.Bip32Derivation, len(multisig.Cosigners))
+	for i, cosigner := range multisig.Cosigners {
+		derivations[i] = &psbt.Bip32Derivation{
+			PubKey:               publicKeys[i].SerializeCompressed(),
+			MasterKeyFingerprint: binary.LittleEndian.Uint32(cosigner.RootFingerprint),
+			Bip32Path: cosigner.AbsoluteKeypath.
+				Child(address.Derivation.SimpleChainIndex(), signing.NonHardened).
+				Child(address.Derivation.AddressIndex, signing.NonHardened).
+				ToUInt32(),
+		}
+	}
+	return derivations, nil
+}
+
 // FinalizeAndExtract adds the signatureScript/witness for each input based on the available
 // signatures and input address configurations, extracts the final signed tx, and performs a
 // consensus validity check on it.
 func (p *ProposedTransaction) FinalizeAndExtract() (*wire.MsgTx, error) {
+	trimMultisigSignatures(p.TXProposal.Psbt)
 	if err := psbt.MaybeFinalizeAll(p.TXProposal.Psbt); err != nil {
 		return nil, err
 	}
@@ -154,32 +218,31 @@ func (p *ProposedTransaction) FinalizeAndExtract() (*wire.MsgTx, error) {
 
 // signTransaction signs all inputs. It assumes all outputs spent belong to this
 // wallet. previousOutputs must contain all outputs which are spent by the transaction.
-// It returns the signed transaction.
+// If all inputs are already signed, e.g. for an imported PSBT signed externally, the keystore is
+// not used. If the keystore is a multisig cosigner and signatures of other cosigners are still
+// missing, errors.ErrMissingCosignerSignatures is returned. The partially signed PSBT remains in the
+// tx proposal so it can be exported and passed on to the other cosigners. It returns the signed
+// transaction.
 func (account *Account) signTransaction(
 	txProposal *maketx.TxProposal,
 	getPrevTx func(chainhash.Hash) (*wire.MsgTx, error),
 ) (*wire.MsgTx, error) {
-	signingConfigs := make([]*signing.Configuration, len(account.subaccounts))
-	for i, subacc := range account.subaccounts {
-		signingConfigs[i] = subacc.signingConfiguration
-	}
-
-	proposedTransaction := &ProposedTransaction{
-		TXProposal:                   txProposal,
-		AccountSigningConfigurations: signingConfigs,
-		GetKeystoreAddress:           account.getAddressFromSameKeystore,
-		GetPrevTx:                    getPrevTx,
-		FormatUnit:                   account.coin.formatUnit,
-	}
+	proposedTransaction := account.newProposedTransaction(txProposal)
+	proposedTransaction.GetPrevTx = getPrevTx
 	if err := proposedTransaction.Update(); err != nil {
 		return nil, err
 	}
-	keystore, err := account.Config().ConnectKeystore()
-	if err != nil {
-		return nil, err
-	}
-	if err := keystore.SignTransaction(proposedTransaction); err != nil {
-		return nil, err
+	if !psbtInputsSigned(txProposal.Psbt) {
+		keystore, err := account.Config().ConnectKeystore()
+		if err != nil {
+			return nil, err
+		}
+		if err := keystore.SignTransaction(proposedTransaction); err != nil {
+			return nil, err
+		}
+		if !psbtInputsSigned(txProposal.Psbt) {
+			return nil, errp.WithStack(errors.ErrMissingCosignerSignatures)
+		}
 	}
 
 	return proposedTransaction.FinalizeAndExtract()
@@ -117,7 +117,40 @@ func (account *Account) pickChangeAddress(utxos map[wire.OutPoint]maketx.UTXO) (
 	return unusedAddresses[0], nil
 }
 
-// newTx creates a new tx to the given recipient address. It also returns a set of used account
+// outputInfo returns the output info to pay the address, which can be a silent payment address.
+func (account *Account) outputInfo(address string) (*maketx.OutputInfo, error) {
+	if err := account.coin.ValidateSilentPaymentAddress(address); err == nil {
+		return maketx.NewOutputInfoSilentPayment(address), nil
+	}
+	pkScript, err := account.coin.AddressToPkScript(address)
+	if err != nil {
+		return nil, err
+	}
+	return maketx.NewOutputInfo(pkScript), nil
+}
+
+// parseAmount parses a send amount, which must not be "send all", in the unit shown to the user and
+// returns it in satoshi.
+func (account *Account) parseAmount(amount coin.SendAmount) (int64, error) {
+	allowZero := false
+
+	unit := int64(unitSatoshi)
+	if account.coin.formatUnit == coin.BtcUnitSats {
+		unit = 1
+	}
+	parsedAmount, err := amount.Amount(big.NewInt(unit), allowZero)
+	if err != nil {
+		return 0, err
+	}
+	parsedAmountInt64, err := parsedAmount.Int64()
+	if err != nil {
+		return 0, errp.WithStack(errors.ErrInvalidAmount)
+	}
+	return parsedAmountInt64, nil
+}
+
+// newTx creates a new tx to the given recipient address, or to multiple recipients if
+// args.Recipients is set. It also returns a set of used account
 // outputs, which contains all outputs that spent in the tx. Those are needed to be able to sign the
 // transaction. selectedUTXOs restricts the available coins; if empty, no restriction is applied and
 // all unspent coins can be used.
@@ -127,14 +160,12 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 	account.log.Debug("Prepare new transaction")
 
 	var outputInfo *maketx.OutputInfo
-	if err := account.coin.ValidateSilentPaymentAddress(args.RecipientAddress); err == nil {
-		outputInfo = maketx.NewOutputInfoSilentPayment(args.RecipientAddress)
-	} else {
-		pkScript, err := account.coin.AddressToPkScript(args.RecipientAddress)
+	if len(args.Recipients) == 0 {
+		var err error
+		outputInfo, err = account.outputInfo(args.RecipientAddress)
 		if err != nil {
 			return nil, nil, err
 		}
-		outputInfo = maketx.NewOutputInfo(pkScript)
 	}
 
 	if !account.Synced() {
@@ -147,15 +178,19 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 	wireUTXO := make(map[wire.OutPoint]maketx.UTXO, len(utxo))
 	for outPoint, txOut := range utxo {
 		// Apply coin control.
+		// Frozen outputs are only spent if explicitly selected.
+		frozen := account.isFrozen(outPoint)
 		if len(args.SelectedUTXOs) != 0 {
 			if _, ok := args.SelectedUTXOs[outPoint]; !ok {
 				continue
 			}
+			frozen = false
 		}
 		addressID := addresses.NewAddressID(txOut.TxOut.PkScript)
 		wireUTXO[outPoint] = maketx.UTXO{
 			TxOut:   txOut.TxOut,
 			Address: account.AddressByID(addressID),
+			Frozen:  frozen,
 		}
 	}
 	feeRatePerKb, err := account.getFeePerKb(args)
@@ -164,7 +199,41 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 	}
 
 	var txProposal *maketx.TxProposal
-	if args.Amount.SendAll() {
+	if len(args.Recipients) != 0 {
+		if args.PaymentRequest != nil {
+			return nil, nil, errp.New("Payment Requests do not allow multiple recipients")
+		}
+		recipients := make([]maketx.Recipient, len(args.Recipients))
+		for i, recipient := range args.Recipients {
+			outputInfo, err := account.outputInfo(recipient.Address)
+			if err != nil {
+				return nil, nil, err
+			}
+			recipients[i] = maketx.Recipient{OutputInfo: outputInfo, SendAll: recipient.Amount.SendAll()}
+			if !recipients[i].SendAll {
+				recipients[i].Amount, err = account.parseAmount(recipient.Amount)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		changeAddress, err := account.pickChangeAddress(wireUTXO)
+		if err != nil {
+			return nil, nil, err
+		}
+		txProposal, err = maketx.NewTxBatch(
+			account.coin,
+			wireUTXO,
+			recipients,
+			feeRatePerKb,
+			changeAddress,
+			args.CoinSelection,
+			account.log,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if args.Amount.SendAll() {
 		if args.PaymentRequest != nil {
 			return nil, nil, errp.New("Payment Requests do not allow send-all transaction proposals")
 		}
@@ -179,20 +248,10 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 			return nil, nil, err
 		}
 	} else {
-		allowZero := false
-
-		unit := int64(unitSatoshi)
-		if account.coin.formatUnit == coin.BtcUnitSats {
-			unit = 1
-		}
-		parsedAmount, err := args.Amount.Amount(big.NewInt(unit), allowZero)
+		parsedAmountInt64, err := account.parseAmount(args.Amount)
 		if err != nil {
 			return nil, nil, err
 		}
-		parsedAmountInt64, err := parsedAmount.Int64()
-		if err != nil {
-			return nil, nil, errp.WithStack(errors.ErrInvalidAmount)
-		}
 		changeAddress, err := account.pickChangeAddress(wireUTXO)
 		if err != nil {
 			return nil, nil, err
@@ -205,6 +264,7 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 			parsedAmountInt64,
 			feeRatePerKb,
 			changeAddress,
+			args.CoinSelection,
 			account.log,
 		)
 		if err != nil {
@@ -216,11 +276,32 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (
 			txProposal.PaymentRequest = args.PaymentRequest
 		}
 	}
+	if args.PayjoinURL != "" && len(args.Recipients) == 0 {
+		account.setPayjoinURL(txProposal, args.PayjoinURL)
+	}
 	account.log.Debugf("creating tx with %d inputs, %d outputs",
 		len(txProposal.Psbt.UnsignedTx.TxIn), len(txProposal.Psbt.UnsignedTx.TxOut))
 	return utxo, txProposal, nil
 }
 
+// setPayjoinURL enables payjoin for the tx proposal if possible. Otherwise, the transaction is sent
+// as a regular transaction.
+func (account *Account) setPayjoinURL(txProposal *maketx.TxProposal, payjoinURL string) {
+	log := account.log.WithField("payjoin-url", payjoinURL)
+	switch {
+	case txProposal.PaymentRequest != nil || txProposal.SilentPaymentAddress != "":
+		log.Info("Payjoin not used for payment requests and silent payments")
+	case account.subaccounts.signingConfigurations().FindScriptType(signing.ScriptTypeP2WSH) >= 0:
+		log.Info("Payjoin not used for multisig accounts")
+	default:
+		if _, err := parsePayjoinEndpoint(payjoinURL); err != nil {
+			log.WithError(err).Warning("Payjoin not used")
+			return
+		}
+		txProposal.PayjoinURL = payjoinURL
+	}
+}
+
 // AddressByID returns the address in the account with the given address ID. Returns nil if the
 // address does not exist in the account.
 func (account *Account) AddressByID(addressID addresses.AddressID) *addresses.AccountAddress {
@@ -243,6 +324,10 @@ func (account *Account) SendTx(txNote string) (string, error) {
 		return "", errp.WithMessage(err, "Failed to sign transaction")
 	}
 
+	if txProposal.PayjoinURL != "" {
+		signedTx = account.payjoinOrOriginal(txProposal, signedTx)
+	}
+
 	account.log.Info("Signed transaction is broadcasted")
 	if err := account.coin.Blockchain().TransactionBroadcast(signedTx); err != nil {
 		return "", err
@@ -277,3 +362,28 @@ func (account *Account) TxProposal(
 		coin.NewAmountFromInt64(int64(txProposal.Fee)),
 		coin.NewAmountFromInt64(int64(txProposal.Total())), nil
 }
+
+// TxProposalRecipientAmounts returns the amounts sent to each recipient of the active tx proposal, in
+// the order of the recipients. Returns nil if there is no active tx proposal.
+func (account *Account) TxProposalRecipientAmounts() []coin.Amount {
+	defer account.activeTxProposalLock.RLock()()
+	txProposal := account.activeTxProposal
+	if txProposal == nil {
+		return nil
+	}
+	amounts := make([]coin.Amount, len(txProposal.OutIndices))
+	for i, outIndex := range txProposal.OutIndices {
+		amounts[i] = coin.NewAmountFromInt64(txProposal.Psbt.UnsignedTx.TxOut[outIndex].Value)
+	}
+	return amounts
+}
+
+// TxProposalCoinSelection returns the coin selection strategy that selected the inputs of the active
+// tx proposal, or an empty string if there is no active tx proposal.
+func (account *Account) TxProposalCoinSelection() maketx.CoinSelectionStrategy {
+	defer account.activeTxProposalLock.RLock()()
+	if account.activeTxProposal == nil {
+		return ""
+	}
+	return account.activeTxProposal.CoinSelection
+}
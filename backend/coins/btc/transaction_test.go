@@ -152,6 +152,41 @@ func TestGetFeePerKb(t *testing.T) {
 
 }
 
+func TestFeeTargetsFeeHistogram(t *testing.T) {
+	account := testAccount(t, nil)
+	blockchainMock := account.coin.blockchain.(*blockchainMocks.BlockchainMock)
+	blockchainMock.MockFeeHistogram = func() (blockchain.FeeHistogram, error) {
+		return blockchain.FeeHistogram{
+			{FeeRate: 50, VSize: 1500000},
+			{FeeRate: 20, VSize: 3000000},
+			{FeeRate: 5, VSize: 4000000},
+		}, nil
+	}
+	type feeTarget struct {
+		code                     accounts.FeeTargetCode
+		feeRatePerKb             btcutil.Amount
+		expectedConfirmationTime time.Duration
+	}
+	got := []feeTarget{}
+	for _, target := range account.feeTargets() {
+		require.NotNil(t, target.feeRatePerKb)
+		got = append(got, feeTarget{target.code, *target.feeRatePerKb, target.expectedConfirmationTime})
+	}
+	require.Equal(t, []feeTarget{
+		// The whole mempool fits into the next 9 blocks, so the min relay fee suffices.
+		{accounts.FeeTargetCodeEconomy, 1001, 90 * time.Minute},
+		{accounts.FeeTargetCodeLow, 1001, 90 * time.Minute},
+		{accounts.FeeTargetCodeNormal, 6000, 50 * time.Minute},
+		{accounts.FeeTargetCodeHigh, 21000, 20 * time.Minute},
+	}, got)
+
+	// Falls back to the fee estimation of the server.
+	blockchainMock.MockFeeHistogram = nil
+	target := account.feeTargets()[3]
+	require.Equal(t, btcutil.Amount(10e7), *target.feeRatePerKb)
+	require.Equal(t, 20*time.Minute, target.expectedConfirmationTime)
+}
+
 func utxo(scriptType signing.ScriptType) maketx.UTXO {
 	return maketx.UTXO{
 		Address: &addresses.AccountAddress{
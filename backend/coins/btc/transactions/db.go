@@ -20,6 +20,12 @@ type DBTxInfo struct {
 	Verified         *bool           `json:"Verified"`
 	HeaderTimestamp  *time.Time      `json:"ts"`
 	CreatedTimestamp *time.Time      `json:"created"`
+	// VerifiedHeight is the height of the header the tx was verified against. 0 for txs verified
+	// before the height was stored, which were verified at Height.
+	VerifiedHeight int `json:"verifiedHeight,omitempty"`
+	// VerificationFailed is true if the merkle proof of the last verification attempt did not match
+	// our header. The tx stays unverified and is verified again later.
+	VerificationFailed bool `json:"verificationFailed,omitempty"`
 
 	// TxHash is the same as Tx.TxHash(), but since we already have this value in the database, it
 	// is faster to access it this way than to recompute it.  It is not serialized and stored in the
@@ -56,8 +62,17 @@ type DBTxInterface interface {
 	// UnverifiedTransactions retrieves all stored transaction hashes of unverified transactions.
 	UnverifiedTransactions() ([]chainhash.Hash, error)
 
-	// MarkTxVerified marks a tx as verified. Stores timestamp of the header this tx appears in.
-	MarkTxVerified(txHash chainhash.Hash, headerTimestamp time.Time) error
+	// MarkTxVerified marks a tx as verified against the header at the given height. Stores
+	// timestamp of the header this tx appears in.
+	MarkTxVerified(txHash chainhash.Hash, height int, headerTimestamp time.Time) error
+
+	// MarkTxVerificationFailed records that the merkle proof of an unverified tx did not match our
+	// header. The tx stays unverified.
+	MarkTxVerificationFailed(txHash chainhash.Hash) error
+
+	// MarkTxUnverified marks a verified tx as unverified, so that it is verified again, e.g. after
+	// a reorg.
+	MarkTxUnverified(txHash chainhash.Hash) error
 
 	// PutInput stores a transaction input. It is referenced by the output it spends. The
 	// transaction hash of the transaction this input was found in is recorded. TODO: store slice of
@@ -36,6 +36,9 @@ var _ transactions.Interface = &InterfaceMock{}
 //			UpdateAddressHistoryFunc: func(scriptHashHex blockchain.ScriptHashHex, txs []*blockchain.TxInfo)  {
 //				panic("mock out the UpdateAddressHistory method")
 //			},
+//			VerificationStatusFunc: func() (*transactions.VerificationStatus, error) {
+//				panic("mock out the VerificationStatus method")
+//			},
 //		}
 //
 //		// use mockedInterface in code that requires transactions.Interface
@@ -58,6 +61,9 @@ type InterfaceMock struct {
 	// UpdateAddressHistoryFunc mocks the UpdateAddressHistory method.
 	UpdateAddressHistoryFunc func(scriptHashHex blockchain.ScriptHashHex, txs []*blockchain.TxInfo)
 
+	// VerificationStatusFunc mocks the VerificationStatus method.
+	VerificationStatusFunc func() (*transactions.VerificationStatus, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// Balance holds details about calls to the Balance method.
@@ -81,12 +87,16 @@ type InterfaceMock struct {
 			// Txs is the txs argument value.
 			Txs []*blockchain.TxInfo
 		}
+		// VerificationStatus holds details about calls to the VerificationStatus method.
+		VerificationStatus []struct {
+		}
 	}
 	lockBalance              sync.RWMutex
 	lockClose                sync.RWMutex
 	lockSpendableOutputs     sync.RWMutex
 	lockTransactions         sync.RWMutex
 	lockUpdateAddressHistory sync.RWMutex
+	lockVerificationStatus   sync.RWMutex
 }
 
 // Balance calls BalanceFunc.
@@ -237,3 +247,30 @@ func (mock *InterfaceMock) UpdateAddressHistoryCalls() []struct {
 	mock.lockUpdateAddressHistory.RUnlock()
 	return calls
 }
+
+// VerificationStatus calls VerificationStatusFunc.
+func (mock *InterfaceMock) VerificationStatus() (*transactions.VerificationStatus, error) {
+	if mock.VerificationStatusFunc == nil {
+		panic("InterfaceMock.VerificationStatusFunc: method is nil but Interface.VerificationStatus was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockVerificationStatus.Lock()
+	mock.calls.VerificationStatus = append(mock.calls.VerificationStatus, callInfo)
+	mock.lockVerificationStatus.Unlock()
+	return mock.VerificationStatusFunc()
+}
+
+// VerificationStatusCalls gets all the calls that were made to VerificationStatus.
+// Check the length with:
+//
+//	len(mockedInterface.VerificationStatusCalls())
+func (mock *InterfaceMock) VerificationStatusCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockVerificationStatus.RLock()
+	calls = mock.calls.VerificationStatus
+	mock.lockVerificationStatus.RUnlock()
+	return calls
+}
@@ -59,6 +59,9 @@ type Interface interface {
 	// an address changes (a new transaction that touches it appears or disappears). The transactions
 	// are downloaded and indexed.
 	UpdateAddressHistory(scriptHashHex blockchain.ScriptHashHex, txs []*blockchain.TxInfo)
+
+	// VerificationStatus returns how many transactions were verified and which failed verification.
+	VerificationStatus() (*VerificationStatus, error)
 }
 
 // Transactions handles wallet transactions: keeping an index of the transactions, inputs, (unspent)
@@ -79,6 +82,10 @@ type Transactions struct {
 	notifier     accounts.Notifier
 	log          *logrus.Entry
 
+	// onVerificationFailed is called when the merkle proof of a tx did not match our headers. Can
+	// be nil.
+	onVerificationFailed func(txHash chainhash.Hash)
+
 	closed     bool
 	closedLock locker.Locker
 
@@ -95,6 +102,7 @@ func NewTransactions(
 	synchronizer *synchronizer.Synchronizer,
 	blockchain blockchain.Interface,
 	notifier accounts.Notifier,
+	onVerificationFailed func(txHash chainhash.Hash),
 	log *logrus.Entry,
 ) *Transactions {
 	transactions := &Transactions{
@@ -109,6 +117,8 @@ func NewTransactions(
 		notifier:     notifier,
 		log:          log.WithFields(logrus.Fields{"group": "transactions", "net": net.Name}),
 
+		onVerificationFailed: onVerificationFailed,
+
 		headerTimestampCache: map[int]*time.Time{},
 	}
 	transactions.unsubscribeHeadersEvent = headers.SubscribeEvent(transactions.onHeadersEvent)
@@ -556,6 +566,19 @@ func (transactions *Transactions) txInfo(
 		numConfirmations = transactions.headersTipHeight - txInfo.Height + 1
 	}
 
+	verification := accounts.TxVerificationUnverified
+	verifiedHeight := 0
+	switch {
+	case txInfo.Verified != nil && *txInfo.Verified:
+		verification = accounts.TxVerificationVerified
+		verifiedHeight = txInfo.VerifiedHeight
+		if verifiedHeight == 0 {
+			verifiedHeight = txInfo.Height
+		}
+	case txInfo.VerificationFailed:
+		verification = accounts.TxVerificationFailed
+	}
+
 	const numConfirmationsComplete = 6
 	status := accounts.TxStatusPending
 	if numConfirmations >= numConfirmationsComplete {
@@ -579,6 +602,8 @@ func (transactions *Transactions) txInfo(
 		Size:             int64(txInfo.Tx.SerializeSize()),
 		Weight:           btcdBlockchain.GetTransactionWeight(btcutilTx),
 		CreatedTimestamp: txInfo.CreatedTimestamp,
+		Verification:     verification,
+		VerifiedHeight:   verifiedHeight,
 		IsErc20:          false,
 	}
 }
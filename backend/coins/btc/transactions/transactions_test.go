@@ -14,6 +14,7 @@ import (
 	blockchainpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
 	blockchainMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/db/transactionsdb"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
 	headersMock "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/synchronizer"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/transactions"
@@ -89,6 +90,9 @@ type transactionsSuite struct {
 	headersMock    *headersMock.Interface
 	notifierMock   *accountsMock.Notifier
 	transactions   *transactions.Transactions
+	onHeadersEvent func(headers.Event)
+	// verificationFailed receives the txs which failed verification.
+	verificationFailed chan chainhash.Hash
 
 	log *logrus.Entry
 }
@@ -109,10 +113,15 @@ func (s *transactionsSuite) SetupTest() {
 		panic(err)
 	}
 	s.headersMock = &headersMock.Interface{}
-	s.headersMock.On("SubscribeEvent", mock.AnythingOfType("func(headers.Event)")).Return(func() {})
+	s.headersMock.On("SubscribeEvent", mock.AnythingOfType("func(headers.Event)")).
+		Run(func(args mock.Arguments) {
+			s.onHeadersEvent = args.Get(0).(func(headers.Event))
+		}).
+		Return(func() {})
 	s.headersMock.On("TipHeight").Return(15).Once()
 	s.headersMock.On("HeaderByHeight", mock.Anything).Return((*wire.BlockHeader)(nil), nil)
 	s.notifierMock = &accountsMock.Notifier{}
+	s.verificationFailed = make(chan chainhash.Hash, 10)
 	s.transactions = transactions.NewTransactions(
 		s.net,
 		db,
@@ -120,6 +129,7 @@ func (s *transactionsSuite) SetupTest() {
 		s.synchronizer,
 		s.blockchainMock,
 		s.notifierMock,
+		func(txHash chainhash.Hash) { s.verificationFailed <- txHash },
 		s.log,
 	)
 }
@@ -201,6 +211,69 @@ func (s *transactionsSuite) TestUpdateAddressHistorySingleTxReceive() {
 	s.Require().Equal(expectedTimestamp.UnixNano(), transactions[0].Timestamp.UnixNano())
 }
 
+// TestVerification checks that confirmed txs are verified against our headers using the merkle
+// proofs of the server, and verified again after a reorg.
+func (s *transactionsSuite) TestVerification() {
+	addresses, err := s.addressChain.EnsureAddresses()
+	s.Require().NoError(err)
+	address := addresses[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 123)
+	tx2 := newTx(chainhash.HashH([]byte("2")), 0, address, 456)
+	tx3 := newTx(chainhash.HashH([]byte("3")), 0, address, 789)
+	s.blockchainMock.RegisterTxs(tx1, tx2, tx3)
+	// With a single tx in the block, the merkle root is the tx hash.
+	s.headersMock.On("VerifiedHeaderByHeight", 10).Return(
+		&wire.BlockHeader{MerkleRoot: tx1.TxHash(), Timestamp: time.Unix(10, 0)}, nil)
+	s.headersMock.On("VerifiedHeaderByHeight", 11).Return(
+		&wire.BlockHeader{MerkleRoot: chainhash.HashH([]byte("other")), Timestamp: time.Unix(11, 0)}, nil)
+	s.blockchainMock.On("GetMerkle", mock.Anything, mock.Anything).Return(
+		&blockchainpkg.GetMerkleResult{Merkle: []blockchainpkg.TXHash{}, Pos: 0}, nil)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 11},
+		{TXHash: blockchainpkg.TXHash(tx3.TxHash()), Height: 0},
+	})
+
+	s.onHeadersEvent(headers.EventSynced)
+	s.Require().Equal(tx2.TxHash(), <-s.verificationFailed)
+	s.Require().Eventually(func() bool {
+		status, err := s.transactions.VerificationStatus()
+		s.Require().NoError(err)
+		return status.Verified == 1
+	}, time.Second, 10*time.Millisecond)
+	status, err := s.transactions.VerificationStatus()
+	s.Require().NoError(err)
+	s.Require().Equal(
+		&transactions.VerificationStatus{
+			Verified:   1,
+			Unverified: 1,
+			Failed:     []chainhash.Hash{tx2.TxHash()},
+		},
+		status)
+
+	txs, err := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	s.Require().NoError(err)
+	verifications := map[string]accounts.TxVerification{}
+	for _, tx := range txs {
+		verifications[tx.TxID] = tx.Verification
+		if tx.TxID == tx1.TxHash().String() {
+			s.Require().Equal(10, tx.VerifiedHeight)
+		}
+	}
+	s.Require().Equal(map[string]accounts.TxVerification{
+		tx1.TxHash().String(): accounts.TxVerificationVerified,
+		tx2.TxHash().String(): accounts.TxVerificationFailed,
+		tx3.TxHash().String(): accounts.TxVerificationUnverified,
+	}, verifications)
+
+	// After a reorg, recent txs need to be verified again.
+	s.headersMock.On("TipHeight").Return(12).Once()
+	s.onHeadersEvent(headers.EventReorg)
+	status, err = s.transactions.VerificationStatus()
+	s.Require().NoError(err)
+	s.Require().Equal(0, status.Verified)
+}
+
 // TestSpendableOutputs checks that the utxo set is correct. Only confirmed (or unconfirmed outputs
 // we own) outputs can be spent.
 func (s *transactionsSuite) TestSpendableOutputs() {
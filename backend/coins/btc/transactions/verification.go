@@ -8,10 +8,22 @@ import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 )
 
+// VerificationStatus summarizes the SPV verification of the transactions of an account.
+type VerificationStatus struct {
+	// Verified is the number of confirmed txs whose merkle proof matched our header chain.
+	Verified int
+	// Unverified is the number of txs which were not verified yet, including unconfirmed txs.
+	Unverified int
+	// Failed are the txs whose merkle proof provided by the server did not match our headers.
+	Failed []chainhash.Hash
+}
+
 func (transactions *Transactions) onHeadersEvent(event headers.Event) {
 	switch event {
 	case headers.EventSynced:
 		transactions.verifyTransactions()
+	case headers.EventReorg:
+		transactions.unverifyRecentTransactions()
 	case headers.EventNewTip:
 		done := transactions.synchronizer.IncRequestsCounter()
 		transactions.headersTipHeight = transactions.headers.TipHeight()
@@ -86,15 +98,79 @@ func (transactions *Transactions) verifyTransaction(txHash chainhash.Hash, heigh
 	}
 	expectedMerkleRoot := hashMerkleRoot(merkle.Merkle, txHash, merkle.Pos)
 	if expectedMerkleRoot != header.MerkleRoot {
-		transactions.log.Warning("Merkle root verification failed")
+		transactions.log.WithField("txHash", txHash.String()).WithField("height", height).
+			Error("Merkle root verification failed, the server provided an invalid proof")
+		err := DBUpdate(transactions.db, func(dbTx DBTxInterface) error {
+			return dbTx.MarkTxVerificationFailed(txHash)
+		})
+		if err != nil {
+			transactions.log.WithError(err).Error("MarkTxVerificationFailed")
+		}
+		if transactions.onVerificationFailed != nil {
+			transactions.onVerificationFailed(txHash)
+		}
 		return
 	}
 	transactions.log.Debugf("Merkle root verification succeeded")
 
 	err = DBUpdate(transactions.db, func(dbTx DBTxInterface) error {
-		return dbTx.MarkTxVerified(txHash, header.Timestamp)
+		return dbTx.MarkTxVerified(txHash, height, header.Timestamp)
 	})
 	if err != nil {
 		transactions.log.WithError(err).Error("MarkTXVerified")
 	}
 }
+
+// unverifyRecentTransactions marks the verified txs in the blocks that might have been reverted by
+// a reorg as unverified, so they are verified again against the new headers once they are synced.
+func (transactions *Transactions) unverifyRecentTransactions() {
+	minHeight := transactions.headers.TipHeight() - headers.ReorgLimit
+	err := DBUpdate(transactions.db, func(dbTx DBTxInterface) error {
+		txHashes, err := dbTx.Transactions()
+		if err != nil {
+			return err
+		}
+		for _, txHash := range txHashes {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return err
+			}
+			if txInfo.Verified == nil || txInfo.Height < minHeight {
+				continue
+			}
+			if err := dbTx.MarkTxUnverified(txHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		transactions.log.WithError(err).Error("Failed to mark transactions as unverified after a reorg")
+	}
+}
+
+// VerificationStatus returns how many transactions were verified and which failed verification.
+func (transactions *Transactions) VerificationStatus() (*VerificationStatus, error) {
+	return DBView(transactions.db, func(dbTx DBTxInterface) (*VerificationStatus, error) {
+		txHashes, err := dbTx.Transactions()
+		if err != nil {
+			return nil, err
+		}
+		status := &VerificationStatus{Failed: []chainhash.Hash{}}
+		for _, txHash := range txHashes {
+			txInfo, err := dbTx.TxInfo(txHash)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case txInfo.Verified != nil && *txInfo.Verified:
+				status.Verified++
+			case txInfo.VerificationFailed:
+				status.Failed = append(status.Failed, txHash)
+			default:
+				status.Unverified++
+			}
+		}
+		return status, nil
+	})
+}
@@ -20,6 +20,14 @@ const (
 	CodeETH Code = "eth"
 	// CodeSEPETH is Ethereum Sepolia.
 	CodeSEPETH Code = "sepeth"
+	// CodeARBETH is Ether on Arbitrum One.
+	CodeARBETH Code = "arbeth"
+	// CodeOPETH is Ether on OP Mainnet (Optimism).
+	CodeOPETH Code = "opeth"
+	// CodeBASEETH is Ether on Base.
+	CodeBASEETH Code = "baseeth"
+	// CodePOL is POL on Polygon PoS.
+	CodePOL Code = "pol"
 	// If you add coins, don't forget to update `testnetCoins` below.
 	// There are some more coin codes for the supported erc20 tokens in erc20.go.
 )
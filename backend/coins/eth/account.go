@@ -6,10 +6,13 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"maps"
 	"math/big"
 	"net/http"
 	"os"
 	"path"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -77,14 +80,21 @@ type Account struct {
 
 	address Address
 
-	// updateLock covers balance, blockNumber, nextNonce, transactions and activeTxProposal.
+	// updateLock covers balance, blockNumber, nextNonce, transactions, ensNames, ensLookupRunning
+	// and activeTxProposals.
 	updateLock   locker.Locker
 	balance      coin.Amount
 	blockNumber  *big.Int
 	transactions []*accounts.TransactionData
+	// ensNames caches the primary ENS names of the counterparties of our transactions. An empty
+	// name means that the address has none.
+	ensNames map[ethcommon.Address]string
+	// ensLookupRunning is true while the ENS names of addresses not cached yet are looked up.
+	ensLookupRunning bool
 
-	// if not nil, SendTx() will sign and send this transaction. Set by TxProposal().
-	activeTxProposal *TxProposal
+	// if not empty, SendTx() will sign and send these transactions. Set by TxProposal(). There are
+	// multiple transactions if paying multiple recipients, in the order of the recipients.
+	activeTxProposals []*TxProposal
 
 	log *logrus.Entry
 
@@ -220,11 +230,19 @@ func (account *Account) updateOutgoingTransactions(tipHeight uint64) {
 		return
 	}
 
+	// Mined txs by nonce, to drop the other txs with the same nonce which were replaced by it or
+	// which it replaced.
+	minedTxs := map[uint64]*ethtypes.TransactionWithMetadata{}
+
 	// Update the stored txs' metadata if up to 12 confirmations.
 	for idx, tx := range outgoingTransactions {
 		txLog := account.log.WithField("idx", idx)
 		remoteTx, err := account.coin.client.TransactionReceiptWithBlockNumber(context.TODO(), tx.Transaction.Hash())
 		if remoteTx == nil || err != nil {
+			if tx.ReplacedBy != nil {
+				// The replacement is broadcast instead.
+				continue
+			}
 			// Transaction not found. This usually happens for pending transactions.
 			// In this case, check if the node actually knows about the transaction, and if not, re-broadcast.
 			// We do this because it seems that sometimes, a transaction that was broadcast without error still ends up lost.
@@ -244,6 +262,15 @@ func (account *Account) updateOutgoingTransactions(tipHeight uint64) {
 			}
 			continue
 		}
+		minedTxs[tx.Transaction.Nonce()] = tx
+		if tx.ReplacedBy != nil {
+			// The replaced tx was mined before its replacement.
+			tx.ReplacedBy = nil
+			if err := dbTx.PutOutgoingTransaction(tx); err != nil {
+				txLog.WithError(err).Error("could not update outgoing tx")
+				continue
+			}
+		}
 		success := remoteTx.Status == types.ReceiptStatusSuccessful
 		if tx.Height == 0 || (tipHeight-remoteTx.BlockNumber) < ethtypes.NumConfirmationsComplete || tx.Success != success {
 			tx.Height = remoteTx.BlockNumber
@@ -255,6 +282,16 @@ func (account *Account) updateOutgoingTransactions(tipHeight uint64) {
 			}
 		}
 	}
+	for _, tx := range outgoingTransactions {
+		minedTx, ok := minedTxs[tx.Transaction.Nonce()]
+		if !ok || minedTx == tx {
+			continue
+		}
+		account.log.WithField("nonce", tx.Transaction.Nonce()).Info("dropping superseded outgoing tx")
+		if err := dbTx.DeleteOutgoingTransaction(tx.Transaction.Hash()); err != nil {
+			account.log.WithError(err).Error("could not delete superseded outgoing tx")
+		}
+	}
 	if err := dbTx.Commit(); err != nil {
 		account.log.WithError(err).Error("could not commit db tx")
 		return
@@ -277,7 +314,7 @@ func (account *Account) confirmedTransactions() ([]*accounts.TransactionData, er
 }
 
 // outgoingTransactions gets all locally stored outgoing transactions. It filters out the ones also
-// present from the transactions source.
+// present from the transactions source and pending ones which were replaced by another tx.
 func (account *Account) outgoingTransactions(allTxs []*accounts.TransactionData) (
 	[]*ethtypes.TransactionWithMetadata, error) {
 	dbTx, err := account.db.Begin()
@@ -301,6 +338,9 @@ func (account *Account) outgoingTransactions(allTxs []*accounts.TransactionData)
 		if _, ok := allTxHashes[tx.TxID()]; ok {
 			continue
 		}
+		if tx.ReplacedBy != nil && tx.Height == 0 {
+			continue
+		}
 		transactions = append(transactions, tx)
 	}
 	return transactions, nil
@@ -377,6 +417,13 @@ func (account *Account) Update(
 		)
 	}
 	outgoingTransactionsData = append(outgoingTransactionsData, confirmedTransactions...)
+	if lookupENSNames := account.Config().ENSReverseLookup; lookupENSNames != nil && lookupENSNames() {
+		missing := account.applyENSNames(outgoingTransactionsData)
+		if len(missing) > 0 && !account.ensLookupRunning {
+			account.ensLookupRunning = true
+			go account.lookupENSNames(missing)
+		}
+	}
 	account.transactions = outgoingTransactionsData
 	for _, transaction := range account.transactions {
 		if err := account.notifier.Put([]byte(transaction.TxID)); err != nil {
@@ -395,6 +442,65 @@ func (account *Account) Update(
 	return nil
 }
 
+// applyENSNames sets the cached primary ENS names of the counterparties of the transactions. It
+// returns at most maxENSLookupsPerUpdate addresses which are not cached yet. The updateLock must be
+// held.
+func (account *Account) applyENSNames(transactions []*accounts.TransactionData) []ethcommon.Address {
+	if !account.coin.SupportsENS() {
+		return nil
+	}
+	var missing []ethcommon.Address
+	for _, transaction := range transactions {
+		for i := range transaction.Addresses {
+			addressAndAmount := &transaction.Addresses[i]
+			if !IsValidEthAddress(addressAndAmount.Address) {
+				continue
+			}
+			address := ethcommon.HexToAddress(addressAndAmount.Address)
+			if address == account.address.Address {
+				continue
+			}
+			name, ok := account.ensNames[address]
+			if ok {
+				addressAndAmount.ENSName = name
+				continue
+			}
+			if len(missing) < maxENSLookupsPerUpdate && !slices.Contains(missing, address) {
+				missing = append(missing, address)
+			}
+		}
+	}
+	return missing
+}
+
+// lookupENSNames looks up the primary ENS names of the addresses and caches them. The lookups are
+// made without holding the updateLock, so that they do not block the account. Afterwards, the names
+// are applied to the transactions and the frontend is notified.
+func (account *Account) lookupENSNames(addresses []ethcommon.Address) {
+	names := map[ethcommon.Address]string{}
+	for _, address := range addresses {
+		name, err := account.coin.LookupENSName(address)
+		if err != nil {
+			account.log.WithError(err).Warning("Could not look up the ENS name")
+			continue
+		}
+		names[address] = name
+	}
+	unlock := account.updateLock.Lock()
+	account.ensLookupRunning = false
+	if account.ensNames == nil {
+		account.ensNames = map[ethcommon.Address]string{}
+	}
+	maps.Copy(account.ensNames, names)
+	account.applyENSNames(account.transactions)
+	unlock()
+	account.Notify(observable.Event{
+		Subject: string(accountsTypes.EventStatusChanged),
+		Action:  action.Reload,
+		Object:  nil,
+	})
+}
+
 // pendingTxsAmount returns the total amount of pending transactions. Fees are not included for erc20 txs.
 func pendingTxsAmount(outgoingTransactionsData []*accounts.TransactionData, isErc20 bool) *big.Int {
 	pendingTxAmount := big.NewInt(0)
@@ -483,14 +589,108 @@ type TxProposal struct {
 	// not used in the transaction or signing except for making sure the BitBox displays the address
 	// with the same case (lowercase/uppercase/mixed) as the user entered.
 	RecipientAddress string
+	// RecipientENSName is the ENS name the user entered instead of an address, which was resolved
+	// to RecipientAddress. Empty if the user entered an address.
+	RecipientENSName string
 	PaymentRequest   *paymentrequest.Request
+	// ReplacedTxHash is the hash of the pending outgoing tx with the same nonce replaced by this tx
+	// (speed up or cancel), nil if this tx does not replace another one.
+	ReplacedTxHash *ethcommon.Hash
 }
 
+// newTx creates a tx to the recipient of the args.
 func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error) {
-	if !IsValidEthAddress(args.RecipientAddress) {
+	if !account.Synced() {
+		return nil, errp.WithStack(errors.ErrAccountNotsynced)
+	}
+	return account.newTxTo(args, args.RecipientAddress, args.Amount, account.balance.BigInt(), nil)
+}
+
+// newBatchTxs creates one tx per recipient in args.Recipients, with consecutive nonces. The txs are
+// returned in the order of the recipients. The funds of each tx are deducted from the balance
+// available to the next one. The recipient receiving all remaining funds, if any, is processed
+// last.
+func (account *Account) newBatchTxs(args *accounts.TxProposalArgs) ([]*TxProposal, error) {
+	if args.PaymentRequest != nil {
+		return nil, errp.New("Payment Requests do not allow multiple recipients")
+	}
+	if !account.Synced() {
+		return nil, errp.WithStack(errors.ErrAccountNotsynced)
+	}
+	order := make([]int, 0, len(args.Recipients))
+	sendAllIndex := -1
+	for i, recipient := range args.Recipients {
+		if !recipient.Amount.SendAll() {
+			order = append(order, i)
+			continue
+		}
+		if sendAllIndex != -1 {
+			return nil, errp.New("only one recipient can receive all remaining funds")
+		}
+		sendAllIndex = i
+	}
+	if sendAllIndex != -1 {
+		order = append(order, sendAllIndex)
+	}
+
+	txProposals := make([]*TxProposal, len(args.Recipients))
+	balance := account.balance.BigInt()
+	var nonce *uint64
+	for _, i := range order {
+		recipient := args.Recipients[i]
+		txProposal, err := account.newTxTo(args, recipient.Address, recipient.Amount, balance, nonce)
+		if err != nil {
+			return nil, err
+		}
+		txProposals[i] = txProposal
+		nextNonce := txProposal.Tx.Nonce() + 1
+		nonce = &nextNonce
+		balance = new(big.Int).Sub(balance, txProposal.Value)
+		if account.coin.erc20Token == nil {
+			balance.Sub(balance, txProposal.Fee)
+		}
+	}
+	if account.coin.erc20Token != nil {
+		// The fees are paid in ETH. The gas estimation only checks that each fee on its own can be
+		// paid, so we check that the ETH balance covers all of them.
+		totalFee := new(big.Int)
+		for _, txProposal := range txProposals {
+			totalFee.Add(totalFee, txProposal.Fee)
+		}
+		ethBalance, err := account.coin.client.Balance(context.TODO(), account.address.Address)
+		if err != nil {
+			return nil, err
+		}
+		if totalFee.Cmp(ethBalance) == 1 {
+			return nil, errp.WithStack(errors.ErrInsufficientFunds)
+		}
+	}
+	return txProposals, nil
+}
+
+// newTxTo creates a tx sending the amount to the recipient address. The balance is the amount
+// available for the tx, the account balance unless other txs are sent before. If nonce is nil, the
+// next nonce of the account is used.
+func (account *Account) newTxTo(
+	args *accounts.TxProposalArgs,
+	recipientAddress string,
+	amount coin.SendAmount,
+	balance *big.Int,
+	nonce *uint64,
+) (*TxProposal, error) {
+	var recipientENSName string
+	if IsENSName(recipientAddress) {
+		resolvedAddress, err := account.coin.ResolveENSName(recipientAddress)
+		if err != nil {
+			return nil, err
+		}
+		recipientENSName = recipientAddress
+		recipientAddress = resolvedAddress.Hex()
+	}
+	if !IsValidEthAddress(recipientAddress) {
 		return nil, errp.WithStack(errors.ErrInvalidAddress)
 	}
-	address := ethcommon.HexToAddress(args.RecipientAddress)
+	address := ethcommon.HexToAddress(recipientAddress)
 
 	suggestedGasFeeCap, suggestedGasTipCap, err := account.gasFees(args)
 	if err != nil {
@@ -501,17 +701,13 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error
 		return nil, errp.WithStack(errors.ErrFeesNotAvailable)
 	}
 
-	if !account.Synced() {
-		return nil, errp.WithStack(errors.ErrAccountNotsynced)
-	}
-
 	var value *big.Int
-	if args.Amount.SendAll() {
-		value = account.balance.BigInt() // set here only temporarily to estimate the gas
+	if amount.SendAll() {
+		value = balance // set here only temporarily to estimate the gas
 	} else {
 		allowZero := true
 
-		parsedAmount, err := args.Amount.Amount(account.coin.unitFactor(false), allowZero)
+		parsedAmount, err := amount.Amount(account.coin.unitFactor(false), allowZero)
 		if err != nil {
 			return nil, err
 		}
@@ -553,9 +749,9 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error
 	// For ERC20 transfers, the EstimateGas call fails if we try to spend more than we have and we
 	// do not have enough ether to pay the fee.
 	// We make some checks upfront to catch this before calling out to the node and failing.
-	if !args.Amount.SendAll() {
+	if !amount.SendAll() {
 		if account.coin.erc20Token != nil {
-			if value.Cmp(account.balance.BigInt()) == 1 {
+			if value.Cmp(balance) == 1 {
 				return nil, errp.WithStack(errors.ErrInsufficientFunds)
 			}
 		}
@@ -576,13 +772,13 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error
 		// in erc 20 tokens, the amount is in the token unit, while the fee is in ETH, so there is
 		// no issue withSendAll.
 
-		if !args.Amount.SendAll() && value.Cmp(account.balance.BigInt()) == 1 {
+		if !amount.SendAll() && value.Cmp(balance) == 1 {
 			return nil, errp.WithStack(errors.ErrInsufficientFunds)
 		}
 	} else {
-		if args.Amount.SendAll() {
+		if amount.SendAll() {
 			// Set the value correctly and check that the fee is smaller than or equal to the balance.
-			value = new(big.Int).Sub(account.balance.BigInt(), fee)
+			value = new(big.Int).Sub(balance, fee)
 			message.Value = value
 			if message.Value.Sign() < 0 {
 				return nil, errp.WithStack(errors.ErrInsufficientFunds)
@@ -590,7 +786,7 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error
 		} else {
 			// Check that the entered value and the estimated fee are not greater than the balance.
 			total := new(big.Int).Add(message.Value, fee)
-			if total.Cmp(account.balance.BigInt()) == 1 {
+			if total.Cmp(balance) == 1 {
 				return nil, errp.WithStack(errors.ErrInsufficientFunds)
 			}
 		}
@@ -603,9 +799,14 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error
 
 	var tx *types.Transaction
 
-	nextNonce, err := account.nextNonce()
-	if err != nil {
-		return nil, err
+	var nextNonce uint64
+	if nonce != nil {
+		nextNonce = *nonce
+	} else {
+		nextNonce, err = account.nextNonce()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if keystore.SupportsEIP1559() {
@@ -639,18 +840,34 @@ func (account *Account) newTx(args *accounts.TxProposalArgs) (*TxProposal, error
 		Value:            value,
 		Signer:           types.NewLondonSigner(account.coin.net.ChainID),
 		Keypath:          account.signingConfiguration.AbsoluteKeypath(),
-		RecipientAddress: args.RecipientAddress,
+		RecipientAddress: recipientAddress,
+		RecipientENSName: recipientENSName,
 		PaymentRequest:   args.PaymentRequest,
 	}, nil
 }
 
-// storePendingOutgoingTransaction puts an outgoing tx into the db with height 0 (pending).
-func (account *Account) storePendingOutgoingTransaction(transaction *types.Transaction) error {
+// storePendingOutgoingTransaction puts an outgoing tx into the db with height 0 (pending). If
+// replacedTxHash is not nil, the stored tx with this hash is marked as replaced by the new tx.
+func (account *Account) storePendingOutgoingTransaction(
+	transaction *types.Transaction, replacedTxHash *ethcommon.Hash) error {
 	dbTx, err := account.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer dbTx.Rollback()
+	if replacedTxHash != nil {
+		replacedTx, err := dbTx.OutgoingTransaction(*replacedTxHash)
+		if err != nil {
+			return err
+		}
+		if replacedTx != nil {
+			txHash := transaction.Hash()
+			replacedTx.ReplacedBy = &txHash
+			if err := dbTx.PutOutgoingTransaction(replacedTx); err != nil {
+				return err
+			}
+		}
+	}
 	if err := dbTx.PutOutgoingTransaction(
 		&ethtypes.TransactionWithMetadata{
 			Transaction:       transaction,
@@ -665,20 +882,49 @@ func (account *Account) storePendingOutgoingTransaction(transaction *types.Trans
 	return nil
 }
 
-// SendTx implements accounts.Interface.
+// SendTx implements accounts.Interface. If paying multiple recipients, the transactions are signed
+// and sent one after another in the order of their nonces, and the ID of the first one is returned.
 func (account *Account) SendTx(txNote string) (string, error) {
 	unlock := account.updateLock.RLock()
-	txProposal := account.activeTxProposal
+	txProposals := append([]*TxProposal{}, account.activeTxProposals...)
 	unlock()
-	if txProposal == nil {
+	if len(txProposals) == 0 {
 		return "", errp.New("No active tx proposal")
 	}
+	sort.Slice(txProposals, func(i, j int) bool {
+		return txProposals[i].Tx.Nonce() < txProposals[j].Tx.Nonce()
+	})
 
 	keystore, err := account.Config().ConnectKeystore()
 	if err != nil {
 		return "", err
 	}
 
+	var txID string
+	for i, txProposal := range txProposals {
+		hash, err := account.signAndSendTx(keystore, txProposal, txNote)
+		if err != nil {
+			if i > 0 {
+				// Drop the sent txs, so that retrying only sends the remaining ones.
+				unlock := account.updateLock.Lock()
+				account.activeTxProposals = txProposals[i:]
+				unlock()
+				account.EnqueueUpdate()
+				return "", errp.WithMessage(err, fmt.Sprintf("Sent only %d of %d transactions", i, len(txProposals)))
+			}
+			return "", err
+		}
+		if i == 0 {
+			txID = hash
+		}
+	}
+	account.EnqueueUpdate()
+	return txID, nil
+}
+
+// signAndSendTx signs and broadcasts a tx proposal and stores the tx note.
+func (account *Account) signAndSendTx(
+	keystore keystorePkg.Keystore, txProposal *TxProposal, txNote string) (string, error) {
 	account.log.Info("Signing and sending transaction")
 	if err := keystore.SignTransaction(txProposal); err != nil {
 		return "", err
@@ -689,7 +935,7 @@ func (account *Account) SendTx(txNote string) (string, error) {
 	if err := account.coin.client.SendTransaction(context.TODO(), txProposal.Tx); err != nil {
 		return "", errp.WithStack(err)
 	}
-	if err := account.storePendingOutgoingTransaction(txProposal.Tx); err != nil {
+	if err := account.storePendingOutgoingTransaction(txProposal.Tx, txProposal.ReplacedTxHash); err != nil {
 		return "", err
 	}
 
@@ -697,7 +943,6 @@ func (account *Account) SendTx(txNote string) (string, error) {
 		// Not critical.
 		account.log.WithError(err).Error("Failed to save transaction note when sending a tx")
 	}
-	account.EnqueueUpdate()
 	return txProposal.Tx.Hash().String(), nil
 }
 
@@ -768,19 +1013,76 @@ func (account *Account) TxProposal(
 	args *accounts.TxProposalArgs,
 ) (coin.Amount, coin.Amount, coin.Amount, error) {
 	defer account.updateLock.Lock()()
-	txProposal, err := account.newTx(args)
-	if err != nil {
-		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	var txProposals []*TxProposal
+	if len(args.Recipients) != 0 {
+		batchTxProposals, err := account.newBatchTxs(args)
+		if err != nil {
+			return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+		}
+		txProposals = batchTxProposals
+	} else {
+		txProposal, err := account.newTx(args)
+		if err != nil {
+			return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+		}
+		txProposals = []*TxProposal{txProposal}
 	}
-	account.activeTxProposal = txProposal
+	value, fee, total := account.activateTxProposals(txProposals)
+	return value, fee, total, nil
+}
 
+// activateTxProposals stores the tx proposals so they can be signed and sent with SendTx() and
+// returns the total value, fee and total (value plus fee, if paid in the same unit). The updateLock
+// must be held.
+func (account *Account) activateTxProposals(txProposals []*TxProposal) (coin.Amount, coin.Amount, coin.Amount) {
+	account.activeTxProposals = txProposals
+
+	value := new(big.Int)
+	fee := new(big.Int)
+	for _, txProposal := range txProposals {
+		value.Add(value, txProposal.Value)
+		fee.Add(fee, txProposal.Fee)
+	}
 	var total *big.Int
 	if account.coin.erc20Token != nil {
-		total = txProposal.Value
+		total = value
 	} else {
-		total = new(big.Int).Add(txProposal.Value, txProposal.Fee)
+		total = new(big.Int).Add(value, fee)
+	}
+	return coin.NewAmount(value), coin.NewAmount(fee), coin.NewAmount(total)
+}
+
+// TxProposalRecipientAmounts returns the amounts sent to each recipient by the active tx proposal,
+// in the order of the recipients.
+func (account *Account) TxProposalRecipientAmounts() []coin.Amount {
+	defer account.updateLock.RLock()()
+	amounts := make([]coin.Amount, len(account.activeTxProposals))
+	for i, txProposal := range account.activeTxProposals {
+		amounts[i] = coin.NewAmount(txProposal.Value)
+	}
+	return amounts
+}
+
+// TxProposalRecipient is a recipient of the active tx proposal.
+type TxProposalRecipient struct {
+	// Address is the address the tx pays to, resolved if the user entered an ENS name.
+	Address string
+	// ENSName is the ENS name the user entered, empty if they entered an address.
+	ENSName string
+}
+
+// TxProposalRecipients returns the recipients of the active tx proposal, in the order of the
+// recipients, so the resolved addresses of ENS names can be shown to the user.
+func (account *Account) TxProposalRecipients() []TxProposalRecipient {
+	defer account.updateLock.RLock()()
+	recipients := make([]TxProposalRecipient, len(account.activeTxProposals))
+	for i, txProposal := range account.activeTxProposals {
+		recipients[i] = TxProposalRecipient{
+			Address: txProposal.RecipientAddress,
+			ENSName: txProposal.RecipientENSName,
+		}
 	}
-	return coin.NewAmount(txProposal.Value), coin.NewAmount(txProposal.Fee), coin.NewAmount(total), nil
+	return recipients
 }
 
 // GetUnusedReceiveAddresses implements accounts.Interface.
@@ -7,12 +7,16 @@ import (
 	"math/big"
 	"net/http"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	accountsMocks "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient/mocks"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/keystore"
@@ -25,8 +29,10 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -191,6 +197,240 @@ func TestTxProposal(t *testing.T) {
 		})
 		require.Equal(t, errors.ErrInvalidAddress, errp.Cause(err))
 	})
+	t.Run("batch", func(t *testing.T) {
+		value, fee, total, err := acct.TxProposal(&accounts.TxProposalArgs{
+			Recipients: []accounts.TxRecipient{
+				{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmount("0.1")},
+				{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmount("0.2")},
+			},
+			FeeTargetCode: accounts.FeeTargetCodeCustom,
+			CustomFee:     "20",
+		})
+		require.NoError(t, err)
+		require.Equal(t, coin.NewAmountFromInt64(300000000000000000), value)
+		require.Equal(t, coin.NewAmountFromInt64(2*420000000000000), fee)
+		require.Equal(t, coin.NewAmountFromInt64(300840000000000000), total)
+		require.Equal(t,
+			[]coin.Amount{
+				coin.NewAmountFromInt64(100000000000000000),
+				coin.NewAmountFromInt64(200000000000000000),
+			},
+			acct.TxProposalRecipientAmounts())
+		require.Len(t, acct.activeTxProposals, 2)
+		require.Equal(t, uint64(0), acct.activeTxProposals[0].Tx.Nonce())
+		require.Equal(t, uint64(1), acct.activeTxProposals[1].Tx.Nonce())
+	})
+	t.Run("batch-send-all", func(t *testing.T) {
+		value, fee, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+			Recipients: []accounts.TxRecipient{
+				{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmountAll()},
+				{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmount("0.1")},
+			},
+			FeeTargetCode: accounts.FeeTargetCodeCustom,
+			CustomFee:     "20",
+		})
+		require.NoError(t, err)
+		require.Equal(t, coin.NewAmountFromInt64(1e18-2*420000000000000), value)
+		require.Equal(t, coin.NewAmountFromInt64(2*420000000000000), fee)
+		require.Equal(t,
+			[]coin.Amount{
+				coin.NewAmountFromInt64(900000000000000000 - 2*420000000000000),
+				coin.NewAmountFromInt64(100000000000000000),
+			},
+			acct.TxProposalRecipientAmounts())
+	})
+	t.Run("batch-two-send-all", func(t *testing.T) {
+		_, _, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+			Recipients: []accounts.TxRecipient{
+				{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmountAll()},
+				{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmountAll()},
+			},
+			FeeTargetCode: accounts.FeeTargetCodeCustom,
+			CustomFee:     "20",
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestTxProposalERC20BatchFees(t *testing.T) {
+	acct := newAccount(t)
+	defer acct.Close()
+	acct.coin.erc20Token = erc20.NewToken("0x0000000000000000000000000000000000000001", 6)
+	client := acct.coin.client.(*mocks.InterfaceMock)
+	// Enough ETH for the fee of one tx (21000 gas * 20 gwei), but not for two.
+	client.BalanceFunc = func(ctx context.Context, account common.Address) (*big.Int, error) {
+		return big.NewInt(21000 * 30e9), nil
+	}
+	require.NoError(t, acct.Update(big.NewInt(1e9), big.NewInt(100), nil))
+	require.Eventually(t, acct.Synced, time.Second, time.Millisecond*200)
+
+	recipients := []accounts.TxRecipient{
+		{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmount("1")},
+	}
+	_, fee, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+		Recipients:    recipients,
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "20",
+	})
+	require.NoError(t, err)
+	require.Equal(t, coin.NewAmountFromInt64(21000*20e9), fee)
+
+	_, _, _, err = acct.TxProposal(&accounts.TxProposalArgs{
+		Recipients:    append(recipients, recipients[0]),
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "20",
+	})
+	require.Equal(t, errors.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func TestSendTxBatchPartialFailure(t *testing.T) {
+	// SendTx enqueues an update of the account.
+	acct := newAccountWithOptions(t, false, make(chan *Account, 10))
+	defer acct.Close()
+	acct.Config().ConnectKeystore = func() (keystore.Keystore, error) {
+		return &keystoremock.KeystoreMock{
+			SupportsEIP1559Func: func() bool { return true },
+			SignTransactionFunc: func(interface{}) error { return nil },
+		}, nil
+	}
+	client := acct.coin.client.(*mocks.InterfaceMock)
+	sendErr := errp.New("connection lost")
+	client.SendTransactionFunc = func(ctx context.Context, tx *types.Transaction) error {
+		if tx.Nonce() == 1 {
+			return sendErr
+		}
+		return nil
+	}
+	require.NoError(t, acct.Update(big.NewInt(1e18), big.NewInt(100), nil))
+	require.Eventually(t, acct.Synced, time.Second, time.Millisecond*200)
+
+	_, _, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+		Recipients: []accounts.TxRecipient{
+			{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmount("0.1")},
+			{Address: "0xa29163852021BF4C139D03Dff59ae763AC73e84e", Amount: coin.NewSendAmount("0.2")},
+		},
+		FeeTargetCode: accounts.FeeTargetCodeCustom,
+		CustomFee:     "20",
+	})
+	require.NoError(t, err)
+
+	_, err = acct.SendTx("")
+	require.Equal(t, sendErr, errp.Cause(err))
+	// Only the tx which was not sent remains, so that it can be retried.
+	require.Len(t, acct.activeTxProposals, 1)
+	require.Equal(t, uint64(1), acct.activeTxProposals[0].Tx.Nonce())
+
+	client.SendTransactionFunc = func(ctx context.Context, tx *types.Transaction) error { return nil }
+	txID, err := acct.SendTx("")
+	require.NoError(t, err)
+	require.Equal(t, acct.activeTxProposals[0].Tx.Hash().String(), txID)
+	require.Len(t, client.SendTransactionCalls(), 3)
+}
+
+func TestReplacementTx(t *testing.T) {
+	acct := newAccount(t)
+	defer acct.Close()
+	notifier := &accountsMocks.Notifier{}
+	notifier.On("Put", mock.Anything).Return(nil)
+	acct.notifier = notifier
+	client := acct.coin.client.(*mocks.InterfaceMock)
+	minedTxs := map[common.Hash]uint64{}
+	var minedTxsLock sync.Mutex
+	client.TransactionReceiptWithBlockNumberFunc = func(
+		ctx context.Context, hash common.Hash) (*rpcclient.RPCTransactionReceipt, error) {
+		minedTxsLock.Lock()
+		defer minedTxsLock.Unlock()
+		height, ok := minedTxs[hash]
+		if !ok {
+			return nil, nil
+		}
+		return &rpcclient.RPCTransactionReceipt{
+			Receipt:     types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 21000},
+			BlockNumber: height,
+		}, nil
+	}
+	client.TransactionByHashFunc = func(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+		return nil, true, nil
+	}
+
+	recipient := common.HexToAddress("0xa29163852021BF4C139D03Dff59ae763AC73e84e")
+	pendingTx := types.NewTx(&types.DynamicFeeTx{
+		Nonce:     0,
+		GasTipCap: big.NewInt(20e9),
+		GasFeeCap: big.NewInt(20e9),
+		Gas:       21000,
+		To:        &recipient,
+		Value:     big.NewInt(1e17),
+	})
+	require.NoError(t, acct.storePendingOutgoingTransaction(pendingTx, nil))
+	require.NoError(t, acct.Update(big.NewInt(1e18), big.NewInt(100), nil))
+	require.Eventually(t, acct.Synced, time.Second, time.Millisecond*200)
+
+	args := func(customFee string) *accounts.TxProposalArgs {
+		return &accounts.TxProposalArgs{FeeTargetCode: accounts.FeeTargetCodeCustom, CustomFee: customFee}
+	}
+
+	t.Run("fee-too-low", func(t *testing.T) {
+		_, _, _, err := acct.BumpFeeProposal(pendingTx.Hash().Hex(), args("20"))
+		require.Equal(t, errors.ErrFeeTooLow, errp.Cause(err))
+	})
+
+	t.Run("unknown-tx", func(t *testing.T) {
+		_, _, _, err := acct.BumpFeeProposal(common.HexToHash("0x1234").Hex(), args("30"))
+		require.Error(t, err)
+	})
+
+	t.Run("speed-up", func(t *testing.T) {
+		// The fees are raised by at least 10%.
+		value, fee, total, err := acct.BumpFeeProposal(pendingTx.Hash().Hex(), args("21"))
+		require.NoError(t, err)
+		require.Equal(t, coin.NewAmountFromInt64(1e17), value)
+		require.Equal(t, coin.NewAmountFromInt64(21000*22e9), fee)
+		require.Equal(t, coin.NewAmountFromInt64(1e17+21000*22e9), total)
+		tx := acct.activeTxProposals[0].Tx
+		require.Equal(t, uint64(0), tx.Nonce())
+		require.Equal(t, &recipient, tx.To())
+		require.Equal(t, big.NewInt(1e17), tx.Value())
+		require.Equal(t, big.NewInt(22e9), tx.GasTipCap())
+		require.Equal(t, pendingTx.Hash(), *acct.activeTxProposals[0].ReplacedTxHash)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		value, fee, _, err := acct.CancelTxProposal(pendingTx.Hash().Hex(), args("30"))
+		require.NoError(t, err)
+		require.Equal(t, coin.NewAmountFromInt64(0), value)
+		require.Equal(t, coin.NewAmountFromInt64(21000*30e9), fee)
+		tx := acct.activeTxProposals[0].Tx
+		require.Equal(t, uint64(0), tx.Nonce())
+		require.Equal(t, acct.address.Address, *tx.To())
+		require.Equal(t, big.NewInt(0), tx.Value())
+	})
+
+	// Store the cancel tx as if it was sent.
+	cancelTx := acct.activeTxProposals[0].Tx
+	require.NoError(t, acct.storePendingOutgoingTransaction(cancelTx, acct.activeTxProposals[0].ReplacedTxHash))
+
+	outgoingTransactions, err := acct.outgoingTransactions(nil)
+	require.NoError(t, err)
+	require.Len(t, outgoingTransactions, 1)
+	require.Equal(t, cancelTx.Hash(), outgoingTransactions[0].Transaction.Hash())
+
+	_, _, _, err = acct.BumpFeeProposal(pendingTx.Hash().Hex(), args("40"))
+	require.Equal(t, errors.ErrTxNotReplaceable, errp.Cause(err))
+
+	// Once the cancel tx is mined, the replaced tx is dropped.
+	minedTxsLock.Lock()
+	minedTxs[cancelTx.Hash()] = 100
+	minedTxsLock.Unlock()
+	acct.updateOutgoingTransactions(100)
+	dbTx, err := acct.db.Begin()
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+	storedTxs, err := dbTx.OutgoingTransactions()
+	require.NoError(t, err)
+	require.Len(t, storedTxs, 1)
+	require.Equal(t, cancelTx.Hash(), storedTxs[0].Transaction.Hash())
+	require.Equal(t, uint64(100), storedTxs[0].Height)
 }
 
 func TestMatchesAddress(t *testing.T) {
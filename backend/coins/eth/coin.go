@@ -3,6 +3,7 @@
 package eth
 
 import (
+	"context"
 	"math/big"
 	"strings"
 
@@ -212,6 +213,12 @@ func (coin *Coin) ERC20Token() *erc20.Token {
 	return coin.erc20Token
 }
 
+// ERC20TokenMetadata queries the name, symbol and decimals of the ERC20 token deployed at
+// contractAddress on this coin's chain.
+func (coin *Coin) ERC20TokenMetadata(contractAddress common.Address) (*erc20.Metadata, error) {
+	return erc20.FetchMetadata(context.TODO(), coin.client, contractAddress)
+}
+
 // Close implements coin.Coin.
 func (coin *Coin) Close() error {
 	// TODO: shut down rpc connection.
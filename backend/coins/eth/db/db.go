@@ -10,6 +10,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/jsonp"
+	"github.com/ethereum/go-ethereum/common"
 	"go.etcd.io/bbolt"
 )
 
@@ -77,6 +78,24 @@ func (tx *Tx) PutOutgoingTransaction(transaction *types.TransactionWithMetadata)
 		jsonp.MustMarshal(transaction))
 }
 
+// OutgoingTransaction implements DBTxInterface.
+func (tx *Tx) OutgoingTransaction(txHash common.Hash) (*types.TransactionWithMetadata, error) {
+	txSerialized := tx.bucketOutgoingTransactions.Get(txHash.Bytes())
+	if txSerialized == nil {
+		return nil, nil
+	}
+	transaction := new(types.TransactionWithMetadata)
+	if err := json.Unmarshal(txSerialized, transaction); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return transaction, nil
+}
+
+// DeleteOutgoingTransaction implements DBTxInterface.
+func (tx *Tx) DeleteOutgoingTransaction(txHash common.Hash) error {
+	return tx.bucketOutgoingTransactions.Delete(txHash.Bytes())
+}
+
 type byNonce []*types.TransactionWithMetadata
 
 func (txs byNonce) Len() int      { return len(txs) }
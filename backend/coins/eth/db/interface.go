@@ -2,7 +2,10 @@
 
 package db
 
-import "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
+import (
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/ethereum/go-ethereum/common"
+)
 
 // TxInterface needs to be implemented to persist all wallet/transaction related data.
 type TxInterface interface {
@@ -15,6 +18,13 @@ type TxInterface interface {
 	// PutOutgoingTransaction stores the transaction in the collection of outgoing transactions.
 	PutOutgoingTransaction(*types.TransactionWithMetadata) error
 
+	// OutgoingTransaction returns the stored outgoing transaction with the given hash, or nil if it
+	// does not exist.
+	OutgoingTransaction(common.Hash) (*types.TransactionWithMetadata, error)
+
+	// DeleteOutgoingTransaction removes the outgoing transaction with the given hash.
+	DeleteOutgoingTransaction(common.Hash) error
+
 	// OutgoingTransactions returns the stored list of outgoing transactions, sorted descending by
 	// the transaction nonce.
 	OutgoingTransactions() ([]*types.TransactionWithMetadata, error)
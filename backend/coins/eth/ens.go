@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ensRegistryAddress is the address of the ENS registry, which is the same on mainnet and Sepolia.
+var ensRegistryAddress = ethcommon.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// ensABI contains the functions of the ENS registry (`resolver`) and of public resolvers (`addr`,
+// `name`) used to resolve names and addresses.
+const ensABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"name":"","type":"address"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"addr","outputs":[{"name":"","type":"address"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"name","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"}]`
+
+// maxENSLookupsPerUpdate limits the number of counterparty addresses looked up by an account
+// update. The remaining ones are looked up in the following updates.
+const maxENSLookupsPerUpdate = 10
+
+// IsENSName returns true if name looks like an ENS name, e.g. "vitalik.eth".
+func IsENSName(name string) bool {
+	name = strings.ToLower(name)
+	if !strings.HasSuffix(name, ".eth") || strings.ContainsAny(name, " \t\n/:") {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ENSNameHash computes the namehash of an ENS name as defined in ENSIP-1. The name is lowercased,
+// but not normalized further.
+func ENSNameHash(name string) ethcommon.Hash {
+	var node ethcommon.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// SupportsENS returns true if ENS names can be resolved on the chain of the coin.
+func (coin *Coin) SupportsENS() bool {
+	chainID := coin.net.ChainID
+	return chainID.Cmp(params.MainnetChainConfig.ChainID) == 0 ||
+		chainID.Cmp(params.SepoliaChainConfig.ChainID) == 0
+}
+
+// ensCall calls a function of ensABI taking the node as its only argument.
+func (coin *Coin) ensCall(contract ethcommon.Address, method string, node ethcommon.Hash) (interface{}, error) {
+	parsed, err := abi.JSON(strings.NewReader(ensABI))
+	if err != nil {
+		panic(errp.WithStack(err))
+	}
+	data, err := parsed.Pack(method, node)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	result, err := coin.client.CallContract(
+		context.TODO(), ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parsed.Unpack(method, result)
+	if err != nil || len(values) != 1 {
+		return nil, errp.Newf("unexpected result of %s()", method)
+	}
+	return values[0], nil
+}
+
+// ensAddr calls a function of ensABI returning an address.
+func (coin *Coin) ensAddr(contract ethcommon.Address, method string, node ethcommon.Hash) (ethcommon.Address, error) {
+	value, err := coin.ensCall(contract, method, node)
+	if err != nil {
+		return ethcommon.Address{}, err
+	}
+	address, ok := value.(ethcommon.Address)
+	if !ok {
+		return ethcommon.Address{}, errp.Newf("unexpected result of %s()", method)
+	}
+	return address, nil
+}
+
+// ResolveENSName returns the address the ENS name points to. Returns ErrInvalidAddress if the name
+// does not exist or does not point to an address.
+func (coin *Coin) ResolveENSName(name string) (ethcommon.Address, error) {
+	if !coin.SupportsENS() || !IsENSName(name) {
+		return ethcommon.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	node := ENSNameHash(name)
+	resolver, err := coin.ensAddr(ensRegistryAddress, "resolver", node)
+	if err != nil {
+		return ethcommon.Address{}, err
+	}
+	if resolver == (ethcommon.Address{}) {
+		return ethcommon.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	address, err := coin.ensAddr(resolver, "addr", node)
+	if err != nil {
+		return ethcommon.Address{}, err
+	}
+	if address == (ethcommon.Address{}) {
+		return ethcommon.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	return address, nil
+}
+
+// LookupENSName returns the primary ENS name of the address (reverse resolution), or an empty
+// string if it has none. The name is only returned if it resolves back to the address, as anyone
+// can claim any name in the reverse record of their address.
+func (coin *Coin) LookupENSName(address ethcommon.Address) (string, error) {
+	if !coin.SupportsENS() {
+		return "", nil
+	}
+	node := ENSNameHash(strings.ToLower(address.Hex()[2:]) + ".addr.reverse")
+	resolver, err := coin.ensAddr(ensRegistryAddress, "resolver", node)
+	if err != nil {
+		return "", err
+	}
+	if resolver == (ethcommon.Address{}) {
+		return "", nil
+	}
+	value, err := coin.ensCall(resolver, "name", node)
+	if err != nil {
+		return "", err
+	}
+	name, ok := value.(string)
+	if !ok {
+		return "", errp.New("unexpected result of name()")
+	}
+	if !IsENSName(name) {
+		return "", nil
+	}
+	resolved, err := coin.ResolveENSName(name)
+	if err != nil {
+		if errp.Cause(err) == errors.ErrInvalidAddress {
+			return "", nil
+		}
+		return "", err
+	}
+	if resolved != address {
+		return "", nil
+	}
+	return name, nil
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient/mocks"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsENSName(t *testing.T) {
+	require.True(t, IsENSName("vitalik.eth"))
+	require.True(t, IsENSName("Pay.Vitalik.ETH"))
+	require.False(t, IsENSName(".eth"))
+	require.False(t, IsENSName("vitalik..eth"))
+	require.False(t, IsENSName("vitalik.com"))
+	require.False(t, IsENSName("vitalik eth.eth"))
+	require.False(t, IsENSName("0xa29163852021BF4C139D03Dff59ae763AC73e84e"))
+}
+
+func TestENSNameHash(t *testing.T) {
+	// Test vectors from ENSIP-1.
+	require.Equal(t, common.Hash{}, ENSNameHash(""))
+	require.Equal(t,
+		common.HexToHash("0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"),
+		ENSNameHash("eth"))
+	require.Equal(t,
+		common.HexToHash("0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"),
+		ENSNameHash("foo.eth"))
+	require.Equal(t, ENSNameHash("foo.eth"), ENSNameHash("FOO.eth"))
+}
+
+// mockENS makes the client answer ENS registry and resolver calls. names maps the names to the
+// addresses they resolve to, reverseNames maps addresses to their primary names. Returns a function
+// returning the number of contract calls made.
+func mockENS(
+	t *testing.T,
+	client *mocks.InterfaceMock,
+	names map[string]common.Address,
+	reverseNames map[common.Address]string,
+) func() int {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(ensABI))
+	require.NoError(t, err)
+	resolver := common.HexToAddress("0x231b0Ee14048e9dCcD1d247744d114a4EB5E8E63")
+	nodes := map[common.Hash]bool{}
+	for name := range names {
+		nodes[ENSNameHash(name)] = true
+	}
+	reverseNodes := map[common.Hash]string{}
+	for address, name := range reverseNames {
+		node := ENSNameHash(strings.ToLower(address.Hex()[2:]) + ".addr.reverse")
+		reverseNodes[node] = name
+		nodes[node] = true
+	}
+	calls := 0
+	client.CallContractFunc = func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		calls++
+		method, err := parsed.MethodById(msg.Data[:4])
+		require.NoError(t, err)
+		args, err := method.Inputs.Unpack(msg.Data[4:])
+		require.NoError(t, err)
+		node := common.Hash(args[0].([32]byte))
+		switch method.Name {
+		case "resolver":
+			require.Equal(t, ensRegistryAddress, *msg.To)
+			if !nodes[node] {
+				return method.Outputs.Pack(common.Address{})
+			}
+			return method.Outputs.Pack(resolver)
+		case "addr":
+			require.Equal(t, resolver, *msg.To)
+			for name, address := range names {
+				if ENSNameHash(name) == node {
+					return method.Outputs.Pack(address)
+				}
+			}
+			return method.Outputs.Pack(common.Address{})
+		default:
+			require.Equal(t, resolver, *msg.To)
+			return method.Outputs.Pack(reverseNodes[node])
+		}
+	}
+	return func() int { return calls }
+}
+
+func TestTxProposalENSName(t *testing.T) {
+	acct := newAccount(t)
+	defer acct.Close()
+	resolvedAddress := common.HexToAddress("0xa29163852021BF4C139D03Dff59ae763AC73e84e")
+	mockENS(t, acct.coin.client.(*mocks.InterfaceMock),
+		map[string]common.Address{"vitalik.eth": resolvedAddress}, nil)
+	require.NoError(t, acct.Update(big.NewInt(1e18), big.NewInt(100), nil))
+	require.Eventually(t, acct.Synced, time.Second, time.Millisecond*200)
+
+	t.Run("resolved", func(t *testing.T) {
+		_, _, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+			RecipientAddress: "Vitalik.eth",
+			Amount:           coin.NewSendAmount("0.1"),
+			FeeTargetCode:    accounts.FeeTargetCodeCustom,
+			CustomFee:        "20",
+		})
+		require.NoError(t, err)
+		require.Equal(t,
+			[]TxProposalRecipient{{Address: resolvedAddress.Hex(), ENSName: "Vitalik.eth"}},
+			acct.TxProposalRecipients())
+		require.Equal(t, resolvedAddress, *acct.activeTxProposals[0].Tx.To())
+	})
+	t.Run("batch", func(t *testing.T) {
+		_, _, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+			Recipients: []accounts.TxRecipient{
+				{Address: "0x71C7656EC7ab88b098defB751B7401B5f6d8976F", Amount: coin.NewSendAmount("0.1")},
+				{Address: "vitalik.eth", Amount: coin.NewSendAmount("0.2")},
+			},
+			FeeTargetCode: accounts.FeeTargetCodeCustom,
+			CustomFee:     "20",
+		})
+		require.NoError(t, err)
+		require.Equal(t,
+			[]TxProposalRecipient{
+				{Address: "0x71C7656EC7ab88b098defB751B7401B5f6d8976F"},
+				{Address: resolvedAddress.Hex(), ENSName: "vitalik.eth"},
+			},
+			acct.TxProposalRecipients())
+	})
+	t.Run("unknown", func(t *testing.T) {
+		_, _, _, err := acct.TxProposal(&accounts.TxProposalArgs{
+			RecipientAddress: "unknown.eth",
+			Amount:           coin.NewSendAmount("0.1"),
+			FeeTargetCode:    accounts.FeeTargetCodeCustom,
+			CustomFee:        "20",
+		})
+		require.Equal(t, errors.ErrInvalidAddress, errp.Cause(err))
+	})
+}
+
+func TestLookupENSNames(t *testing.T) {
+	acct := newAccount(t)
+	defer acct.Close()
+	named := common.HexToAddress("0xa29163852021BF4C139D03Dff59ae763AC73e84e")
+	spoofing := common.HexToAddress("0x71C7656EC7ab88b098defB751B7401B5f6d8976F")
+	unnamed := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	calls := mockENS(t, acct.coin.client.(*mocks.InterfaceMock),
+		map[string]common.Address{"vitalik.eth": named},
+		map[common.Address]string{
+			named: "vitalik.eth",
+			// The name does not resolve to this address.
+			spoofing: "vitalik.eth",
+		})
+
+	newTransactions := func() []*accounts.TransactionData {
+		return []*accounts.TransactionData{
+			{Addresses: []accounts.AddressAndAmount{{Address: named.Hex()}}},
+			{Addresses: []accounts.AddressAndAmount{{Address: spoofing.Hex()}}},
+			{Addresses: []accounts.AddressAndAmount{{Address: unnamed.Hex()}}},
+			{Addresses: []accounts.AddressAndAmount{{Address: acct.address.Address.Hex()}}},
+		}
+	}
+	transactions := newTransactions()
+	missing := acct.applyENSNames(transactions)
+	require.Equal(t, []common.Address{named, spoofing, unnamed}, missing)
+	// The names are looked up outside of the update, then applied from the cache.
+	acct.lookupENSNames(missing)
+	require.Empty(t, acct.applyENSNames(transactions))
+	require.Equal(t, "vitalik.eth", transactions[0].Addresses[0].ENSName)
+	require.Equal(t, "", transactions[1].Addresses[0].ENSName)
+	require.Equal(t, "", transactions[2].Addresses[0].ENSName)
+	require.Equal(t, "", transactions[3].Addresses[0].ENSName)
+
+	// The names are cached.
+	numCalls := calls()
+	transactions = newTransactions()
+	require.Empty(t, acct.applyENSNames(transactions))
+	require.Equal(t, numCalls, calls())
+	require.Equal(t, "vitalik.eth", transactions[0].Addresses[0].ENSName)
+}
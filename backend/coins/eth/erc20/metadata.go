@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package erc20
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// IERC20MetadataABI is the ABI of the optional metadata functions of ERC20 tokens, which are not
+// part of IERC20 (see `ERC20Detailed` in IERC20.sol).
+const IERC20MetadataABI = `[{"constant":true,"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"payable":false,"stateMutability":"view","type":"function"}]`
+
+// Metadata holds the name, symbol and decimals of an ERC20 token, as reported by its contract.
+type Metadata struct {
+	Name     string
+	Symbol   string
+	Decimals uint
+}
+
+// callString calls a contract function returning a string. Some old tokens (e.g. MKR) return a
+// bytes32 instead of a string, which is also supported.
+func callString(
+	ctx context.Context,
+	caller ethereum.ContractCaller,
+	parsed abi.ABI,
+	contractAddress common.Address,
+	method string) (string, error) {
+	data, err := parsed.Pack(method)
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &contractAddress, Data: data}, nil)
+	if err != nil {
+		return "", err
+	}
+	values, err := parsed.Unpack(method, result)
+	if err == nil && len(values) == 1 {
+		if value, ok := values[0].(string); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	if len(result) == 32 {
+		return strings.TrimSpace(string(bytes.TrimRight(result, "\x00"))), nil
+	}
+	return "", errp.Newf("unexpected result of %s()", method)
+}
+
+// FetchMetadata queries the name, symbol and decimals of the ERC20 token deployed at
+// contractAddress.
+func FetchMetadata(
+	ctx context.Context,
+	caller ethereum.ContractCaller,
+	contractAddress common.Address) (*Metadata, error) {
+	parsed, err := abi.JSON(strings.NewReader(IERC20MetadataABI))
+	if err != nil {
+		panic(errp.WithStack(err))
+	}
+	name, err := callString(ctx, caller, parsed, contractAddress, "name")
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := callString(ctx, caller, parsed, contractAddress, "symbol")
+	if err != nil {
+		return nil, err
+	}
+	if symbol == "" {
+		return nil, errp.New("the token has no symbol")
+	}
+	data, err := parsed.Pack("decimals")
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{To: &contractAddress, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parsed.Unpack("decimals", result)
+	if err != nil {
+		return nil, errp.WithMessage(err, "unexpected result of decimals()")
+	}
+	decimals, ok := values[0].(uint8)
+	if !ok {
+		return nil, errp.New("unexpected result of decimals()")
+	}
+	return &Metadata{
+		Name:     name,
+		Symbol:   symbol,
+		Decimals: uint(decimals),
+	}, nil
+}
@@ -61,6 +61,18 @@ func NewEtherScan(chainId string, httpClient *http.Client, limiter *rate.Limiter
 	}
 }
 
+// NewBlockscout creates an instance of EtherScan talking to the EtherScan compatible API of a
+// Blockscout instance, e.g. "https://eth.blockscout.com/api" or a self-hosted one. Only the
+// account module is compatible, so it must only be used as a source of transactions
+// (Transactions() and TokenTransactionsByContract()), not as an RPC client.
+func NewBlockscout(apiURL string, httpClient *http.Client, limiter *rate.Limiter) *EtherScan {
+	return &EtherScan{
+		url:        apiURL,
+		httpClient: httpClient,
+		limiter:    limiter,
+	}
+}
+
 func (etherScan *EtherScan) call(ctx context.Context, params url.Values, result interface{}) error {
 	return etherScan.callWithMethod(ctx, http.MethodGet, params, result)
 }
@@ -74,7 +86,9 @@ func (etherScan *EtherScan) callWithMethod(
 	if err := etherScan.limiter.Wait(ctx); err != nil {
 		return errp.WithStack(err)
 	}
-	params.Set("chainId", etherScan.chainId)
+	if etherScan.chainId != "" {
+		params.Set("chainId", etherScan.chainId)
+	}
 	encodedParams := params.Encode()
 	requestURL := etherScan.url
 	var requestBody io.Reader
@@ -242,3 +242,34 @@ func TestTokenTransactionsByContractPaginationDedup(t *testing.T) {
 	}
 	require.Equal(t, 2, dupCount)
 }
+
+func TestBlockscoutTransactions(t *testing.T) {
+	address := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	other := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	blockscout := NewBlockscout("https://blockscout.example.test/api", &http.Client{
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			require.Equal(t, "blockscout.example.test", req.URL.Host)
+			require.Equal(t, "/api", req.URL.Path)
+			values := formValues(t, req)
+			require.False(t, values.Has("chainId"))
+			require.Equal(t, address.Hex(), values.Get("address"))
+			var result []map[string]string
+			if values.Get("action") == "txlist" {
+				tx := makeTokenTx(
+					"0x00000000000000000000000000000000000000000000000000000000000000aa",
+					"10", other, address, common.Address{})
+				tx["contractAddress"] = ""
+				result = append(result, tx)
+			}
+			body, err := json.Marshal(map[string]interface{}{"status": "1", "result": result})
+			require.NoError(t, err)
+			return jsonRPCResponse(t, string(body)), nil
+		}),
+	}, rate.NewLimiter(rate.Inf, 1))
+
+	transactions, err := blockscout.Transactions(big.NewInt(20), address, big.NewInt(20), nil)
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	require.Equal(t, "0x00000000000000000000000000000000000000000000000000000000000000aa", transactions[0].TxID)
+	require.Equal(t, 11, transactions[0].NumConfirmations)
+}
@@ -0,0 +1,277 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonrpc
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient"
+	ethtypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// feeHistoryBlocks is the number of recent blocks considered to estimate the priority fees.
+	feeHistoryBlocks = 20
+	// maxBalancesPerBatch is the maximum number of eth_getBalance calls per batch request.
+	maxBalancesPerBatch = 20
+)
+
+// feeHistoryPercentiles are the priority fee percentiles of the txs in each block used for the
+// low, normal and high fee targets.
+var feeHistoryPercentiles = []float64{10, 50, 90}
+
+// Client is an rpcclient.Interface talking directly to the JSON-RPC API of an Ethereum node, e.g.
+// a self-hosted geth or Nethermind node, instead of going through EtherScan.
+type Client struct {
+	rpc *rpc.Client
+}
+
+// NewClient creates a new client for the JSON-RPC endpoint at url. The http client is used for all
+// requests, so that the proxy settings apply.
+func NewClient(url string, httpClient *http.Client) (*Client, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, errp.Newf("invalid JSON-RPC URL %q, expected a http:// or https:// URL", url)
+	}
+	rpcClient, err := rpc.DialHTTPWithClient(url, httpClient)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &Client{rpc: rpcClient}, nil
+}
+
+func (client *Client) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := client.rpc.CallContext(ctx, result, method, args...); err != nil {
+		return errp.WithMessage(err, method)
+	}
+	return nil
+}
+
+// TransactionReceiptWithBlockNumber implements rpcclient.Interface.
+func (client *Client) TransactionReceiptWithBlockNumber(
+	ctx context.Context, hash common.Hash) (*rpcclient.RPCTransactionReceipt, error) {
+	var result *rpcclient.RPCTransactionReceipt
+	if err := client.call(ctx, &result, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BlockNumber implements rpcclient.Interface.
+func (client *Client) BlockNumber(ctx context.Context) (*big.Int, error) {
+	var result hexutil.Big
+	if err := client.call(ctx, &result, "eth_blockNumber"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
+// TransactionByHash implements rpcclient.Interface.
+func (client *Client) TransactionByHash(
+	ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	var result *rpcclient.RPCTransaction
+	if err := client.call(ctx, &result, "eth_getTransactionByHash", hash); err != nil {
+		return nil, false, err
+	}
+	if result == nil {
+		return nil, false, errp.WithStack(ethereum.NotFound)
+	}
+	return &result.Transaction, result.BlockNumber == nil, nil
+}
+
+// Balance implements rpcclient.Interface.
+func (client *Client) Balance(ctx context.Context, account common.Address) (*big.Int, error) {
+	var result hexutil.Big
+	if err := client.call(ctx, &result, "eth_getBalance", account, "latest"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
+// Balances returns the balances for multiple addresses, using batch requests.
+func (client *Client) Balances(ctx context.Context, addresses []common.Address) (map[common.Address]*big.Int, error) {
+	balances := make(map[common.Address]*big.Int, len(addresses))
+	for addressesChunk := range slices.Chunk(addresses, maxBalancesPerBatch) {
+		results := make([]hexutil.Big, len(addressesChunk))
+		batch := make([]rpc.BatchElem, len(addressesChunk))
+		for i, address := range addressesChunk {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getBalance",
+				Args:   []interface{}{address, "latest"},
+				Result: &results[i],
+			}
+		}
+		if err := client.rpc.BatchCallContext(ctx, batch); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		for i, address := range addressesChunk {
+			if batch[i].Error != nil {
+				return nil, errp.WithMessage(batch[i].Error, "eth_getBalance")
+			}
+			balances[address] = (*big.Int)(&results[i])
+		}
+	}
+	return balances, nil
+}
+
+// ERC20Balance implements rpcclient.Interface.
+func (client *Client) ERC20Balance(account common.Address, erc20Token *erc20.Token) (*big.Int, error) {
+	parsed, err := abi.JSON(strings.NewReader(erc20.IERC20ABI))
+	if err != nil {
+		panic(errp.WithStack(err))
+	}
+	data, err := parsed.Pack("balanceOf", account)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	contractAddress := erc20Token.ContractAddress()
+	result, err := client.CallContract(
+		context.TODO(), ethereum.CallMsg{To: &contractAddress, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parsed.Unpack("balanceOf", result)
+	if err != nil {
+		return nil, errp.WithMessage(err, "unexpected result of balanceOf()")
+	}
+	balance, ok := values[0].(*big.Int)
+	if !ok {
+		return nil, errp.New("unexpected result of balanceOf()")
+	}
+	return balance, nil
+}
+
+// callArgs converts a call message to the argument of eth_call and eth_estimateGas.
+func callArgs(msg ethereum.CallMsg) map[string]interface{} {
+	args := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		args["input"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		args["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		args["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil && msg.GasPrice.Sign() > 0 {
+		args["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return args
+}
+
+// CallContract implements rpcclient.Interface.
+func (client *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	block := "latest"
+	if blockNumber != nil {
+		block = hexutil.EncodeBig(blockNumber)
+	}
+	var result hexutil.Bytes
+	if err := client.call(ctx, &result, "eth_call", callArgs(msg), block); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SendTransaction implements rpcclient.Interface.
+func (client *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	encodedTx, err := tx.MarshalBinary()
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return client.call(ctx, nil, "eth_sendRawTransaction", hexutil.Bytes(encodedTx))
+}
+
+// PendingNonceAt implements rpcclient.Interface.
+func (client *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	var result hexutil.Uint64
+	if err := client.call(ctx, &result, "eth_getTransactionCount", account, "pending"); err != nil {
+		return 0, err
+	}
+	return uint64(result), nil
+}
+
+// EstimateGas implements rpcclient.Interface.
+func (client *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	var result hexutil.Uint64
+	if err := client.call(ctx, &result, "eth_estimateGas", callArgs(msg)); err != nil {
+		return 0, err
+	}
+	return uint64(result), nil
+}
+
+// SuggestGasPrice implements rpcclient.Interface.
+func (client *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	var result hexutil.Big
+	if err := client.call(ctx, &result, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
+// medianBigInt returns the median of values, which must not be empty. values is sorted in place.
+func medianBigInt(values []*big.Int) *big.Int {
+	slices.SortFunc(values, func(a, b *big.Int) int { return a.Cmp(b) })
+	return values[len(values)/2]
+}
+
+// FeeTargets implements rpcclient.Interface. The priority fees are the median over the recent
+// blocks of the 10th, 50th and 90th percentile of the priority fees paid in each block (see
+// eth_feeHistory). The max fee allows the base fee to double before the tx is mined.
+func (client *Client) FeeTargets(ctx context.Context) ([]*ethtypes.FeeTarget, error) {
+	var result struct {
+		BaseFeePerGas []*hexutil.Big   `json:"baseFeePerGas"`
+		Reward        [][]*hexutil.Big `json:"reward"`
+	}
+	if err := client.call(ctx, &result, "eth_feeHistory",
+		hexutil.Uint64(feeHistoryBlocks), "latest", feeHistoryPercentiles); err != nil {
+		return nil, err
+	}
+	if len(result.BaseFeePerGas) == 0 || result.BaseFeePerGas[len(result.BaseFeePerGas)-1] == nil ||
+		len(result.Reward) == 0 {
+		return nil, errp.New("unexpected response of eth_feeHistory")
+	}
+	// The last element is the base fee of the next block.
+	baseFee := (*big.Int)(result.BaseFeePerGas[len(result.BaseFeePerGas)-1])
+	targetCodes := []accounts.FeeTargetCode{
+		accounts.FeeTargetCodeLow,
+		accounts.FeeTargetCodeNormal,
+		accounts.FeeTargetCodeHigh,
+	}
+	feeTargets := make([]*ethtypes.FeeTarget, len(targetCodes))
+	for i, targetCode := range targetCodes {
+		tips := make([]*big.Int, 0, len(result.Reward))
+		for _, blockRewards := range result.Reward {
+			if len(blockRewards) != len(feeHistoryPercentiles) {
+				return nil, errp.New("unexpected response of eth_feeHistory")
+			}
+			// Some nodes return null instead of zero, e.g. for empty blocks.
+			tip := new(big.Int)
+			if blockRewards[i] != nil {
+				tip = (*big.Int)(blockRewards[i])
+			}
+			tips = append(tips, tip)
+		}
+		tip := medianBigInt(tips)
+		feeTargets[len(targetCodes)-1-i] = &ethtypes.FeeTarget{
+			TargetCode: targetCode,
+			GasTipCap:  tip,
+			GasFeeCap:  new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip),
+		}
+	}
+	return feeTargets, nil
+}
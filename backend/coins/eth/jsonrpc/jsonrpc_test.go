@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// newTestClient returns a client talking to a JSON-RPC server which answers requests using
+// handle, which returns the result of a call.
+func newTestClient(t *testing.T, handle func(method string, params []json.RawMessage) interface{}) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		respond := func(req request) map[string]interface{} {
+			return map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"result":  handle(req.Method, req.Params),
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			var reqs []request
+			require.NoError(t, json.Unmarshal(body, &reqs))
+			responses := make([]map[string]interface{}, len(reqs))
+			for i, req := range reqs {
+				responses[i] = respond(req)
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(responses))
+			return
+		}
+		var req request
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.NoError(t, json.NewEncoder(w).Encode(respond(req)))
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClient(server.URL, server.Client())
+	require.NoError(t, err)
+	return client
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient("ws://localhost:8546", http.DefaultClient)
+	require.Error(t, err)
+	_, err = NewClient("https://node.example.test", http.DefaultClient)
+	require.NoError(t, err)
+}
+
+func TestBlockNumberAndBalances(t *testing.T) {
+	address1 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	address2 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	client := newTestClient(t, func(method string, params []json.RawMessage) interface{} {
+		switch method {
+		case "eth_blockNumber":
+			return "0x10"
+		case "eth_getBalance":
+			var address common.Address
+			require.NoError(t, json.Unmarshal(params[0], &address))
+			return hexutil.EncodeBig(new(big.Int).SetBytes(address.Bytes()))
+		}
+		t.Fatalf("unexpected method %s", method)
+		return nil
+	})
+
+	blockNumber, err := client.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(16), blockNumber)
+
+	balances, err := client.Balances(context.Background(), []common.Address{address1, address2})
+	require.NoError(t, err)
+	require.Equal(t,
+		map[common.Address]*big.Int{address1: big.NewInt(1), address2: big.NewInt(2)},
+		balances,
+	)
+}
+
+func TestERC20Balance(t *testing.T) {
+	account := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	token := erc20.NewToken("0xdac17f958d2ee523a2206206994597c13d831ec7", 6)
+	client := newTestClient(t, func(method string, params []json.RawMessage) interface{} {
+		require.Equal(t, "eth_call", method)
+		var args struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+		}
+		require.NoError(t, json.Unmarshal(params[0], &args))
+		require.Equal(t, token.ContractAddress(), args.To)
+		// balanceOf(address)
+		require.Equal(t, "70a08231", common.Bytes2Hex(args.Input[:4]))
+		return hexutil.Bytes(common.LeftPadBytes(big.NewInt(1234).Bytes(), 32))
+	})
+	balance, err := client.ERC20Balance(account, token)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1234), balance)
+}
+
+func TestFeeTargets(t *testing.T) {
+	client := newTestClient(t, func(method string, params []json.RawMessage) interface{} {
+		require.Equal(t, "eth_feeHistory", method)
+		return map[string]interface{}{
+			"baseFeePerGas": []string{"0x64", "0x6e", "0x78", "0xc8"},
+			"reward": [][]string{
+				{"0x1", "0xa", "0x64"},
+				{"0x3", "0x14", "0xc8"},
+				{"0x2", "0xf", "0x96"},
+			},
+		}
+	})
+	feeTargets, err := client.FeeTargets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, feeTargets, 3)
+	// Base fee of the next block is 200.
+	require.Equal(t, accounts.FeeTargetCodeHigh, feeTargets[0].TargetCode)
+	require.Equal(t, big.NewInt(150), feeTargets[0].GasTipCap)
+	require.Equal(t, big.NewInt(550), feeTargets[0].GasFeeCap)
+	require.Equal(t, accounts.FeeTargetCodeNormal, feeTargets[1].TargetCode)
+	require.Equal(t, big.NewInt(15), feeTargets[1].GasTipCap)
+	require.Equal(t, big.NewInt(415), feeTargets[1].GasFeeCap)
+	require.Equal(t, accounts.FeeTargetCodeLow, feeTargets[2].TargetCode)
+	require.Equal(t, big.NewInt(2), feeTargets[2].GasTipCap)
+	require.Equal(t, big.NewInt(402), feeTargets[2].GasFeeCap)
+
+	// Missing rewards are treated as zero.
+	client = newTestClient(t, func(method string, params []json.RawMessage) interface{} {
+		return map[string]interface{}{
+			"baseFeePerGas": []string{"0x64", "0xc8"},
+			"reward": [][]interface{}{
+				{nil, nil, nil},
+				{"0x1", "0xa", "0x64"},
+				{nil, "0x14", "0xc8"},
+			},
+		}
+	})
+	feeTargets, err = client.FeeTargets(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(100), feeTargets[0].GasTipCap)
+	require.Equal(t, big.NewInt(10), feeTargets[1].GasTipCap)
+	require.Equal(t, big.NewInt(0), feeTargets[2].GasTipCap)
+	require.Equal(t, big.NewInt(400), feeTargets[2].GasFeeCap)
+
+	client = newTestClient(t, func(method string, params []json.RawMessage) interface{} {
+		return map[string]interface{}{
+			"baseFeePerGas": []interface{}{"0x64", nil},
+			"reward":        [][]string{{"0x1", "0xa", "0x64"}},
+		}
+	})
+	_, err = client.FeeTargets(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	ethtypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// minFeeBumpPercent is the minimum increase of maxFeePerGas and maxPriorityFeePerGas required by
+// nodes (geth) to accept a replacement of a pending tx.
+const minFeeBumpPercent = 10
+
+// bumpedFee returns fee increased by minFeeBumpPercent, rounded up.
+func bumpedFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+minFeeBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBigInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// getReplaceableTx returns the pending outgoing tx with the given ID. Returns ErrTxNotReplaceable
+// if the tx is already mined or was replaced already.
+func (account *Account) getReplaceableTx(txID string) (*ethtypes.TransactionWithMetadata, error) {
+	txHash := ethcommon.HexToHash(txID)
+	if txHash.Hex() != strings.ToLower(txID) {
+		return nil, errp.Newf("invalid transaction ID %s", txID)
+	}
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	tx, err := dbTx.OutgoingTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, errp.Newf("transaction %s not found", txID)
+	}
+	if tx.Height > 0 || tx.ReplacedBy != nil {
+		account.log.Info("Cannot replace a mined or already replaced transaction")
+		return nil, errp.WithStack(errors.ErrTxNotReplaceable)
+	}
+	return tx, nil
+}
+
+// newReplacementTx creates a tx replacing the pending outgoing tx with the given ID, using the same
+// nonce and the fees given by args. If cancel is false, the tx is the same apart from the fees
+// (speed up). If cancel is true, the tx sends nothing to ourselves, for ERC20 tokens a transfer of
+// zero tokens to ourselves.
+func (account *Account) newReplacementTx(
+	txID string, cancel bool, args *accounts.TxProposalArgs) (*TxProposal, error) {
+	if !account.Synced() {
+		return nil, errp.WithStack(errors.ErrAccountNotsynced)
+	}
+	replaced, err := account.getReplaceableTx(txID)
+	if err != nil {
+		return nil, err
+	}
+	replacedTx := replaced.Transaction
+
+	gasFeeCap, gasTipCap, err := account.gasFees(args)
+	if err != nil {
+		if _, ok := errp.Cause(err).(errors.TxValidationError); ok {
+			return nil, err
+		}
+		account.log.WithError(err).Error("error getting the gas price")
+		return nil, errp.WithStack(errors.ErrFeesNotAvailable)
+	}
+	if gasFeeCap.Cmp(replacedTx.GasFeeCap()) <= 0 {
+		return nil, errp.WithStack(errors.ErrFeeTooLow)
+	}
+	gasFeeCap = maxBigInt(gasFeeCap, bumpedFee(replacedTx.GasFeeCap()))
+	gasTipCap = maxBigInt(gasTipCap, bumpedFee(replacedTx.GasTipCap()))
+
+	replacedTxData := replaced.TransactionData(
+		account.blockNumber.Uint64(), account.coin.erc20Token, account.address.Address.Hex())
+	recipientAddress := replacedTxData.Addresses[0].Address
+	value := replacedTxData.Amount.BigInt()
+	to := replacedTx.To()
+	txValue := replacedTx.Value()
+	data := replacedTx.Data()
+	gasLimit := replacedTx.Gas()
+	if cancel {
+		ownAddress := account.address.Address
+		recipientAddress = ownAddress.Hex()
+		value = big.NewInt(0)
+		txValue = big.NewInt(0)
+		data = nil
+		if account.coin.erc20Token == nil {
+			to = &ownAddress
+		} else {
+			parsed, err := abi.JSON(strings.NewReader(erc20.IERC20ABI))
+			if err != nil {
+				panic(errp.WithStack(err))
+			}
+			data, err = parsed.Pack("transfer", &ownAddress, big.NewInt(0))
+			if err != nil {
+				panic(errp.WithStack(err))
+			}
+		}
+		gasLimit, err = account.coin.client.EstimateGas(context.TODO(), ethereum.CallMsg{
+			From:     ownAddress,
+			To:       to,
+			GasPrice: big.NewInt(0),
+			Value:    txValue,
+			Data:     data,
+		})
+		if err != nil {
+			account.log.WithError(err).Error("Could not estimate the gas limit.")
+			return nil, errp.WithStack(errors.TxValidationError(err.Error()))
+		}
+	}
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasFeeCap)
+	if account.coin.erc20Token == nil {
+		// The balance already excludes the value and fee of the pending tx being replaced.
+		replacedFee := new(big.Int).Mul(new(big.Int).SetUint64(replacedTx.Gas()), replacedTx.GasFeeCap())
+		available := new(big.Int).Add(account.balance.BigInt(), replacedTx.Value())
+		available.Add(available, replacedFee)
+		if new(big.Int).Add(txValue, fee).Cmp(available) > 0 {
+			return nil, errp.WithStack(errors.ErrInsufficientFunds)
+		}
+	}
+
+	keystore, err := account.Config().ConnectKeystore()
+	if err != nil {
+		return nil, err
+	}
+	var tx *types.Transaction
+	if keystore.SupportsEIP1559() {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			Nonce:     replacedTx.Nonce(),
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       gasLimit,
+			To:        to,
+			Value:     txValue,
+			Data:      data,
+		})
+	} else {
+		tx = types.NewTransaction(replacedTx.Nonce(), *to, txValue, gasLimit, gasFeeCap, data)
+	}
+	replacedTxHash := replacedTx.Hash()
+	return &TxProposal{
+		Coin:             account.coin,
+		Tx:               tx,
+		Fee:              fee,
+		Value:            value,
+		Signer:           types.NewLondonSigner(account.coin.net.ChainID),
+		Keypath:          account.signingConfiguration.AbsoluteKeypath(),
+		RecipientAddress: recipientAddress,
+		ReplacedTxHash:   &replacedTxHash,
+	}, nil
+}
+
+func (account *Account) replacementTxProposal(txID string, cancel bool, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	defer account.updateLock.Lock()()
+
+	account.log.WithField("cancel", cancel).Debug("Proposing replacement transaction")
+	txProposal, err := account.newReplacementTx(txID, cancel, args)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+	value, fee, total := account.activateTxProposals([]*TxProposal{txProposal})
+	return value, fee, total, nil
+}
+
+// BumpFeeProposal creates a tx replacing the pending outgoing tx with the given ID with the same tx
+// paying the fees given by args (speed up). Only the fee related fields of args are used. Like
+// TxProposal(), the proposal is stored and can be signed and sent with SendTx().
+func (account *Account) BumpFeeProposal(txID string, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	return account.replacementTxProposal(txID, false, args)
+}
+
+// CancelTxProposal creates a tx replacing the pending outgoing tx with the given ID with a
+// transfer of zero to ourselves paying the fees given by args, effectively cancelling the original
+// tx. The proposal can be signed and sent with SendTx().
+func (account *Account) CancelTxProposal(txID string, args *accounts.TxProposalArgs) (
+	coin.Amount, coin.Amount, coin.Amount, error) {
+	return account.replacementTxProposal(txID, true, args)
+}
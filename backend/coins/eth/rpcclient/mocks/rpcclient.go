@@ -31,6 +31,9 @@ var _ rpcclient.Interface = &InterfaceMock{}
 //			BlockNumberFunc: func(ctx context.Context) (*big.Int, error) {
 //				panic("mock out the BlockNumber method")
 //			},
+//			CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+//				panic("mock out the CallContract method")
+//			},
 //			ERC20BalanceFunc: func(account common.Address, erc20Token *erc20.Token) (*big.Int, error) {
 //				panic("mock out the ERC20Balance method")
 //			},
@@ -68,6 +71,9 @@ type InterfaceMock struct {
 	// BlockNumberFunc mocks the BlockNumber method.
 	BlockNumberFunc func(ctx context.Context) (*big.Int, error)
 
+	// CallContractFunc mocks the CallContract method.
+	CallContractFunc func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+
 	// ERC20BalanceFunc mocks the ERC20Balance method.
 	ERC20BalanceFunc func(account common.Address, erc20Token *erc20.Token) (*big.Int, error)
 
@@ -106,6 +112,15 @@ type InterfaceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// CallContract holds details about calls to the CallContract method.
+		CallContract []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Msg is the msg argument value.
+			Msg ethereum.CallMsg
+			// BlockNumber is the blockNumber argument value.
+			BlockNumber *big.Int
+		}
 		// ERC20Balance holds details about calls to the ERC20Balance method.
 		ERC20Balance []struct {
 			// Account is the account argument value.
@@ -161,6 +176,7 @@ type InterfaceMock struct {
 	}
 	lockBalance                           sync.RWMutex
 	lockBlockNumber                       sync.RWMutex
+	lockCallContract                      sync.RWMutex
 	lockERC20Balance                      sync.RWMutex
 	lockEstimateGas                       sync.RWMutex
 	lockFeeTargets                        sync.RWMutex
@@ -239,6 +255,46 @@ func (mock *InterfaceMock) BlockNumberCalls() []struct {
 	return calls
 }
 
+// CallContract calls CallContractFunc.
+func (mock *InterfaceMock) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if mock.CallContractFunc == nil {
+		panic("InterfaceMock.CallContractFunc: method is nil but Interface.CallContract was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		Msg         ethereum.CallMsg
+		BlockNumber *big.Int
+	}{
+		Ctx:         ctx,
+		Msg:         msg,
+		BlockNumber: blockNumber,
+	}
+	mock.lockCallContract.Lock()
+	mock.calls.CallContract = append(mock.calls.CallContract, callInfo)
+	mock.lockCallContract.Unlock()
+	return mock.CallContractFunc(ctx, msg, blockNumber)
+}
+
+// CallContractCalls gets all the calls that were made to CallContract.
+// Check the length with:
+//
+//	len(mockedInterface.CallContractCalls())
+func (mock *InterfaceMock) CallContractCalls() []struct {
+	Ctx         context.Context
+	Msg         ethereum.CallMsg
+	BlockNumber *big.Int
+} {
+	var calls []struct {
+		Ctx         context.Context
+		Msg         ethereum.CallMsg
+		BlockNumber *big.Int
+	}
+	mock.lockCallContract.RLock()
+	calls = mock.calls.CallContract
+	mock.lockCallContract.RUnlock()
+	return calls
+}
+
 // ERC20Balance calls ERC20BalanceFunc.
 func (mock *InterfaceMock) ERC20Balance(account common.Address, erc20Token *erc20.Token) (*big.Int, error) {
 	if mock.ERC20BalanceFunc == nil {
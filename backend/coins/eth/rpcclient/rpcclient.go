@@ -21,6 +21,9 @@ type Interface interface {
 		ctx context.Context, hash common.Hash) (*RPCTransactionReceipt, error)
 	// BlockNumber returns the current latest block number.
 	BlockNumber(ctx context.Context) (*big.Int, error)
+	// CallContract executes a message call transaction against the latest block without creating
+	// a transaction on the blockchain, e.g. to read data from a contract.
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
 	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
 	// Balance returns the current confirmed balance of the address.
 	Balance(ctx context.Context, account common.Address) (*big.Int, error)
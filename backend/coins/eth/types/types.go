@@ -32,6 +32,9 @@ type TransactionWithMetadata struct {
 	Success bool
 	// Number of broadcast attempts.
 	BroadcastAttempts uint16
+	// ReplacedBy is the hash of the tx with the same nonce replacing this pending tx (speed up or
+	// cancel), nil if the tx was not replaced.
+	ReplacedBy *common.Hash
 }
 
 // FeeTarget contains the gas price for a specific fee target.
@@ -71,6 +74,7 @@ func (txh *TransactionWithMetadata) MarshalJSON() ([]byte, error) {
 		"gasUsed":           hexutil.Uint64(txh.GasUsed),
 		"success":           txh.Success,
 		"broadcastAttempts": txh.BroadcastAttempts,
+		"replacedBy":        txh.ReplacedBy,
 	})
 }
 
@@ -82,6 +86,7 @@ func (txh *TransactionWithMetadata) UnmarshalJSON(input []byte) error {
 		GasUsed           hexutil.Uint64 `json:"gasUsed"`
 		Success           bool           `json:"success"`
 		BroadcastAttempts uint16         `json:"broadcastAttempts"`
+		ReplacedBy        *common.Hash   `json:"replacedBy"`
 	}{}
 	if err := json.Unmarshal(input, &m); err != nil {
 		return err
@@ -94,6 +99,7 @@ func (txh *TransactionWithMetadata) UnmarshalJSON(input []byte) error {
 	txh.GasUsed = uint64(m.GasUsed)
 	txh.Success = m.Success
 	txh.BroadcastAttempts = m.BroadcastAttempts
+	txh.ReplacedBy = m.ReplacedBy
 	return nil
 }
 
@@ -37,6 +37,13 @@ func TestTransactionWithHeightJSON(t *testing.T) {
 	require.Equal(t, tx.Success, tx2.Success)
 	require.Equal(t, tx.Transaction.Hash(), tx2.Transaction.Hash())
 	require.Equal(t, tx.BroadcastAttempts, tx2.BroadcastAttempts)
+	require.Nil(t, tx2.ReplacedBy)
+
+	replacedBy := common.HexToHash("0x1234")
+	tx.ReplacedBy = &replacedBy
+	tx2 = new(ethtypes.TransactionWithMetadata)
+	require.NoError(t, json.Unmarshal(jsonp.MustMarshal(tx), tx2))
+	require.Equal(t, &replacedBy, tx2.ReplacedBy)
 }
 
 func TestFeeTarget(t *testing.T) {
@@ -117,8 +117,7 @@ func (u *Updater) PollBalances() {
 			case account := <-u.enqueueUpdateForAccount:
 				go func() {
 					// A single ETH accounts needs an update.
-					etherScanClient := etherscan.NewEtherScan(account.ETHCoin().ChainIDstr(), u.etherscanClient, u.etherscanRateLimiter)
-					u.UpdateBalancesAndBlockNumber([]*Account{account}, etherScanClient)
+					u.UpdateBalancesAndBlockNumber([]*Account{account}, u.balanceFetcher(account))
 				}()
 			case <-u.updateETHAccountsCh:
 				go updateAll()
@@ -132,6 +131,15 @@ func (u *Updater) PollBalances() {
 
 }
 
+// balanceFetcher returns the client of the account's coin if it can fetch balances, which is the
+// case for EtherScan and for nodes configured by the user, or EtherScan otherwise.
+func (u *Updater) balanceFetcher(account *Account) BalanceAndBlockNumberFetcher {
+	if fetcher, ok := account.coin.client.(BalanceAndBlockNumberFetcher); ok {
+		return fetcher
+	}
+	return etherscan.NewEtherScan(account.ETHCoin().ChainIDstr(), u.etherscanClient, u.etherscanRateLimiter)
+}
+
 // UpdateBalancesAndBlockNumber updates the balances of the accounts in the provided slice.
 func (u *Updater) UpdateBalancesAndBlockNumber(ethAccounts []*Account, etherScanClient BalanceAndBlockNumberFetcher) {
 	if len(ethAccounts) == 0 {
@@ -40,6 +40,11 @@ type Account struct {
 	// when Watchonly is enabled should do this.
 	Watch *bool `json:"watch"`
 
+	// Imported is true if the account was imported from an extended public key or an output
+	// descriptor instead of being derived from a keystore. Such accounts are always loaded and have
+	// no keystore to sign with.
+	Imported bool `json:"imported,omitempty"`
+
 	CoinCode              coin.Code              `json:"coinCode"`
 	Name                  string                 `json:"name"`
 	Code                  accountsTypes.Code     `json:"code"`
@@ -47,16 +52,16 @@ type Account struct {
 	// ReceiveScriptType stores the user's receive address type for this account.
 	ReceiveScriptType *signing.ScriptType `json:"receiveScriptType,omitempty"`
 	// ActiveTokens list the tokens that should be loaded along with the account.  Currently, this
-	// only applies to ETH, and the elements are ERC20 token codes (e.g. "eth-erc20-usdt",
-	// "eth-erc20-bat", etc).
+	// only applies to ETH and other EVM chains, and the elements are ERC20 token codes (e.g.
+	// "eth-erc20-usdt", "arbeth-erc20-usdc", etc).
 	ActiveTokens []string `json:"activeTokens,omitempty"`
 }
 
 // SetTokenActive activates/deactivates an token on an account. `tokenCode` must be an ERC20 token
 // code, e.g. "eth-erc20-usdt", "eth-erc20-bat", etc.
 func (acct *Account) SetTokenActive(tokenCode string, active bool) error {
-	if acct.CoinCode != coin.CodeETH {
-		return errp.New("tokens are only enabled for ETH")
+	if !acct.SupportsTokens() {
+		return errp.New("tokens are only enabled for ETH and other EVM chains")
 	}
 	var activeTokens []string
 	for _, activeToken := range acct.ActiveTokens {
@@ -71,6 +76,17 @@ func (acct *Account) SetTokenActive(tokenCode string, active bool) error {
 	return nil
 }
 
+// SupportsTokens returns true if ERC20 tokens can be activated on this account, which is the case for
+// ETH and other EVM chain mainnet accounts.
+func (acct *Account) SupportsTokens() bool {
+	switch acct.CoinCode {
+	case coin.CodeETH, coin.CodeARBETH, coin.CodeOPETH, coin.CodeBASEETH, coin.CodePOL:
+		return true
+	default:
+		return false
+	}
+}
+
 // SetReceiveScriptType stores the receive script type for this account.
 func (acct *Account) SetReceiveScriptType(scriptType signing.ScriptType) error {
 	if acct.SigningConfigurations.FindScriptType(scriptType) == -1 {
@@ -102,10 +118,25 @@ type Keystore struct {
 	LastConnected time.Time `json:"lastConnected"`
 }
 
+// CustomERC20Token is an ERC20 token added by the user by its contract address, in addition to the
+// tokens built into the app. Its code can be used in `Account.ActiveTokens` like the code of a
+// built-in token.
+type CustomERC20Token struct {
+	// Code is the coin code of the token, e.g. "eth-erc20-0x1f9840a85d5af5bf1d1762f925bdaddc4201f984".
+	Code coin.Code `json:"code"`
+	// Chain is the code of the native coin of the chain the token is deployed on, e.g. "eth".
+	Chain           coin.Code `json:"chain"`
+	ContractAddress string    `json:"contractAddress"`
+	Name            string    `json:"name"`
+	Unit            string    `json:"unit"`
+	Decimals        uint      `json:"decimals"`
+}
+
 // AccountsConfig persists the list of accounts added to the app.
 type AccountsConfig struct {
-	Accounts  []*Account  `json:"accounts"`
-	Keystores []*Keystore `json:"keystores"`
+	Accounts          []*Account          `json:"accounts"`
+	Keystores         []*Keystore         `json:"keystores"`
+	CustomERC20Tokens []*CustomERC20Token `json:"customERC20Tokens,omitempty"`
 }
 
 // newDefaultAccountsConfig returns the default accounts config.
@@ -126,6 +157,17 @@ func (cfg AccountsConfig) Lookup(code accountsTypes.Code) *Account {
 	return nil
 }
 
+// LookupCustomERC20Token returns the custom ERC20 token with the given code, or nil if no such token
+// exists.
+func (cfg AccountsConfig) LookupCustomERC20Token(code coin.Code) *CustomERC20Token {
+	for _, token := range cfg.CustomERC20Tokens {
+		if token.Code == code {
+			return token
+		}
+	}
+	return nil
+}
+
 // LookupByXpub returns the account code of the account containing the xpub in one of its signing
 // configurations, or an error otherwise. Only te "xpub" format should be provided, not
 // "ypub"/"zpub" etc. ERC20-tokens are excluded.
@@ -173,11 +215,15 @@ func (cfg *AccountsConfig) GetOrAddKeystore(rootFingerprint []byte) *Keystore {
 	return ks
 }
 
-// IsAccountWatchOnly returns true if the keystore for the account is marked as watchonly.
+// IsAccountWatchOnly returns true if the keystore for the account is marked as watchonly, or if the
+// account was imported without a keystore.
 func (cfg AccountsConfig) IsAccountWatchOnly(account *Account) (bool, error) {
 	if account.HiddenBecauseUnused {
 		return false, nil
 	}
+	if account.Imported {
+		return true, nil
+	}
 
 	rootFingerprint, err := account.SigningConfigurations.RootFingerprint()
 	if err != nil {
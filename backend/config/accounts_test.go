@@ -79,6 +79,11 @@ func TestSetTokenActive(t *testing.T) {
 
 	require.NoError(t, acct.SetTokenActive("TOKEN-1", false))
 	require.Equal(t, []string{"TOKEN-2"}, acct.ActiveTokens)
+
+	// Accounts of other EVM chains.
+	for _, code := range []coin.Code{coin.CodeARBETH, coin.CodeOPETH, coin.CodeBASEETH, coin.CodePOL} {
+		require.NoError(t, (&Account{CoinCode: code}).SetTokenActive("TOKEN", true))
+	}
 }
 
 func TestSetReceiveScriptType(t *testing.T) {
@@ -30,6 +30,32 @@ func (s *ServerInfo) String() string {
 // btcCoinConfig holds configurations specific to a btc-based coin.
 type btcCoinConfig struct {
 	ElectrumServers []*ServerInfo `json:"electrumServers"`
+	// DiscoverPeers enables discovering the peers of the Electrum servers, whose tips are shown for
+	// comparison. Discovered servers are not used for requests and don't count towards the majority.
+	DiscoverPeers bool `json:"discoverPeers"`
+	// Bitcoind configures a Bitcoin Core node to use instead of the Electrum servers.
+	Bitcoind BitcoindRPC `json:"bitcoind"`
+}
+
+// BitcoindRPC configures the connection to the JSON-RPC interface of a Bitcoin Core node.
+type BitcoindRPC struct {
+	// URL is the address of the RPC interface, e.g. "http://127.0.0.1:8332". The node is not used
+	// if empty.
+	URL      string `json:"url"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	// CookieFile is the path to the `.cookie` file of the node. If set, it is used instead of User
+	// and Password.
+	CookieFile string `json:"cookieFile"`
+	// StartHeight is the height from which the blockchain is scanned for the transactions of new
+	// accounts. If 0, segwit scripts are scanned for from the activation of segwit, and other
+	// scripts from the genesis block.
+	StartHeight int `json:"startHeight"`
+}
+
+// Enabled returns true if a node is configured.
+func (bitcoind *BitcoindRPC) Enabled() bool {
+	return bitcoind.URL != ""
 }
 
 // ETHTransactionsSource  where to get Ethereum transactions from. See the list of consts
@@ -42,11 +68,31 @@ const (
 	ETHTransactionsSourceNone ETHTransactionsSource = "none"
 	// ETHTransactionsSourceEtherScan configures to get transactions from EtherScan.
 	ETHTransactionsSourceEtherScan ETHTransactionsSource = "etherScan"
+	// ETHTransactionsSourceBlockscout configures to get transactions from the Blockscout instance
+	// at ETHSourceConfig.BlockscoutURL.
+	ETHTransactionsSourceBlockscout ETHTransactionsSource = "blockscout"
 )
 
+// ETHSourceConfig configures where an Ethereum based coin gets its data from. By default,
+// everything is fetched from EtherScan.
+type ETHSourceConfig struct {
+	// NodeURL is the URL of the JSON-RPC API of a node, e.g. a self-hosted one, used instead of
+	// EtherScan to get balances and fees and to broadcast transactions.
+	NodeURL string `json:"nodeURL,omitempty"`
+	// TransactionsSource is where the transaction history is fetched from. Empty means
+	// ETHTransactionsSourceEtherScan.
+	TransactionsSource ETHTransactionsSource `json:"transactionsSource,omitempty"`
+	// BlockscoutURL is the URL of the API of a Blockscout instance, e.g.
+	// "https://eth.blockscout.com/api". Only used with ETHTransactionsSourceBlockscout.
+	BlockscoutURL string `json:"blockscoutURL,omitempty"`
+}
+
 // ethCoinConfig holds configurations for ethereum coins.
 type ethCoinConfig struct {
 	DeprecatedActiveERC20Tokens []string `json:"activeERC20Tokens"`
+	ETHSourceConfig
+	// ENSReverseLookup enables showing the ENS names of the recipients in the transaction history.
+	ENSReverseLookup bool `json:"ensReverseLookup"`
 }
 
 type proxyConfig struct {
@@ -54,6 +100,28 @@ type proxyConfig struct {
 	ProxyAddress string `json:"proxyAddress"`
 }
 
+// RatesProviderType is the kind of a source of exchange rates. See the RatesProvider* constants.
+type RatesProviderType string
+
+const (
+	// RatesProviderCoinGecko is the CoinGecko API or a server implementing the same API, like the
+	// BitBoxApp mirror or a self-hosted price server.
+	RatesProviderCoinGecko RatesProviderType = "coingecko"
+	// RatesProviderBitstamp is the public ticker of the Bitstamp exchange.
+	RatesProviderBitstamp RatesProviderType = "bitstamp"
+	// RatesProviderCSV is a static CSV file, e.g. for offline use.
+	RatesProviderCSV RatesProviderType = "csv"
+)
+
+// RatesProvider configures a source of exchange rates.
+type RatesProvider struct {
+	Type RatesProviderType `json:"type"`
+	// URL is the API of a RatesProviderCoinGecko provider. If empty, the BitBoxApp mirror is used.
+	URL string `json:"url"`
+	// Path is the file of a RatesProviderCSV provider.
+	Path string `json:"path"`
+}
+
 // Backend holds the backend specific configuration.
 type Backend struct {
 	Proxy proxyConfig `json:"proxy"`
@@ -71,6 +139,13 @@ type Backend struct {
 	TLTC btcCoinConfig `json:"tltc"`
 	ETH  ethCoinConfig `json:"eth"`
 
+	// Data sources of the other Ethereum based coins. The ones of ETH are part of `ETH`.
+	SEPETH  ETHSourceConfig `json:"sepeth"`
+	ARBETH  ETHSourceConfig `json:"arbeth"`
+	OPETH   ETHSourceConfig `json:"opeth"`
+	BASEETH ETHSourceConfig `json:"baseeth"`
+	POL     ETHSourceConfig `json:"pol"`
+
 	// Removed in v4.35 - don't reuse these two keys.
 	TETH struct{} `json:"teth"`
 	RETH struct{} `json:"reth"`
@@ -82,6 +157,10 @@ type Backend struct {
 	// and transaction amounts.
 	MainFiat string `json:"mainFiat"`
 
+	// RatesProviders are the sources of exchange rates in order of preference. If one fails, the next
+	// one is used. If empty, the BitBoxApp mirror of CoinGecko is used.
+	RatesProviders []RatesProvider `json:"ratesProviders"`
+
 	// UserLanguage is the UI language preferred by the user.
 	// It may be missing from an app config.json if the user never selected one
 	// or set to empty by the frontend if its value matches native locale
@@ -140,17 +140,31 @@ func (keystore *keystore) VerifyAddressBTC(
 	if !canVerifyAddress {
 		panic("CanVerifyAddress must be true")
 	}
-	msgScriptType, ok := btcMsgScriptTypeMap[accountConfiguration.ScriptType()]
-	if !ok {
-		panic("unsupported scripttype")
+	msgCoin := btcMsgCoinMap[coin.Code()]
+	var scriptConfig *messages.BTCScriptConfig
+	if accountConfiguration.BitcoinMultisig != nil {
+		multisigScriptConfig, err := keystore.btcMultisigScriptConfig(msgCoin, accountConfiguration.BitcoinMultisig)
+		if firmware.IsErrorAbort(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		scriptConfig = multisigScriptConfig.ScriptConfig
+	} else {
+		msgScriptType, ok := btcMsgScriptTypeMap[accountConfiguration.ScriptType()]
+		if !ok {
+			panic("unsupported scripttype")
+		}
+		scriptConfig = firmware.NewBTCScriptConfigSimple(msgScriptType)
 	}
 	keypath := accountConfiguration.AbsoluteKeypath().
 		Child(derivation.SimpleChainIndex(), false).
 		Child(derivation.AddressIndex, false)
 	_, err = keystore.device.BTCAddress(
-		btcMsgCoinMap[coin.Code()],
+		msgCoin,
 		keypath.ToUInt32(),
-		firmware.NewBTCScriptConfigSimple(msgScriptType),
+		scriptConfig,
 		true,
 	)
 	if firmware.IsErrorAbort(err) {
@@ -346,6 +360,27 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 		},
 	}
 
+	// Multisig script configs are not inferred from the PSBT and must be registered on the device.
+	for _, prevOut := range btcProposedTx.TXProposal.PreviousOutputs {
+		if prevOut.Address == nil {
+			// Input of another party, e.g. of the receiver of a payjoin.
+			continue
+		}
+		multisig := prevOut.Address.AccountConfiguration.BitcoinMultisig
+		if multisig == nil {
+			continue
+		}
+		scriptConfig, err := keystore.btcMultisigScriptConfig(msgCoin, multisig)
+		if firmware.IsErrorAbort(err) {
+			return errp.WithStack(keystorePkg.ErrSigningAborted)
+		}
+		if err != nil {
+			return err
+		}
+		signOptions.ForceScriptConfig = scriptConfig
+		break
+	}
+
 	// Include previous transactions in PSBT if the BitBox requires it.
 	needsPrevTxs, err := keystore.device.BTCSignNeedsNonWitnessUTXOs(
 		btcProposedTx.TXProposal.Psbt, signOptions)
@@ -379,6 +414,39 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 	return err
 }
 
+// btcMultisigScriptConfig returns the script config of the multisig account, registering it on
+// the device first if needed. The user is asked to name the account during the registration. If
+// the user aborts the registration, an abort error is returned (see firmware.IsErrorAbort()).
+func (keystore *keystore) btcMultisigScriptConfig(
+	msgCoin messages.BTCCoin,
+	multisig *signing.BitcoinMultisig,
+) (*messages.BTCScriptConfigWithKeypath, error) {
+	xpubs := make([]string, len(multisig.Cosigners))
+	for i, cosigner := range multisig.Cosigners {
+		xpubs[i] = cosigner.ExtendedPublicKey.String()
+	}
+	scriptConfig, err := firmware.NewBTCScriptConfigMultisig(
+		multisig.Threshold, xpubs, uint32(multisig.OurCosignerIndex))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	keypath := multisig.OurKeyInfo().AbsoluteKeypath.ToUInt32()
+	registered, err := keystore.device.BTCIsScriptConfigRegistered(msgCoin, scriptConfig, keypath)
+	if err != nil {
+		return nil, err
+	}
+	if !registered {
+		keystore.log.Info("Registering multisig account on the device")
+		if err := keystore.device.BTCRegisterScriptConfig(msgCoin, scriptConfig, keypath, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &messages.BTCScriptConfigWithKeypath{
+		ScriptConfig: scriptConfig,
+		Keypath:      keypath,
+	}, nil
+}
+
 func (keystore *keystore) signETHTransaction(txProposal *eth.TxProposal) error {
 	var signature []byte
 	var err error
@@ -521,6 +589,10 @@ func (keystore *keystore) CanSignMessage(code coinpkg.Code) bool {
 		code == coinpkg.CodeTBTC ||
 		code == coinpkg.CodeETH ||
 		code == coinpkg.CodeSEPETH ||
+		code == coinpkg.CodeARBETH ||
+		code == coinpkg.CodeOPETH ||
+		code == coinpkg.CodeBASEETH ||
+		code == coinpkg.CodePOL ||
 		code == coinpkg.CodeRBTC
 }
 
@@ -624,7 +696,8 @@ func (keystore *keystore) SupportsSwapPaymentRequests() error {
 	return keystorePkg.ErrFirmwareUpgradeRequired
 }
 
-// Features reports optional capabilities supported by the BitBox02 keystore.
+// Features reports optional capabilities supported by the BitBox02 keystore. The BitBox02 refuses
+// to sign transactions with inputs it does not have the key of.
 func (keystore *keystore) Features() *keystorePkg.Features {
 	return &keystorePkg.Features{
 		SupportsSendToSelf: keystore.device.Version().AtLeast(semver.NewSemVer(9, 22, 0)),
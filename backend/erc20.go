@@ -3,12 +3,22 @@
 package backend
 
 import (
+	"fmt"
+	"strings"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 type erc20Token struct {
-	code  coin.Code
+	code coin.Code
+	// chain is the code of the native coin of the chain the token lives on, e.g. coin.CodeETH.
+	chain coin.Code
 	name  string
 	unit  string
 	token *erc20.Token
@@ -26,60 +36,120 @@ var erc20Tokens = []erc20Token{
 	// instances of it in the frontend.
 	// The frontend sends them to the backend to store in the config without the prefix
 	// in frontend/web/src/routes/settings/settings.tsx.
+	// Tokens on other chains are prefixed with the code of the chain's native coin instead of "eth",
+	// e.g. "arbeth-erc20-usdc".
 	{
 		code:  "eth-erc20-usdt",
+		chain: coin.CodeETH,
 		name:  "Tether USD",
 		unit:  "USDT",
 		token: erc20.NewToken("0xdac17f958d2ee523a2206206994597c13d831ec7", 6),
 	},
 	{
 		code:  "eth-erc20-usdc",
+		chain: coin.CodeETH,
 		name:  "USD Coin",
 		unit:  "USDC",
 		token: erc20.NewToken("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", 6),
 	},
 	{
 		code:  "eth-erc20-bat",
+		chain: coin.CodeETH,
 		name:  "Basic Attention Token",
 		unit:  "BAT",
 		token: erc20.NewToken("0x0d8775f648430679a709e98d2b0cb6250d2887ef", 18),
 	},
 	{
 		code:  "eth-erc20-dai0x6b17",
+		chain: coin.CodeETH,
 		name:  "Dai",
 		unit:  "DAI",
 		token: erc20.NewToken("0x6b175474e89094c44da98b954eedeac495271d0f", 18),
 	},
 	{
 		code:  "eth-erc20-link",
+		chain: coin.CodeETH,
 		name:  "Chainlink",
 		unit:  "LINK",
 		token: erc20.NewToken("0x514910771af9ca656af840dff83e8264ecf986ca", 18),
 	},
 	{
 		code:  "eth-erc20-mkr",
+		chain: coin.CodeETH,
 		name:  "Maker",
 		unit:  "MKR",
 		token: erc20.NewToken("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2", 18),
 	},
 	{
 		code:  "eth-erc20-zrx",
+		chain: coin.CodeETH,
 		name:  "0x",
 		unit:  "ZRX",
 		token: erc20.NewToken("0xe41d2489571d322189246dafa5ebde1f4699f498", 18),
 	},
 	{
 		code:  "eth-erc20-wbtc",
+		chain: coin.CodeETH,
 		name:  "Wrapped Bitcoin",
 		unit:  "WBTC",
 		token: erc20.NewToken("0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599", 8),
 	},
 	{
 		code:  "eth-erc20-paxg",
+		chain: coin.CodeETH,
 		name:  "Pax Gold",
 		unit:  "PAXG",
 		token: erc20.NewToken("0x45804880De22913dAFE09f4980848ECE6EcbAf78", 18),
 	},
+	{
+		code:  "arbeth-erc20-usdc",
+		chain: coin.CodeARBETH,
+		name:  "USD Coin",
+		unit:  "USDC",
+		token: erc20.NewToken("0xaf88d065e77c8cC2239327C5EDb3A432268e5831", 6),
+	},
+	{
+		code:  "arbeth-erc20-usdt",
+		chain: coin.CodeARBETH,
+		name:  "Tether USD",
+		unit:  "USDT",
+		token: erc20.NewToken("0xFd086bC7CD5C481DCC9C85ebE478A1C0b69FCbb9", 6),
+	},
+	{
+		code:  "opeth-erc20-usdc",
+		chain: coin.CodeOPETH,
+		name:  "USD Coin",
+		unit:  "USDC",
+		token: erc20.NewToken("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85", 6),
+	},
+	{
+		code:  "opeth-erc20-usdt",
+		chain: coin.CodeOPETH,
+		name:  "Tether USD",
+		unit:  "USDT",
+		token: erc20.NewToken("0x94b008aA00579c1307B0EF2c499aD98a8ce58e58", 6),
+	},
+	{
+		code:  "baseeth-erc20-usdc",
+		chain: coin.CodeBASEETH,
+		name:  "USD Coin",
+		unit:  "USDC",
+		token: erc20.NewToken("0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", 6),
+	},
+	{
+		code:  "pol-erc20-usdc",
+		chain: coin.CodePOL,
+		name:  "USD Coin",
+		unit:  "USDC",
+		token: erc20.NewToken("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359", 6),
+	},
+	{
+		code:  "pol-erc20-usdt",
+		chain: coin.CodePOL,
+		name:  "Tether USD",
+		unit:  "USDT",
+		token: erc20.NewToken("0xc2132D05D31c914a87C6611C10748AEb04B58e8F", 6),
+	},
 }
 
 func erc20TokenByCode(code coin.Code) *erc20Token {
@@ -92,10 +162,129 @@ func erc20TokenByCode(code coin.Code) *erc20Token {
 	return nil
 }
 
-// ERC20Tokens returns the supported ERC20 tokens exposed to the frontend.
-func ERC20Tokens() []ERC20TokenInfo {
-	result := make([]ERC20TokenInfo, 0, len(erc20Tokens))
+// erc20TokenByContract returns the built-in token deployed at contractAddress on the given chain, or
+// nil if there is no such built-in token.
+func erc20TokenByContract(chain coin.Code, contractAddress common.Address) *erc20Token {
 	for _, token := range erc20Tokens {
+		if token.chain == chain && token.token.ContractAddress() == contractAddress {
+			token := token
+			return &token
+		}
+	}
+	return nil
+}
+
+// customERC20TokenCode returns the coin code of a custom token, e.g.
+// "eth-erc20-0x1f9840a85d5af5bf1d1762f925bdaddc4201f984". The contract address is part of the code
+// as the symbol of a token is not unique.
+func customERC20TokenCode(chain coin.Code, contractAddress common.Address) coin.Code {
+	return coin.Code(fmt.Sprintf("%s-erc20-%s", chain, strings.ToLower(contractAddress.Hex())))
+}
+
+// lookupERC20Token returns the built-in or custom token with the given code, or nil if no such
+// token exists.
+func (backend *Backend) lookupERC20Token(code coin.Code) *erc20Token {
+	if token := erc20TokenByCode(code); token != nil {
+		return token
+	}
+	customToken := backend.config.AccountsConfig().LookupCustomERC20Token(code)
+	if customToken == nil || !common.IsHexAddress(customToken.ContractAddress) ||
+		evmChainByCode(customToken.Chain) == nil {
+		return nil
+	}
+	return &erc20Token{
+		code:  customToken.Code,
+		chain: customToken.Chain,
+		name:  customToken.Name,
+		unit:  customToken.Unit,
+		token: erc20.NewToken(customToken.ContractAddress, customToken.Decimals),
+	}
+}
+
+// CustomERC20Tokens returns the tokens added by the user with AddCustomERC20Token().
+func (backend *Backend) CustomERC20Tokens() []config.CustomERC20Token {
+	result := []config.CustomERC20Token{}
+	for _, token := range backend.config.AccountsConfig().CustomERC20Tokens {
+		result = append(result, *token)
+	}
+	return result
+}
+
+// AddCustomERC20Token adds the ERC20 token deployed at contractAddress on the chain of the given
+// account and activates it on this account. The name, unit and decimals of the token are queried
+// from the token contract. If the token is built-in or has been added before, it is only
+// activated. Returns the code of the token.
+func (backend *Backend) AddCustomERC20Token(accountCode accountsTypes.Code, contractAddress string) (coin.Code, error) {
+	acct := backend.config.AccountsConfig().Lookup(accountCode)
+	if acct == nil {
+		return "", errp.Newf("Could not find account %s", accountCode)
+	}
+	if !acct.SupportsTokens() {
+		return "", errp.New("tokens are only enabled for ETH and other EVM chains")
+	}
+	if !common.IsHexAddress(contractAddress) {
+		return "", errp.Newf("invalid contract address %q", contractAddress)
+	}
+	address := common.HexToAddress(contractAddress)
+	chain := acct.CoinCode
+
+	var tokenCode coin.Code
+	var customToken *config.CustomERC20Token
+	if token := erc20TokenByContract(chain, address); token != nil {
+		tokenCode = token.code
+	} else {
+		tokenCode = customERC20TokenCode(chain, address)
+		if backend.config.AccountsConfig().LookupCustomERC20Token(tokenCode) == nil {
+			nativeCoin, err := backend.Coin(chain)
+			if err != nil {
+				return "", err
+			}
+			metadata, err := nativeCoin.(*eth.Coin).ERC20TokenMetadata(address)
+			if err != nil {
+				backend.log.WithError(err).Error("Could not query the ERC20 token metadata")
+				return "", errp.WithMessage(err, "could not query the token contract")
+			}
+			name := metadata.Name
+			if name == "" {
+				name = metadata.Symbol
+			}
+			customToken = &config.CustomERC20Token{
+				Code:            tokenCode,
+				Chain:           chain,
+				ContractAddress: address.Hex(),
+				Name:            name,
+				Unit:            metadata.Symbol,
+				Decimals:        metadata.Decimals,
+			}
+		}
+	}
+
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		acct := accountsConfig.Lookup(accountCode)
+		if acct == nil {
+			return errp.Newf("Could not find account %s", accountCode)
+		}
+		if customToken != nil && accountsConfig.LookupCustomERC20Token(tokenCode) == nil {
+			accountsConfig.CustomERC20Tokens = append(accountsConfig.CustomERC20Tokens, customToken)
+		}
+		acct.Inactive = false
+		return acct.SetTokenActive(string(tokenCode), true)
+	})
+	if err != nil {
+		return "", err
+	}
+	backend.ReinitializeAccounts()
+	return tokenCode, nil
+}
+
+// ERC20Tokens returns the supported ERC20 tokens of the chain with the given native coin, exposed to
+// the frontend.
+func ERC20Tokens(chain coin.Code) []ERC20TokenInfo {
+	result := []ERC20TokenInfo{}
+	for _, token := range erc20Tokens {
+		if token.chain != chain {
+			continue
+		}
 		result = append(result, ERC20TokenInfo{
 			Code: token.code,
 			Name: token.name,
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"math/big"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/jsonrpc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth/rpcclient"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ethClient is used to get balances and fees and to broadcast transactions. It is implemented by
+// EtherScan and by the JSON-RPC client of a node.
+type ethClient interface {
+	rpcclient.Interface
+	eth.BalanceAndBlockNumberFetcher
+}
+
+// ethTransactionsSource is the source of the transaction history. It is implemented by EtherScan,
+// also when talking to Blockscout.
+type ethTransactionsSource interface {
+	eth.TransactionsSource
+	TokenTransactionsByContract(
+		blockTipHeight *big.Int,
+		address common.Address,
+		endBlock *big.Int,
+	) (map[common.Address][]*accounts.TransactionData, error)
+}
+
+// ethUpdateFetcher is used by the ETH updater to fetch balances and the block number from the client
+// and token transactions from the transactions source of a chain.
+type ethUpdateFetcher struct {
+	ethClient
+	transactionsSource ethTransactionsSource
+}
+
+// TokenTransactionsByContract implements eth.TokenTransactionsFetcher.
+func (fetcher ethUpdateFetcher) TokenTransactionsByContract(
+	blockTipHeight *big.Int,
+	address common.Address,
+	endBlock *big.Int,
+) (map[common.Address][]*accounts.TransactionData, error) {
+	return fetcher.transactionsSource.TokenTransactionsByContract(blockTipHeight, address, endBlock)
+}
+
+// ethBalanceFetcher hides all methods of the wrapped fetcher but the ones of
+// eth.BalanceAndBlockNumberFetcher, so no token transactions are prefetched.
+type ethBalanceFetcher struct {
+	eth.BalanceAndBlockNumberFetcher
+}
+
+// ethSourceConfig returns the configured data sources of the chain with the given native coin.
+func (backend *Backend) ethSourceConfig(code coinpkg.Code) config.ETHSourceConfig {
+	backendConfig := backend.config.AppConfig().Backend
+	switch code {
+	case coinpkg.CodeETH:
+		return backendConfig.ETH.ETHSourceConfig
+	case coinpkg.CodeSEPETH:
+		return backendConfig.SEPETH
+	case coinpkg.CodeARBETH:
+		return backendConfig.ARBETH
+	case coinpkg.CodeOPETH:
+		return backendConfig.OPETH
+	case coinpkg.CodeBASEETH:
+		return backendConfig.BASEETH
+	case coinpkg.CodePOL:
+		return backendConfig.POL
+	default:
+		return config.ETHSourceConfig{}
+	}
+}
+
+// ethSources returns the client and the transactions source of the chain, as configured in the
+// app config. EtherScan is used for both by default. The transactions source is nil if it is
+// configured to be "none".
+func (backend *Backend) ethSources(chain *evmChain) (ethClient, ethTransactionsSource, error) {
+	sourceConfig := backend.ethSourceConfig(chain.code)
+	etherScan := etherscan.NewEtherScan(
+		chain.chainConfig.ChainID.String(), backend.httpClient, backend.etherScanRateLimiter)
+
+	var client ethClient = etherScan
+	if sourceConfig.NodeURL != "" {
+		nodeClient, err := jsonrpc.NewClient(sourceConfig.NodeURL, backend.httpClient)
+		if err != nil {
+			return nil, nil, err
+		}
+		client = nodeClient
+	}
+
+	var transactionsSource ethTransactionsSource
+	switch sourceConfig.TransactionsSource {
+	case "", config.ETHTransactionsSourceEtherScan:
+		transactionsSource = etherScan
+	case config.ETHTransactionsSourceBlockscout:
+		if sourceConfig.BlockscoutURL == "" {
+			return nil, nil, errp.Newf("no Blockscout URL configured for %s", chain.code)
+		}
+		transactionsSource = etherscan.NewBlockscout(
+			sourceConfig.BlockscoutURL, backend.httpClient, backend.blockscoutRateLimiter)
+	case config.ETHTransactionsSourceNone:
+	default:
+		return nil, nil, errp.Newf("unknown transactions source %q", sourceConfig.TransactionsSource)
+	}
+	return client, transactionsSource, nil
+}
+
+// ethUpdateFetcher returns what the ETH updater uses to update the accounts of the chain.
+func (backend *Backend) ethUpdateFetcher(chain *evmChain) (eth.BalanceAndBlockNumberFetcher, error) {
+	client, transactionsSource, err := backend.ethSources(chain)
+	if err != nil {
+		return nil, err
+	}
+	if transactionsSource == nil {
+		return ethBalanceFetcher{client}, nil
+	}
+	return ethUpdateFetcher{ethClient: client, transactionsSource: transactionsSource}, nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"math/big"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// evmChain is an Ethereum compatible chain whose native coin is supported. Accounts of all chains
+// use the Ethereum keypath, so they share the address of the Ethereum account.
+type evmChain struct {
+	code coinpkg.Code
+	name string
+	// unit is the unit of the native coin, which also pays the fees of ERC20 token transfers.
+	unit                   string
+	chainConfig            *params.ChainConfig
+	blockExplorerURLPrefix string
+}
+
+// l2ChainConfig returns the chain config of a chain for which go-ethereum has no predefined config.
+// The chains support the same transaction types as mainnet, so it is a copy of the mainnet config
+// with a different chain ID.
+func l2ChainConfig(chainID int64) *params.ChainConfig {
+	config := *params.MainnetChainConfig
+	config.ChainID = big.NewInt(chainID)
+	return &config
+}
+
+var evmChains = []evmChain{
+	{
+		code:                   coinpkg.CodeETH,
+		name:                   "Ethereum",
+		unit:                   "ETH",
+		chainConfig:            params.MainnetChainConfig,
+		blockExplorerURLPrefix: "https://etherscan.io/",
+	},
+	{
+		code:                   coinpkg.CodeSEPETH,
+		name:                   "Ethereum Sepolia",
+		unit:                   "SEPETH",
+		chainConfig:            params.SepoliaChainConfig,
+		blockExplorerURLPrefix: "https://sepolia.etherscan.io/",
+	},
+	{
+		code:                   coinpkg.CodeARBETH,
+		name:                   "Arbitrum One",
+		unit:                   "ETH",
+		chainConfig:            l2ChainConfig(42161),
+		blockExplorerURLPrefix: "https://arbiscan.io/",
+	},
+	{
+		code:                   coinpkg.CodeOPETH,
+		name:                   "Optimism",
+		unit:                   "ETH",
+		chainConfig:            l2ChainConfig(10),
+		blockExplorerURLPrefix: "https://optimistic.etherscan.io/",
+	},
+	{
+		code:                   coinpkg.CodeBASEETH,
+		name:                   "Base",
+		unit:                   "ETH",
+		chainConfig:            l2ChainConfig(8453),
+		blockExplorerURLPrefix: "https://basescan.org/",
+	},
+	{
+		code:                   coinpkg.CodePOL,
+		name:                   "Polygon",
+		unit:                   "POL",
+		chainConfig:            l2ChainConfig(137),
+		blockExplorerURLPrefix: "https://polygonscan.com/",
+	},
+}
+
+func evmChainByCode(code coinpkg.Code) *evmChain {
+	for i := range evmChains {
+		if evmChains[i].code == code {
+			return &evmChains[i]
+		}
+	}
+	return nil
+}
+
+// evmChainIndex returns the position of the chain with the given chain ID in evmChains, or -1 if
+// the chain is unknown.
+func evmChainIndex(chainID uint64) int {
+	for i, chain := range evmChains {
+		if chain.chainConfig.ChainID.Uint64() == chainID {
+			return i
+		}
+	}
+	return -1
+}
@@ -15,6 +15,8 @@ import (
 	"os"
 	"runtime/debug"
 	"slices"
+	"strconv"
+	"time"
 
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
@@ -23,6 +25,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/banners"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/bitsurance"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
 	accountHandlers "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/handlers"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/eth"
@@ -39,6 +42,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/market"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/market/swapkit"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/reporting"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
 	backendutil "github.com/BitBoxSwiss/bitbox-wallet-app/backend/util"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/versioninfo"
@@ -85,6 +89,7 @@ type Backend interface {
 	CoinFiatPrices(coinpkg.Coin) *coinpkg.FormattedAmountWithConversions
 	DownloadCert(string) (string, error)
 	CheckElectrumServer(*config.ServerInfo) error
+	ElectrumServersHealth(coinpkg.Code) ([]electrum.ServerHealth, error)
 	RegisterTestKeystore(string)
 	NotifyUser(string)
 	SystemOpen(string) error
@@ -96,15 +101,27 @@ type Backend interface {
 	ExportNotes() error
 	ImportNotes(jsonLines []byte) (*backend.ImportNotesResult, error)
 	ChartData() (*backend.Chart, error)
+	TaxReport(year int, method reporting.Method) (*reporting.Summary, error)
+	ExportTaxReport(year int, method reporting.Method) error
+	ExportTransactions(format reporting.ExportFormat, accountCodes []accountsTypes.Code, from, until time.Time) error
+	PortfolioChart(args backend.PortfolioChartArgs) (*backend.PortfolioChart, error)
+	ImportRatesHistory(coinCode coinpkg.Code, fiat string, format rates.HistoryFormat, contents []byte) (int, error)
+	ExportRatesHistory(coinCode coinpkg.Code, fiat string, format rates.HistoryFormat) error
 	SupportedCoins(keystore.Keystore) []coinpkg.Code
 	CanAddAccount(coinpkg.Code, keystore.Keystore) (string, bool)
 	CreateAndPersistAccountConfig(coinCode coinpkg.Code, name string, keystore keystore.Keystore) (accountsTypes.Code, error)
+	CreateMultisigAccount(coinCode coinpkg.Code, name string, threshold uint32, cosigners []signing.KeyInfo, keystore keystore.Keystore) (accountsTypes.Code, error)
+	ImportAccount(coinCode coinpkg.Code, name string, extendedPublicKeyOrDescriptor string, scriptType signing.ScriptType) (accountsTypes.Code, error)
 	SetAccountActive(accountCode accountsTypes.Code, active bool) error
 	SetTokenActive(accountCode accountsTypes.Code, tokenCode string, active bool) error
+	AddCustomERC20Token(accountCode accountsTypes.Code, contractAddress string) (coinpkg.Code, error)
+	CustomERC20Tokens() []config.CustomERC20Token
 	SetAccountReceiveScriptType(accountCode accountsTypes.Code, scriptType signing.ScriptType) error
 	RenameAccount(accountCode accountsTypes.Code, name string) error
 	AOPP() backend.AOPP
 	AOPPCancel()
+	PaymentURI() *backend.PaymentURI
+	PaymentURICancel()
 	AOPPApprove()
 	AOPPChooseAccount(code accountsTypes.Code)
 	GetAccountFromCode(code accountsTypes.Code) (accounts.Interface, error)
@@ -215,6 +232,8 @@ func NewHandlers(
 	getAPIRouterNoError(apiRouter)("/testing", handlers.getTesting).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/dev-servers", handlers.getDevServers).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/account-add", handlers.postAddAccount).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-add-multisig", handlers.postAddMultisigAccount).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/account-import", handlers.postImportAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/keystores", handlers.getKeystores).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/keystore/{rootFingerprint}/features", handlers.getKeystoreFeatures).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/accounts", handlers.getAccounts).Methods("GET")
@@ -223,6 +242,8 @@ func NewHandlers(
 	getAPIRouter(apiRouter)("/accounts/balance-summary", handlers.getAccountsBalanceSummary).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/set-account-active", handlers.postSetAccountActive).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/set-token-active", handlers.postSetTokenActive).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/custom-erc20-tokens", handlers.getCustomERC20Tokens).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/custom-erc20-tokens/add", handlers.postAddCustomERC20Token).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/set-account-receive-script-type", handlers.postSetAccountReceiveScriptType).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/rename-account", handlers.postRenameAccount).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/accounts/reinitialize", handlers.postAccountsReinitialize).Methods("POST")
@@ -241,6 +262,7 @@ func NewHandlers(
 	getAPIRouterNoError(apiRouter)("/coins/btc/parse-external-amount", handlers.getBTCParseExternalAmount).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/certs/download", handlers.postCertsDownload).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/electrum/check", handlers.postElectrumCheck).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/electrum/health/{code}", handlers.getElectrumHealth).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/socksproxy/check", handlers.postSocksProxyCheck).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/market/region-codes", handlers.getMarketRegionCodes).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/market/deals/{action}/{code}", handlers.getMarketDeals).Methods("GET")
@@ -258,6 +280,8 @@ func NewHandlers(
 	getAPIRouterNoError(apiRouter)("/aopp/cancel", handlers.postAOPPCancel).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/aopp/approve", handlers.postAOPPApprove).Methods("POST")
 	getAPIRouter(apiRouter)("/aopp/choose-account", handlers.postAOPPChooseAccount).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/payment-uri", handlers.getPaymentURI).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/payment-uri/cancel", handlers.postPaymentURICancel).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/connect-keystore", handlers.postConnectKeystore).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/cancel-connect-keystore", handlers.postCancelConnectKeystore).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/set-watchonly", handlers.postSetWatchonly).Methods("POST")
@@ -266,6 +290,13 @@ func NewHandlers(
 	getAPIRouterNoError(apiRouter)("/accounts/eth-account-code", handlers.lookupEthAccountCode).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/notes/export", handlers.postExportNotes).Methods("POST")
 	getAPIRouterNoError(apiRouter)("/notes/import", handlers.postImportNotes).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/tax-report", handlers.getTaxReport).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/tax-report/export", handlers.postExportTaxReport).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/export-transactions/formats", handlers.getExportTransactionsFormats).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/export-transactions", handlers.postExportTransactions).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/portfolio-chart", handlers.getPortfolioChart).Methods("GET")
+	getAPIRouterNoError(apiRouter)("/rates/history/import", handlers.postImportRatesHistory).Methods("POST")
+	getAPIRouterNoError(apiRouter)("/rates/history/export", handlers.postExportRatesHistory).Methods("POST")
 
 	getAPIRouterNoError(apiRouter)("/bluetooth/state", handlers.getBluetoothState).Methods("GET")
 	getAPIRouterNoError(apiRouter)("/bluetooth/connect", handlers.postBluetoothConnect).Methods("POST")
@@ -423,7 +454,7 @@ type swapAccountJSON struct {
 }
 
 func activeTokensJSON(account *config.Account, tokenCodes []string) []activeToken {
-	if account.CoinCode != coinpkg.CodeETH {
+	if !account.SupportsTokens() {
 		return nil
 	}
 	activeTokens := make([]activeToken, 0, len(tokenCodes))
@@ -665,6 +696,73 @@ func (handlers *Handlers) postAddAccount(r *http.Request) interface{} {
 	return response{Success: true, AccountCode: accountCode}
 }
 
+func (handlers *Handlers) postAddMultisigAccount(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode  coinpkg.Code      `json:"coinCode"`
+		Name      string            `json:"name"`
+		Threshold uint32            `json:"threshold"`
+		Cosigners []signing.KeyInfo `json:"cosigners"`
+	}
+
+	type response struct {
+		Success      bool               `json:"success"`
+		AccountCode  accountsTypes.Code `json:"accountCode,omitempty"`
+		ErrorMessage string             `json:"errorMessage,omitempty"`
+		ErrorCode    string             `json:"errorCode,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+
+	keystore := handlers.backend.Keystore()
+	if keystore == nil {
+		return response{Success: false, ErrorMessage: "Keystore not found"}
+	}
+
+	accountCode, err := handlers.backend.CreateMultisigAccount(
+		jsonBody.CoinCode, jsonBody.Name, jsonBody.Threshold, jsonBody.Cosigners, keystore)
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not add multisig account")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, AccountCode: accountCode}
+}
+
+func (handlers *Handlers) postImportAccount(r *http.Request) interface{} {
+	var jsonBody struct {
+		CoinCode         coinpkg.Code       `json:"coinCode"`
+		Name             string             `json:"name"`
+		XPubOrDescriptor string             `json:"xpubOrDescriptor"`
+		ScriptType       signing.ScriptType `json:"scriptType"`
+	}
+
+	type response struct {
+		Success      bool               `json:"success"`
+		AccountCode  accountsTypes.Code `json:"accountCode,omitempty"`
+		ErrorMessage string             `json:"errorMessage,omitempty"`
+		ErrorCode    string             `json:"errorCode,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+
+	accountCode, err := handlers.backend.ImportAccount(
+		jsonBody.CoinCode, jsonBody.Name, jsonBody.XPubOrDescriptor, jsonBody.ScriptType)
+	if err != nil {
+		handlers.log.WithError(err).Error("Could not import account")
+		if errCode, ok := errp.Cause(err).(errp.ErrorCode); ok {
+			return response{Success: false, ErrorCode: string(errCode)}
+		}
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, AccountCode: accountCode}
+}
+
 func (handlers *Handlers) getKeystores(*http.Request) interface{} {
 	type json struct {
 		Type keystore.Type `json:"type"`
@@ -924,6 +1022,32 @@ func (handlers *Handlers) postSetTokenActive(r *http.Request) interface{} {
 	return response{Success: true}
 }
 
+func (handlers *Handlers) getCustomERC20Tokens(*http.Request) interface{} {
+	return handlers.backend.CustomERC20Tokens()
+}
+
+func (handlers *Handlers) postAddCustomERC20Token(r *http.Request) interface{} {
+	var jsonBody struct {
+		AccountCode     accountsTypes.Code `json:"accountCode"`
+		ContractAddress string             `json:"contractAddress"`
+	}
+
+	type response struct {
+		Success      bool         `json:"success"`
+		TokenCode    coinpkg.Code `json:"tokenCode,omitempty"`
+		ErrorMessage string       `json:"errorMessage,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	tokenCode, err := handlers.backend.AddCustomERC20Token(jsonBody.AccountCode, jsonBody.ContractAddress)
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, TokenCode: tokenCode}
+}
+
 func (handlers *Handlers) postRenameAccount(r *http.Request) interface{} {
 	var jsonBody struct {
 		AccountCode accountsTypes.Code `json:"accountCode"`
@@ -1170,6 +1294,19 @@ func (handlers *Handlers) postElectrumCheck(r *http.Request) interface{} {
 	return response{Success: true}
 }
 
+func (handlers *Handlers) getElectrumHealth(r *http.Request) interface{} {
+	type response struct {
+		Success      bool                    `json:"success"`
+		ErrorMessage string                  `json:"errorMessage,omitempty"`
+		Servers      []electrum.ServerHealth `json:"servers,omitempty"`
+	}
+	servers, err := handlers.backend.ElectrumServersHealth(coinpkg.Code(mux.Vars(r)["code"]))
+	if err != nil {
+		return response{Success: false, ErrorMessage: err.Error()}
+	}
+	return response{Success: true, Servers: servers}
+}
+
 func (handlers *Handlers) postSocksProxyCheck(r *http.Request) interface{} {
 	type response struct {
 		Success      bool   `json:"success"`
@@ -1620,6 +1757,15 @@ func (handlers *Handlers) postAOPPCancel(r *http.Request) interface{} {
 	return nil
 }
 
+func (handlers *Handlers) getPaymentURI(r *http.Request) interface{} {
+	return handlers.backend.PaymentURI()
+}
+
+func (handlers *Handlers) postPaymentURICancel(r *http.Request) interface{} {
+	handlers.backend.PaymentURICancel()
+	return nil
+}
+
 func (handlers *Handlers) postAOPPApprove(r *http.Request) interface{} {
 	handlers.backend.AOPPApprove()
 	return nil
@@ -1704,6 +1850,203 @@ func (handlers *Handlers) postImportNotes(r *http.Request) interface{} {
 	return result{Success: true, Data: data}
 }
 
+// taxReportMethod returns the cost basis method of a tax report, which defaults to FIFO.
+func taxReportMethod(method string) reporting.Method {
+	if method == "" {
+		return reporting.MethodFIFO
+	}
+	return reporting.Method(method)
+}
+
+func (handlers *Handlers) getTaxReport(r *http.Request) interface{} {
+	type result struct {
+		Success bool               `json:"success"`
+		Message string             `json:"message,omitempty"`
+		Data    *reporting.Summary `json:"data,omitempty"`
+	}
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil {
+		return result{Success: false, Message: err.Error()}
+	}
+	data, err := handlers.backend.TaxReport(year, taxReportMethod(r.URL.Query().Get("method")))
+	if err != nil {
+		handlers.log.WithError(err).Error("Error computing tax report")
+		return result{Success: false, Message: err.Error()}
+	}
+	return result{Success: true, Data: data}
+}
+
+func (handlers *Handlers) postExportTaxReport(r *http.Request) interface{} {
+	type result struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+		Aborted bool   `json:"aborted"`
+	}
+	var args struct {
+		Year   int    `json:"year"`
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, Message: err.Error()}
+	}
+	if err := handlers.backend.ExportTaxReport(args.Year, taxReportMethod(args.Method)); err != nil {
+		if errp.Cause(err) == errp.ErrUserAbort {
+			return result{Success: false, Aborted: true}
+		}
+		handlers.log.WithError(err).Error("Error exporting tax report")
+		return result{Success: false, Message: err.Error()}
+	}
+	return result{Success: true}
+}
+
+func (handlers *Handlers) getExportTransactionsFormats(*http.Request) interface{} {
+	return reporting.ExportFormats()
+}
+
+func (handlers *Handlers) postExportTransactions(r *http.Request) interface{} {
+	type result struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+		Aborted bool   `json:"aborted"`
+	}
+	var args struct {
+		Format       reporting.ExportFormat `json:"format"`
+		AccountCodes []accountsTypes.Code   `json:"accountCodes"`
+		// From and Until are dates in the format YYYY-MM-DD, both inclusive. Empty means no bound.
+		From  string `json:"from"`
+		Until string `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, Message: err.Error()}
+	}
+	var from, until time.Time
+	if args.From != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, args.From, time.Local)
+		if err != nil {
+			return result{Success: false, Message: err.Error()}
+		}
+		from = parsed
+	}
+	if args.Until != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, args.Until, time.Local)
+		if err != nil {
+			return result{Success: false, Message: err.Error()}
+		}
+		until = parsed.AddDate(0, 0, 1)
+	}
+	if err := handlers.backend.ExportTransactions(args.Format, args.AccountCodes, from, until); err != nil {
+		if errp.Cause(err) == errp.ErrUserAbort {
+			return result{Success: false, Aborted: true}
+		}
+		handlers.log.WithError(err).Error("Error exporting transactions")
+		return result{Success: false, Message: err.Error()}
+	}
+	return result{Success: true}
+}
+
+// portfolioChartGranularities are the intervals between two points of the portfolio chart.
+var portfolioChartGranularities = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+func (handlers *Handlers) getPortfolioChart(r *http.Request) interface{} {
+	type result struct {
+		Success bool                    `json:"success"`
+		Message string                  `json:"message,omitempty"`
+		Data    *backend.PortfolioChart `json:"data,omitempty"`
+	}
+	query := r.URL.Query()
+	granularity := query.Get("granularity")
+	if granularity == "" {
+		granularity = "day"
+	}
+	interval, ok := portfolioChartGranularities[granularity]
+	if !ok {
+		return result{Success: false, Message: fmt.Sprintf("invalid granularity %s", granularity)}
+	}
+	args := backend.PortfolioChartArgs{
+		Interval: interval,
+		Fiat:     query.Get("fiat"),
+		Method:   taxReportMethod(query.Get("method")),
+	}
+	// from and until are dates in the format YYYY-MM-DD, both inclusive. Empty means the whole
+	// history.
+	if from := query.Get("from"); from != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, from, time.Local)
+		if err != nil {
+			return result{Success: false, Message: err.Error()}
+		}
+		args.From = parsed
+	}
+	if until := query.Get("until"); until != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, until, time.Local)
+		if err != nil {
+			return result{Success: false, Message: err.Error()}
+		}
+		args.Until = parsed.AddDate(0, 0, 1)
+	}
+	data, err := handlers.backend.PortfolioChart(args)
+	if err != nil {
+		handlers.log.WithError(err).Error("Error computing portfolio chart")
+		return result{Success: false, Message: err.Error()}
+	}
+	return result{Success: true, Data: data}
+}
+
+func (handlers *Handlers) postImportRatesHistory(r *http.Request) interface{} {
+	type result struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+		Count   int    `json:"count"`
+	}
+	var args struct {
+		Coin   coinpkg.Code        `json:"coin"`
+		Fiat   string              `json:"fiat"`
+		Format rates.HistoryFormat `json:"format"`
+		// Contents is the hex encoded contents of the file.
+		Contents string `json:"contents"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, Message: err.Error()}
+	}
+	contents, err := hex.DecodeString(args.Contents)
+	if err != nil {
+		return result{Success: false, Message: err.Error()}
+	}
+	count, err := handlers.backend.ImportRatesHistory(args.Coin, args.Fiat, args.Format, contents)
+	if err != nil {
+		handlers.log.WithError(err).Error("Error importing historical rates")
+		return result{Success: false, Message: err.Error()}
+	}
+	return result{Success: true, Count: count}
+}
+
+func (handlers *Handlers) postExportRatesHistory(r *http.Request) interface{} {
+	type result struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+		Aborted bool   `json:"aborted"`
+	}
+	var args struct {
+		Coin   coinpkg.Code        `json:"coin"`
+		Fiat   string              `json:"fiat"`
+		Format rates.HistoryFormat `json:"format"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		return result{Success: false, Message: err.Error()}
+	}
+	if err := handlers.backend.ExportRatesHistory(args.Coin, args.Fiat, args.Format); err != nil {
+		if errp.Cause(err) == errp.ErrUserAbort {
+			return result{Success: false, Aborted: true}
+		}
+		handlers.log.WithError(err).Error("Error exporting historical rates")
+		return result{Success: false, Message: err.Error()}
+	}
+	return result{Success: true}
+}
+
 func (handlers *Handlers) getBluetoothState(r *http.Request) interface{} {
 	return handlers.backend.Bluetooth().State()
 }
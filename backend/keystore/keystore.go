@@ -164,4 +164,7 @@ type Features struct {
 	// SupportsSendToSelf indicates whether the keystore can explicitly verify outputs that belong to
 	// the same keystore (used for the send-to-self recipient dropdown flow).
 	SupportsSendToSelf bool `json:"supportsSendToSelf"`
+	// SupportsForeignInputs indicates whether the keystore can sign BTC transactions which also
+	// spend inputs of other parties, e.g. the payjoin proposal of a receiver.
+	SupportsForeignInputs bool `json:"supportsForeignInputs"`
 }
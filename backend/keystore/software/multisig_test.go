@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package software
+
+import (
+	"testing"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/signing"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/socksproxy"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignMultisigTransaction(t *testing.T) {
+	net := &chaincfg.TestNet3Params
+	tbtc := btc.NewCoin(coin.CodeTBTC, "Bitcoin Testnet", "TBTC", coin.BtcUnitDefault, net, ".",
+		[]*config.ServerInfo{}, "", socksproxy.NewSocksProxy(false, ""))
+	keypath := signing.NewAbsoluteKeypathFromUint32(
+		48+hdkeychain.HardenedKeyStart,
+		1+hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart,
+		2+hdkeychain.HardenedKeyStart)
+
+	keystores := make([]*Keystore, 3)
+	cosigners := make([]signing.KeyInfo, 3)
+	for i := range keystores {
+		seed := make([]byte, hdkeychain.RecommendedSeedLen)
+		seed[0] = byte(i)
+		master, err := hdkeychain.NewMaster(seed, net)
+		require.NoError(t, err)
+		keystores[i] = NewKeystore(master)
+		rootFingerprint, err := keystores[i].RootFingerprint()
+		require.NoError(t, err)
+		xprv, err := keypath.Derive(master)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		cosigners[i] = signing.KeyInfo{
+			RootFingerprint:   rootFingerprint,
+			AbsoluteKeypath:   keypath,
+			ExtendedPublicKey: xpub,
+		}
+	}
+	configuration, err := signing.NewBitcoinMultisigConfiguration(2, cosigners, 0)
+	require.NoError(t, err)
+	address := addresses.NewAccountAddress(
+		configuration,
+		types.Derivation{Change: false, AddressIndex: 3},
+		net,
+		logging.Get().WithGroup("software_test"),
+	)
+
+	outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("prev")), Index: 0}
+	prevOut := wire.NewTxOut(100000, address.PubkeyScript())
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(90000, address.PubkeyScript()))
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+
+	proposedTransaction := &btc.ProposedTransaction{
+		TXProposal: &maketx.TxProposal{
+			Coin: tbtc,
+			PreviousOutputs: maketx.PreviousOutputs{
+				outPoint: maketx.UTXO{TxOut: prevOut, Address: address},
+			},
+			Psbt: packet,
+		},
+		AccountSigningConfigurations: signing.Configurations{configuration},
+		GetKeystoreAddress: func(coin.Code, addresses.AddressID) (*addresses.AccountAddress, error) {
+			return nil, nil
+		},
+	}
+	require.NoError(t, proposedTransaction.Update())
+	require.Len(t, packet.Inputs[0].Bip32Derivation, 3)
+
+	// One signature is not enough.
+	require.NoError(t, keystores[0].SignTransaction(proposedTransaction))
+	require.Len(t, packet.Inputs[0].PartialSigs, 1)
+	// Signing twice with the same keystore replaces the signature.
+	require.NoError(t, keystores[0].SignTransaction(proposedTransaction))
+	require.Len(t, packet.Inputs[0].PartialSigs, 1)
+
+	require.NoError(t, keystores[2].SignTransaction(proposedTransaction))
+	require.Len(t, packet.Inputs[0].PartialSigs, 2)
+	// A superfluous signature is dropped when finalizing.
+	require.NoError(t, keystores[1].SignTransaction(proposedTransaction))
+	require.Len(t, packet.Inputs[0].PartialSigs, 3)
+
+	signedTx, err := proposedTransaction.FinalizeAndExtract()
+	require.NoError(t, err)
+	// <empty> <sig1> <sig2> <witnessScript>
+	require.Len(t, signedTx.TxIn[0].Witness, 4)
+
+	// A keystore which is not a cosigner can't sign.
+	master, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen-1), net)
+	require.NoError(t, err)
+	require.Error(t, NewKeystore(master).SignTransaction(proposedTransaction))
+}
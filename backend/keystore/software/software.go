@@ -123,7 +123,8 @@ func (keystore *Keystore) SupportsAccount(coin coin.Coin, meta interface{}) bool
 		return scriptType == signing.ScriptTypeP2PKH ||
 			scriptType == signing.ScriptTypeP2WPKHP2SH ||
 			scriptType == signing.ScriptTypeP2WPKH ||
-			scriptType == signing.ScriptTypeP2TR
+			scriptType == signing.ScriptTypeP2TR ||
+			scriptType == signing.ScriptTypeP2WSH
 	case *eth.Coin:
 		return true
 	default:
@@ -194,7 +195,8 @@ func (keystore *Keystore) BTCXPubs(
 // Features reports optional capabilities supported by the software keystore.
 func (keystore *Keystore) Features() *keystorePkg.Features {
 	return &keystorePkg.Features{
-		SupportsSendToSelf: true,
+		SupportsSendToSelf:    true,
+		SupportsForeignInputs: true,
 	}
 }
 
@@ -208,6 +210,17 @@ func (keystore *Keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 			keystore.log.Error("There needs to be exactly one output being spent per input.")
 			return errp.New("There needs to be exactly one output being spent per input.")
 		}
+		if spentOutput.Address == nil {
+			// Input of another party, e.g. of the receiver of a payjoin.
+			continue
+		}
+		if spentOutput.Address.AccountConfiguration.ScriptType() == signing.ScriptTypeP2WSH {
+			if err := keystore.signMultisigInput(
+				btcProposedTx.TXProposal.Psbt, index, sigHashes, spentOutput.TxOut.Value); err != nil {
+				return err
+			}
+			continue
+		}
 		addressID := spentOutput.Address.PubkeyScriptHashHex()
 		address, err := btcProposedTx.GetKeystoreAddress(
 			btcProposedTx.TXProposal.Coin.Code(),
@@ -273,6 +286,53 @@ func (keystore *Keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 	return nil
 }
 
+// signMultisigInput adds our signature to a P2WSH multisig input. Our key is looked up in the key
+// infos of the PSBT input, so the keystore can sign as any of the cosigners. Signatures of the other
+// cosigners are kept.
+func (keystore *Keystore) signMultisigInput(
+	psbtPacket *psbt.Packet, index int, sigHashes *txscript.TxSigHashes, value int64) error {
+	rootFingerprint, err := keystore.RootFingerprint()
+	if err != nil {
+		return err
+	}
+	input := &psbtPacket.Inputs[index]
+	for _, derivation := range input.Bip32Derivation {
+		if derivation.MasterKeyFingerprint != binary.LittleEndian.Uint32(rootFingerprint) {
+			continue
+		}
+		xprv, err := signing.NewAbsoluteKeypathFromUint32(derivation.Bip32Path...).Derive(keystore.master)
+		if err != nil {
+			return err
+		}
+		prv, err := xprv.ECPrivKey()
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		publicKey := prv.PubKey().SerializeCompressed()
+		if !bytes.Equal(publicKey, derivation.PubKey) {
+			return errp.New("The multisig key info does not match the keystore.")
+		}
+		signatureHash, err := txscript.CalcWitnessSigHash(input.WitnessScript, sigHashes,
+			txscript.SigHashAll, psbtPacket.UnsignedTx, index, value)
+		if err != nil {
+			return errp.Wrap(err, "Failed to calculate SegWit signature hash")
+		}
+		partialSig := &psbt.PartialSig{
+			PubKey:    publicKey,
+			Signature: append(ecdsa.Sign(prv, signatureHash).Serialize(), byte(txscript.SigHashAll)),
+		}
+		for i, existing := range input.PartialSigs {
+			if bytes.Equal(existing.PubKey, publicKey) {
+				input.PartialSigs[i] = partialSig
+				return nil
+			}
+		}
+		input.PartialSigs = append(input.PartialSigs, partialSig)
+		return nil
+	}
+	return errp.New("The keystore is not a cosigner of the multisig input.")
+}
+
 func (keystore *Keystore) signETHTransaction(tx *eth.TxProposal) error {
 	xprv, err := tx.Keypath.Derive(keystore.master)
 	if err != nil {
@@ -306,7 +366,11 @@ func (keystore *Keystore) CanSignMessage(code coin.Code) bool {
 		code == coin.CodeTBTC ||
 		code == coin.CodeRBTC ||
 		code == coin.CodeETH ||
-		code == coin.CodeSEPETH
+		code == coin.CodeSEPETH ||
+		code == coin.CodeARBETH ||
+		code == coin.CodeOPETH ||
+		code == coin.CodeBASEETH ||
+		code == coin.CodePOL
 }
 
 func btcMessageHash(message []byte) ([]byte, error) {
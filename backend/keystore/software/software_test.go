@@ -40,6 +40,8 @@ func TestCanSignMessage(t *testing.T) {
 	require.True(t, keystore.CanSignMessage(coin.CodeRBTC))
 	require.True(t, keystore.CanSignMessage(coin.CodeETH))
 	require.True(t, keystore.CanSignMessage(coin.CodeSEPETH))
+	require.True(t, keystore.CanSignMessage(coin.CodeARBETH))
+	require.True(t, keystore.CanSignMessage(coin.CodePOL))
 	require.False(t, keystore.CanSignMessage(coin.CodeLTC))
 }
 
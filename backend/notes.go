@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/util"
 	utilcfg "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/wire"
 )
 
 // We extend the BIP-329 JSON entry with this data so the BitBoxApp can more easily identify which
@@ -42,8 +44,11 @@ type bip329BitBoxApp struct {
 type bip329Type string
 
 const (
-	bip329TypeTx   bip329Type = "tx"
-	bip329TypeXpub bip329Type = "xpub"
+	bip329TypeTx     bip329Type = "tx"
+	bip329TypeAddr   bip329Type = "addr"
+	bip329TypeXpub   bip329Type = "xpub"
+	bip329TypeInput  bip329Type = "input"
+	bip329TypeOutput bip329Type = "output"
 )
 
 // https://github.com/bitcoin/bips/blob/master/bip-0329.mediawiki#specification
@@ -52,6 +57,9 @@ type bip329Entry struct {
 	Type  bip329Type `json:"type"`
 	Ref   string     `json:"ref"`
 	Label string     `json:"label,omitempty"`
+	// Spendable is only used for the "output" type. We only export it for frozen outputs
+	// (spendable=false).
+	Spendable *bool `json:"spendable,omitempty"`
 
 	// We don't use the origin field currently, see the docstring of `bip329BitBoxApp` above for the
 	// reason why.
@@ -133,12 +141,66 @@ func (backend *Backend) exportNotes(writer io.Writer) error {
 				return err
 			}
 		}
+
+		for _, labels := range []struct {
+			entryType bip329Type
+			labels    map[string]string
+		}{
+			{bip329TypeAddr, notesData.AddressLabels},
+			{bip329TypeInput, notesData.InputLabels},
+		} {
+			refs := make([]string, 0, len(labels.labels))
+			for ref := range labels.labels {
+				refs = append(refs, ref)
+			}
+			sort.Strings(refs)
+			for _, ref := range refs {
+				entry := bip329Entry{
+					Type:  labels.entryType,
+					Ref:   ref,
+					Label: labels.labels[ref],
+					BitBoxApp: &bip329BitBoxApp{
+						CoinCode:    account.Config().Config.CoinCode,
+						AccountCode: accountCode,
+					},
+				}
+				if err := json.NewEncoder(writer).Encode(entry); err != nil {
+					return err
+				}
+			}
+		}
+
+		outPoints := make([]string, 0, len(notesData.OutputNotes))
+		for outPoint := range notesData.OutputNotes {
+			outPoints = append(outPoints, outPoint)
+		}
+		sort.Strings(outPoints)
+		for _, outPoint := range outPoints {
+			outputNote := notesData.OutputNotes[outPoint]
+			entry := bip329Entry{
+				Type:  bip329TypeOutput,
+				Ref:   outPoint,
+				Label: outputNote.Label,
+				BitBoxApp: &bip329BitBoxApp{
+					CoinCode:    account.Config().Config.CoinCode,
+					AccountCode: accountCode,
+				},
+			}
+			if outputNote.Frozen {
+				spendable := false
+				entry.Spendable = &spendable
+			}
+			if err := json.NewEncoder(writer).Encode(entry); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
-// ExportNotes exports the transactions and accounts labels of all accounts of all
-// connected/remembered keystores. Deactivated accounts are included in the export, except for
+// ExportNotes exports the transactions, addresses, inputs, outputs and accounts labels of all
+// accounts of all connected/remembered keystores. Frozen outputs are exported as non-spendable
+// outputs. Deactivated accounts are included in the export, except for
 // deactivated ERC-20 accounts. We export to a file using an extended version of BIP-329:
 // https://github.com/bitcoin/bips/blob/master/bip-0329.mediawiki
 func (backend *Backend) ExportNotes() error {
@@ -183,11 +245,24 @@ type ImportNotesResult struct {
 	AccountCount int `json:"accountCount"`
 	// TransactionCount is the number of transaction notes updated.
 	TransactionCount int `json:"transactionCount"`
+	// AddressCount is the number of address labels updated.
+	AddressCount int `json:"addressCount"`
+	// InputCount is the number of input labels updated.
+	InputCount int `json:"inputCount"`
+	// OutputCount is the number of output labels or frozen states updated.
+	OutputCount int `json:"outputCount"`
+	// SkippedCount is the number of labels not imported because they do not belong to any of the
+	// accounts or their type is not supported.
+	SkippedCount int `json:"skippedCount"`
 }
 
 // ImportNotes imports notes from a jsonlines document according to BIP-329:
 // https://github.com/bitcoin/bips/blob/master/bip-0329.mediawiki
 //
+// Labels of transactions, addresses, inputs, outputs and xpubs are imported, as well as the
+// spendable flag of outputs. Entries of other wallets without the BitBoxApp extension are assigned
+// to the account containing the transaction, address or xpub. The origin field is ignored.
+//
 // Only accounts of connected/remembered keystores are considered, also deactivated accounts (except
 // for deactivated ERC-20 accounts). If a label in the import does not belong to one of them, it is
 // ignored.
@@ -213,6 +288,30 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 
 	result := &ImportNotesResult{}
 
+	// findAccount returns the initialized account the entry belongs to, using the BitBoxApp data if
+	// available, and the lookup function otherwise. `nil, nil` is returned if there is none.
+	findAccount := func(
+		entry bip329Entry, lookup func() (accounts.Interface, error)) (accounts.Interface, error) {
+		var account accounts.Interface
+		if entry.BitBoxApp != nil {
+			account = backend.Accounts().lookup(entry.BitBoxApp.AccountCode)
+		} else {
+			acct, err := lookup()
+			if err != nil {
+				return nil, err
+			}
+			account = acct
+		}
+		if account == nil {
+			return nil, nil
+		}
+		// So `account.Notes()` is ready to use.
+		if err := account.Initialize(); err != nil {
+			return nil, err
+		}
+		return account, nil
+	}
+
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		if len(line) == 0 {
@@ -225,7 +324,11 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 
 		label := util.TruncateString(strings.TrimSpace(entry.Label), notes.MaxNoteLen)
 		ref := strings.TrimSpace(entry.Ref)
-		if label == "" || ref == "" {
+		if ref == "" {
+			continue
+		}
+		// Outputs without a label can still be frozen.
+		if label == "" && (entry.Type != bip329TypeOutput || entry.Spendable == nil) {
 			continue
 		}
 
@@ -239,6 +342,7 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 				acctCode, err := backend.config.AccountsConfig().LookupByXpub(ref)
 				if err != nil {
 					// Could not find any account for this label, skipping.
+					result.SkippedCount += 1
 					continue
 				}
 				accountCode = acctCode
@@ -247,15 +351,18 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 				acct := accountsConfig.Lookup(accountCode)
 				if acct == nil {
 					// Could not find account using this account code, cannot apply label. Skipping.
+					result.SkippedCount += 1
 					return nil
 				}
 				if entry.BitBoxApp != nil && entry.BitBoxApp.CoinCode != acct.CoinCode {
 					// Wrong coin.
+					result.SkippedCount += 1
 					return nil
 				}
 				if acct.HiddenBecauseUnused {
 					// We don't want to modify accounts that were never automatically or manually
 					// added, same as we don't export the name of such accounts during notes export.
+					result.SkippedCount += 1
 					return nil
 				}
 				if label != acct.Name {
@@ -270,24 +377,17 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 
 		case bip329TypeTx:
 			// Import transaction note.
-			var account accounts.Interface
-			if entry.BitBoxApp != nil {
-				account = backend.Accounts().lookup(entry.BitBoxApp.AccountCode)
-			} else {
-				acct, err := backend.Accounts().lookupByTransactionInternalID(ref)
-				if err != nil {
-					return nil, err
-				}
-				account = acct
+			account, err := findAccount(entry, func() (accounts.Interface, error) {
+				return backend.Accounts().lookupByTransactionInternalID(ref)
+			})
+			if err != nil {
+				return nil, err
 			}
 			if account == nil {
 				// Could not find account containing this tx. Skipping.
+				result.SkippedCount += 1
 				continue
 			}
-			// So `account.Notes()` is ready to use.
-			if err := account.Initialize(); err != nil {
-				return nil, err
-			}
 
 			// It is inefficient to store dump all notes to disk for every imported note, which
 			// happens by using SetTxNote(). This could be optimized in the future.
@@ -298,6 +398,81 @@ func (backend *Backend) ImportNotes(jsonLines []byte) (*ImportNotesResult, error
 			if changed {
 				result.TransactionCount += 1
 			}
+
+		case bip329TypeAddr:
+			// Import address label.
+			account, err := findAccount(entry, func() (accounts.Interface, error) {
+				return backend.Accounts().lookupByAddress(ref)
+			})
+			if err != nil {
+				return nil, err
+			}
+			if account == nil {
+				// Could not find account containing this address. Skipping.
+				result.SkippedCount += 1
+				continue
+			}
+			changed, err := account.Notes().SetAddressLabel(ref, label)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				result.AddressCount += 1
+			}
+
+		case bip329TypeInput, bip329TypeOutput:
+			// Import input label, or output label and frozen state. Inputs are identified by
+			// `<txID>:<input index>`, which has the same format as an outpoint.
+			outPoint, err := wire.NewOutPointFromString(ref)
+			if err != nil {
+				// Invalid reference. Skipping.
+				result.SkippedCount += 1
+				continue
+			}
+			ref = outPoint.String()
+			account, err := findAccount(entry, func() (accounts.Interface, error) {
+				return backend.Accounts().lookupByTransactionInternalID(outPoint.Hash.String())
+			})
+			if err != nil {
+				return nil, err
+			}
+			if account == nil {
+				// Could not find account containing this transaction. Skipping.
+				result.SkippedCount += 1
+				continue
+			}
+			if entry.Type == bip329TypeInput {
+				changed, err := account.Notes().SetInputLabel(ref, label)
+				if err != nil {
+					return nil, err
+				}
+				if changed {
+					result.InputCount += 1
+				}
+				continue
+			}
+			changed := false
+			if label != "" {
+				labelChanged, err := account.Notes().SetOutputLabel(ref, label)
+				if err != nil {
+					return nil, err
+				}
+				changed = labelChanged
+			}
+			if entry.Spendable != nil {
+				frozenChanged, err := account.Notes().SetOutputFrozen(ref, !*entry.Spendable)
+				if err != nil {
+					return nil, err
+				}
+				changed = changed || frozenChanged
+			}
+			if changed {
+				result.OutputCount += 1
+			}
+
+		default:
+			// Unsupported type, e.g. pubkey.
+			result.SkippedCount += 1
 		}
 	}
 
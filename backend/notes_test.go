@@ -49,7 +49,7 @@ func (s *notesTestSuite) SetupTest() {
 			case "v0-55555555-btc-0":
 				return accounts.OrderedTransactions{
 					&accounts.TransactionData{
-						InternalID: "btc-tx-id",
+						InternalID: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
 					},
 				}, nil
 			case "v0-55555555-eth-0":
@@ -118,7 +118,7 @@ func (s *notesTestSuite) TestExport() {
 	s.Require().NoError(s.backend.RenameAccount("v0-55555555-btc-0", "My BTC"))
 	s.Require().NoError(s.backend.RenameAccount("v0-55555555-eth-0", "My ETH"))
 
-	_, err := btcAcct.Notes().SetTxNote("btc-tx-id", "test btc note")
+	_, err := btcAcct.Notes().SetTxNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b", "test btc note")
 	s.Require().NoError(err)
 	_, err = ethAcct.Notes().SetTxNote("eth-tx-id", "test eth note")
 	s.Require().NoError(err)
@@ -131,7 +131,7 @@ func (s *notesTestSuite) TestExport() {
 	expected := `{"type":"xpub","ref":"xpub6Cxa67Bfe1Aw5VvLM1Ppua9x28CXH1zUYoAuBzFRjR6hWnA6aUcny84KYkeVcZWnWXxKSkxCEyMA8xic54ydBPWm5oziXpsXq6nX8FELMQn","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6CC9Tsi4eJvmRsGuXwKBfHDWUWN66voNeZFmXRJhYZS6yYgXKZmtz5qnxK9WL2FZP8uF3abyFZ29d7RfMks4FjCCu4LMh3edyeCoyEFuZLZ","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6CUmEcJb7juvnw7fFYybCwvCJuPSEdhTWZCep9X1DBznwB8RRKTYBUidbEPJ9L7ExjrXhem9S759cX3BpzSUSoP2rWh9vqumJ9MPSAbi98F","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
-{"type":"tx","ref":"btc-tx-id","label":"test btc note","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"tx","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","label":"test btc note","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6DReBHtKxgeZGBKTaaF1GjeBHa8dZwQpRfgYr3kxt782s8KKqio2pR6piBsiqHEPF7Rg3onMkwt9XrSxNTuW4N1VBjVbn6DQ3GPCBEUgtgP","label":"Litecoin","bitboxapp":{"coinCode":"ltc","code":"v0-55555555-ltc-0"}}
 {"type":"xpub","ref":"xpub6CrhULuXbYzo7gXNhSNZ6tzgfMWpwRFEisekvFfuWLtpXcV4jfvWf5yCuhRBvhZoisH4JCVp4ddGEi7XF2QE2S4N8pMkirJbp7N2TF5p5qQ","label":"Litecoin","bitboxapp":{"coinCode":"ltc","code":"v0-55555555-ltc-0"}}
 {"type":"xpub","ref":"xpub6GP83vJASH1kS7dQPWXFjVHDfYajopbG8U3j8peBH67CRCnb8QmDxZJfWpbgCQNHAzCDJ4MyVYjoh7Yv9yo7PQuZ9YyktgrtD9vmeo67Y4E","label":"My ETH","bitboxapp":{"coinCode":"eth","code":"v0-55555555-eth-0"}}
@@ -155,12 +155,12 @@ func (s *notesTestSuite) TestNotesImport() {
 	s.Require().NotNil(erc20Acct)
 
 	// Sanity check that there are no notes.
-	s.Require().Empty(btcAcct.Notes().TxNote("btc-tx-id"))
+	s.Require().Empty(btcAcct.Notes().TxNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"))
 
 	export := `{"type":"xpub","ref":"xpub6Cxa67Bfe1Aw5VvLM1Ppua9x28CXH1zUYoAuBzFRjR6hWnA6aUcny84KYkeVcZWnWXxKSkxCEyMA8xic54ydBPWm5oziXpsXq6nX8FELMQn","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6CC9Tsi4eJvmRsGuXwKBfHDWUWN66voNeZFmXRJhYZS6yYgXKZmtz5qnxK9WL2FZP8uF3abyFZ29d7RfMks4FjCCu4LMh3edyeCoyEFuZLZ","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6CUmEcJb7juvnw7fFYybCwvCJuPSEdhTWZCep9X1DBznwB8RRKTYBUidbEPJ9L7ExjrXhem9S759cX3BpzSUSoP2rWh9vqumJ9MPSAbi98F","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
-{"type":"tx","ref":"btc-tx-id","label":"test btc note","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"tx","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","label":"test btc note","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 
 {"type":"xpub","ref":"xpub6DReBHtKxgeZGBKTaaF1GjeBHa8dZwQpRfgYr3kxt782s8KKqio2pR6piBsiqHEPF7Rg3onMkwt9XrSxNTuW4N1VBjVbn6DQ3GPCBEUgtgP","label":"Litecoin","bitboxapp":{"coinCode":"ltc","code":"v0-55555555-ltc-0"}}
 {"type":"xpub","ref":"xpub6CrhULuXbYzo7gXNhSNZ6tzgfMWpwRFEisekvFfuWLtpXcV4jfvWf5yCuhRBvhZoisH4JCVp4ddGEi7XF2QE2S4N8pMkirJbp7N2TF5p5qQ","label":"Litecoin","bitboxapp":{"coinCode":"ltc","code":"v0-55555555-ltc-0"}}
@@ -177,10 +177,11 @@ func (s *notesTestSuite) TestNotesImport() {
 		&ImportNotesResult{
 			AccountCount:     2,
 			TransactionCount: 3,
+			SkippedCount:     1,
 		},
 		result)
 
-	s.Require().Equal("test btc note", btcAcct.Notes().TxNote("btc-tx-id"))
+	s.Require().Equal("test btc note", btcAcct.Notes().TxNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"))
 	s.Require().Equal("test eth note", ethAcct.Notes().TxNote("eth-tx-id"))
 	s.Require().Equal("test erc20 note", erc20Acct.Notes().TxNote("erc20-tx-id"))
 	s.Require().Equal("My BTC", btcAcct.Config().Config.Name)
@@ -202,14 +203,14 @@ func (s *notesTestSuite) TestNotesImportWithoutBitBoxAppData() {
 	s.Require().NotNil(erc20Acct)
 
 	// Sanity check that there are no notes.
-	s.Require().Empty(btcAcct.Notes().TxNote("btc-tx-id"))
+	s.Require().Empty(btcAcct.Notes().TxNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"))
 
 	veryLong := strings.Repeat("a", 2000)
 	export := fmt.Sprintf(
 		`{"type":"xpub","ref":"xpub6Cxa67Bfe1Aw5VvLM1Ppua9x28CXH1zUYoAuBzFRjR6hWnA6aUcny84KYkeVcZWnWXxKSkxCEyMA8xic54ydBPWm5oziXpsXq6nX8FELMQn","label":"My BTC"}
 {"type":"xpub","ref":"xpub6CC9Tsi4eJvmRsGuXwKBfHDWUWN66voNeZFmXRJhYZS6yYgXKZmtz5qnxK9WL2FZP8uF3abyFZ29d7RfMks4FjCCu4LMh3edyeCoyEFuZLZ","label":"My BTC"}
 {"type":"xpub","ref":"xpub6CUmEcJb7juvnw7fFYybCwvCJuPSEdhTWZCep9X1DBznwB8RRKTYBUidbEPJ9L7ExjrXhem9S759cX3BpzSUSoP2rWh9vqumJ9MPSAbi98F","label":"My BTC"}
-{"type":"tx","ref":"btc-tx-id","label":"test btc note"}
+{"type":"tx","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","label":"test btc note"}
 
 {"type":"xpub","ref":"xpub6DReBHtKxgeZGBKTaaF1GjeBHa8dZwQpRfgYr3kxt782s8KKqio2pR6piBsiqHEPF7Rg3onMkwt9XrSxNTuW4N1VBjVbn6DQ3GPCBEUgtgP","label":"Litecoin"}
 {"type":"xpub","ref":"xpub6CrhULuXbYzo7gXNhSNZ6tzgfMWpwRFEisekvFfuWLtpXcV4jfvWf5yCuhRBvhZoisH4JCVp4ddGEi7XF2QE2S4N8pMkirJbp7N2TF5p5qQ","label":"Litecoin"}
@@ -225,10 +226,11 @@ func (s *notesTestSuite) TestNotesImportWithoutBitBoxAppData() {
 		&ImportNotesResult{
 			AccountCount:     2,
 			TransactionCount: 3,
+			SkippedCount:     1,
 		},
 		result)
 
-	s.Require().Equal("test btc note", btcAcct.Notes().TxNote("btc-tx-id"))
+	s.Require().Equal("test btc note", btcAcct.Notes().TxNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"))
 	s.Require().Equal("test eth note", ethAcct.Notes().TxNote("eth-tx-id"))
 	// Truncated to 1024 chars.
 	s.Require().Equal(veryLong[:1024], erc20Acct.Notes().TxNote("erc20-tx-id"))
@@ -241,7 +243,7 @@ func (s *notesTestSuite) TestNotesInvalidLine() {
 	export := `{"type":"xpub","ref":"xpub6Cxa67Bfe1Aw5VvLM1Ppua9x28CXH1zUYoAuBzFRjR6hWnA6aUcny84KYkeVcZWnWXxKSkxCEyMA8xic54ydBPWm5oziXpsXq6nX8FELMQn","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6CC9Tsi4eJvmRsGuXwKBfHDWUWN66voNeZFmXRJhYZS6yYgXKZmtz5qnxK9WL2FZP8uF3abyFZ29d7RfMks4FjCCu4LMh3edyeCoyEFuZLZ","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6CUmEcJb7juvnw7fFYybCwvCJuPSEdhTWZCep9X1DBznwB8RRKTYBUidbEPJ9L7ExjrXhem9S759cX3BpzSUSoP2rWh9vqumJ9MPSAbi98F","label":"My BTC","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
-{"type":"tx","ref":"btc-tx-id","label":"test btc note","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"tx","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","label":"test btc note","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
 {"type":"xpub","ref":"xpub6DReBHtKxgeZGBKTaaF1GjeBHa8dZwQpRfgYr3kxt782s8KKqio2pR6piBsiqHEPF7Rg3onMkwt9XrSxNTuW4N1VBjVbn6DQ3GPCBEUgtgP","label":"Litecoin","bitboxapp":{"coinCode":"ltc","code":"v0-55555555-ltc-0"}}
 {"type":"xpub","ref":"xpub6CrhULuXbYzo7gXNhSNZ6tzgfMWpwRFEisekvFfuWLtpXcV4jfvWf5yCuhRBvhZoisH4JCVp4ddGEi7XF2QE2S4N8pMkirJbp7N2TF5p5qQ","label":"Litecoin","bitboxapp":{"coinCode":"ltc","code":"v0-55555555-ltc-0"}}
 {"type":"xpub","ref":"xpub6GP83vJASH1kS7dQPWXFjVHDfYajopbG8U3j8peBH67CRCnb8QmDxZJfWpbgCQNHAzCDJ4MyVYjoh7Yv9yo7PQuZ9YyktgrtD9vmeo67Y4E","label":"My ETH","bitboxapp":{"coinCode":"eth","code":"v0-55555555-eth-0"}}
@@ -255,5 +257,90 @@ INVALID LINE
 	// Check that a note before the invalid line was not imported.
 	btcAcct := s.backend.Accounts().lookup("v0-55555555-btc-0")
 	s.Require().NotNil(btcAcct)
-	s.Require().Equal("", btcAcct.Notes().TxNote("btc-tx-id"))
+	s.Require().Equal("", btcAcct.Notes().TxNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"))
+}
+
+func (s *notesTestSuite) TestOutputNotes() {
+	btcAcct := s.backend.Accounts().lookup("v0-55555555-btc-0")
+	s.Require().NotNil(btcAcct)
+
+	_, err := btcAcct.Notes().SetOutputLabel("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1", "from exchange")
+	s.Require().NoError(err)
+	_, err = btcAcct.Notes().SetOutputFrozen("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1", true)
+	s.Require().NoError(err)
+	_, err = btcAcct.Notes().SetOutputFrozen("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0", true)
+	s.Require().NoError(err)
+
+	var export bytes.Buffer
+	s.Require().NoError(s.backend.exportNotes(&export))
+	s.Require().Contains(export.String(),
+		`{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0","spendable":false,"bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1","label":"from exchange","spendable":false,"bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+`)
+
+	// Unfreeze one output, label another and freeze an output without BitBoxApp data, which is
+	// looked up by the transaction ID.
+	result, err := s.backend.ImportNotes([]byte(
+		`{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0","spendable":true,"bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1","label":"from exchange","spendable":false}
+{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:2","label":"change"}
+{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:3","spendable":false}
+{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:4"}
+{"type":"output","ref":"0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098:0","label":"test"}
+{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","label":"no index"}
+{"type":"output","ref":"invalid-tx-id:0","label":"invalid"}
+{"type":"input","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:x","label":"invalid index"}
+`))
+	s.Require().NoError(err)
+	s.Require().Equal(&ImportNotesResult{OutputCount: 3, SkippedCount: 4}, result)
+
+	s.Require().Equal(notes.OutputNote{}, btcAcct.Notes().OutputNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0"))
+	s.Require().Equal(notes.OutputNote{Label: "from exchange", Frozen: true}, btcAcct.Notes().OutputNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1"))
+	s.Require().Equal(notes.OutputNote{Label: "change"}, btcAcct.Notes().OutputNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:2"))
+	s.Require().Equal(notes.OutputNote{Frozen: true}, btcAcct.Notes().OutputNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:3"))
+}
+
+func (s *notesTestSuite) TestAddressAndInputNotes() {
+	btcAcct := s.backend.Accounts().lookup("v0-55555555-btc-0")
+	s.Require().NotNil(btcAcct)
+
+	const address = "bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3"
+	_, err := btcAcct.Notes().SetAddressLabel(address, "donations")
+	s.Require().NoError(err)
+	_, err = btcAcct.Notes().SetInputLabel("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0", "consolidation")
+	s.Require().NoError(err)
+
+	var export bytes.Buffer
+	s.Require().NoError(s.backend.exportNotes(&export))
+	s.Require().Contains(export.String(),
+		`{"type":"addr","ref":"bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3","label":"donations","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"input","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0","label":"consolidation","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+`)
+
+	// Entries as exported by other wallets, e.g. Sparrow, with an origin and without BitBoxApp data.
+	result, err := s.backend.ImportNotes([]byte(
+		`{"type":"tx","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","label":"rent","origin":"wpkh([55555555/84'/0'/0'])"}
+{"type":"addr","ref":"bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3","label":"donations 2024","bitboxapp":{"coinCode":"btc","code":"v0-55555555-btc-0"}}
+{"type":"addr","ref":"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4","label":"unknown"}
+{"type":"input","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0","label":"consolidation","origin":"wpkh([55555555/84'/0'/0'])"}
+{"type":"input","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1","label":"rent payment","origin":"wpkh([55555555/84'/0'/0'])"}
+{"type":"output","ref":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1","label":"change","spendable":true,"origin":"wpkh([55555555/84'/0'/0'])"}
+{"type":"pubkey","ref":"0283409659355b6d1cc3c32decd5d561abaac86c37a353b52895a5e6c196d6f448","label":"unsupported"}
+`))
+	s.Require().NoError(err)
+	s.Require().Equal(
+		&ImportNotesResult{
+			TransactionCount: 1,
+			AddressCount:     1,
+			InputCount:       1,
+			OutputCount:      1,
+			SkippedCount:     2,
+		},
+		result)
+
+	s.Require().Equal("rent", btcAcct.Notes().TxNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"))
+	s.Require().Equal("donations 2024", btcAcct.Notes().AddressLabel(address))
+	s.Require().Equal("consolidation", btcAcct.Notes().InputLabel("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:0"))
+	s.Require().Equal("rent payment", btcAcct.Notes().InputLabel("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1"))
+	s.Require().Equal(notes.OutputNote{Label: "change"}, btcAcct.Notes().OutputNote("4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b:1"))
 }
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"math/big"
+	"net/url"
+	"strings"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable/action"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// bip21Networks maps the BIP-21 URI schemes to the coins that can be paid with it. The coin is
+// determined by the network of the address.
+var bip21Networks = map[string][]struct {
+	coinCode coinpkg.Code
+	net      *chaincfg.Params
+}{
+	"bitcoin": {
+		{coinpkg.CodeBTC, &chaincfg.MainNetParams},
+		{coinpkg.CodeTBTC, &chaincfg.TestNet3Params},
+		{coinpkg.CodeRBTC, &chaincfg.RegressionNetParams},
+	},
+	"litecoin": {
+		{coinpkg.CodeLTC, &ltc.MainNetParams},
+		{coinpkg.CodeTLTC, &ltc.TestNet4Params},
+	},
+}
+
+// eip681ChainIDs maps the EIP-155 chain IDs to the coin code of the native coin of the chain.
+var eip681ChainIDs = map[string]coinpkg.Code{
+	"1":        coinpkg.CodeETH,
+	"11155111": coinpkg.CodeSEPETH,
+	"42161":    coinpkg.CodeARBETH,
+	"10":       coinpkg.CodeOPETH,
+	"8453":     coinpkg.CodeBASEETH,
+	"137":      coinpkg.CodePOL,
+}
+
+// PaymentURI is a payment request from a clicked bitcoin:, litecoin: or ethereum: URI. The frontend
+// uses it to pre-fill the send screen of the chosen account.
+type PaymentURI struct {
+	// Accounts is the list of accounts the user can choose from to pay the request.
+	Accounts []account `json:"accounts"`
+	// AccountCode is set if there is exactly one account that can pay the request.
+	AccountCode accountsTypes.Code `json:"accountCode"`
+	// Address is the recipient address.
+	Address string `json:"address"`
+	// Amount is the requested amount in the default unit of the coin, e.g. "0.001" for BTC. Empty
+	// if the request does not specify an amount.
+	Amount string `json:"amount"`
+	// Note is the label of the request, which is used as the transaction note.
+	Note string `json:"note"`
+	// Message is the BIP-21 message describing the payment.
+	Message string `json:"message"`
+	// Lightning is the BOLT11 invoice included in a unified BIP-21 URI.
+	Lightning string `json:"lightning,omitempty"`
+	// PayjoinURL is the BIP-78 payjoin endpoint of the receiver.
+	PayjoinURL string `json:"payjoinURL,omitempty"`
+	// coinCodes are the coins the request can be paid with.
+	coinCodes []coinpkg.Code
+}
+
+// formatURIAmount formats an amount in the smallest unit in the default unit with the given number
+// of decimals, without trailing zeros.
+func formatURIAmount(amount *big.Int, decimals uint) string {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	formatted := new(big.Rat).SetFrac(amount, factor).FloatString(int(decimals))
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+	return formatted
+}
+
+// parseURIAmount parses a positive integer amount in the smallest unit. Scientific notation as used
+// by EIP-681, e.g. "2.014e18", is allowed.
+func parseURIAmount(value string) (*big.Int, error) {
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok || strings.ContainsRune(value, '/') || !rat.IsInt() || rat.Sign() < 0 {
+		return nil, errp.Newf("invalid amount %q", value)
+	}
+	return rat.Num(), nil
+}
+
+// parseBIP21 parses a BIP-21 URI, e.g. `bitcoin:<address>?amount=0.001&label=Shop`.
+func parseBIP21(uri url.URL) (*PaymentURI, error) {
+	address := uri.Opaque
+	if address == "" {
+		// Some wallets produce `bitcoin://<address>`.
+		address = uri.Host
+	}
+	paymentURI := &PaymentURI{Address: address}
+	for _, network := range bip21Networks[uri.Scheme] {
+		decoded, err := btcutil.DecodeAddress(address, network.net)
+		if err != nil || !decoded.IsForNet(network.net) {
+			continue
+		}
+		paymentURI.coinCodes = append(paymentURI.coinCodes, network.coinCode)
+	}
+	if len(paymentURI.coinCodes) == 0 {
+		return nil, errp.Newf("invalid %s address %q", uri.Scheme, address)
+	}
+
+	query := uri.Query()
+	for key := range query {
+		// Required parameters we don't understand make the URI invalid, see BIP-21.
+		if strings.HasPrefix(key, "req-") && key != "req-pj" {
+			return nil, errp.Newf("unsupported required parameter %q", key)
+		}
+	}
+	if amount := query.Get("amount"); amount != "" {
+		parsedAmount, err := coinpkg.NewAmountFromString(amount, big.NewInt(1e8))
+		if err != nil || parsedAmount.BigInt().Sign() < 0 {
+			return nil, errp.Newf("invalid amount %q", amount)
+		}
+		paymentURI.Amount = formatURIAmount(parsedAmount.BigInt(), 8)
+	}
+	paymentURI.Note = query.Get("label")
+	paymentURI.Message = query.Get("message")
+	paymentURI.Lightning = query.Get("lightning")
+	paymentURI.PayjoinURL = query.Get("pj")
+	if paymentURI.PayjoinURL == "" {
+		paymentURI.PayjoinURL = query.Get("req-pj")
+	}
+	return paymentURI, nil
+}
+
+// parseEIP681 parses an EIP-681 URI. Both native transfers, e.g.
+// `ethereum:<address>@1?value=1e18`, and ERC20 transfers, e.g.
+// `ethereum:<token>@1/transfer?address=<address>&uint256=1e6`, are supported.
+func parseEIP681(uri url.URL) (*PaymentURI, error) {
+	target, function, _ := strings.Cut(strings.TrimPrefix(uri.Opaque, "pay-"), "/")
+	target, chainID, hasChainID := strings.Cut(target, "@")
+	if !hasChainID {
+		chainID = "1"
+	}
+	coinCode, ok := eip681ChainIDs[chainID]
+	if !ok {
+		return nil, errp.Newf("unsupported chain ID %s", chainID)
+	}
+	if !common.IsHexAddress(target) {
+		return nil, errp.Newf("invalid ethereum address %q", target)
+	}
+	query := uri.Query()
+	decimals := uint(18)
+	var value string
+	paymentURI := &PaymentURI{}
+	switch function {
+	case "":
+		paymentURI.Address = target
+		value = query.Get("value")
+	case "transfer":
+		contractAddress := common.HexToAddress(target)
+		token := erc20TokenByContract(coinCode, contractAddress)
+		if token == nil {
+			return nil, errp.Newf("unsupported ERC20 token %s", target)
+		}
+		coinCode = token.code
+		decimals = token.token.Decimals()
+		paymentURI.Address = query.Get("address")
+		if !common.IsHexAddress(paymentURI.Address) {
+			return nil, errp.Newf("invalid ethereum address %q", paymentURI.Address)
+		}
+		value = query.Get("uint256")
+	default:
+		return nil, errp.Newf("unsupported function %q", function)
+	}
+	if value != "" {
+		amount, err := parseURIAmount(value)
+		if err != nil {
+			return nil, err
+		}
+		paymentURI.Amount = formatURIAmount(amount, decimals)
+	}
+	paymentURI.coinCodes = []coinpkg.Code{coinCode}
+	return paymentURI, nil
+}
+
+// PaymentURI returns the payment request of the last clicked payment URI, or nil if there is none.
+// The accounts able to pay it are updated on every call, as they might have been loaded only after
+// the URI was clicked.
+func (backend *Backend) PaymentURI() *PaymentURI {
+	defer backend.accountsAndKeystoreLock.RLock()()
+	if backend.paymentURI == nil {
+		return nil
+	}
+	paymentURI := *backend.paymentURI
+	paymentURI.Accounts = nil
+	paymentURI.AccountCode = ""
+	for _, acct := range backend.accounts {
+		accountConfig := acct.Config().Config
+		if accountConfig.Inactive || accountConfig.HiddenBecauseUnused {
+			continue
+		}
+		for _, coinCode := range paymentURI.coinCodes {
+			if acct.Coin().Code() == coinCode {
+				paymentURI.Accounts = append(paymentURI.Accounts, account{
+					Name: accountConfig.Name,
+					Code: accountConfig.Code,
+				})
+			}
+		}
+	}
+	if len(paymentURI.Accounts) == 1 {
+		paymentURI.AccountCode = paymentURI.Accounts[0].Code
+	}
+	return &paymentURI
+}
+
+// PaymentURICancel discards the payment request.
+func (backend *Backend) PaymentURICancel() {
+	unlock := backend.accountsAndKeystoreLock.Lock()
+	backend.paymentURI = nil
+	unlock()
+	backend.notifyPaymentURI()
+}
+
+// notifyPaymentURI sends the payment request to the frontend. `accountsAndKeystoreLock` must not be
+// held when calling this function.
+func (backend *Backend) notifyPaymentURI() {
+	backend.Notify(observable.Event{
+		Subject: "payment-uri",
+		Action:  action.Replace,
+		Object:  backend.PaymentURI(),
+	})
+}
+
+// handlePaymentURI handles a BIP-21 (bitcoin:, litecoin:) or EIP-681 (ethereum:) payment URI.
+func (backend *Backend) handlePaymentURI(uri url.URL) {
+	var paymentURI *PaymentURI
+	var err error
+	if uri.Scheme == "ethereum" {
+		paymentURI, err = parseEIP681(uri)
+	} else {
+		paymentURI, err = parseBIP21(uri)
+	}
+	if err != nil {
+		backend.log.WithError(err).Warningf("Invalid payment URI: %s", uri.String())
+		return
+	}
+	unlock := backend.accountsAndKeystoreLock.Lock()
+	backend.paymentURI = paymentURI
+	unlock()
+	backend.notifyPaymentURI()
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"net/url"
+	"testing"
+
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseURL(t *testing.T, uri string) url.URL {
+	t.Helper()
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	return *u
+}
+
+func TestParseBIP21(t *testing.T) {
+	ltcAddress, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &ltc.MainNetParams)
+	require.NoError(t, err)
+
+	tests := []struct {
+		uri       string
+		coinCodes []coinpkg.Code
+		expected  PaymentURI
+	}{
+		{
+			uri:       "bitcoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3",
+			coinCodes: []coinpkg.Code{coinpkg.CodeBTC},
+			expected:  PaymentURI{Address: "bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3"},
+		},
+		{
+			uri: "bitcoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3?amount=0.0012000&label=Coffee%20shop" +
+				"&message=Order%201&lightning=lnbc1&pj=https://example.com/pj",
+			coinCodes: []coinpkg.Code{coinpkg.CodeBTC},
+			expected: PaymentURI{
+				Address:    "bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3",
+				Amount:     "0.0012",
+				Note:       "Coffee shop",
+				Message:    "Order 1",
+				Lightning:  "lnbc1",
+				PayjoinURL: "https://example.com/pj",
+			},
+		},
+		{
+			uri:       "BITCOIN:BC1QXP6XR63T098RL9UDLYNRKTQ00UN6VQDUZJGUA3?amount=20",
+			coinCodes: []coinpkg.Code{coinpkg.CodeBTC},
+			expected:  PaymentURI{Address: "BC1QXP6XR63T098RL9UDLYNRKTQ00UN6VQDUZJGUA3", Amount: "20"},
+		},
+		{
+			uri:       "bitcoin://tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx?req-pj=https://example.com/pj",
+			coinCodes: []coinpkg.Code{coinpkg.CodeTBTC},
+			expected: PaymentURI{
+				Address:    "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+				PayjoinURL: "https://example.com/pj",
+			},
+		},
+		{
+			uri:       "litecoin:" + ltcAddress.EncodeAddress() + "?amount=1.5",
+			coinCodes: []coinpkg.Code{coinpkg.CodeLTC},
+			expected:  PaymentURI{Address: ltcAddress.EncodeAddress(), Amount: "1.5"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			paymentURI, err := parseBIP21(mustParseURL(t, test.uri))
+			require.NoError(t, err)
+			test.expected.coinCodes = test.coinCodes
+			require.Equal(t, &test.expected, paymentURI)
+		})
+	}
+
+	for _, uri := range []string{
+		"bitcoin:",
+		"bitcoin:invalid",
+		"litecoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3",
+		"bitcoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3?amount=abc",
+		"bitcoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3?amount=-1",
+		"bitcoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3?amount=0.000000001",
+		"bitcoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3?req-somethingyoudontunderstand=50",
+	} {
+		_, err := parseBIP21(mustParseURL(t, uri))
+		require.Error(t, err, uri)
+	}
+}
+
+func TestParseEIP681(t *testing.T) {
+	const recipient = "0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359"
+	tests := []struct {
+		uri      string
+		coinCode coinpkg.Code
+		expected PaymentURI
+	}{
+		{
+			uri:      "ethereum:" + recipient,
+			coinCode: coinpkg.CodeETH,
+			expected: PaymentURI{Address: recipient},
+		},
+		{
+			uri:      "ethereum:pay-" + recipient + "@1?value=2.014e18",
+			coinCode: coinpkg.CodeETH,
+			expected: PaymentURI{Address: recipient, Amount: "2.014"},
+		},
+		{
+			uri:      "ethereum:" + recipient + "@11155111?value=1",
+			coinCode: coinpkg.CodeSEPETH,
+			expected: PaymentURI{Address: recipient, Amount: "0.000000000000000001"},
+		},
+		{
+			uri:      "ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7@1/transfer?address=" + recipient + "&uint256=1.5e6",
+			coinCode: "eth-erc20-usdt",
+			expected: PaymentURI{Address: recipient, Amount: "1.5"},
+		},
+		{
+			uri:      "ethereum:" + recipient + "@42161?value=1e17",
+			coinCode: coinpkg.CodeARBETH,
+			expected: PaymentURI{Address: recipient, Amount: "0.1"},
+		},
+		{
+			uri:      "ethereum:0x3c499c542cef5e3811e1192ce70d8cc03d5c3359@137/transfer?address=" + recipient + "&uint256=2e6",
+			coinCode: "pol-erc20-usdc",
+			expected: PaymentURI{Address: recipient, Amount: "2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			paymentURI, err := parseEIP681(mustParseURL(t, test.uri))
+			require.NoError(t, err)
+			test.expected.coinCodes = []coinpkg.Code{test.coinCode}
+			require.Equal(t, &test.expected, paymentURI)
+		})
+	}
+
+	for _, uri := range []string{
+		"ethereum:invalid",
+		"ethereum:" + recipient + "@56",
+		"ethereum:" + recipient + "?value=1.5",
+		"ethereum:" + recipient + "/approve",
+		"ethereum:0x0000000000000000000000000000000000000001/transfer?address=" + recipient,
+		"ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7/transfer?address=invalid",
+		"ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7@11155111/transfer?address=" + recipient,
+		// USDT on Ethereum, but not on Arbitrum.
+		"ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7@42161/transfer?address=" + recipient,
+	} {
+		_, err := parseEIP681(mustParseURL(t, uri))
+		require.Error(t, err, uri)
+	}
+}
+
+func TestHandlePaymentURI(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+	require.Nil(t, b.PaymentURI())
+
+	// Invalid URIs are ignored.
+	b.HandleURI("bitcoin:invalid")
+	require.Nil(t, b.PaymentURI())
+
+	b.HandleURI("bitcoin:bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3?amount=0.1&label=Shop")
+	paymentURI := b.PaymentURI()
+	require.NotNil(t, paymentURI)
+	require.Empty(t, paymentURI.Accounts)
+	require.Empty(t, paymentURI.AccountCode)
+
+	// The matching accounts are available once the keystore is registered.
+	b.registerKeystore(makeBitBox02Multi())
+	paymentURI = b.PaymentURI()
+	require.Equal(t, []account{{Name: "Bitcoin", Code: "v0-55555555-btc-0"}}, paymentURI.Accounts)
+	require.Equal(t, "v0-55555555-btc-0", string(paymentURI.AccountCode))
+
+	require.Equal(t, "bc1qxp6xr63t098rl9udlynrktq00un6vqduzjgua3", paymentURI.Address)
+	require.Equal(t, "0.1", paymentURI.Amount)
+	require.Equal(t, "Shop", paymentURI.Note)
+
+	b.PaymentURICancel()
+	require.Nil(t, b.PaymentURI())
+}
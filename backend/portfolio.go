@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/errors"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/reporting"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// maxPortfolioChartPoints limits the number of points of each series of the portfolio chart.
+const maxPortfolioChartPoints = 10000
+
+// PortfolioChartArgs are the arguments of PortfolioChart.
+type PortfolioChartArgs struct {
+	// From and Until delimit the chart. A zero From starts the chart at the earliest transaction.
+	// Until is capped at the latest available exchange rate, which is also used if it is zero.
+	From  time.Time
+	Until time.Time
+	// Interval is the time between two points of the chart.
+	Interval time.Duration
+	// Fiat is one of the fiat currencies of the FiatList setting. If empty, the main fiat currency
+	// is used.
+	Fiat string
+	// Method is the cost basis method. If empty, FIFO is used.
+	Method reporting.Method
+}
+
+// PortfolioSeries is the value of a coin, an account or all accounts over time.
+type PortfolioSeries struct {
+	CoinCode    coin.Code          `json:"coinCode,omitempty"`
+	AccountCode accountsTypes.Code `json:"accountCode,omitempty"`
+	// Name is the account name or the unit of the coin.
+	Name  string       `json:"name"`
+	Value []ChartEntry `json:"value"`
+	// CostBasis is the cost basis of the coins held. It is not available for accounts, as the cost
+	// basis of coins transferred between accounts is pooled per coin.
+	CostBasis []ChartEntry `json:"costBasis,omitempty"`
+}
+
+// PortfolioChart contains the value of the coins held over time, per coin and per account, which
+// can be stacked to show the total.
+type PortfolioChart struct {
+	// DataMissing is true if block headers or historical exchange rates needed for the chart are
+	// missing. Missing values are treated as zero.
+	DataMissing bool               `json:"dataMissing"`
+	Fiat        string             `json:"fiat"`
+	Method      reporting.Method   `json:"method"`
+	Coins       []*PortfolioSeries `json:"coins"`
+	Accounts    []*PortfolioSeries `json:"accounts"`
+	Total       *PortfolioSeries   `json:"total"`
+}
+
+func portfolioChartEntries(times []time.Time, values []*big.Rat, fiat string) []ChartEntry {
+	entries := make([]ChartEntry, len(times))
+	for i, at := range times {
+		value, _ := values[i].Float64()
+		entries[i] = ChartEntry{
+			Time:           at.Unix(),
+			Value:          value,
+			FormattedValue: coin.FormatAsCurrency(values[i], fiat),
+		}
+	}
+	return entries
+}
+
+func zeroValues(n int) []*big.Rat {
+	values := make([]*big.Rat, n)
+	for i := range values {
+		values[i] = new(big.Rat)
+	}
+	return values
+}
+
+// PortfolioChart returns the value and cost basis of the coins held in all active accounts over
+// time, broken down per coin and per account.
+func (backend *Backend) PortfolioChart(args PortfolioChartArgs) (*PortfolioChart, error) {
+	backendConfig := backend.Config().AppConfig().Backend
+	fiat := args.Fiat
+	if fiat == "" {
+		fiat = backendConfig.MainFiat
+	} else if !slices.Contains(backendConfig.FiatList, fiat) {
+		return nil, errp.Newf("fiat currency %s is not enabled", fiat)
+	}
+	method := args.Method
+	if method == "" {
+		method = reporting.MethodFIFO
+	}
+	if args.Interval <= 0 {
+		return nil, errp.New("invalid interval")
+	}
+	reportingAccounts, err := backend.reportingAccounts(nil)
+	if err != nil {
+		return nil, err
+	}
+	chart := &PortfolioChart{
+		Fiat:     fiat,
+		Method:   method,
+		Coins:    []*PortfolioSeries{},
+		Accounts: []*PortfolioSeries{},
+		Total:    &PortfolioSeries{Value: []ChartEntry{}, CostBasis: []ChartEntry{}},
+	}
+
+	until := args.Until
+	latest := backend.RatesUpdater().HistoryLatestTimestampFiat(backend.allCoinCodes(), fiat)
+	if latest.IsZero() {
+		chart.DataMissing = true
+		if until.IsZero() {
+			until = time.Now()
+		}
+	} else if until.IsZero() || until.After(latest) {
+		until = latest
+	}
+	from := args.From
+	if from.IsZero() {
+		for _, account := range reportingAccounts {
+			earliest, err := accounts.OrderedTransactions(account.Transactions).EarliestTime()
+			if errp.Cause(err) == errors.ErrNotAvailable {
+				chart.DataMissing = true
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !earliest.IsZero() && (from.IsZero() || earliest.Before(from)) {
+				from = earliest
+			}
+		}
+		if from.IsZero() {
+			// No transactions.
+			return chart, nil
+		}
+		from = from.Truncate(args.Interval)
+	}
+	if from.After(until) {
+		return nil, errp.New("the start of the chart is after its end")
+	}
+	if until.Sub(from)/args.Interval >= maxPortfolioChartPoints {
+		return nil, errp.New("too many points, choose a larger interval")
+	}
+	var times []time.Time
+	for at := from; !at.After(until); at = at.Add(args.Interval) {
+		times = append(times, at)
+	}
+
+	costBasis, err := reporting.NewCostBasisSeries(
+		reportingAccounts, backend.RatesUpdater(), fiat, method, times)
+	if err != nil {
+		return nil, err
+	}
+	if costBasis.PricesMissing {
+		chart.DataMissing = true
+	}
+
+	total := zeroValues(len(times))
+	totalCostBasis := zeroValues(len(times))
+	coinValues := map[coin.Code][]*big.Rat{}
+	for _, account := range reportingAccounts {
+		timeseries, err := accounts.OrderedTransactions(account.Transactions).Timeseries(
+			from, until, args.Interval)
+		if errp.Cause(err) == errors.ErrNotAvailable {
+			backend.log.WithField("coin", account.CoinCode).Info("PortfolioChart data missing")
+			chart.DataMissing = true
+			timeseries = nil
+		} else if err != nil {
+			return nil, err
+		}
+		decimals := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(account.Decimals)), nil)
+		values := zeroValues(len(times))
+		for i, entry := range timeseries {
+			if entry.Value.BigInt().Sign() == 0 {
+				continue
+			}
+			price := backend.RatesUpdater().HistoricalPriceAt(string(account.CoinCode), fiat, entry.Time)
+			if price == 0 {
+				chart.DataMissing = true
+			}
+			values[i].Mul(
+				new(big.Rat).SetFrac(entry.Value.BigInt(), decimals),
+				new(big.Rat).SetFloat64(price))
+		}
+		chart.Accounts = append(chart.Accounts, &PortfolioSeries{
+			CoinCode:    account.CoinCode,
+			AccountCode: account.Code,
+			Name:        account.Name,
+			Value:       portfolioChartEntries(times, values, fiat),
+		})
+
+		sums, ok := coinValues[account.CoinCode]
+		if !ok {
+			sums = zeroValues(len(times))
+			coinValues[account.CoinCode] = sums
+			coinCostBasis := costBasis.Coins[account.CoinCode]
+			for i := range times {
+				totalCostBasis[i].Add(totalCostBasis[i], coinCostBasis[i])
+			}
+			chart.Coins = append(chart.Coins, &PortfolioSeries{
+				CoinCode:  account.CoinCode,
+				Name:      account.Unit,
+				CostBasis: portfolioChartEntries(times, coinCostBasis, fiat),
+			})
+		}
+		for i := range times {
+			sums[i].Add(sums[i], values[i])
+			total[i].Add(total[i], values[i])
+		}
+	}
+	for _, series := range chart.Coins {
+		series.Value = portfolioChartEntries(times, coinValues[series.CoinCode], fiat)
+	}
+	chart.Total.Value = portfolioChartEntries(times, total, fiat)
+	chart.Total.CostBasis = portfolioChartEntries(times, totalCostBasis, fiat)
+	return chart, nil
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/notes"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/types"
+	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortfolioChart(t *testing.T) {
+	b := newBackend(t, testnetDisabled, regtestDisabled)
+	defer b.Close()
+
+	receive := func(height int, at time.Time, amount int64) *accounts.TransactionData {
+		return &accounts.TransactionData{
+			Type:      accounts.TxTypeReceive,
+			Status:    accounts.TxStatusComplete,
+			Height:    height,
+			Timestamp: &at,
+			Amount:    coinpkg.NewAmountFromInt64(amount),
+		}
+	}
+	transactions := map[accountsTypes.Code][]*accounts.TransactionData{
+		"v0-55555555-btc-0": {
+			receive(10, time.Date(2020, 8, 31, 12, 0, 0, 0, time.UTC), 100000000),
+		},
+		"v0-55555555-btc-1": {
+			receive(20, time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC), 50000000),
+		},
+	}
+	b.makeBtcAccount = func(config *accounts.AccountConfig, coin *btc.Coin, gapLimits *types.GapLimits, getAddress func(coinpkg.Code, blockchain.ScriptHashHex) (*addresses.AccountAddress, error), log *logrus.Entry) accounts.Interface {
+		accountMock := MockBtcAccount(t, config, coin, gapLimits, log)
+		accountMock.TransactionsFunc = func() (accounts.OrderedTransactions, error) {
+			return accounts.NewOrderedTransactions(transactions[config.Config.Code]), nil
+		}
+		accountNotes, err := notes.LoadNotes(test.TstTempFile("notes"))
+		require.NoError(t, err)
+		accountMock.NotesFunc = func() *notes.Notes { return accountNotes }
+		return accountMock
+	}
+
+	ks := makeBitBox02BTCOnly()
+	b.registerKeystore(ks)
+	_, err := b.CreateAndPersistAccountConfig(coinpkg.CodeBTC, "A second Bitcoin account", ks)
+	require.NoError(t, err)
+	b.ratesUpdater = rates.MockRateUpdater()
+	defer b.ratesUpdater.Stop()
+
+	// The series of the coins and accounts can be stacked to the total.
+	checkSeries := func(chart *PortfolioChart, times []time.Time) {
+		t.Helper()
+		expectedTimes := make([]int64, len(times))
+		for i, at := range times {
+			expectedTimes[i] = at.Unix()
+		}
+		seriesTimes := func(entries []ChartEntry) []int64 {
+			result := make([]int64, len(entries))
+			for i, entry := range entries {
+				result[i] = entry.Time
+			}
+			return result
+		}
+		require.Equal(t, expectedTimes, seriesTimes(chart.Total.Value))
+		require.Equal(t, expectedTimes, seriesTimes(chart.Total.CostBasis))
+		require.Len(t, chart.Coins, 1)
+		require.Equal(t, coinpkg.CodeBTC, chart.Coins[0].CoinCode)
+		require.Equal(t, expectedTimes, seriesTimes(chart.Coins[0].Value))
+		require.Equal(t, expectedTimes, seriesTimes(chart.Coins[0].CostBasis))
+		require.Len(t, chart.Accounts, 2)
+		for i := range times {
+			var sum float64
+			for _, series := range chart.Accounts {
+				require.Equal(t, expectedTimes, seriesTimes(series.Value))
+				require.Nil(t, series.CostBasis)
+				sum += series.Value[i].Value
+			}
+			require.InDelta(t, chart.Total.Value[i].Value, sum, 1e-9)
+			require.InDelta(t, chart.Total.Value[i].Value, chart.Coins[0].Value[i].Value, 1e-9)
+			require.InDelta(t, chart.Total.CostBasis[i].Value, chart.Coins[0].CostBasis[i].Value, 1e-9)
+		}
+	}
+
+	// Until is capped at the latest exchange rate, 2020-09-03 00:01:02. The chart starts at the
+	// earliest transaction, truncated to the interval.
+	for _, until := range []time.Time{{}, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)} {
+		chart, err := b.PortfolioChart(PortfolioChartArgs{
+			Until: until, Interval: 24 * time.Hour, Fiat: "USD",
+		})
+		require.NoError(t, err)
+		require.False(t, chart.DataMissing)
+		require.Equal(t, "USD", chart.Fiat)
+		checkSeries(chart, []time.Time{
+			time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 9, 3, 0, 0, 0, 0, time.UTC),
+		})
+		require.Equal(t, []string{"v0-55555555-btc-0", "v0-55555555-btc-1"}, []string{
+			string(chart.Accounts[0].AccountCode), string(chart.Accounts[1].AccountCode),
+		})
+		// Nothing was held before the first transaction.
+		require.Zero(t, chart.Total.Value[0].Value)
+		require.Zero(t, chart.Accounts[1].Value[1].Value)
+		// 1.5 BTC at 4 USD.
+		require.InDelta(t, 4*1.5, chart.Total.Value[3].Value, 0.1)
+	}
+
+	chart, err := b.PortfolioChart(PortfolioChartArgs{
+		From:     time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC),
+		Interval: 12 * time.Hour,
+		Fiat:     "USD",
+	})
+	require.NoError(t, err)
+	require.False(t, chart.DataMissing)
+	checkSeries(chart, []time.Time{
+		time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC),
+	})
+
+	// There are no EUR exchange rates. The values are zero, but all series still cover the range.
+	chart, err = b.PortfolioChart(PortfolioChartArgs{
+		Until:    time.Date(2020, 9, 3, 0, 0, 0, 0, time.UTC),
+		Interval: 24 * time.Hour,
+		Fiat:     "EUR",
+	})
+	require.NoError(t, err)
+	require.True(t, chart.DataMissing)
+	checkSeries(chart, []time.Time{
+		time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 9, 3, 0, 0, 0, 0, time.UTC),
+	})
+	for _, entry := range chart.Total.Value {
+		require.Zero(t, entry.Value)
+	}
+
+	// A confirmed transaction without a timestamp (missing block header).
+	transactions["v0-55555555-btc-1"] = append(transactions["v0-55555555-btc-1"],
+		&accounts.TransactionData{
+			Type:   accounts.TxTypeReceive,
+			Status: accounts.TxStatusComplete,
+			Height: 30,
+			Amount: coinpkg.NewAmountFromInt64(1),
+		})
+	chart, err = b.PortfolioChart(PortfolioChartArgs{Interval: 24 * time.Hour, Fiat: "USD"})
+	require.NoError(t, err)
+	require.True(t, chart.DataMissing)
+	require.Len(t, chart.Total.Value, 4)
+	require.Len(t, chart.Accounts[1].Value, 4)
+	transactions["v0-55555555-btc-1"] = transactions["v0-55555555-btc-1"][:1]
+
+	// Too many points.
+	_, err = b.PortfolioChart(PortfolioChartArgs{Interval: time.Second, Fiat: "USD"})
+	require.Error(t, err)
+	_, err = b.PortfolioChart(PortfolioChartArgs{Fiat: "USD"})
+	require.Error(t, err)
+	_, err = b.PortfolioChart(PortfolioChartArgs{Interval: time.Hour, Fiat: "JPY"})
+	require.Error(t, err)
+	_, err = b.PortfolioChart(PortfolioChartArgs{
+		From:     time.Date(2020, 9, 3, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC),
+		Interval: time.Hour,
+		Fiat:     "USD",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// See https://www.bitstamp.net/api/ for docs and details.
+	bitstampAPIV2 = "https://www.bitstamp.net/api/v2"
+	// Bitstamp allows 400 requests per second and 10000 requests per 10 minutes. We use a much
+	// lower value.
+	bitstampRateLimit = rate.Limit(5)
+	// bitstampMaxOHLC is the maximum number of OHLC entries Bitstamp returns in a single response.
+	bitstampMaxOHLC = 1000
+)
+
+// bitstampProvider fetches exchange rates from the public ticker of the Bitstamp exchange. Only the
+// pairs traded on Bitstamp are available, e.g. no rates in CHF or JPY.
+type bitstampProvider struct {
+	httpClient *http.Client
+	url        string
+	// All requests to url are rate-limited using limiter.
+	limiter *rate.Limiter
+}
+
+// NewBitstampProvider returns a provider using the public API of Bitstamp.
+func NewBitstampProvider(client *http.Client) Provider {
+	return &bitstampProvider{
+		httpClient: client,
+		url:        bitstampAPIV2,
+		limiter:    rate.NewLimiter(bitstampRateLimit, 1),
+	}
+}
+
+// Name implements Provider.
+func (provider *bitstampProvider) Name() string {
+	return "bitstamp"
+}
+
+// get fetches the given path of the API and decodes the JSON response into result.
+func (provider *bitstampProvider) get(ctx context.Context, path string, maxSize int64, result interface{}) error {
+	if err := provider.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodGet, provider.url+path, nil)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := provider.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if res.StatusCode != http.StatusOK {
+		return errp.Newf("bad response code %d", res.StatusCode)
+	}
+	return errp.WithStack(json.NewDecoder(io.LimitReader(res.Body, maxSize)).Decode(result))
+}
+
+// Latest implements Provider.
+func (provider *bitstampProvider) Latest(ctx context.Context) (map[string]map[string]float64, error) {
+	supportedUnits := map[string]bool{}
+	for _, unit := range geckoCoinToUnit {
+		supportedUnits[unit] = true
+	}
+	var tickers []struct {
+		Pair string `json:"pair"`
+		Last string `json:"last"`
+	}
+	if err := provider.get(ctx, "/ticker/", 1<<20, &tickers); err != nil {
+		return nil, err
+	}
+	rates := map[string]map[string]float64{}
+	for _, ticker := range tickers {
+		unit, fiat, ok := strings.Cut(ticker.Pair, "/")
+		if !ok || !supportedUnits[unit] || !isSupportedFiat(fiat) {
+			continue
+		}
+		value, err := strconv.ParseFloat(ticker.Last, 64)
+		if err != nil {
+			return nil, errp.Newf("invalid price %q of %s", ticker.Last, ticker.Pair)
+		}
+		if rates[unit] == nil {
+			rates[unit] = map[string]float64{}
+		}
+		rates[unit][fiat] = value
+	}
+	return rates, nil
+}
+
+// History implements Provider using the OHLC data of Bitstamp. The rates are hourly if the time
+// range fits into a single response, and daily otherwise.
+func (provider *bitstampProvider) History(ctx context.Context, coin, fiat string, start, end time.Time) ([]HistoricalRate, error) {
+	unit := coinUnit(coin)
+	if unit == "" {
+		return nil, errp.Newf("unsupported coin %s", coin)
+	}
+	if !isSupportedFiat(fiat) {
+		return nil, errp.Newf("unsupported fiat %s", fiat)
+	}
+	step := time.Hour
+	if end.Sub(start) > bitstampMaxOHLC*step {
+		step = 24 * time.Hour
+	}
+	param := url.Values{
+		"step":  {strconv.Itoa(int(step.Seconds()))},
+		"limit": {strconv.Itoa(bitstampMaxOHLC)},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+	}
+	pair := strings.ToLower(unit + fiat)
+	var response struct {
+		Data struct {
+			OHLC []struct {
+				Timestamp string `json:"timestamp"`
+				Open      string `json:"open"`
+			} `json:"ohlc"`
+		} `json:"data"`
+	}
+	if err := provider.get(ctx, fmt.Sprintf("/ohlc/%s/?%s", pair, param.Encode()), 1<<20, &response); err != nil {
+		return nil, err
+	}
+	rates := make([]HistoricalRate, 0, len(response.Data.OHLC))
+	for _, entry := range response.Data.OHLC {
+		timestamp, err := strconv.ParseInt(entry.Timestamp, 10, 64)
+		if err != nil {
+			return nil, errp.Newf("invalid timestamp %q", entry.Timestamp)
+		}
+		value, err := strconv.ParseFloat(entry.Open, 64)
+		if err != nil {
+			return nil, errp.Newf("invalid price %q", entry.Open)
+		}
+		at := time.Unix(timestamp, 0)
+		// Bitstamp ignores start if end is given, returning the last entries before end.
+		if at.Before(start) || at.After(end) {
+			continue
+		}
+		rates = append(rates, HistoricalRate{Time: at, Value: value})
+	}
+	return rates, nil
+}
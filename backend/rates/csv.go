@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package rates
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// parseRateTime parses the time of an exchange rate, given as unix timestamp in seconds, as RFC3339
+// timestamp or as date (UTC).
+func parseRateTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(timestamp, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, errp.Newf("invalid time %q", value)
+	}
+	return t, nil
+}
+
+// csvRate is an exchange rate read from a CSV file.
+type csvRate struct {
+	unit string
+	fiat string
+	HistoricalRate
+}
+
+// csvProvider reads the exchange rates from a static CSV file, e.g. for offline use. Each line
+// contains the coin unit (e.g. "BTC"), the fiat currency (e.g. "USD"), the time (see
+// parseRateTime) and the price, for example:
+//
+//	coin,fiat,time,price
+//	BTC,USD,2024-01-01,42280.23
+//
+// The header line is optional. The latest rates are the most recent rates of each pair in the file.
+// The file is read again when it changes.
+type csvProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	// rates are sorted by unit, fiat and time.
+	rates []csvRate
+}
+
+// NewCSVProvider returns a provider reading the exchange rates from the CSV file at the given path.
+func NewCSVProvider(path string) Provider {
+	return &csvProvider{path: path}
+}
+
+// Name implements Provider.
+func (provider *csvProvider) Name() string {
+	return "csv " + provider.path
+}
+
+// readCSVRates parses a CSV file of exchange rates, see csvProvider.
+func readCSVRates(r io.Reader) ([]csvRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	var rates []csvRate
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if first && strings.EqualFold(record[0], "coin") {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		t, err := parseRateTime(record[2])
+		if err != nil {
+			return nil, errp.Newf("line %d: %v", line, err)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil || value < 0 {
+			return nil, errp.Newf("line %d: invalid price %q", line, record[3])
+		}
+		rates = append(rates, csvRate{
+			unit:           strings.ToUpper(strings.TrimSpace(record[0])),
+			fiat:           strings.ToUpper(strings.TrimSpace(record[1])),
+			HistoricalRate: HistoricalRate{Time: t, Value: value},
+		})
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		a, b := rates[i], rates[j]
+		if a.unit != b.unit {
+			return a.unit < b.unit
+		}
+		if a.fiat != b.fiat {
+			return a.fiat < b.fiat
+		}
+		return a.Time.Before(b.Time)
+	})
+	return rates, nil
+}
+
+// load returns the rates of the file, reading it if it changed since it was last read.
+func (provider *csvProvider) load() ([]csvRate, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	info, err := os.Stat(provider.path)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if provider.rates != nil && info.ModTime().Equal(provider.modTime) {
+		return provider.rates, nil
+	}
+	file, err := os.Open(provider.path)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer file.Close() //nolint:errcheck
+	rates, err := readCSVRates(file)
+	if err != nil {
+		return nil, err
+	}
+	provider.rates = rates
+	provider.modTime = info.ModTime()
+	return rates, nil
+}
+
+// Latest implements Provider.
+func (provider *csvProvider) Latest(context.Context) (map[string]map[string]float64, error) {
+	rates, err := provider.load()
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]map[string]float64{}
+	for _, rate := range rates {
+		if result[rate.unit] == nil {
+			result[rate.unit] = map[string]float64{}
+		}
+		// The rates are sorted by time, so the latest one wins.
+		result[rate.unit][rate.fiat] = rate.Value
+	}
+	return result, nil
+}
+
+// History implements Provider.
+func (provider *csvProvider) History(_ context.Context, coin, fiat string, start, end time.Time) ([]HistoricalRate, error) {
+	unit := coinUnit(coin)
+	if unit == "" {
+		return nil, errp.Newf("unsupported coin %s", coin)
+	}
+	rates, err := provider.load()
+	if err != nil {
+		return nil, err
+	}
+	var result []HistoricalRate
+	for _, rate := range rates {
+		if rate.unit == unit && rate.fiat == fiat &&
+			!rate.Time.Before(start) && !rate.Time.After(end) {
+			result = append(result, rate.HistoricalRate)
+		}
+	}
+	return result, nil
+}
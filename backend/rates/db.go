@@ -59,3 +59,46 @@ func (updater *RateUpdater) dumpHistoryBucket(key string, rates []exchangeRate)
 		return nil
 	})
 }
+
+// historyGapsBucket is the DB bucket containing the gaps of the history, keyed by coin+fiat pair.
+const historyGapsBucket = "gaps"
+
+// loadHistoryGaps loads the gaps of the history identified by the key, sorted by time.
+func (updater *RateUpdater) loadHistoryGaps(key string) ([]historyGap, error) {
+	var gaps []historyGap
+	err := updater.historyDB.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(historyGapsBucket))
+		if bucket == nil {
+			return nil
+		}
+		// Each gap is stored as the start and end timestamps.
+		v := bucket.Get([]byte(key))
+		for ; len(v) >= 16; v = v[16:] {
+			gaps = append(gaps, historyGap{
+				start: time.Unix(int64(binary.BigEndian.Uint64(v[:8])), 0),
+				end:   time.Unix(int64(binary.BigEndian.Uint64(v[8:16])), 0),
+			})
+		}
+		return nil
+	})
+	return gaps, err
+}
+
+// dumpHistoryGaps stores the gaps of the history identified by the key, replacing the stored ones.
+func (updater *RateUpdater) dumpHistoryGaps(key string, gaps []historyGap) error {
+	return updater.historyDB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(historyGapsBucket))
+		if err != nil {
+			return err
+		}
+		if len(gaps) == 0 {
+			return bucket.Delete([]byte(key))
+		}
+		v := make([]byte, 0, 16*len(gaps))
+		for _, gap := range gaps {
+			v = binary.BigEndian.AppendUint64(v, uint64(gap.start.Unix()))
+			v = binary.BigEndian.AppendUint64(v, uint64(gap.end.Unix()))
+		}
+		return bucket.Put([]byte(key), v)
+	})
+}
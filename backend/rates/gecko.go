@@ -1,8 +1,18 @@
 package rates
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/time/rate"
 )
 
@@ -18,6 +28,10 @@ const (
 	// to increased request failures especially with an intermittent connection.
 	// For comparison, a range of 2 years is about 1Mb.
 	maxGeckoRange = 364 * 24 * time.Hour
+
+	// Latest rates are fetched for all these (coin, fiat) pairs.
+	simplePriceAllIDs        = "bitcoin,litecoin,ethereum,basic-attention-token,dai,chainlink,maker,usd-coin,tether,0x,wrapped-bitcoin,pax-gold,polygon-ecosystem-token"
+	simplePriceAllCurrencies = "usd,eur,chf,gbp,jpy,krw,cny,rub,cad,aud,ils,btc,sgd,hkd,brl,nok,nzd,sek,pln,czk"
 )
 
 // apiRateLimit specifies the maximum number of API calls per second
@@ -59,6 +73,18 @@ var (
 		"eth-erc20-zrx":       "0x",
 		"eth-erc20-wbtc":      "wrapped-bitcoin",
 		"eth-erc20-paxg":      "pax-gold",
+		// Native coins of other EVM chains and their tokens.
+		"arbeth":             "ethereum",
+		"opeth":              "ethereum",
+		"baseeth":            "ethereum",
+		"pol":                "polygon-ecosystem-token",
+		"arbeth-erc20-usdc":  "usd-coin",
+		"arbeth-erc20-usdt":  "tether",
+		"opeth-erc20-usdc":   "usd-coin",
+		"opeth-erc20-usdt":   "tether",
+		"baseeth-erc20-usdc": "usd-coin",
+		"pol-erc20-usdc":     "usd-coin",
+		"pol-erc20-usdt":     "tether",
 	}
 
 	// The keys are CoinGecko coin codes.
@@ -67,6 +93,8 @@ var (
 		"bitcoin":  "BTC",
 		"litecoin": "LTC",
 		"ethereum": "ETH",
+		// Polygon PoS native coin.
+		"polygon-ecosystem-token": "POL",
 		// ERC20 tokens as used in the backend.
 		"basic-attention-token": "BAT",
 		"dai":                   "DAI",
@@ -131,3 +159,150 @@ var (
 		"czk": "CZK",
 	}
 )
+
+// coinGeckoProvider fetches exchange rates from the CoinGecko API or from a server implementing the
+// same API, like the BitBoxApp mirror or a self-hosted price server.
+type coinGeckoProvider struct {
+	httpClient *http.Client
+	log        *logrus.Entry
+	// See https://www.coingecko.com/en/api for details.
+	url string
+	// All requests to url are rate-limited using limiter.
+	limiter *rate.Limiter
+}
+
+// NewCoinGeckoProvider returns a provider using the CoinGecko API at the given URL. If the URL is
+// empty, the BitBoxApp mirror is used.
+func NewCoinGeckoProvider(client *http.Client, apiURL string) Provider {
+	if apiURL == "" {
+		apiURL = shiftGeckoMirrorAPIV3
+	}
+	return &coinGeckoProvider{
+		httpClient: client,
+		log:        logging.Get().WithGroup("rates"),
+		url:        apiURL,
+		limiter:    rate.NewLimiter(apiRateLimit(apiURL), 1),
+	}
+}
+
+// Name implements Provider.
+func (provider *coinGeckoProvider) Name() string {
+	return "coingecko " + provider.url
+}
+
+// Latest implements Provider.
+func (provider *coinGeckoProvider) Latest(ctx context.Context) (map[string]map[string]float64, error) {
+	param := url.Values{
+		"ids":           {simplePriceAllIDs},
+		"vs_currencies": {simplePriceAllCurrencies},
+	}
+	endpoint := fmt.Sprintf("%s/simple/price?%s", provider.url, param.Encode())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errp.WithMessage(err, "could not create request")
+	}
+
+	var geckoRates map[string]map[string]float64
+	if err := provider.limiter.Wait(ctx); err != nil {
+		return nil, errp.WithMessage(err, "could not wait for rate limiter")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := provider.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errp.WithMessage(err, "could not make request")
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if res.StatusCode != http.StatusOK {
+		return nil, errp.Newf("bad response code %d", res.StatusCode)
+	}
+	const max = 10240
+	responseBody, err := io.ReadAll(io.LimitReader(res.Body, max+1))
+	if err != nil {
+		return nil, errp.WithMessage(err, "could not read response")
+	}
+	if len(responseBody) > max {
+		return nil, errp.Newf("rates response too long (> %d bytes)", max)
+	}
+	if err := json.Unmarshal(responseBody, &geckoRates); err != nil {
+		return nil, errp.Newf("could not parse rates response: %s", string(responseBody))
+	}
+
+	// Convert the map with coingecko coin/fiat codes to a map of coin/fiat units.
+	rates := map[string]map[string]float64{}
+	for coin, val := range geckoRates {
+		coinUnit := geckoCoinToUnit[coin]
+		if coinUnit == "" {
+			provider.log.Errorf("unsupported CoinGecko coin: %s", coin)
+			continue
+		}
+		newVal := map[string]float64{}
+		for geckoFiat, rates := range val {
+			fiat, ok := fromGeckoFiat[geckoFiat]
+			if !ok {
+				provider.log.Errorf("unsupported fiat: %s", geckoFiat)
+				continue
+			}
+			newVal[fiat] = rates
+		}
+		rates[coinUnit] = newVal
+	}
+	return rates, nil
+}
+
+// History implements Provider using CoinGecko's "market_chart/range" API.
+func (provider *coinGeckoProvider) History(ctx context.Context, coin, fiat string, start, end time.Time) ([]HistoricalRate, error) {
+	// Prepare a request URL to call the upstream API.
+	gcoin := geckoCoin[coin]
+	if gcoin == "" {
+		return nil, errp.Newf("unsupported coin %s", coin)
+	}
+	gfiat := toGeckoFiat[fiat]
+	if gfiat == "" {
+		return nil, errp.Newf("unsupported fiat %s", fiat)
+	}
+
+	// Make the call, abiding the upstream rate limits.
+	var jsonBody struct{ Prices [][2]float64 } // [timestamp in milliseconds, value]
+	if err := provider.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	param := url.Values{
+		"from":        {strconv.FormatInt(start.Unix(), 10)},
+		"to":          {strconv.FormatInt(end.Unix(), 10)},
+		"vs_currency": {gfiat},
+	}
+	endpoint := fmt.Sprintf("%s/coins/%s/market_chart/range?%s", provider.url, gcoin, param.Encode())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := provider.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if res.StatusCode != http.StatusOK {
+		return nil, errp.Newf("bad response code %d", res.StatusCode)
+	}
+
+	// 1Mb is more than enough for a single response, but make sure initial
+	// download with empty cache fits here. See maxGeckoRange
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&jsonBody); err != nil {
+		return nil, err
+	}
+	// Transform the response into a usable result.
+	rates := make([]HistoricalRate, len(jsonBody.Prices))
+	for i, v := range jsonBody.Prices {
+		rates[i] = HistoricalRate{
+			Value: v[1],
+			Time:  time.Unix(int64(v[0])/1000, 0), // local timezone
+		}
+	}
+	return rates, nil
+}
@@ -4,14 +4,9 @@ package rates
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"math/rand"
-	"net/http"
-	"net/url"
+	"slices"
 	"sort"
-	"strconv"
 	"time"
 )
 
@@ -28,6 +23,7 @@ func (updater *RateUpdater) ReconfigureHistory(coins, fiats []string) {
 		stop()
 		delete(updater.historyGo, key)
 		delete(updater.history, key)
+		delete(updater.historyGaps, key)
 	}
 	// Enable those requested.
 	for _, coin := range coins {
@@ -51,6 +47,11 @@ func (updater *RateUpdater) ReconfigureHistory(coins, fiats []string) {
 			} else {
 				updater.history[key] = rates
 			}
+			if gaps, err := updater.loadHistoryGaps(key); err != nil {
+				updater.log.Errorf("loadHistoryGaps(%q): %v", key, err)
+			} else {
+				updater.historyGaps[key] = gaps
+			}
 			ctx, cancel := context.WithCancel(context.Background())
 			updater.historyGo[key] = cancel
 			go updater.historyUpdateLoop(ctx, coin, fiat)
@@ -119,6 +120,10 @@ func (updater *RateUpdater) historyUpdateLoop(ctx context.Context, coin, fiat st
 // Callers are expected to run this in a separate goroutine.
 func (updater *RateUpdater) backfillHistory(ctx context.Context, coin, fiat string) {
 	updater.log.Printf("started backfillHistory for %s/%s", coin, fiat)
+	if err := updater.backfillHistoryGaps(ctx, coin, fiat); err != nil && err != context.Canceled {
+		// Retried the next time the pair is activated.
+		updater.log.Printf("backfillHistoryGaps(%s, %s): %v", coin, fiat, err)
+	}
 	for {
 		// When to update next, after this loop iteration is done.
 		untilNext := time.Duration(1+rand.Intn(5)) * time.Second
@@ -164,14 +169,59 @@ func (updater *RateUpdater) backfillHistory(ctx context.Context, coin, fiat stri
 	}
 }
 
+// backfillHistoryGaps fetches the missing rates in the gaps of the history, e.g. between imported
+// rates and the rates fetched before, newest first. Gaps for which no rates are available stay
+// recorded, so that no prices are interpolated across them.
+func (updater *RateUpdater) backfillHistoryGaps(ctx context.Context, coin, fiat string) error {
+	gaps := func() []historyGap {
+		updater.historyMu.RLock()
+		defer updater.historyMu.RUnlock()
+		return slices.Clone(updater.historyGaps[coin+fiat])
+	}()
+	for i := len(gaps) - 1; i >= 0; i-- {
+		gap := gaps[i]
+		for end := gap.end; end.After(gap.start); {
+			start := end.Add(-maxGeckoRange)
+			if start.Before(gap.start) {
+				start = gap.start
+			}
+			n, err := updater.updateHistory(ctx, coin, fiat, fixedTimeRange(start, end))
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				updater.log.Printf("backfillHistoryGaps for %s/%s: no rates between %s and %s",
+					coin, fiat, start, end)
+				break
+			}
+			end = start
+		}
+	}
+	return nil
+}
+
 // updateHistory fetches and stores historical data in the specified time range
 // for later use. It returns the number of the newly fetched and stored entries.
 // The data is stored in updater.history.
 func (updater *RateUpdater) updateHistory(ctx context.Context, coin, fiat string, t fetchTimeRange) (n int, err error) {
-	fetchedRates, err := updater.fetchGeckoMarketRange(ctx, coin, fiat, t)
+	// Rates in sat are computed from the BTC rates.
+	providerFiat := fiat
+	if fiat == SAT.String() {
+		providerFiat = BTC.String()
+	}
+	end := t.end()
+	providerRates, err := updater.fetchHistory(ctx, coin, providerFiat, t.start, end)
 	if err != nil {
 		return 0, err
 	}
+	fetchedRates := make([]exchangeRate, len(providerRates))
+	for i, rate := range providerRates {
+		value := rate.Value
+		if fiat == SAT.String() {
+			value *= unitSatoshi
+		}
+		fetchedRates[i] = exchangeRate{value: value, timestamp: rate.Time}
+	}
 
 	bucketName := coin + fiat
 	if err := updater.dumpHistoryBucket(bucketName, fetchedRates); err != nil {
@@ -182,10 +232,18 @@ func (updater *RateUpdater) updateHistory(ctx context.Context, coin, fiat string
 	updater.historyMu.Lock()
 	defer updater.historyMu.Unlock()
 
-	updater.history[bucketName] = append(updater.history[bucketName], fetchedRates...)
-	sort.Slice(updater.history[bucketName], func(i, j int) bool {
-		return updater.history[bucketName][i].timestamp.Before(updater.history[bucketName][j].timestamp)
+	// The fetched rates come first so that they replace existing rates at the same time, like they
+	// do in the DB.
+	history := append(fetchedRates, updater.history[bucketName]...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].timestamp.Before(history[j].timestamp)
 	})
+	updater.history[bucketName] = slices.CompactFunc(history, func(a, b exchangeRate) bool {
+		return a.timestamp.Unix() == b.timestamp.Unix()
+	})
+	if gaps := updater.historyGaps[bucketName]; len(gaps) > 0 && len(fetchedRates) > 0 {
+		updater.setHistoryGaps(bucketName, removeHistoryGaps(gaps, t.start, end))
+	}
 
 	return len(fetchedRates), nil
 }
@@ -254,75 +312,76 @@ func (updater *RateUpdater) HistoryLatestTimestampCoin(coin string) time.Time {
 	return result
 }
 
-type fetchTimeRange struct {
+// historyGapThreshold is the time between two rates above which the rates in between are missing.
+// Daily rates fetched in consecutive time ranges can be up to two days apart.
+const historyGapThreshold = 3 * 24 * time.Hour
+
+// historyGap is a time range of missing rates between two available rates.
+type historyGap struct {
 	start time.Time
-	end   func() time.Time
+	end   time.Time
 }
 
-func fixedTimeRange(start, end time.Time) fetchTimeRange {
-	return fetchTimeRange{
-		start: start,
-		end:   func() time.Time { return end },
+// removeHistoryGaps returns the gaps without the time range from start to end, which was filled.
+func removeHistoryGaps(gaps []historyGap, start, end time.Time) []historyGap {
+	var result []historyGap
+	for _, gap := range gaps {
+		if !gap.end.After(start) || !gap.start.Before(end) {
+			result = append(result, gap)
+			continue
+		}
+		if start.Sub(gap.start) > historyGapThreshold {
+			result = append(result, historyGap{start: gap.start, end: start})
+		}
+		if gap.end.Sub(end) > historyGapThreshold {
+			result = append(result, historyGap{start: end, end: gap.end})
+		}
 	}
+	return result
 }
 
-// fetchGeckoMarketRange slurps historical exchange rates in the specified time range
-// using CoinGecko's "market_chart/range" API.
-func (updater *RateUpdater) fetchGeckoMarketRange(ctx context.Context, coin, fiat string, timeRange fetchTimeRange) ([]exchangeRate, error) {
-	// Prepare a request URL to call the upstream API.
-	gcoin := geckoCoin[coin]
-	if gcoin == "" {
-		return nil, fmt.Errorf("fetchGeckoMarketRange: unsupported coin %s", coin)
+// addHistoryGaps returns the gaps after adding rates to the existing ones, both sorted by time. A
+// gap is added between the existing and the added rates if they are not adjacent, as the update
+// loops only fetch rates before the earliest and after the latest rate.
+func addHistoryGaps(gaps []historyGap, existing, added []exchangeRate) []historyGap {
+	if len(added) == 0 {
+		return gaps
 	}
-	gfiat := toGeckoFiat[fiat]
-	if gfiat == "" {
-		return nil, fmt.Errorf("fetchGeckoMarketRange: unsupported fiat %s", fiat)
+	first, last := added[0].timestamp, added[len(added)-1].timestamp
+	gaps = removeHistoryGaps(gaps, first, last)
+	if len(existing) == 0 {
+		return gaps
 	}
-
-	// Make the call, abiding the upstream rate limits.
-	var jsonBody struct{ Prices [][2]float64 } // [timestamp in milliseconds, value]
-	if err := updater.geckoLimiter.Wait(ctx); err != nil {
-		return nil, err
+	if earliest := existing[0].timestamp; earliest.Sub(last) > historyGapThreshold {
+		gaps = append(gaps, historyGap{start: last, end: earliest})
 	}
-
-	param := url.Values{
-		"from":        {strconv.FormatInt(timeRange.start.Unix(), 10)},
-		"to":          {strconv.FormatInt(timeRange.end().Unix(), 10)},
-		"vs_currency": {gfiat},
-	}
-	endpoint := fmt.Sprintf("%s/coins/%s/market_chart/range?%s", updater.coingeckoURL, gcoin, param.Encode())
-	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, err
+	if latest := existing[len(existing)-1].timestamp; first.Sub(latest) > historyGapThreshold {
+		gaps = append(gaps, historyGap{start: latest, end: first})
 	}
+	sort.Slice(gaps, func(i, j int) bool {
+		return gaps[i].start.Before(gaps[j].start)
+	})
+	return gaps
+}
 
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-	res, err := updater.httpClient.Do(req.WithContext(ctx))
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close() //nolint:errcheck
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetchGeckoMarketRange: bad response code %d", res.StatusCode)
+// setHistoryGaps replaces the gaps of the history identified by the key and stores them in the DB.
+// The caller must hold historyMu.
+func (updater *RateUpdater) setHistoryGaps(key string, gaps []historyGap) {
+	if err := updater.dumpHistoryGaps(key, gaps); err != nil {
+		// Non-critical: the gaps are only filled in this session.
+		updater.log.Errorf("dumpHistoryGaps(%q): %v", key, err)
 	}
+	updater.historyGaps[key] = gaps
+}
 
-	// 1Mb is more than enough for a single response, but make sure initial
-	// download with empty cache fits here. See maxGeckoRange
-	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&jsonBody); err != nil {
-		return nil, err
-	}
-	// Transform the response into a usable result.
-	rates := make([]exchangeRate, len(jsonBody.Prices))
-	for i, v := range jsonBody.Prices {
-		value := v[1]
-		if fiat == SAT.String() {
-			value *= unitSatoshi
-		}
-		rates[i] = exchangeRate{
-			value:     value,
-			timestamp: time.Unix(int64(v[0])/1000, 0), // local timezone
-		}
+type fetchTimeRange struct {
+	start time.Time
+	end   func() time.Time
+}
+
+func fixedTimeRange(start, end time.Time) fetchTimeRange {
+	return fetchTimeRange{
+		start: start,
+		end:   func() time.Time { return end },
 	}
-	return rates, nil
 }
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package rates
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// HistoryFormat is the file format of imported and exported historical exchange rates.
+type HistoryFormat string
+
+const (
+	// HistoryFormatCSV has one rate per line: the time (unix timestamp in seconds, RFC3339
+	// timestamp or date) and the price. The header line `time,price` is optional.
+	HistoryFormatCSV HistoryFormat = "csv"
+	// HistoryFormatJSON is the format of the market chart responses of the CoinGecko API, so those
+	// can be imported directly. The prices are [timestamp in milliseconds, price] pairs:
+	//
+	//	{"prices": [[1598918700000, 10000.0], [1598922501000, 10001.0]]}
+	HistoryFormatJSON HistoryFormat = "json"
+)
+
+// maxHistoryImportSize limits the size of imported files. Daily rates since 2009 are well below.
+const maxHistoryImportSize = 64 << 20
+
+// earliestHistoricalRate is the earliest time for which historical rates can be imported, the day of
+// the Bitcoin genesis block.
+var earliestHistoricalRate = time.Date(2009, time.January, 3, 0, 0, 0, 0, time.UTC)
+
+// readHistory reads historical rates in the given format. The returned rates are sorted by timestamp
+// in ascending order.
+func readHistory(r io.Reader, format HistoryFormat) ([]exchangeRate, error) {
+	limited := &io.LimitedReader{R: r, N: maxHistoryImportSize + 1}
+	var rates []exchangeRate
+	switch format {
+	case HistoryFormatCSV:
+		reader := csv.NewReader(limited)
+		reader.FieldsPerRecord = 2
+		reader.TrimLeadingSpace = true
+		reader.Comment = '#'
+		for first := true; ; first = false {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, errp.WithStack(err)
+			}
+			if first && strings.EqualFold(record[0], "time") {
+				continue
+			}
+			line, _ := reader.FieldPos(0)
+			timestamp, err := parseRateTime(record[0])
+			if err != nil {
+				return nil, errp.Newf("line %d: %v", line, err)
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+			if err != nil {
+				return nil, errp.Newf("line %d: invalid price %q", line, record[1])
+			}
+			rates = append(rates, exchangeRate{value: value, timestamp: timestamp})
+		}
+	case HistoryFormatJSON:
+		var jsonBody struct {
+			Prices [][2]float64 `json:"prices"`
+		}
+		if err := json.NewDecoder(limited).Decode(&jsonBody); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		for _, v := range jsonBody.Prices {
+			rates = append(rates, exchangeRate{
+				value:     v[1],
+				timestamp: time.Unix(int64(v[0])/1000, 0),
+			})
+		}
+	default:
+		return nil, errp.Newf("unknown format %s", format)
+	}
+	if limited.N <= 0 {
+		return nil, errp.Newf("file too large (> %d bytes)", maxHistoryImportSize)
+	}
+	return validateHistory(rates)
+}
+
+// validateHistory checks the imported rates, sorts them and removes duplicates.
+func validateHistory(rates []exchangeRate) ([]exchangeRate, error) {
+	if len(rates) == 0 {
+		return nil, errp.New("no rates found")
+	}
+	latest := time.Now().Add(time.Hour)
+	for i, rate := range rates {
+		if rate.timestamp.Before(earliestHistoricalRate) || rate.timestamp.After(latest) {
+			return nil, errp.Newf("invalid time %s", rate.timestamp.UTC().Format(time.RFC3339))
+		}
+		if math.IsNaN(rate.value) || math.IsInf(rate.value, 0) || rate.value <= 0 {
+			return nil, errp.Newf("invalid price %v at %s",
+				rate.value, rate.timestamp.UTC().Format(time.RFC3339))
+		}
+		// The database stores timestamps in seconds.
+		rates[i].timestamp = time.Unix(rate.timestamp.Unix(), 0)
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].timestamp.Before(rates[j].timestamp)
+	})
+	result := rates[:1]
+	for _, rate := range rates[1:] {
+		previous := result[len(result)-1]
+		if !rate.timestamp.Equal(previous.timestamp) {
+			result = append(result, rate)
+			continue
+		}
+		if rate.value != previous.value {
+			return nil, errp.Newf("conflicting prices at %s",
+				rate.timestamp.UTC().Format(time.RFC3339))
+		}
+	}
+	return result, nil
+}
+
+// writeHistory writes historical rates in the given format.
+func writeHistory(w io.Writer, format HistoryFormat, rates []exchangeRate) error {
+	writer := bufio.NewWriter(w)
+	switch format {
+	case HistoryFormatCSV:
+		csvWriter := csv.NewWriter(writer)
+		if err := csvWriter.Write([]string{"time", "price"}); err != nil {
+			return errp.WithStack(err)
+		}
+		for _, rate := range rates {
+			err := csvWriter.Write([]string{
+				rate.timestamp.UTC().Format(time.RFC3339),
+				strconv.FormatFloat(rate.value, 'f', -1, 64),
+			})
+			if err != nil {
+				return errp.WithStack(err)
+			}
+		}
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return errp.WithStack(err)
+		}
+	case HistoryFormatJSON:
+		prices := make([][2]float64, len(rates))
+		for i, rate := range rates {
+			prices[i] = [2]float64{float64(rate.timestamp.Unix() * 1000), rate.value}
+		}
+		err := json.NewEncoder(writer).Encode(struct {
+			Prices [][2]float64 `json:"prices"`
+		}{Prices: prices})
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	default:
+		return errp.Newf("unknown format %s", format)
+	}
+	return errp.WithStack(writer.Flush())
+}
+
+// checkHistoryPair returns an error if historical rates of the coin/fiat pair are not supported.
+func checkHistoryPair(coin, fiat string) error {
+	if geckoCoin[coin] == "" {
+		return errp.Newf("unsupported coin %q", coin)
+	}
+	if toGeckoFiat[fiat] == "" {
+		return errp.Newf("unsupported fiat %q", fiat)
+	}
+	return nil
+}
+
+// ImportHistory reads historical exchange rates of the coin/fiat pair in the given format and
+// merges them into the history database, replacing existing rates at the same times. The coin is a
+// coin code as passed to ReconfigureHistory. The imported rates are available immediately if the
+// pair is active. If the imported rates are not adjacent to the existing ones, the rates in between
+// are fetched by backfillHistoryGaps once the pair is (re)activated. Returns the number of imported
+// rates.
+func (updater *RateUpdater) ImportHistory(coin, fiat string, format HistoryFormat, r io.Reader) (int, error) {
+	if err := checkHistoryPair(coin, fiat); err != nil {
+		return 0, err
+	}
+	imported, err := readHistory(r, format)
+	if err != nil {
+		return 0, err
+	}
+	key := coin + fiat
+
+	updater.historyMu.Lock()
+	defer updater.historyMu.Unlock()
+	_, active := updater.historyGo[key]
+	existing, gaps := updater.history[key], updater.historyGaps[key]
+	if !active {
+		if existing, err = updater.loadHistoryBucket(key); err != nil {
+			return 0, errp.WithMessage(err, "could not load rates")
+		}
+		if gaps, err = updater.loadHistoryGaps(key); err != nil {
+			return 0, errp.WithMessage(err, "could not load rates")
+		}
+	}
+	gaps = addHistoryGaps(gaps, existing, imported)
+	if err := updater.dumpHistoryBucket(key, imported); err != nil {
+		return 0, errp.WithMessage(err, "could not store rates")
+	}
+	if err := updater.dumpHistoryGaps(key, gaps); err != nil {
+		return 0, errp.WithMessage(err, "could not store rates")
+	}
+	updater.log.Infof("imported %d historical rates of %s/%s", len(imported), coin, fiat)
+	if !active {
+		// Loaded from the database when the pair is activated.
+		return len(imported), nil
+	}
+	updater.historyGaps[key] = gaps
+	values := map[int64]float64{}
+	for _, rate := range updater.history[key] {
+		values[rate.timestamp.Unix()] = rate.value
+	}
+	for _, rate := range imported {
+		values[rate.timestamp.Unix()] = rate.value
+	}
+	merged := make([]exchangeRate, 0, len(values))
+	for timestamp, value := range values {
+		merged = append(merged, exchangeRate{value: value, timestamp: time.Unix(timestamp, 0)})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].timestamp.Before(merged[j].timestamp)
+	})
+	updater.history[key] = merged
+	return len(imported), nil
+}
+
+// ExportHistory writes the historical exchange rates of the coin/fiat pair stored in the history
+// database in the given format.
+func (updater *RateUpdater) ExportHistory(coin, fiat string, format HistoryFormat, w io.Writer) error {
+	if err := checkHistoryPair(coin, fiat); err != nil {
+		return err
+	}
+	rates, err := updater.loadHistoryBucket(coin + fiat)
+	if err != nil {
+		return errp.WithMessage(err, "could not load rates")
+	}
+	if len(rates) == 0 {
+		return errp.Newf("no historical rates of %s/%s available", coin, fiat)
+	}
+	return writeHistory(w, format, rates)
+}
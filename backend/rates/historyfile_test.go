@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package rates
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadHistory(t *testing.T) {
+	want := []exchangeRate{
+		{value: 10000, timestamp: time.Unix(1598918700, 0)},
+		{value: 10001.5, timestamp: time.Unix(1598922501, 0)},
+		{value: 10002, timestamp: time.Unix(1599004800, 0)},
+	}
+	rates, err := readHistory(strings.NewReader(`time,price
+1598922501,10001.5
+2020-09-02,10002
+# Duplicates with the same price are fine.
+2020-09-01T00:05:00Z,10000
+1598918700,10000
+`), HistoryFormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, want, rates)
+
+	rates, err = readHistory(strings.NewReader(
+		`{"prices": [[1598918700000, 10000], [1598922501000, 10001.5], [1599004800000, 10002]], "market_caps": []}`),
+		HistoryFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, want, rates)
+
+	for _, format := range []HistoryFormat{HistoryFormatCSV, HistoryFormatJSON} {
+		var buf bytes.Buffer
+		require.NoError(t, writeHistory(&buf, format, want))
+		rates, err := readHistory(&buf, format)
+		require.NoError(t, err)
+		assert.Equal(t, want, rates, format)
+	}
+}
+
+func TestReadHistoryInvalid(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"time,price\n",
+		"2020-09-01,abc\n",
+		"2020-09-01,0\n",
+		"2020-09-01,-1\n",
+		"2020-09-01,NaN\n",
+		"2008-12-31,1\n",
+		"2999-01-01,1\n",
+		"2020-09-01,1\n2020-09-01,2\n",
+		"2020-09-01\n",
+	} {
+		_, err := readHistory(strings.NewReader(content), HistoryFormatCSV)
+		require.Error(t, err, content)
+	}
+	_, err := readHistory(strings.NewReader(`{"prices": [[1598918700000]]}`), HistoryFormatJSON)
+	require.Error(t, err)
+	_, err = readHistory(strings.NewReader(`[]`), HistoryFormatJSON)
+	require.Error(t, err)
+	_, err = readHistory(strings.NewReader("2020-09-01,1\n"), "xml")
+	require.Error(t, err)
+}
+
+func TestImportExportHistory(t *testing.T) {
+	dbdir := test.TstTempDir("TestImportExportHistory")
+	defer os.RemoveAll(dbdir)
+	updater := NewRateUpdater(http.DefaultClient, dbdir)
+	defer updater.Stop()
+
+	_, err := updater.ImportHistory("unsupported", "USD", HistoryFormatCSV, strings.NewReader("2020-09-01,1\n"))
+	require.Error(t, err)
+	_, err = updater.ImportHistory("btc", "XYZ", HistoryFormatCSV, strings.NewReader("2020-09-01,1\n"))
+	require.Error(t, err)
+	require.Error(t, updater.ExportHistory("btc", "USD", HistoryFormatCSV, &bytes.Buffer{}))
+
+	// An inactive pair is only stored in the database.
+	n, err := updater.ImportHistory("btc", "EUR", HistoryFormatCSV, strings.NewReader("2020-09-01,8500\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, updater.history["btcEUR"])
+
+	// An active pair is merged into the loaded history.
+	updater.historyGo["btcUSD"] = func() {}
+	updater.history["btcUSD"] = []exchangeRate{
+		{value: 1, timestamp: time.Unix(1598832062, 0)},
+		{value: 2, timestamp: time.Unix(1598918700, 0)},
+	}
+	n, err = updater.ImportHistory("btc", "USD", HistoryFormatCSV,
+		strings.NewReader("1598918700,10000\n1598922501,10001\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []exchangeRate{
+		{value: 1, timestamp: time.Unix(1598832062, 0)},
+		{value: 10000, timestamp: time.Unix(1598918700, 0)},
+		{value: 10001, timestamp: time.Unix(1598922501, 0)},
+	}, updater.history["btcUSD"])
+	assert.Equal(t, 10001.0, updater.HistoricalPriceAt("btc", "USD", time.Unix(1598922501, 0)))
+
+	var buf bytes.Buffer
+	require.NoError(t, updater.ExportHistory("btc", "EUR", HistoryFormatCSV, &buf))
+	assert.Equal(t, "time,price\n2020-09-01T00:00:00Z,8500\n", buf.String())
+	buf.Reset()
+	require.NoError(t, updater.ExportHistory("btc", "USD", HistoryFormatJSON, &buf))
+	assert.Equal(t, "{\"prices\":[[1598918700000,10000],[1598922501000,10001]]}\n", buf.String())
+}
+
+func TestImportHistoryGap(t *testing.T) {
+	dbdir := test.TstTempDir("TestImportHistoryGap")
+	defer os.RemoveAll(dbdir)
+	updater := NewRateUpdater(http.DefaultClient, dbdir)
+	defer updater.Stop()
+	updater.historyGo["btcUSD"] = func() {}
+	updater.history["btcUSD"] = []exchangeRate{
+		{value: 10000, timestamp: time.Unix(1598832062, 0)}, // 2020-08-31 00:01:02
+		{value: 10001, timestamp: time.Unix(1598918700, 0)},
+	}
+	// The rates and gaps are in local time, as loaded from the DB.
+	day := func(month time.Month, day int) time.Time {
+		return time.Unix(time.Date(2020, month, day, 0, 0, 0, 0, time.UTC).Unix(), 0)
+	}
+	requireGaps := func(gaps []historyGap) {
+		t.Helper()
+		require.Equal(t, gaps, updater.historyGaps["btcUSD"])
+		stored, err := updater.loadHistoryGaps("btcUSD")
+		require.NoError(t, err)
+		require.Equal(t, gaps, stored)
+	}
+
+	// Rates older than the existing ones leave a gap, which is not interpolated across.
+	_, err := updater.ImportHistory("btc", "USD", HistoryFormatCSV,
+		strings.NewReader("2020-01-01,7000\n2020-01-02,7100\n2020-01-03,7200\n"))
+	require.NoError(t, err)
+	requireGaps([]historyGap{{start: day(1, 3), end: time.Unix(1598832062, 0)}})
+	assert.Equal(t, 7150.0, updater.HistoricalPriceAt("btc", "USD", day(1, 2).Add(12*time.Hour)))
+	assert.Equal(t, 0.0, updater.HistoricalPriceAt("btc", "USD", day(5, 1)))
+
+	// Adjacent rates shrink the gap.
+	_, err = updater.ImportHistory("btc", "USD", HistoryFormatCSV, strings.NewReader("2020-08-30,9900\n"))
+	require.NoError(t, err)
+	requireGaps([]historyGap{{start: day(1, 3), end: day(8, 30)}})
+
+	updater.SetProviders([]Provider{NewCSVProvider(writeTestCSV(t, `coin,fiat,time,price
+BTC,USD,2020-03-01,8000
+BTC,USD,2020-06-01,9000
+`))})
+	require.NoError(t, updater.backfillHistoryGaps(t.Context(), "btc", "USD"))
+	requireGaps(nil)
+	assert.Equal(t, 8000.0, updater.HistoricalPriceAt("btc", "USD", day(3, 1)))
+	assert.InDelta(t, 8500.0, updater.HistoricalPriceAt("btc", "USD", day(4, 16)), 10)
+
+	// A gap without available rates stays recorded.
+	_, err = updater.ImportHistory("btc", "USD", HistoryFormatCSV, strings.NewReader("2019-06-01,8500\n"))
+	require.NoError(t, err)
+	gap := []historyGap{{start: day(1, 1).AddDate(0, -7, 0), end: day(1, 1)}}
+	requireGaps(gap)
+	require.NoError(t, updater.backfillHistoryGaps(t.Context(), "btc", "USD"))
+	requireGaps(gap)
+	assert.Equal(t, 0.0, updater.HistoricalPriceAt("btc", "USD", day(1, 1).AddDate(0, -4, 0)))
+}
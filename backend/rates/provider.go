@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package rates
+
+import (
+	"context"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// HistoricalRate is the exchange rate of a coin/fiat pair at a point in time.
+type HistoricalRate struct {
+	Time  time.Time
+	Value float64
+}
+
+// Provider is a source of exchange rates. All requests of a provider to its API must be
+// rate-limited by the provider itself.
+type Provider interface {
+	// Name identifies the provider in logs.
+	Name() string
+	// Latest returns the most recent exchange rates, keyed by coin unit (e.g. "BTC") and fiat
+	// (e.g. "USD"). Providers do not need to return the rates of the "sat" unit and fiat, which
+	// are computed from the BTC rates.
+	Latest(ctx context.Context) (map[string]map[string]float64, error)
+	// History returns the exchange rates of a coin/fiat pair between start and end in ascending
+	// order. The coin is a coin code as passed to ReconfigureHistory (e.g. "btc"). The fiat is never
+	// "sat". An empty result without error means there is no data in the time range, e.g. because
+	// it is before the coin was listed.
+	History(ctx context.Context, coin, fiat string, start, end time.Time) ([]HistoricalRate, error)
+}
+
+// coinUnit returns the unit of the coin with the given code as used in the latest rates, e.g. "BTC"
+// for "btc" and "tbtc", or "USDC" for "eth-erc20-usdc". Returns an empty string for unsupported
+// coins.
+func coinUnit(coin string) string {
+	return geckoCoinToUnit[geckoCoin[coin]]
+}
+
+// isSupportedFiat returns true if fiat is one of the fiat currencies supported by the updater,
+// excluding "sat".
+func isSupportedFiat(fiat string) bool {
+	_, ok := fromGeckoFiat[toGeckoFiat[fiat]]
+	return ok && fiat != SAT.String()
+}
+
+// SetProviders sets the sources of the exchange rates in order of preference. If a provider fails,
+// the next one is used. It must be called before StartCurrentRates and ReconfigureHistory.
+func (updater *RateUpdater) SetProviders(providers []Provider) {
+	updater.providers = providers
+}
+
+// fetchLatest returns the latest rates of the first provider which does not fail. The coin/fiat
+// pairs missing in its rates are added from the next providers, until all supported pairs are
+// present.
+func (updater *RateUpdater) fetchLatest(ctx context.Context) (map[string]map[string]float64, error) {
+	merged := map[string]map[string]float64{}
+	for _, provider := range updater.providers {
+		rates, err := provider.Latest(ctx)
+		if err == nil && len(rates) > 0 {
+			mergeLatest(merged, rates)
+			if latestComplete(merged) {
+				return merged, nil
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err == nil {
+			err = errp.New("no rates")
+		}
+		updater.log.WithError(err).Errorf("%s: could not fetch latest rates", provider.Name())
+	}
+	if len(merged) == 0 {
+		return nil, errp.New("no rates provider available")
+	}
+	return merged, nil
+}
+
+// mergeLatest adds the coin/fiat pairs of rates which are missing in merged.
+func mergeLatest(merged, rates map[string]map[string]float64) {
+	for coin, fiatRates := range rates {
+		if merged[coin] == nil {
+			merged[coin] = map[string]float64{}
+		}
+		for fiat, rate := range fiatRates {
+			if _, ok := merged[coin][fiat]; !ok {
+				merged[coin][fiat] = rate
+			}
+		}
+	}
+}
+
+// latestComplete returns true if rates contains all pairs of the supported coins and fiat
+// currencies, excluding "sat".
+func latestComplete(rates map[string]map[string]float64) bool {
+	for _, unit := range geckoCoinToUnit {
+		for _, fiat := range fromGeckoFiat {
+			if !isSupportedFiat(fiat) {
+				continue
+			}
+			if _, ok := rates[unit][fiat]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fetchHistory returns the historical rates of the first provider which does not fail.
+func (updater *RateUpdater) fetchHistory(ctx context.Context, coin, fiat string, start, end time.Time) ([]HistoricalRate, error) {
+	err := errp.New("no rates provider available")
+	for _, provider := range updater.providers {
+		var rates []HistoricalRate
+		rates, err = provider.History(ctx, coin, fiat, start, end)
+		if err == nil {
+			return rates, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		err = errp.WithMessage(err, provider.Name())
+		updater.log.WithError(err).Infof("could not fetch history of %s/%s", coin, fiat)
+	}
+	return nil, err
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package rates
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSVRates = `coin,fiat,time,price
+# Comments are ignored.
+BTC,USD,2020-09-01,10000
+btc, usd, 1598922000, 10001.5
+BTC,EUR,2020-09-01T02:00:00Z,8500
+ETH,USD,2020-09-01,400
+`
+
+func writeTestCSV(t *testing.T, content string) string {
+	t.Helper()
+	dir := test.TstTempDir("rates-csv")
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "rates.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestCSVProvider(t *testing.T) {
+	provider := NewCSVProvider(writeTestCSV(t, testCSVRates))
+
+	latest, err := provider.Latest(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]float64{
+		"BTC": {"USD": 10001.5, "EUR": 8500},
+		"ETH": {"USD": 400},
+	}, latest)
+
+	history, err := provider.History(t.Context(), "tbtc", "USD",
+		time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []HistoricalRate{
+		{Time: time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC), Value: 10000},
+		{Time: time.Unix(1598922000, 0), Value: 10001.5},
+	}, history)
+
+	history, err = provider.History(t.Context(), "btc", "USD",
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	_, err = provider.History(t.Context(), "unsupported", "USD", time.Time{}, time.Now())
+	require.Error(t, err)
+}
+
+func TestCSVProviderInvalid(t *testing.T) {
+	for _, content := range []string{
+		"BTC,USD,yesterday,10000\n",
+		"BTC,USD,2020-09-01,-1\n",
+		"BTC,USD,2020-09-01\n",
+	} {
+		_, err := NewCSVProvider(writeTestCSV(t, content)).Latest(t.Context())
+		require.Error(t, err, content)
+	}
+	_, err := NewCSVProvider("/does/not/exist.csv").Latest(t.Context())
+	require.Error(t, err)
+}
+
+func TestBitstampProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ticker/":
+			fmt.Fprintln(w, `[
+				{"pair": "BTC/USD", "last": "10000.5"},
+				{"pair": "ETH/BTC", "last": "0.04"},
+				{"pair": "XRP/USD", "last": "0.2"},
+				{"pair": "BTC/USDT", "last": "10001"}
+			]`)
+		case "/ohlc/btceur/":
+			assert.Equal(t, "3600", r.URL.Query().Get("step"))
+			assert.Equal(t, "1598918400", r.URL.Query().Get("start"))
+			assert.Equal(t, "1598925600", r.URL.Query().Get("end"))
+			// Entries before start are returned, as Bitstamp ignores start if end is given.
+			fmt.Fprintln(w, `{"data": {"pair": "BTC/EUR", "ohlc": [
+				{"timestamp": "1598914800", "open": "8490.0", "close": "8500.0"},
+				{"timestamp": "1598918400", "open": "8500.0", "close": "8510.0"},
+				{"timestamp": "1598922000", "open": "8510.0", "close": "8490.0"}
+			]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	provider := NewBitstampProvider(http.DefaultClient).(*bitstampProvider)
+	provider.url = ts.URL
+
+	latest, err := provider.Latest(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]float64{
+		"BTC": {"USD": 10000.5},
+		"ETH": {"BTC": 0.04},
+	}, latest)
+
+	history, err := provider.History(t.Context(), "btc", "EUR",
+		time.Unix(1598918400, 0), time.Unix(1598925600, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []HistoricalRate{
+		{Time: time.Unix(1598918400, 0), Value: 8500},
+		{Time: time.Unix(1598922000, 0), Value: 8510},
+	}, history)
+
+	_, err = provider.History(t.Context(), "btc", "CHF", time.Unix(1598918400, 0), time.Unix(1598925600, 0))
+	require.Error(t, err)
+}
+
+func TestProviderFallback(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+
+	updater := NewRateUpdater(http.DefaultClient, "/dev/null")
+	defer updater.Stop()
+	updater.SetProviders([]Provider{
+		NewCoinGeckoProvider(http.DefaultClient, failing.URL),
+		NewCSVProvider(writeTestCSV(t, testCSVRates)),
+	})
+
+	updater.updateLast(t.Context())
+	assert.Equal(t, 10001.5, updater.LatestPrice()["BTC"]["USD"])
+	assert.Equal(t, 10001.5, updater.LatestPrice()["TBTC"]["USD"])
+	assert.Equal(t, 10001.5/unitSatoshi, updater.LatestPrice()["sat"]["USD"])
+
+	n, err := updater.updateHistory(t.Context(), "btc", "USD", fixedTimeRange(
+		time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 10000.0,
+		updater.HistoricalPriceAt("btc", "USD", time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)))
+
+	// The pairs missing in the rates of a provider are added from the next providers.
+	updater.SetProviders([]Provider{
+		NewCSVProvider(writeTestCSV(t, "BTC,USD,2020-09-02,11000\n")),
+		NewCoinGeckoProvider(http.DefaultClient, failing.URL),
+		NewCSVProvider(writeTestCSV(t, testCSVRates)),
+	})
+	updater.updateLast(t.Context())
+	assert.Equal(t, map[string]float64{"USD": 11000, "EUR": 8500}, updater.LatestPrice()["BTC"])
+	assert.Equal(t, map[string]float64{"USD": 400}, updater.LatestPrice()["ETH"])
+
+	updater.SetProviders([]Provider{NewCoinGeckoProvider(http.DefaultClient, failing.URL)})
+	updater.updateLast(t.Context())
+	assert.Nil(t, updater.LatestPrice())
+	_, err = updater.updateHistory(t.Context(), "btc", "USD", fixedTimeRange(
+		time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 9, 2, 0, 0, 0, 0, time.UTC)))
+	require.Error(t, err)
+}
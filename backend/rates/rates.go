@@ -4,18 +4,12 @@ package rates
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"net/url"
 	"reflect"
 	"sort"
 	"sync"
 	"time"
 
-	"golang.org/x/time/rate"
-
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/logging"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/observable"
@@ -25,9 +19,6 @@ import (
 )
 
 const (
-	// Latest rates are fetched for all these (coin, fiat) pairs.
-	simplePriceAllIDs        = "bitcoin,litecoin,ethereum,basic-attention-token,dai,chainlink,maker,usd-coin,tether,0x,wrapped-bitcoin,pax-gold"
-	simplePriceAllCurrencies = "usd,eur,chf,gbp,jpy,krw,cny,rub,cad,aud,ils,btc,sgd,hkd,brl,nok,nzd,sek,pln,czk"
 	// RatesEventSubject is the Subject of the event generated by new rates fetching.
 	RatesEventSubject = "rates"
 
@@ -95,7 +86,7 @@ type RateUpdater struct {
 	// it may be impacted by API rate limits.
 	historyDB *bbolt.DB
 
-	historyMu sync.RWMutex // guards both history and historyGo
+	historyMu sync.RWMutex // guards history, historyGo and historyGaps
 	// history contains historical conversion rates in asc order, keyed by coin+fiat pair.
 	// For example, BTC/CHF pair's key is "btcCHF".
 	history map[string][]exchangeRate
@@ -103,12 +94,12 @@ type RateUpdater struct {
 	// of historical data, keyed by coin+fiat pair.
 	// For example, BTC/EUR pair's key is "btcEUR".
 	historyGo map[string]context.CancelFunc
+	// historyGaps contains the time ranges of missing rates in history, keyed by coin+fiat pair.
+	// No prices are interpolated across them.
+	historyGaps map[string][]historyGap
 
-	// CoinGecko is where updater gets the historical conversion rates.
-	// See https://www.coingecko.com/en/api for details.
-	coingeckoURL string
-	// All requests to coingeckoURL are rate-limited using geckoLimiter.
-	geckoLimiter *rate.Limiter
+	// providers are the sources of the exchange rates in order of preference.
+	providers []Provider
 }
 
 // NewRateUpdater returns a new rates updater.
@@ -133,22 +124,22 @@ func NewRateUpdater(client *http.Client, dbdir string) *RateUpdater {
 		// An unopened DB will simply return bbolt.ErrDatabaseNotOpen on all operations.
 		db = &bbolt.DB{}
 	}
-	apiURL := shiftGeckoMirrorAPIV3
 	return &RateUpdater{
-		last:         make(map[string]map[string]float64),
-		history:      make(map[string][]exchangeRate),
-		historyGo:    make(map[string]context.CancelFunc),
-		historyDB:    db,
-		log:          log,
-		httpClient:   client,
-		coingeckoURL: apiURL,
-		geckoLimiter: rate.NewLimiter(apiRateLimit(apiURL), 1),
+		last:        make(map[string]map[string]float64),
+		history:     make(map[string][]exchangeRate),
+		historyGo:   make(map[string]context.CancelFunc),
+		historyGaps: make(map[string][]historyGap),
+		historyDB:   db,
+		log:         log,
+		httpClient:  client,
+		providers:   []Provider{NewCoinGeckoProvider(client, shiftGeckoMirrorAPIV3)},
 	}
 }
 
-// SetCoingeckoURL overrides the default URL the rates updater connects to. Useful for testing.
+// SetCoingeckoURL overrides the default URL the rates updater connects to, replacing all providers
+// by CoinGecko at the given URL. Useful for testing.
 func (updater *RateUpdater) SetCoingeckoURL(url string) {
-	updater.coingeckoURL = url
+	updater.SetProviders([]Provider{NewCoinGeckoProvider(updater.httpClient, url)})
 }
 
 // LatestPrice returns the most recent conversion rates.
@@ -193,6 +184,11 @@ func (updater *RateUpdater) HistoricalPriceAt(coin, fiat string, at time.Time) f
 	if data[idx].timestamp.Equal(at) {
 		return data[idx].value // don't need to interpolate
 	}
+	for _, gap := range updater.historyGaps[coin+fiat] {
+		if gap.start.Before(at) && at.Before(gap.end) {
+			return 0 // rates missing, see backfillHistoryGaps
+		}
+	}
 
 	// Approximate value, somewhere between a and b.
 	// https://en.wikipedia.org/wiki/Linear_interpolation#Linear_interpolation_as_approximation
@@ -249,78 +245,18 @@ func (updater *RateUpdater) lastUpdateLoop(ctx context.Context) {
 }
 
 func (updater *RateUpdater) updateLast(ctx context.Context) {
-	param := url.Values{
-		"ids":           {simplePriceAllIDs},
-		"vs_currencies": {simplePriceAllCurrencies},
-	}
-	endpoint := fmt.Sprintf("%s/simple/price?%s", updater.coingeckoURL, param.Encode())
-	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	rates, err := updater.fetchLatest(ctx)
 	if err != nil {
-		updater.log.WithError(err).Error("could not create request")
-		updater.last = nil
-		return
-	}
-
-	var geckoRates map[string]map[string]float64
-	if err := updater.geckoLimiter.Wait(ctx); err != nil {
-		updater.log.WithError(err).Error("updatelast: could not wait for rate limiter")
+		updater.log.WithError(err).Error("updatelast: could not fetch rates")
 		updater.last = nil
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-	res, err := updater.httpClient.Do(req.WithContext(ctx))
-	if err != nil {
-		updater.log.WithError(err).Error("updatelast: could not make request")
-		updater.last = nil
-		return
-	}
-	defer res.Body.Close() //nolint:errcheck
-	if res.StatusCode != http.StatusOK {
-		updater.log.Errorf("updatelast: bad response code %d", res.StatusCode)
-		updater.last = nil
-		return
-	}
-	const max = 10240
-	responseBody, err := io.ReadAll(io.LimitReader(res.Body, max+1))
-	if err != nil {
-		updater.log.WithError(err).Error("updatelast: could not read response")
-		updater.last = nil
-		return
-	}
-	if len(responseBody) > max {
-		updater.last = nil
-		updater.log.Errorf("updatelast: rates response too long (> %d bytes)", max)
-		return
-	}
-	if err := json.Unmarshal(responseBody, &geckoRates); err != nil {
-		updater.last = nil
-		updater.log.Errorf("updatelast: could not parse rates response: %s", string(responseBody))
-		return
-	}
-
-	// Convert the map with coingecko coin/fiat codes to a map of coin/fiat units.
-	rates := map[string]map[string]float64{}
-	for coin, val := range geckoRates {
-		coinUnit := geckoCoinToUnit[coin]
-		if coinUnit == "" {
-			updater.log.Errorf("unsupported CoinGecko coin: %s", coin)
-			continue
-		}
-		newVal := map[string]float64{}
-		for geckoFiat, rates := range val {
-			fiat, ok := fromGeckoFiat[geckoFiat]
-			if !ok {
-				updater.log.Errorf("unsupported fiat: %s", geckoFiat)
-				continue
-			}
-			if fiat == BTC.String() {
-				newVal[SAT.String()] = rates * unitSatoshi
-			}
-			newVal[fiat] = rates
+	// Provide rates in the sat fiat unit using the BTC rates.
+	for _, val := range rates {
+		if rate, ok := val[BTC.String()]; ok {
+			val[SAT.String()] = rate * unitSatoshi
 		}
-		rates[coinUnit] = newVal
 	}
 
 	// Create sat rates from BTC
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/rates"
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ImportRatesHistory imports the historical exchange rates of a coin/fiat pair from the contents of
+// a file in the given format, e.g. to seed a fresh install. Returns the number of imported rates.
+func (backend *Backend) ImportRatesHistory(
+	coinCode coin.Code, fiat string, format rates.HistoryFormat, contents []byte) (int, error) {
+	return backend.RatesUpdater().ImportHistory(string(coinCode), fiat, format, bytes.NewReader(contents))
+}
+
+// ExportRatesHistory exports the stored historical exchange rates of a coin/fiat pair to a file in
+// the given format. Returns errp.ErrUserAbort if the user did not choose a file.
+func (backend *Backend) ExportRatesHistory(coinCode coin.Code, fiat string, format rates.HistoryFormat) error {
+	var buf bytes.Buffer
+	if err := backend.RatesUpdater().ExportHistory(string(coinCode), fiat, format, &buf); err != nil {
+		return err
+	}
+	exportsDir, err := utilConfig.ExportsDir()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-rates-%s-%s.%s",
+		time.Now().Format("2006-01-02-at-15-04-05"), coinCode, fiat, format)
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := backend.Environment().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return errp.ErrUserAbort
+	}
+	backend.log.Infof("Export historical rates to %s.", path)
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return errp.WithStack(err)
+	}
+	return backend.Environment().SystemOpen(path)
+}
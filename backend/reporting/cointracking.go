@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// coinTrackingExporter writes the CSV import format of CoinTracking. The account name is used as
+// the exchange, the address labels as the trade group and the note as the comment. Fees not
+// belonging to a withdrawal, e.g. of transfers between the exported accounts, are exported as
+// "Other Fee".
+type coinTrackingExporter struct{}
+
+// Extension implements Exporter.
+func (coinTrackingExporter) Extension() string {
+	return "csv"
+}
+
+// Export implements Exporter.
+func (coinTrackingExporter) Export(w io.Writer, entries []*Entry) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"Type",
+		"Buy Amount",
+		"Buy Currency",
+		"Sell Amount",
+		"Sell Currency",
+		"Fee",
+		"Fee Currency",
+		"Exchange",
+		"Trade-Group",
+		"Comment",
+		"Date",
+		"Tx-ID",
+	})
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	for _, entry := range entries {
+		var rowType, buyAmount, buyCurrency, sellAmount, sellCurrency, fee, feeCurrency string
+		switch {
+		case entry.Tx.Type == accounts.TxTypeReceive:
+			rowType = "Deposit"
+			buyAmount = formatAmount(entry.Amount)
+			buyCurrency = entry.Account.Unit
+		case entry.Amount.Sign() != 0:
+			rowType = "Withdrawal"
+			sellAmount = formatAmount(entry.Amount)
+			sellCurrency = entry.Account.Unit
+			if entry.Fee != nil {
+				fee = formatAmount(entry.Fee)
+				feeCurrency = entry.Account.Unit
+			}
+		case entry.Fee != nil:
+			rowType = "Other Fee"
+			sellAmount = formatAmount(entry.Fee)
+			sellCurrency = entry.Account.Unit
+		default:
+			// A transfer between the exported accounts without fee.
+			continue
+		}
+		err := writer.Write([]string{
+			rowType,
+			buyAmount,
+			buyCurrency,
+			sellAmount,
+			sellCurrency,
+			fee,
+			feeCurrency,
+			entry.Account.Name,
+			strings.Join(entry.Labels, ", "),
+			entry.Note,
+			entry.Time().UTC().Format("2006-01-02 15:04:05"),
+			entry.Tx.TxID,
+		})
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
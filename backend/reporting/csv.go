@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// WriteCSV writes the report in CSV format (comma-separated). There is one row per disposed lot
+// ("disposal", or "fee" for the fee of a transfer between our own accounts) with the realized gain,
+// followed by one row per lot held at the end of the period ("holding") with the unrealized gain.
+func (report *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"Type",
+		"Amount",
+		"Unit",
+		"Date acquired",
+		"Date disposed",
+		"Holding period (days)",
+		"Cost basis",
+		"Proceeds or value",
+		"Gain",
+		"Currency",
+		"Account",
+		"Transaction ID",
+	})
+	if err != nil {
+		return errp.WithStack(err)
+	}
+
+	fiat := report.Config.Fiat
+	for _, disposal := range report.Disposals {
+		rowType := "disposal"
+		if disposal.Fee {
+			rowType = "fee"
+		}
+		acquired := ""
+		holdingPeriod := ""
+		if disposal.AcquiredAt != nil {
+			acquired = disposal.AcquiredAt.Format(time.RFC3339)
+			holdingPeriod = strconv.Itoa(int(disposal.DisposedAt.Sub(*disposal.AcquiredAt) / (24 * time.Hour)))
+		}
+		err := writer.Write([]string{
+			rowType,
+			formatAmount(disposal.Amount),
+			disposal.Unit,
+			acquired,
+			disposal.DisposedAt.Format(time.RFC3339),
+			holdingPeriod,
+			coin.FormatAsPlainCurrency(disposal.CostBasis, fiat),
+			coin.FormatAsPlainCurrency(disposal.Proceeds, fiat),
+			coin.FormatAsPlainCurrency(disposal.Gain(), fiat),
+			fiat,
+			disposal.AccountName,
+			disposal.TxID,
+		})
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	for _, lot := range report.OpenLots {
+		err := writer.Write([]string{
+			"holding",
+			formatAmount(lot.Amount),
+			lot.Unit,
+			lot.AcquiredAt.Format(time.RFC3339),
+			"",
+			strconv.Itoa(int(report.Config.ValuedAt.Sub(lot.AcquiredAt) / (24 * time.Hour))),
+			coin.FormatAsPlainCurrency(lot.CostBasis, fiat),
+			coin.FormatAsPlainCurrency(lot.Value, fiat),
+			coin.FormatAsPlainCurrency(lot.UnrealizedGain(), fiat),
+			fiat,
+			lot.AccountName,
+			lot.TxID,
+		})
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+)
+
+// Entry is a confirmed transaction of an account to be exported.
+//
+// A transfer between two of the exported accounts is a single entry of the sending account. The
+// receiving side is only included in Transfers.
+type Entry struct {
+	Account *Account
+	Tx      *accounts.TransactionData
+	// Amount is the amount received from or sent to others than the exported accounts, in the unit
+	// of the account. It is zero for sends to ourselves and failed transactions, of which only the
+	// fee is spent.
+	Amount *big.Rat
+	// Fee is the fee paid by the account, nil if none. Fees paid in another coin, e.g. ETH for ERC20
+	// tokens, are part of the entry of the account of that coin.
+	Fee *big.Rat
+	// Transfers are the entries of the exported accounts receiving coins in the same transaction.
+	// Only set for sends.
+	Transfers []*Entry
+	// Note is the transaction note.
+	Note string
+	// Labels are the labels of the addresses of the transaction.
+	Labels []string
+}
+
+// Time is the time of confirmation of the transaction.
+func (entry *Entry) Time() time.Time {
+	return *entry.Tx.Timestamp
+}
+
+// Description is the note of the transaction, or the labels of its addresses if there is none.
+func (entry *Entry) Description() string {
+	if entry.Note != "" {
+		return entry.Note
+	}
+	return strings.Join(entry.Labels, ", ")
+}
+
+func newEntry(account *Account, tx *accounts.TransactionData) *Entry {
+	entry := &Entry{
+		Account: account,
+		Tx:      tx,
+		Amount:  toUnit(tx.Amount, account.Decimals),
+	}
+	if tx.Fee != nil && !tx.FeeIsDifferentUnit && tx.Type != accounts.TxTypeReceive {
+		entry.Fee = toUnit(*tx.Fee, account.Decimals)
+	}
+	if tx.Status == accounts.TxStatusFailed || tx.Type == accounts.TxTypeSendSelf {
+		entry.Amount = new(big.Rat)
+	}
+	if account.Notes != nil {
+		entry.Note = account.Notes.TxNote(tx.InternalID)
+		for _, address := range tx.Addresses {
+			label := account.Notes.AddressLabel(address.Address)
+			if label != "" && !slices.Contains(entry.Labels, label) {
+				entry.Labels = append(entry.Labels, label)
+			}
+		}
+	}
+	return entry
+}
+
+// NewEntries returns the entries of the transactions of the accounts confirmed at or after from and
+// before until, ordered by time. A zero from or until means no bound. Pending transactions are
+// ignored.
+func NewEntries(accts []*Account, from, until time.Time) []*Entry {
+	// Transactions sent from one of the accounts.
+	sent := map[txKey]bool{}
+	for _, account := range accts {
+		for _, tx := range account.Transactions {
+			if tx.Timestamp == nil || tx.Status == accounts.TxStatusFailed {
+				continue
+			}
+			if tx.Type == accounts.TxTypeSend || tx.Type == accounts.TxTypeSendSelf {
+				sent[txKey{coinCode: account.CoinCode, txID: tx.TxID}] = true
+			}
+		}
+	}
+
+	var entries []*Entry
+	transfers := map[txKey][]*Entry{}
+	for _, account := range accts {
+		for _, tx := range account.Transactions {
+			if tx.Timestamp == nil {
+				continue
+			}
+			if (!from.IsZero() && tx.Timestamp.Before(from)) ||
+				(!until.IsZero() && !tx.Timestamp.Before(until)) {
+				continue
+			}
+			entry := newEntry(account, tx)
+			key := txKey{coinCode: account.CoinCode, txID: tx.TxID}
+			if tx.Type == accounts.TxTypeReceive && sent[key] {
+				transfers[key] = append(transfers[key], entry)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range entries {
+		if entry.Tx.Type != accounts.TxTypeSend || entry.Tx.Status == accounts.TxStatusFailed {
+			continue
+		}
+		entry.Transfers = transfers[txKey{coinCode: entry.Account.CoinCode, txID: entry.Tx.TxID}]
+		for _, transfer := range entry.Transfers {
+			entry.Amount.Sub(entry.Amount, transfer.Amount)
+		}
+		if entry.Amount.Sign() < 0 {
+			entry.Amount.SetInt64(0)
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b *Entry) int {
+		return a.Time().Compare(b.Time())
+	})
+	return entries
+}
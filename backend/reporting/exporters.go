@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"io"
+	"slices"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/locker"
+)
+
+// ExportFormat identifies a transaction export format. See the ExportFormat* constants.
+type ExportFormat string
+
+const (
+	// ExportFormatKoinly is the universal CSV format of Koinly.
+	ExportFormatKoinly ExportFormat = "koinly"
+	// ExportFormatCoinTracking is the CSV import format of CoinTracking.
+	ExportFormatCoinTracking ExportFormat = "cointracking"
+	// ExportFormatHledger is a double-entry journal for hledger and ledger.
+	ExportFormatHledger ExportFormat = "hledger"
+	// ExportFormatBeancount is a double-entry ledger for beancount.
+	ExportFormatBeancount ExportFormat = "beancount"
+)
+
+// Exporter writes entries in an export format.
+type Exporter interface {
+	// Extension is the file extension of the format, e.g. "csv".
+	Extension() string
+	// Export writes the entries, which are ordered by time.
+	Export(w io.Writer, entries []*Entry) error
+}
+
+var (
+	exporters = map[ExportFormat]Exporter{
+		ExportFormatKoinly:       koinlyExporter{},
+		ExportFormatCoinTracking: coinTrackingExporter{},
+		ExportFormatHledger:      hledgerExporter{},
+		ExportFormatBeancount:    beancountExporter{},
+	}
+	exportersLock locker.Locker
+)
+
+// RegisterExporter adds an export format, or replaces the exporter of an existing one.
+func RegisterExporter(format ExportFormat, exporter Exporter) {
+	defer exportersLock.Lock()()
+	exporters[format] = exporter
+}
+
+// ExportFormats returns all registered export formats, sorted by name.
+func ExportFormats() []ExportFormat {
+	defer exportersLock.RLock()()
+	formats := make([]ExportFormat, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// LookupExporter returns the exporter of the format.
+func LookupExporter(format ExportFormat) (Exporter, error) {
+	defer exportersLock.RLock()()
+	exporter, ok := exporters[format]
+	if !ok {
+		return nil, errp.Newf("unknown export format %q", format)
+	}
+	return exporter, nil
+}
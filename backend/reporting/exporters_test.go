@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/stretchr/testify/require"
+)
+
+type testNotes map[string]string
+
+func (notes testNotes) TxNote(txID string) string {
+	return notes["tx:"+txID]
+}
+
+func (notes testNotes) AddressLabel(address string) string {
+	return notes["addr:"+address]
+}
+
+// testExportAccounts returns the accounts of testAccounts() with notes and address labels.
+func testExportAccounts() []*Account {
+	accts := testAccounts()
+	accts[0].Notes = testNotes{"tx:payment": "Coffee; with milk", "addr:shop-address": "Shop"}
+	accts[0].Transactions[4].InternalID = "payment"
+	accts[0].Transactions[4].Addresses = []accounts.AddressAndAmount{{Address: "shop-address"}}
+	accts[0].Transactions[5].InternalID = "next-year"
+	accts[0].Transactions[5].Addresses = []accounts.AddressAndAmount{{Address: "shop-address"}}
+	return accts
+}
+
+func exportString(t *testing.T, format ExportFormat) string {
+	t.Helper()
+	var buf bytes.Buffer
+	exporter, err := LookupExporter(format)
+	require.NoError(t, err)
+	require.NoError(t, exporter.Export(&buf, NewEntries(testExportAccounts(), date(2024, 1, 1), date(2025, 1, 2))))
+	return buf.String()
+}
+
+func TestNewEntries(t *testing.T) {
+	entries := NewEntries(testExportAccounts(), date(2024, 1, 1), date(2025, 1, 1))
+	require.Len(t, entries, 4)
+	require.Equal(t, "lot1", entries[0].Tx.TxID)
+
+	// The transfer to the second account is one entry of the sending account.
+	transfer := entries[2]
+	require.Equal(t, "transfer", transfer.Tx.TxID)
+	requireRat(t, "0", transfer.Amount)
+	requireRat(t, "0.001", transfer.Fee)
+	require.Len(t, transfer.Transfers, 1)
+	require.Equal(t, "Bitcoin 2", transfer.Transfers[0].Account.Name)
+	requireRat(t, "0.5", transfer.Transfers[0].Amount)
+
+	payment := entries[3]
+	require.Equal(t, "Coffee; with milk", payment.Note)
+	require.Equal(t, []string{"Shop"}, payment.Labels)
+	require.Equal(t, "Coffee; with milk", payment.Description())
+
+	entries = NewEntries(testExportAccounts(), date(2025, 1, 1), date(2026, 1, 1))
+	require.Len(t, entries, 1)
+	require.Equal(t, "Shop", entries[0].Description())
+	require.Len(t, NewEntries(testExportAccounts(), time.Time{}, time.Time{}), 6)
+}
+
+func TestExportKoinly(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewBufferString(exportString(t, ExportFormatKoinly))).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"Date", "Sent Amount", "Sent Currency", "Received Amount", "Received Currency", "Fee Amount", "Fee Currency", "Net Worth Amount", "Net Worth Currency", "Label", "Description", "TxHash"},
+		{"2024-01-01 00:00 UTC", "", "", "1", "BTC", "", "", "", "", "", "", "lot1"},
+		{"2024-02-01 00:00 UTC", "", "", "1", "BTC", "", "", "", "", "", "", "lot2"},
+		{"2024-03-01 00:00 UTC", "", "", "", "", "0.001", "BTC", "", "", "", "", "transfer"},
+		{"2024-06-01 00:00 UTC", "0.999", "BTC", "", "", "0.001", "BTC", "", "", "", "Coffee; with milk", "payment"},
+		{"2025-01-01 00:00 UTC", "0.1", "BTC", "", "", "0.00001", "BTC", "", "", "", "Shop", "next-year"},
+	}, records)
+}
+
+func TestExportCoinTracking(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewBufferString(exportString(t, ExportFormatCoinTracking))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+	require.Equal(t,
+		[]string{"Other Fee", "", "", "0.001", "BTC", "", "", "Bitcoin", "", "", "2024-03-01 00:00:00", "transfer"},
+		records[3])
+	require.Equal(t,
+		[]string{"Withdrawal", "", "", "0.999", "BTC", "0.001", "BTC", "Bitcoin", "Shop", "Coffee; with milk", "2024-06-01 00:00:00", "payment"},
+		records[4])
+}
+
+func TestExportHledger(t *testing.T) {
+	require.Equal(t, `2024-01-01   ; txid:lot1
+    assets:crypto:Bitcoin  1 BTC
+    equity:external  -1 BTC
+
+2024-02-01   ; txid:lot2
+    assets:crypto:Bitcoin  1 BTC
+    equity:external  -1 BTC
+
+2024-03-01   ; txid:transfer
+    assets:crypto:Bitcoin 2  0.5 BTC
+    expenses:fees  0.001 BTC
+    assets:crypto:Bitcoin  -0.501 BTC
+
+2024-06-01 Coffee with milk  ; txid:payment
+    equity:external  0.999 BTC
+    expenses:fees  0.001 BTC
+    assets:crypto:Bitcoin  -1 BTC
+
+2025-01-01 Shop  ; txid:next-year
+    equity:external  0.1 BTC
+    expenses:fees  0.00001 BTC
+    assets:crypto:Bitcoin  -0.10001 BTC
+
+`, exportString(t, ExportFormatHledger))
+}
+
+func TestExportBeancount(t *testing.T) {
+	exported := exportString(t, ExportFormatBeancount)
+	require.Contains(t, exported, `2024-01-01 open Assets:Crypto:Bitcoin
+2024-01-01 open Equity:External
+2024-03-01 open Assets:Crypto:Bitcoin-2
+2024-03-01 open Expenses:Fees
+`)
+	require.Contains(t, exported, `2024-06-01 * "Coffee; with milk"
+  txid: "payment"
+  Equity:External  0.999 BTC
+  Expenses:Fees  0.001 BTC
+  Assets:Crypto:Bitcoin  -1 BTC
+`)
+	require.Equal(t, "My-BTC-2", beancountName("my BTC (2)"))
+	require.Equal(t, "Account", beancountName("€"))
+	require.Equal(t, "USDC.E", beancountCurrency("USDC.e"))
+}
+
+type testExporter struct{}
+
+func (testExporter) Extension() string { return "txt" }
+
+func (testExporter) Export(w io.Writer, entries []*Entry) error {
+	_, err := w.Write([]byte{byte(len(entries))})
+	return err
+}
+
+func TestRegisterExporter(t *testing.T) {
+	require.Equal(t,
+		[]ExportFormat{ExportFormatBeancount, ExportFormatCoinTracking, ExportFormatHledger, ExportFormatKoinly},
+		ExportFormats())
+	_, err := LookupExporter("test")
+	require.Error(t, err)
+
+	RegisterExporter("test", testExporter{})
+	defer func() {
+		defer exportersLock.Lock()()
+		delete(exporters, "test")
+	}()
+	require.Contains(t, ExportFormats(), ExportFormat("test"))
+	require.Equal(t, "\x05", exportString(t, "test"))
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// koinlyExporter writes the universal CSV format of Koinly. The Label column is left empty, as
+// Koinly only accepts its own tags there. Notes go into the Description column.
+type koinlyExporter struct{}
+
+// Extension implements Exporter.
+func (koinlyExporter) Extension() string {
+	return "csv"
+}
+
+// Export implements Exporter.
+func (koinlyExporter) Export(w io.Writer, entries []*Entry) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"Date",
+		"Sent Amount",
+		"Sent Currency",
+		"Received Amount",
+		"Received Currency",
+		"Fee Amount",
+		"Fee Currency",
+		"Net Worth Amount",
+		"Net Worth Currency",
+		"Label",
+		"Description",
+		"TxHash",
+	})
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	for _, entry := range entries {
+		var sentAmount, sentCurrency, receivedAmount, receivedCurrency, feeAmount, feeCurrency string
+		if entry.Tx.Type == accounts.TxTypeReceive {
+			receivedAmount = formatAmount(entry.Amount)
+			receivedCurrency = entry.Account.Unit
+		} else if entry.Amount.Sign() != 0 {
+			sentAmount = formatAmount(entry.Amount)
+			sentCurrency = entry.Account.Unit
+		}
+		if entry.Fee != nil {
+			feeAmount = formatAmount(entry.Fee)
+			feeCurrency = entry.Account.Unit
+		}
+		if receivedAmount == "" && sentAmount == "" && feeAmount == "" {
+			// A transfer between the exported accounts without fee.
+			continue
+		}
+		err := writer.Write([]string{
+			entry.Time().UTC().Format("2006-01-02 15:04 UTC"),
+			sentAmount,
+			sentCurrency,
+			receivedAmount,
+			receivedCurrency,
+			feeAmount,
+			feeCurrency,
+			"",
+			"",
+			"",
+			entry.Description(),
+			entry.Tx.TxID,
+		})
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,242 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// ledgerAccountKind is the kind of account a posting of a double-entry ledger goes to.
+type ledgerAccountKind int
+
+const (
+	// ledgerAsset is one of the exported accounts.
+	ledgerAsset ledgerAccountKind = iota
+	// ledgerExternal is the counterparty of receives and sends.
+	ledgerExternal
+	// ledgerFees collects the fees.
+	ledgerFees
+)
+
+type posting struct {
+	kind ledgerAccountKind
+	// account is only set for ledgerAsset.
+	account *Account
+	amount  *big.Rat
+	unit    string
+}
+
+// postings returns the postings of an entry, which sum up to zero. The sending account pays the
+// amount, the fee and the transfers to our other accounts.
+func postings(entry *Entry) []posting {
+	unit := entry.Account.Unit
+	if entry.Tx.Type == accounts.TxTypeReceive {
+		return []posting{
+			{kind: ledgerAsset, account: entry.Account, amount: entry.Amount, unit: unit},
+			{kind: ledgerExternal, amount: new(big.Rat).Neg(entry.Amount), unit: unit},
+		}
+	}
+	var result []posting
+	paid := new(big.Rat)
+	for _, transfer := range entry.Transfers {
+		result = append(result, posting{
+			kind: ledgerAsset, account: transfer.Account, amount: transfer.Amount, unit: unit,
+		})
+		paid.Add(paid, transfer.Amount)
+	}
+	if entry.Amount.Sign() != 0 {
+		result = append(result, posting{kind: ledgerExternal, amount: entry.Amount, unit: unit})
+		paid.Add(paid, entry.Amount)
+	}
+	if entry.Fee != nil {
+		result = append(result, posting{kind: ledgerFees, amount: entry.Fee, unit: unit})
+		paid.Add(paid, entry.Fee)
+	}
+	if paid.Sign() == 0 {
+		return nil
+	}
+	return append(result, posting{
+		kind: ledgerAsset, account: entry.Account, amount: new(big.Rat).Neg(paid), unit: unit,
+	})
+}
+
+// ledgerNames assigns each account a unique name, made of the characters allowed by the format.
+func ledgerNames(entries []*Entry, sanitize func(string) string) map[accountsTypes.Code]string {
+	names := map[accountsTypes.Code]string{}
+	taken := map[string]bool{}
+	add := func(account *Account) {
+		if _, ok := names[account.Code]; ok {
+			return
+		}
+		base := sanitize(account.Name)
+		name := base
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[name] = true
+		names[account.Code] = name
+	}
+	for _, entry := range entries {
+		add(entry.Account)
+		for _, transfer := range entry.Transfers {
+			add(transfer.Account)
+		}
+	}
+	return names
+}
+
+// ledgerText removes line breaks and the given characters from free text.
+func ledgerText(text string, remove string) string {
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(remove, r)
+	}), " ")
+}
+
+// hledgerExporter writes a journal for hledger, which can also be read by ledger. The notes are the
+// descriptions of the transactions.
+type hledgerExporter struct{}
+
+// Extension implements Exporter.
+func (hledgerExporter) Extension() string {
+	return "journal"
+}
+
+// Export implements Exporter.
+func (hledgerExporter) Export(w io.Writer, entries []*Entry) error {
+	names := ledgerNames(entries, func(name string) string {
+		name = ledgerText(strings.ReplaceAll(name, ":", "-"), ";")
+		if name == "" {
+			return "account"
+		}
+		return name
+	})
+	accountName := func(p posting) string {
+		switch p.kind {
+		case ledgerAsset:
+			return "assets:crypto:" + names[p.account.Code]
+		case ledgerFees:
+			return "expenses:fees"
+		default:
+			return "equity:external"
+		}
+	}
+	commodity := func(unit string) string {
+		for _, r := range unit {
+			if !unicode.IsLetter(r) {
+				return strconv.Quote(unit)
+			}
+		}
+		return unit
+	}
+	writer := bufio.NewWriter(w)
+	for _, entry := range entries {
+		entryPostings := postings(entry)
+		if len(entryPostings) == 0 {
+			continue
+		}
+		fmt.Fprintf(writer, "%s %s  ; txid:%s\n",
+			entry.Time().UTC().Format(time.DateOnly),
+			ledgerText(entry.Description(), ";"),
+			entry.Tx.TxID)
+		for _, p := range entryPostings {
+			fmt.Fprintf(writer, "    %s  %s %s\n",
+				accountName(p), formatAmount(p.amount), commodity(p.unit))
+		}
+		fmt.Fprintln(writer)
+	}
+	return errp.WithStack(writer.Flush())
+}
+
+// beancountExporter writes a beancount ledger. The notes are the narrations of the transactions.
+type beancountExporter struct{}
+
+// Extension implements Exporter.
+func (beancountExporter) Extension() string {
+	return "beancount"
+}
+
+// beancountName returns a valid component of a beancount account name, consisting of ASCII letters,
+// digits and dashes and starting with a capital letter or digit.
+func beancountName(name string) string {
+	var builder strings.Builder
+	for _, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			builder.WriteRune(r)
+		case builder.Len() > 0 && !strings.HasSuffix(builder.String(), "-"):
+			builder.WriteRune('-')
+		}
+	}
+	result := strings.TrimSuffix(builder.String(), "-")
+	if result == "" {
+		return "Account"
+	}
+	return strings.ToUpper(result[:1]) + result[1:]
+}
+
+// beancountCurrency returns a valid beancount currency for the unit.
+func beancountCurrency(unit string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("'._-", r) {
+			return r
+		}
+		return '-'
+	}, strings.ToUpper(unit))
+}
+
+// Export implements Exporter.
+func (beancountExporter) Export(w io.Writer, entries []*Entry) error {
+	names := ledgerNames(entries, beancountName)
+	accountName := func(p posting) string {
+		switch p.kind {
+		case ledgerAsset:
+			return "Assets:Crypto:" + names[p.account.Code]
+		case ledgerFees:
+			return "Expenses:Fees"
+		default:
+			return "Equity:External"
+		}
+	}
+	writer := bufio.NewWriter(w)
+	// Accounts must be opened before they are used.
+	opened := map[string]bool{}
+	for _, entry := range entries {
+		for _, p := range postings(entry) {
+			name := accountName(p)
+			if !opened[name] {
+				opened[name] = true
+				fmt.Fprintf(writer, "%s open %s\n", entry.Time().UTC().Format(time.DateOnly), name)
+			}
+		}
+	}
+	if len(opened) > 0 {
+		fmt.Fprintln(writer)
+	}
+	for _, entry := range entries {
+		entryPostings := postings(entry)
+		if len(entryPostings) == 0 {
+			continue
+		}
+		fmt.Fprintf(writer, "%s * %s\n",
+			entry.Time().UTC().Format(time.DateOnly),
+			strconv.Quote(ledgerText(entry.Description(), "")))
+		fmt.Fprintf(writer, "  txid: %s\n", strconv.Quote(entry.Tx.TxID))
+		for _, p := range entryPostings {
+			fmt.Fprintf(writer, "  %s  %s %s\n",
+				accountName(p), formatAmount(p.amount), beancountCurrency(p.unit))
+		}
+		fmt.Fprintln(writer)
+	}
+	return errp.WithStack(writer.Flush())
+}
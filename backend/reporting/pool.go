@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"math/big"
+)
+
+// portion is the part of a lot disposed of.
+type portion struct {
+	// lot is nil if the disposed amount exceeds the amount of all lots.
+	lot       *Lot
+	amount    *big.Rat
+	costBasis *big.Rat
+}
+
+// pool holds the lots of a coin, ordered by acquisition time.
+type pool struct {
+	lots []*Lot
+}
+
+func (p *pool) add(lot *Lot) {
+	if lot.Amount.Sign() <= 0 {
+		return
+	}
+	p.lots = append(p.lots, lot)
+}
+
+// next returns the index of the lot to dispose of next according to the cost basis method.
+func (p *pool) next(method Method) int {
+	switch method {
+	case MethodLIFO:
+		return len(p.lots) - 1
+	case MethodHIFO:
+		best := 0
+		var bestCost *big.Rat
+		for i, lot := range p.lots {
+			cost := new(big.Rat).Quo(lot.CostBasis, lot.Amount)
+			// Of lots with equal costs, the earliest one is chosen.
+			if bestCost == nil || cost.Cmp(bestCost) > 0 {
+				best = i
+				bestCost = cost
+			}
+		}
+		return best
+	default:
+		return 0
+	}
+}
+
+// dispose removes the given amount from the lots, returning the disposed portions of the lots.
+// The cost basis of the lots is reduced proportionally to the disposed amount.
+func (p *pool) dispose(amount *big.Rat, method Method) []portion {
+	var result []portion
+	remaining := new(big.Rat).Set(amount)
+	for remaining.Sign() > 0 && len(p.lots) > 0 {
+		i := p.next(method)
+		lot := p.lots[i]
+		if lot.Amount.Cmp(remaining) <= 0 {
+			result = append(result, portion{lot: lot, amount: lot.Amount, costBasis: lot.CostBasis})
+			remaining.Sub(remaining, lot.Amount)
+			p.lots = append(p.lots[:i], p.lots[i+1:]...)
+			continue
+		}
+		costBasis := new(big.Rat).Mul(lot.CostBasis, new(big.Rat).Quo(remaining, lot.Amount))
+		result = append(result, portion{
+			lot:       lot,
+			amount:    new(big.Rat).Set(remaining),
+			costBasis: costBasis,
+		})
+		lot.Amount = new(big.Rat).Sub(lot.Amount, remaining)
+		lot.CostBasis = new(big.Rat).Sub(lot.CostBasis, costBasis)
+		remaining.SetInt64(0)
+	}
+	if remaining.Sign() > 0 {
+		result = append(result, portion{amount: remaining, costBasis: new(big.Rat)})
+	}
+	return result
+}
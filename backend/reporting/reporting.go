@@ -0,0 +1,426 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package reporting computes the realized and unrealized gains of the coins held in our accounts
+// for tax reports, based on the transactions of the accounts and the historical exchange rates.
+//
+// Every receive creates a lot with the fiat value at the time of the receive as its cost basis.
+// Sends dispose of lots according to the chosen cost basis method. The lots are pooled per coin
+// across all accounts, so transfers between our own accounts and sends to ourselves are not
+// taxable. Fees are treated as a cost: the fee of a send is disposed of together with the sent
+// amount without adding to the proceeds, and the fee of a transfer between our own accounts is
+// disposed of without proceeds.
+package reporting
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// Method is the cost basis method, which determines the lots disposed of first.
+type Method string
+
+const (
+	// MethodFIFO disposes of the earliest acquired lots first.
+	MethodFIFO Method = "fifo"
+	// MethodLIFO disposes of the latest acquired lots first.
+	MethodLIFO Method = "lifo"
+	// MethodHIFO disposes of the lots with the highest cost per unit first.
+	MethodHIFO Method = "hifo"
+)
+
+// Rates provides the historical exchange rates. It is implemented by rates.RateUpdater.
+type Rates interface {
+	HistoricalPriceAt(coin, fiat string, at time.Time) float64
+}
+
+// Account holds the transactions of an account to be included in the report.
+type Account struct {
+	Code     accountsTypes.Code
+	Name     string
+	CoinCode coin.Code
+	// Unit is the unit the amounts in the report are denominated in, e.g. "BTC".
+	Unit string
+	// Decimals is the number of decimals of the unit, i.e. the amounts of the transactions are
+	// in 10^-Decimals of the unit.
+	Decimals     uint
+	Transactions []*accounts.TransactionData
+	// Notes are the notes of the account. Can be nil.
+	Notes Notes
+}
+
+// Notes provides the transaction notes and address labels of an account. It is implemented by
+// notes.Notes.
+type Notes interface {
+	TxNote(txID string) string
+	AddressLabel(address string) string
+}
+
+// Config configures a report.
+type Config struct {
+	// Fiat is the currency the cost basis, proceeds and gains are computed in.
+	Fiat   string
+	Method Method
+	// From and Until delimit the reporting period. Disposals at or after From and before Until are
+	// reported, as well as the lots held at Until.
+	From  time.Time
+	Until time.Time
+	// ValuedAt is the time at which the lots held at the end of the period are valued. It is
+	// usually Until, or the time of the latest available exchange rate if the period has not ended
+	// yet. If zero, Until is used.
+	ValuedAt time.Time
+}
+
+// Lot is an amount of a coin acquired in a single transaction.
+type Lot struct {
+	CoinCode coin.Code
+	Unit     string
+	// AccountCode and AccountName identify the account which received the coins.
+	AccountCode accountsTypes.Code
+	AccountName string
+	TxID        string
+	AcquiredAt  time.Time
+	Amount      *big.Rat
+	// CostBasis is the fiat value of the amount at the time of acquisition.
+	CostBasis *big.Rat
+	// Value is the fiat value of the amount at Config.ValuedAt.
+	Value *big.Rat
+}
+
+// UnrealizedGain is the gain (negative for a loss) if the lot was sold at its current value.
+func (lot *Lot) UnrealizedGain() *big.Rat {
+	return new(big.Rat).Sub(lot.Value, lot.CostBasis)
+}
+
+// Disposal is the disposal of (a part of) a lot, which realizes a gain or loss.
+type Disposal struct {
+	CoinCode coin.Code
+	Unit     string
+	// AccountCode and AccountName identify the account which sent the coins.
+	AccountCode accountsTypes.Code
+	AccountName string
+	TxID        string
+	// Fee is true if only the fee of a transaction was disposed of, e.g. in a transfer between our
+	// own accounts. There are no proceeds in this case.
+	Fee    bool
+	Amount *big.Rat
+	// AcquiredAt is nil if the disposed amount exceeds the amount acquired in the known
+	// transactions, e.g. because the coins were received in an account not included in the
+	// report. The cost basis is zero in this case.
+	AcquiredAt *time.Time
+	DisposedAt time.Time
+	CostBasis  *big.Rat
+	Proceeds   *big.Rat
+}
+
+// Gain is the realized gain, negative for a loss.
+func (disposal *Disposal) Gain() *big.Rat {
+	return new(big.Rat).Sub(disposal.Proceeds, disposal.CostBasis)
+}
+
+// Report contains the gains realized in the reporting period and the lots held at its end.
+type Report struct {
+	Config Config
+	// Disposals are the disposals in the reporting period, ordered by time.
+	Disposals []*Disposal
+	// OpenLots are the lots held at the end of the reporting period, ordered by acquisition time.
+	OpenLots []*Lot
+	// PricesMissing is true if an exchange rate needed for the report was not available. A
+	// missing rate is treated as zero.
+	PricesMissing bool
+}
+
+// event is an acquisition or disposal.
+type event struct {
+	account *Account
+	tx      *accounts.TransactionData
+	at      time.Time
+	// acquired is true for an acquisition and false for a disposal.
+	acquired bool
+	// fee is true for the disposal of only the fee of a transaction.
+	fee bool
+	// amount is the acquired or disposed of amount in the unit of the coin, including the fee for
+	// disposals.
+	amount *big.Rat
+	// valued is the amount of the coin whose value determines the cost basis of an acquisition or
+	// the proceeds of a disposal.
+	valued *big.Rat
+}
+
+// txKey identifies a transaction across accounts of the same coin.
+type txKey struct {
+	coinCode coin.Code
+	txID     string
+}
+
+// holdings processes the events of all coins in order, tracking the lots held.
+type holdings struct {
+	rates         Rates
+	fiat          string
+	method        Method
+	pools         map[coin.Code]*pool
+	pricesMissing bool
+}
+
+func newHoldings(rates Rates, fiat string, method Method) (*holdings, error) {
+	switch method {
+	case MethodFIFO, MethodLIFO, MethodHIFO:
+	default:
+		return nil, errp.Newf("unknown cost basis method %q", method)
+	}
+	return &holdings{
+		rates:  rates,
+		fiat:   fiat,
+		method: method,
+		pools:  map[coin.Code]*pool{},
+	}, nil
+}
+
+func (holdings *holdings) value(coinCode coin.Code, amount *big.Rat, at time.Time) *big.Rat {
+	price := holdings.rates.HistoricalPriceAt(string(coinCode), holdings.fiat, at)
+	if price == 0 && amount.Sign() != 0 {
+		holdings.pricesMissing = true
+	}
+	return new(big.Rat).Mul(amount, new(big.Rat).SetFloat64(price))
+}
+
+// process adds the lot of an acquisition, or returns the disposals of a disposal.
+func (holdings *holdings) process(event *event) []*Disposal {
+	coinCode := event.account.CoinCode
+	p, ok := holdings.pools[coinCode]
+	if !ok {
+		p = &pool{}
+		holdings.pools[coinCode] = p
+	}
+	if event.acquired {
+		p.add(&Lot{
+			CoinCode:    coinCode,
+			Unit:        event.account.Unit,
+			AccountCode: event.account.Code,
+			AccountName: event.account.Name,
+			TxID:        event.tx.TxID,
+			AcquiredAt:  event.at,
+			Amount:      event.amount,
+			CostBasis:   holdings.value(coinCode, event.valued, event.at),
+		})
+		return nil
+	}
+	if event.amount.Sign() == 0 {
+		return nil
+	}
+	proceeds := new(big.Rat)
+	if event.valued.Sign() != 0 {
+		proceeds = holdings.value(coinCode, event.valued, event.at)
+	}
+	var disposals []*Disposal
+	for _, portion := range p.dispose(event.amount, holdings.method) {
+		disposal := &Disposal{
+			CoinCode:    coinCode,
+			Unit:        event.account.Unit,
+			AccountCode: event.account.Code,
+			AccountName: event.account.Name,
+			TxID:        event.tx.TxID,
+			Fee:         event.fee,
+			Amount:      portion.amount,
+			DisposedAt:  event.at,
+			CostBasis:   portion.costBasis,
+			// The proceeds are split proportionally to the disposed amounts.
+			Proceeds: new(big.Rat).Mul(
+				proceeds, new(big.Rat).Quo(portion.amount, event.amount)),
+		}
+		if portion.lot != nil {
+			acquiredAt := portion.lot.AcquiredAt
+			disposal.AcquiredAt = &acquiredAt
+		}
+		disposals = append(disposals, disposal)
+	}
+	return disposals
+}
+
+func toUnit(amount coin.Amount, decimals uint) *big.Rat {
+	return new(big.Rat).SetFrac(
+		amount.BigInt(),
+		new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
+
+// events collects the acquisitions and disposals of all accounts, ordered by time. Pending
+// transactions are ignored.
+func events(accts []*Account) []*event {
+	// Transactions sent from one of our accounts, and the amounts received by our accounts in
+	// transactions.
+	sent := map[txKey]bool{}
+	received := map[txKey]*big.Rat{}
+	for _, account := range accts {
+		for _, tx := range account.Transactions {
+			if tx.Timestamp == nil || tx.Status == accounts.TxStatusFailed {
+				continue
+			}
+			key := txKey{coinCode: account.CoinCode, txID: tx.TxID}
+			switch tx.Type {
+			case accounts.TxTypeReceive:
+				if received[key] == nil {
+					received[key] = new(big.Rat)
+				}
+				received[key].Add(received[key], toUnit(tx.Amount, account.Decimals))
+			case accounts.TxTypeSend, accounts.TxTypeSendSelf:
+				sent[key] = true
+			}
+		}
+	}
+
+	var result []*event
+	for _, account := range accts {
+		for _, tx := range account.Transactions {
+			if tx.Timestamp == nil {
+				continue
+			}
+			fee := new(big.Rat)
+			// Fees paid in another coin, e.g. ETH for ERC20 tokens, are accounted for in the
+			// account of that coin.
+			if tx.Fee != nil && !tx.FeeIsDifferentUnit {
+				fee = toUnit(*tx.Fee, account.Decimals)
+			}
+			feeEvent := &event{
+				account: account, tx: tx, at: *tx.Timestamp,
+				fee: true, amount: fee, valued: new(big.Rat),
+			}
+			key := txKey{coinCode: account.CoinCode, txID: tx.TxID}
+			amount := toUnit(tx.Amount, account.Decimals)
+			switch {
+			case tx.Status == accounts.TxStatusFailed:
+				// Only the fee was spent.
+				result = append(result, feeEvent)
+			case tx.Type == accounts.TxTypeReceive:
+				if sent[key] {
+					// Transfer from another of our accounts.
+					continue
+				}
+				result = append(result, &event{
+					account: account, tx: tx, at: *tx.Timestamp,
+					acquired: true, amount: amount, valued: amount,
+				})
+			case tx.Type == accounts.TxTypeSendSelf:
+				result = append(result, feeEvent)
+			case tx.Type == accounts.TxTypeSend:
+				// The part received by our other accounts is a transfer.
+				if transferred := received[key]; transferred != nil {
+					amount.Sub(amount, transferred)
+				}
+				if amount.Sign() <= 0 {
+					result = append(result, feeEvent)
+					continue
+				}
+				result = append(result, &event{
+					account: account, tx: tx, at: *tx.Timestamp,
+					amount: new(big.Rat).Add(amount, fee), valued: amount,
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(result, func(a, b *event) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		// Acquisitions first, so coins received and spent at the same time have a cost basis.
+		switch {
+		case a.acquired && !b.acquired:
+			return -1
+		case !a.acquired && b.acquired:
+			return 1
+		}
+		return 0
+	})
+	return result
+}
+
+// NewReport computes the report of the given accounts for the reporting period.
+func NewReport(accts []*Account, rates Rates, config Config) (*Report, error) {
+	holdings, err := newHoldings(rates, config.Fiat, config.Method)
+	if err != nil {
+		return nil, err
+	}
+	if !config.From.Before(config.Until) {
+		return nil, errp.New("the reporting period is empty")
+	}
+	if config.ValuedAt.IsZero() {
+		config.ValuedAt = config.Until
+	}
+	var disposals []*Disposal
+	for _, event := range events(accts) {
+		if !event.at.Before(config.Until) {
+			break
+		}
+		eventDisposals := holdings.process(event)
+		if !event.at.Before(config.From) {
+			disposals = append(disposals, eventDisposals...)
+		}
+	}
+
+	var openLots []*Lot
+	for _, p := range holdings.pools {
+		for _, lot := range p.lots {
+			lot.Value = holdings.value(lot.CoinCode, lot.Amount, config.ValuedAt)
+			openLots = append(openLots, lot)
+		}
+	}
+	slices.SortStableFunc(openLots, func(a, b *Lot) int {
+		if c := a.AcquiredAt.Compare(b.AcquiredAt); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.CoinCode), string(b.CoinCode))
+	})
+	return &Report{
+		Config:        config,
+		Disposals:     disposals,
+		OpenLots:      openLots,
+		PricesMissing: holdings.pricesMissing,
+	}, nil
+}
+
+// CostBasisSeries is the cost basis of the coins held over time.
+type CostBasisSeries struct {
+	// Coins contains the cost basis of each coin at each of the times.
+	Coins map[coin.Code][]*big.Rat
+	// PricesMissing is true if an exchange rate needed for the cost basis was not available. A
+	// missing rate is treated as zero.
+	PricesMissing bool
+}
+
+// NewCostBasisSeries computes the cost basis of the coins held at each of the given times, which
+// must be in ascending order. Transactions at exactly one of the times are included.
+func NewCostBasisSeries(
+	accts []*Account, rates Rates, fiat string, method Method, times []time.Time,
+) (*CostBasisSeries, error) {
+	holdings, err := newHoldings(rates, fiat, method)
+	if err != nil {
+		return nil, err
+	}
+	result := &CostBasisSeries{Coins: map[coin.Code][]*big.Rat{}}
+	for _, account := range accts {
+		result.Coins[account.CoinCode] = make([]*big.Rat, len(times))
+	}
+	allEvents := events(accts)
+	for i, at := range times {
+		for len(allEvents) > 0 && !allEvents[0].at.After(at) {
+			holdings.process(allEvents[0])
+			allEvents = allEvents[1:]
+		}
+		for coinCode, series := range result.Coins {
+			costBasis := new(big.Rat)
+			if p, ok := holdings.pools[coinCode]; ok {
+				for _, lot := range p.lots {
+					costBasis.Add(costBasis, lot.CostBasis)
+				}
+			}
+			series[i] = costBasis
+		}
+	}
+	result.PricesMissing = holdings.pricesMissing
+	return result, nil
+}
@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/stretchr/testify/require"
+)
+
+type testRates map[time.Time]float64
+
+func (rates testRates) HistoricalPriceAt(coinCode, fiat string, at time.Time) float64 {
+	if coinCode != "btc" || fiat != "USD" {
+		return 0
+	}
+	return rates[at]
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func tx(txType accounts.TxType, txID string, at time.Time, amount int64, fee int64) *accounts.TransactionData {
+	transaction := &accounts.TransactionData{
+		Type:      txType,
+		TxID:      txID,
+		Timestamp: &at,
+		Status:    accounts.TxStatusComplete,
+		Amount:    coin.NewAmountFromInt64(amount),
+	}
+	if fee != 0 {
+		feeAmount := coin.NewAmountFromInt64(fee)
+		transaction.Fee = &feeAmount
+	}
+	return transaction
+}
+
+func requireRat(t *testing.T, expected string, actual *big.Rat) {
+	t.Helper()
+	expectedRat, ok := new(big.Rat).SetString(expected)
+	require.True(t, ok)
+	require.Zero(t, expectedRat.Cmp(actual), "expected %s, got %s", expected, actual.RatString())
+}
+
+var rates = testRates{
+	date(2023, 12, 1): 10000,
+	date(2024, 1, 1):  30000,
+	date(2024, 2, 1):  20000,
+	date(2024, 3, 1):  30000,
+	date(2024, 6, 1):  15000,
+	date(2025, 1, 1):  40000,
+}
+
+// testAccounts returns two BTC accounts. Three lots of 1 BTC are received at 10000, 30000 and
+// 20000 USD. Then 0.5 BTC are transferred to the second account with a fee of 0.001 BTC, and 0.999
+// BTC are sent with a fee of 0.001 BTC at 15000 USD.
+func testAccounts() []*Account {
+	pending := tx(accounts.TxTypeSend, "pending", time.Time{}, 1e8, 1000)
+	pending.Timestamp = nil
+	return []*Account{
+		{
+			Code: "btc-1", Name: "Bitcoin", CoinCode: coin.CodeBTC, Unit: "BTC", Decimals: 8,
+			Transactions: []*accounts.TransactionData{
+				tx(accounts.TxTypeReceive, "lot0", date(2023, 12, 1), 1e8, 0),
+				tx(accounts.TxTypeReceive, "lot1", date(2024, 1, 1), 1e8, 0),
+				tx(accounts.TxTypeReceive, "lot2", date(2024, 2, 1), 1e8, 0),
+				tx(accounts.TxTypeSend, "transfer", date(2024, 3, 1), 0.5e8, 0.001e8),
+				tx(accounts.TxTypeSend, "payment", date(2024, 6, 1), 0.999e8, 0.001e8),
+				tx(accounts.TxTypeSend, "next-year", date(2025, 1, 1), 0.1e8, 1000),
+				pending,
+			},
+		},
+		{
+			Code: "btc-2", Name: "Bitcoin 2", CoinCode: coin.CodeBTC, Unit: "BTC", Decimals: 8,
+			Transactions: []*accounts.TransactionData{
+				tx(accounts.TxTypeReceive, "transfer", date(2024, 3, 1), 0.5e8, 0),
+			},
+		},
+	}
+}
+
+func testConfig(method Method) Config {
+	return Config{
+		Fiat:   "USD",
+		Method: method,
+		From:   date(2024, 1, 1),
+		Until:  date(2025, 1, 1),
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	report, err := NewReport(testAccounts(), rates, testConfig(MethodFIFO))
+	require.NoError(t, err)
+	require.False(t, report.PricesMissing)
+	require.Len(t, report.Disposals, 3)
+
+	fee := report.Disposals[0]
+	require.True(t, fee.Fee)
+	require.Equal(t, "transfer", fee.TxID)
+	requireRat(t, "0.001", fee.Amount)
+	require.Equal(t, date(2023, 12, 1), *fee.AcquiredAt)
+	requireRat(t, "10", fee.CostBasis)
+	requireRat(t, "0", fee.Proceeds)
+
+	// The fee is disposed of together with the payment, but only the payment adds to the proceeds.
+	first, second := report.Disposals[1], report.Disposals[2]
+	require.False(t, first.Fee)
+	require.Equal(t, "payment", first.TxID)
+	require.Equal(t, "Bitcoin", first.AccountName)
+	requireRat(t, "0.999", first.Amount)
+	requireRat(t, "9990", first.CostBasis)
+	requireRat(t, "14970.015", first.Proceeds)
+	require.Equal(t, date(2024, 1, 1), *second.AcquiredAt)
+	requireRat(t, "0.001", second.Amount)
+	requireRat(t, "30", second.CostBasis)
+	requireRat(t, "14.985", second.Proceeds)
+
+	require.Len(t, report.OpenLots, 2)
+	requireRat(t, "0.999", report.OpenLots[0].Amount)
+	requireRat(t, "29970", report.OpenLots[0].CostBasis)
+	requireRat(t, "39960", report.OpenLots[0].Value)
+	requireRat(t, "1", report.OpenLots[1].Amount)
+	requireRat(t, "20000", report.OpenLots[1].UnrealizedGain())
+
+	// Disposals of the previous year are not reported, but reduce the lots.
+	config := testConfig(MethodFIFO)
+	config.From, config.Until = date(2025, 1, 1), date(2026, 1, 1)
+	config.ValuedAt = date(2025, 1, 1)
+	report, err = NewReport(testAccounts(), rates, config)
+	require.NoError(t, err)
+	require.Len(t, report.Disposals, 1)
+	require.Equal(t, "next-year", report.Disposals[0].TxID)
+	requireRat(t, "0.10001", report.Disposals[0].Amount)
+	requireRat(t, "3000.3", report.Disposals[0].CostBasis)
+
+	_, err = NewReport(testAccounts(), rates, testConfig("average"))
+	require.Error(t, err)
+}
+
+func TestReportMethods(t *testing.T) {
+	tests := []struct {
+		method         Method
+		realizedGain   string
+		unrealizedGain string
+	}{
+		{MethodFIFO, "4'955.00", "29'990.00"},
+		{MethodLIFO, "-5'045.00", "39'990.00"},
+		{MethodHIFO, "-15'035.00", "49'980.00"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.method), func(t *testing.T) {
+			report, err := NewReport(testAccounts(), rates, testConfig(test.method))
+			require.NoError(t, err)
+			summary := report.Summary()
+			require.Equal(t, test.realizedGain, summary.RealizedGain)
+			require.Equal(t, test.unrealizedGain, summary.UnrealizedGain)
+			require.Len(t, summary.Coins, 1)
+			require.Equal(t, "1.001", summary.Coins[0].Disposed)
+			require.Equal(t, "1.999", summary.Coins[0].Holdings)
+			require.Equal(t, "79'960.00", summary.Coins[0].HoldingsValue)
+			require.Equal(t, "14'985.00", summary.Coins[0].Proceeds)
+		})
+	}
+}
+
+func TestReportSendToSelf(t *testing.T) {
+	accts := []*Account{{
+		Code: "btc-1", Name: "Bitcoin", CoinCode: coin.CodeBTC, Unit: "BTC", Decimals: 8,
+		Transactions: []*accounts.TransactionData{
+			tx(accounts.TxTypeReceive, "lot0", date(2023, 12, 1), 1e8, 0),
+			tx(accounts.TxTypeSendSelf, "consolidation", date(2024, 3, 1), 1e8, 0.01e8),
+			// Coins of unknown origin have no cost basis, and no rate was available for them.
+			tx(accounts.TxTypeSend, "payment", date(2024, 4, 1), 1e8, 0),
+		},
+	}}
+	report, err := NewReport(accts, rates, testConfig(MethodFIFO))
+	require.NoError(t, err)
+	require.True(t, report.PricesMissing)
+	require.Len(t, report.Disposals, 3)
+	require.True(t, report.Disposals[0].Fee)
+	requireRat(t, "0.01", report.Disposals[0].Amount)
+	requireRat(t, "-100", report.Disposals[0].Gain())
+	requireRat(t, "0.99", report.Disposals[1].Amount)
+	requireRat(t, "0.01", report.Disposals[2].Amount)
+	require.Nil(t, report.Disposals[2].AcquiredAt)
+	requireRat(t, "0", report.Disposals[2].CostBasis)
+	require.Empty(t, report.OpenLots)
+}
+
+func TestReportWriteCSV(t *testing.T) {
+	report, err := NewReport(testAccounts(), rates, testConfig(MethodFIFO))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCSV(&buf))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 1+3+2)
+	require.Equal(t,
+		[]string{"fee", "0.001", "BTC", "2023-12-01T00:00:00Z", "2024-03-01T00:00:00Z", "91",
+			"10.00", "0.00", "-10.00", "USD", "Bitcoin", "transfer"},
+		records[1])
+	require.Equal(t,
+		[]string{"holding", "1", "BTC", "2024-02-01T00:00:00Z", "", "335",
+			"20000.00", "40000.00", "20000.00", "USD", "Bitcoin", "lot2"},
+		records[5])
+}
+
+func TestNewCostBasisSeries(t *testing.T) {
+	times := []time.Time{date(2023, 11, 1), date(2024, 1, 1), date(2024, 3, 1), date(2024, 6, 1)}
+	series, err := NewCostBasisSeries(testAccounts(), rates, "USD", MethodFIFO, times)
+	require.NoError(t, err)
+	require.False(t, series.PricesMissing)
+	require.Len(t, series.Coins, 1)
+	btc := series.Coins[coin.CodeBTC]
+	require.Len(t, btc, 4)
+	requireRat(t, "0", btc[0])
+	requireRat(t, "40000", btc[1])
+	requireRat(t, "59990", btc[2])
+	requireRat(t, "49970", btc[3])
+
+	_, err = NewCostBasisSeries(testAccounts(), rates, "USD", "average", times)
+	require.Error(t, err)
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package reporting
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+)
+
+// CoinSummary sums up the gains of a coin in a report. Amounts are in the unit of the coin, fiat
+// values in the fiat currency of the report.
+type CoinSummary struct {
+	CoinCode coin.Code `json:"coinCode"`
+	Unit     string    `json:"unit"`
+	// Disposed is the amount disposed of in the reporting period, including fees.
+	Disposed     string `json:"disposed"`
+	Proceeds     string `json:"proceeds"`
+	CostBasis    string `json:"costBasis"`
+	RealizedGain string `json:"realizedGain"`
+	// Fees is the cost basis of the fees of transfers between our own accounts, which are included
+	// in CostBasis.
+	Fees string `json:"fees"`
+	// Holdings is the amount held at the end of the reporting period.
+	Holdings          string `json:"holdings"`
+	HoldingsCostBasis string `json:"holdingsCostBasis"`
+	HoldingsValue     string `json:"holdingsValue"`
+	UnrealizedGain    string `json:"unrealizedGain"`
+}
+
+// Summary sums up a report per coin.
+type Summary struct {
+	Fiat   string `json:"fiat"`
+	Method Method `json:"method"`
+	// From, Until and ValuedAt are unix timestamps, see Config.
+	From     int64          `json:"from"`
+	Until    int64          `json:"until"`
+	ValuedAt int64          `json:"valuedAt"`
+	Coins    []*CoinSummary `json:"coins"`
+	// RealizedGain and UnrealizedGain are the totals of all coins.
+	RealizedGain   string `json:"realizedGain"`
+	UnrealizedGain string `json:"unrealizedGain"`
+	PricesMissing  bool   `json:"pricesMissing"`
+}
+
+// formatAmount formats an amount of a coin without trailing zeros.
+func formatAmount(amount *big.Rat) string {
+	formatted := amount.FloatString(18)
+	return strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+}
+
+// formatFiat formats a fiat value, which can be negative for gains.
+func formatFiat(value *big.Rat, fiat string) string {
+	if value.Sign() < 0 {
+		return "-" + coin.FormatAsCurrency(new(big.Rat).Neg(value), fiat)
+	}
+	return coin.FormatAsCurrency(value, fiat)
+}
+
+// coinTotals holds the sums of a coin.
+type coinTotals struct {
+	unit                                       string
+	disposed, proceeds, costBasis, fees        big.Rat
+	holdings, holdingsCostBasis, holdingsValue big.Rat
+}
+
+// Summary sums up the report per coin.
+func (report *Report) Summary() *Summary {
+	totals := map[coin.Code]*coinTotals{}
+	get := func(coinCode coin.Code, unit string) *coinTotals {
+		t, ok := totals[coinCode]
+		if !ok {
+			t = &coinTotals{unit: unit}
+			totals[coinCode] = t
+		}
+		return t
+	}
+	for _, disposal := range report.Disposals {
+		t := get(disposal.CoinCode, disposal.Unit)
+		t.disposed.Add(&t.disposed, disposal.Amount)
+		t.proceeds.Add(&t.proceeds, disposal.Proceeds)
+		t.costBasis.Add(&t.costBasis, disposal.CostBasis)
+		if disposal.Fee {
+			t.fees.Add(&t.fees, disposal.CostBasis)
+		}
+	}
+	for _, lot := range report.OpenLots {
+		t := get(lot.CoinCode, lot.Unit)
+		t.holdings.Add(&t.holdings, lot.Amount)
+		t.holdingsCostBasis.Add(&t.holdingsCostBasis, lot.CostBasis)
+		t.holdingsValue.Add(&t.holdingsValue, lot.Value)
+	}
+
+	fiat := report.Config.Fiat
+	realizedGain := new(big.Rat)
+	unrealizedGain := new(big.Rat)
+	coins := []*CoinSummary{}
+	for coinCode, t := range totals {
+		realized := new(big.Rat).Sub(&t.proceeds, &t.costBasis)
+		unrealized := new(big.Rat).Sub(&t.holdingsValue, &t.holdingsCostBasis)
+		realizedGain.Add(realizedGain, realized)
+		unrealizedGain.Add(unrealizedGain, unrealized)
+		coins = append(coins, &CoinSummary{
+			CoinCode:          coinCode,
+			Unit:              t.unit,
+			Disposed:          formatAmount(&t.disposed),
+			Proceeds:          formatFiat(&t.proceeds, fiat),
+			CostBasis:         formatFiat(&t.costBasis, fiat),
+			RealizedGain:      formatFiat(realized, fiat),
+			Fees:              formatFiat(&t.fees, fiat),
+			Holdings:          formatAmount(&t.holdings),
+			HoldingsCostBasis: formatFiat(&t.holdingsCostBasis, fiat),
+			HoldingsValue:     formatFiat(&t.holdingsValue, fiat),
+			UnrealizedGain:    formatFiat(unrealized, fiat),
+		})
+	}
+	slices.SortFunc(coins, func(a, b *CoinSummary) int {
+		return strings.Compare(string(a.CoinCode), string(b.CoinCode))
+	})
+	return &Summary{
+		Fiat:           fiat,
+		Method:         report.Config.Method,
+		From:           report.Config.From.Unix(),
+		Until:          report.Config.Until.Unix(),
+		ValuedAt:       report.Config.ValuedAt.Unix(),
+		Coins:          coins,
+		RealizedGain:   formatFiat(realizedGain, fiat),
+		UnrealizedGain: formatFiat(unrealizedGain, fiat),
+		PricesMissing:  report.PricesMissing,
+	}
+}
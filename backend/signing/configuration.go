@@ -87,46 +87,115 @@ func (configuration *BitcoinSimple) Descriptor(net *chaincfg.Params) (string, er
 	if net == nil {
 		return "", errp.New("network is nil")
 	}
-	if configuration.KeyInfo.ExtendedPublicKey == nil {
+	keyExpression, err := descriptorKeyExpression(configuration.KeyInfo, net)
+	if err != nil {
+		return "", err
+	}
+
+	var descriptor string
+	switch configuration.ScriptType {
+	case ScriptTypeP2PKH:
+		descriptor = fmt.Sprintf("pkh(%s)", keyExpression)
+	case ScriptTypeP2WPKHP2SH:
+		descriptor = fmt.Sprintf("sh(wpkh(%s))", keyExpression)
+	case ScriptTypeP2WPKH:
+		descriptor = fmt.Sprintf("wpkh(%s)", keyExpression)
+	case ScriptTypeP2TR:
+		descriptor = fmt.Sprintf("tr(%s)", keyExpression)
+	default:
+		return "", errp.Newf("unsupported script type: %s", configuration.ScriptType)
+	}
+	return addDescriptorChecksum(descriptor)
+}
+
+// descriptorKeyExpression returns the descriptor key expression of an account-level key in the form
+// [fingerprint/path]xpub-or-tpub/<0;1>/*.
+func descriptorKeyExpression(keyInfo KeyInfo, net *chaincfg.Params) (string, error) {
+	if keyInfo.ExtendedPublicKey == nil {
 		return "", errp.New("extended public key is nil")
 	}
-	if configuration.KeyInfo.ExtendedPublicKey.IsPrivate() {
+	if keyInfo.ExtendedPublicKey.IsPrivate() {
 		return "", errp.New("extended key must be public")
 	}
-	if len(configuration.KeyInfo.RootFingerprint) != 4 {
+	if len(keyInfo.RootFingerprint) != 4 {
 		return "", errp.Newf(
 			"root fingerprint must be 4 bytes, got %d",
-			len(configuration.KeyInfo.RootFingerprint),
+			len(keyInfo.RootFingerprint),
 		)
 	}
 
-	xpub, err := hdkeychain.NewKeyFromString(configuration.KeyInfo.ExtendedPublicKey.String())
+	xpub, err := hdkeychain.NewKeyFromString(keyInfo.ExtendedPublicKey.String())
 	if err != nil {
 		return "", errp.Wrap(err, "could not clone extended public key")
 	}
 	xpub.SetNet(net)
 
-	originPath := strings.TrimPrefix(configuration.KeyInfo.AbsoluteKeypath.Encode(), "m/")
-	keyOrigin := hex.EncodeToString(configuration.KeyInfo.RootFingerprint)
+	originPath := strings.TrimPrefix(keyInfo.AbsoluteKeypath.Encode(), "m/")
+	keyOrigin := hex.EncodeToString(keyInfo.RootFingerprint)
 	if originPath != "" {
 		keyOrigin += "/" + originPath
 	}
-	keyExpression := fmt.Sprintf("[%s]%s/<0;1>/*", keyOrigin, xpub.String())
+	return fmt.Sprintf("[%s]%s/<0;1>/*", keyOrigin, xpub.String()), nil
+}
 
-	var descriptor string
-	switch configuration.ScriptType {
-	case ScriptTypeP2PKH:
-		descriptor = fmt.Sprintf("pkh(%s)", keyExpression)
-	case ScriptTypeP2WPKHP2SH:
-		descriptor = fmt.Sprintf("sh(wpkh(%s))", keyExpression)
-	case ScriptTypeP2WPKH:
-		descriptor = fmt.Sprintf("wpkh(%s)", keyExpression)
-	case ScriptTypeP2TR:
-		descriptor = fmt.Sprintf("tr(%s)", keyExpression)
-	default:
+// BitcoinMultisig represents a multisig Bitcoin/Litecoin signing configuration. The keys of all
+// cosigners are sorted (BIP-67) in the script, which corresponds to the descriptor
+// wsh(sortedmulti(threshold,key1,key2,...)). This is the multisig script supported by the BitBox02.
+type BitcoinMultisig struct {
+	// Threshold is the number of signatures required to spend.
+	Threshold uint32 `json:"threshold"`
+	// Cosigners are the account-level keys of all cosigners, including our own.
+	Cosigners []KeyInfo `json:"cosigners"`
+	// OurCosignerIndex is the index of the key of the keystore of this account in Cosigners.
+	OurCosignerIndex int        `json:"ourCosignerIndex"`
+	ScriptType       ScriptType `json:"scriptType"`
+}
+
+// OurKeyInfo returns the key of the keystore of this account.
+func (configuration *BitcoinMultisig) OurKeyInfo() KeyInfo {
+	return configuration.Cosigners[configuration.OurCosignerIndex]
+}
+
+// DerivePublicKeys derives the public keys of all cosigners at the given keypath relative to the
+// account-level keys. The keys are returned in the order of the cosigners, not sorted.
+func (configuration *BitcoinMultisig) DerivePublicKeys(
+	relativeKeypath RelativeKeypath) ([]*btcec.PublicKey, error) {
+	publicKeys := make([]*btcec.PublicKey, len(configuration.Cosigners))
+	for i, cosigner := range configuration.Cosigners {
+		derivedXpub, err := relativeKeypath.Derive(cosigner.ExtendedPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys[i], err = derivedXpub.ECPubKey()
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return publicKeys, nil
+}
+
+// Descriptor returns a descriptor for this configuration in the form
+// wsh(sortedmulti(threshold,[fingerprint/path]xpub-or-tpub/<0;1>/*,...))#checksum.
+func (configuration *BitcoinMultisig) Descriptor(net *chaincfg.Params) (string, error) {
+	if configuration == nil {
+		return "", errp.New("bitcoin configuration is nil")
+	}
+	if net == nil {
+		return "", errp.New("network is nil")
+	}
+	if configuration.ScriptType != ScriptTypeP2WSH {
 		return "", errp.Newf("unsupported script type: %s", configuration.ScriptType)
 	}
-	return addDescriptorChecksum(descriptor)
+	keyExpressions := make([]string, len(configuration.Cosigners))
+	for i, cosigner := range configuration.Cosigners {
+		keyExpression, err := descriptorKeyExpression(cosigner, net)
+		if err != nil {
+			return "", err
+		}
+		keyExpressions[i] = keyExpression
+	}
+	return addDescriptorChecksum(fmt.Sprintf("wsh(sortedmulti(%d,%s))",
+		configuration.Threshold, strings.Join(keyExpressions, ",")))
 }
 
 // Ported from Bitcoin Core PolyMod():
@@ -208,8 +277,9 @@ type EthereumSimple struct {
 type Configuration struct {
 	// Poor man's union type: only one of the below can be non-nil.
 
-	BitcoinSimple  *BitcoinSimple  `json:"bitcoinSimple,omitempty"`
-	EthereumSimple *EthereumSimple `json:"ethereumSimple,omitempty"`
+	BitcoinSimple   *BitcoinSimple   `json:"bitcoinSimple,omitempty"`
+	BitcoinMultisig *BitcoinMultisig `json:"bitcoinMultisig,omitempty"`
+	EthereumSimple  *EthereumSimple  `json:"ethereumSimple,omitempty"`
 }
 
 // NewBitcoinConfiguration creates a new configuration.
@@ -234,6 +304,49 @@ func NewBitcoinConfiguration(
 	}
 }
 
+// maxMultisigCosigners is the maximum number of keys in a standard P2WSH multisig script.
+const maxMultisigCosigners = 15
+
+// NewBitcoinMultisigConfiguration creates a new P2WSH sortedmulti configuration. An error is
+// returned if the threshold or the cosigner keys are invalid.
+func NewBitcoinMultisigConfiguration(
+	threshold uint32,
+	cosigners []KeyInfo,
+	ourCosignerIndex int,
+) (*Configuration, error) {
+	if len(cosigners) < 2 || len(cosigners) > maxMultisigCosigners {
+		return nil, errp.Newf("a multisig needs between 2 and %d cosigners", maxMultisigCosigners)
+	}
+	if threshold == 0 || int(threshold) > len(cosigners) {
+		return nil, errp.Newf("invalid threshold %d for %d cosigners", threshold, len(cosigners))
+	}
+	if ourCosignerIndex < 0 || ourCosignerIndex >= len(cosigners) {
+		return nil, errp.Newf("invalid cosigner index %d", ourCosignerIndex)
+	}
+	xpubs := map[string]struct{}{}
+	for _, cosigner := range cosigners {
+		if cosigner.ExtendedPublicKey == nil || cosigner.ExtendedPublicKey.IsPrivate() {
+			return nil, errp.New("cosigner keys must be extended public keys")
+		}
+		if len(cosigner.RootFingerprint) != 4 {
+			return nil, errp.New("cosigner root fingerprints must be 4 bytes")
+		}
+		xpub := cosigner.ExtendedPublicKey.String()
+		if _, ok := xpubs[xpub]; ok {
+			return nil, errp.New("cosigner keys must be unique")
+		}
+		xpubs[xpub] = struct{}{}
+	}
+	return &Configuration{
+		BitcoinMultisig: &BitcoinMultisig{
+			Threshold:        threshold,
+			Cosigners:        cosigners,
+			OurCosignerIndex: ourCosignerIndex,
+			ScriptType:       ScriptTypeP2WSH,
+		},
+	}, nil
+}
+
 // NewEthereumConfiguration creates a new configuration.
 func NewEthereumConfiguration(
 	rootFingerprint []byte,
@@ -256,6 +369,9 @@ func NewEthereumConfiguration(
 
 // ScriptType returns the configuration's keypath.
 func (configuration *Configuration) ScriptType() ScriptType {
+	if configuration.BitcoinMultisig != nil {
+		return configuration.BitcoinMultisig.ScriptType
+	}
 	return configuration.BitcoinSimple.ScriptType
 }
 
@@ -264,6 +380,9 @@ func (configuration *Configuration) AbsoluteKeypath() AbsoluteKeypath {
 	if configuration.BitcoinSimple != nil {
 		return configuration.BitcoinSimple.KeyInfo.AbsoluteKeypath
 	}
+	if configuration.BitcoinMultisig != nil {
+		return configuration.BitcoinMultisig.OurKeyInfo().AbsoluteKeypath
+	}
 	return configuration.EthereumSimple.KeyInfo.AbsoluteKeypath
 }
 
@@ -272,12 +391,16 @@ func (configuration *Configuration) ExtendedPublicKey() *hdkeychain.ExtendedKey
 	if configuration.BitcoinSimple != nil {
 		return configuration.BitcoinSimple.KeyInfo.ExtendedPublicKey
 	}
+	if configuration.BitcoinMultisig != nil {
+		return configuration.BitcoinMultisig.OurKeyInfo().ExtendedPublicKey
+	}
 	return configuration.EthereumSimple.KeyInfo.ExtendedPublicKey
 }
 
 // AccountNumber returns the account number as present in the BIP44 keypath.
 // The configuration keypath must be a BIP44 keypath:
 // m/purpose'/coin'/account' for Bitcoin-based coins.
+// m/48'/coin'/account'/script_type' for Bitcoin-based multisig (BIP-48).
 // m/44'/coin'/0'/0/account for Ethereum.
 // For invalid keypaths, zero is returned for the account number, along with an error.
 func (configuration *Configuration) AccountNumber() (uint16, error) {
@@ -288,6 +411,13 @@ func (configuration *Configuration) AccountNumber() (uint16, error) {
 		}
 		return uint16(keypath[2] - hdkeychain.HardenedKeyStart), nil
 	}
+	if configuration.BitcoinMultisig != nil {
+		keypath := configuration.BitcoinMultisig.OurKeyInfo().AbsoluteKeypath.ToUInt32()
+		if len(keypath) != 4 || keypath[2] < hdkeychain.HardenedKeyStart {
+			return 0, errp.Newf("unexpected bitcoin multisig keypath: %v", keypath)
+		}
+		return uint16(keypath[2] - hdkeychain.HardenedKeyStart), nil
+	}
 	if configuration.EthereumSimple != nil {
 		keypath := configuration.EthereumSimple.KeyInfo.AbsoluteKeypath.ToUInt32()
 		if len(keypath) != 5 || keypath[4] >= hdkeychain.HardenedKeyStart {
@@ -313,6 +443,13 @@ func (configuration *Configuration) String() string {
 		return fmt.Sprintf("bitcoinSimple;scriptType=%s;%s",
 			configuration.BitcoinSimple.ScriptType, configuration.BitcoinSimple.KeyInfo)
 	}
+	if configuration.BitcoinMultisig != nil {
+		return fmt.Sprintf("bitcoinMultisig;scriptType=%s;threshold=%d;cosigners=%d;%s",
+			configuration.BitcoinMultisig.ScriptType,
+			configuration.BitcoinMultisig.Threshold,
+			len(configuration.BitcoinMultisig.Cosigners),
+			configuration.BitcoinMultisig.OurKeyInfo())
+	}
 	return fmt.Sprintf("ethereumSimple;%s", configuration.EthereumSimple.KeyInfo)
 }
 
@@ -337,6 +474,9 @@ func (configs Configurations) RootFingerprint() ([]byte, error) {
 		if config.BitcoinSimple != nil {
 			return config.BitcoinSimple.KeyInfo.RootFingerprint, nil
 		}
+		if config.BitcoinMultisig != nil {
+			return config.BitcoinMultisig.OurKeyInfo().RootFingerprint, nil
+		}
 		if config.EthereumSimple != nil {
 			return config.EthereumSimple.KeyInfo.RootFingerprint, nil
 		}
@@ -352,6 +492,11 @@ func (configs Configurations) ContainsRootFingerprint(rootFingerprint []byte) bo
 				return true
 			}
 		}
+		if config.BitcoinMultisig != nil {
+			if bytes.Equal(config.BitcoinMultisig.OurKeyInfo().RootFingerprint, rootFingerprint) {
+				return true
+			}
+		}
 		if config.EthereumSimple != nil {
 			if bytes.Equal(config.EthereumSimple.KeyInfo.RootFingerprint, rootFingerprint) {
 				return true
@@ -361,6 +506,16 @@ func (configs Configurations) ContainsRootFingerprint(rootFingerprint []byte) bo
 	return false
 }
 
+// IsMultisig returns true if the configurations belong to a multisig account.
+func (configs Configurations) IsMultisig() bool {
+	for _, config := range configs {
+		if config.BitcoinMultisig != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // FindScriptType returns the index of the first configuration that is a Bitcoin configuration
 // and uses the provided script type. Returns -1 if none is found.
 func (configs Configurations) FindScriptType(scriptType ScriptType) int {
@@ -299,3 +299,83 @@ func TestBitcoinSimpleDescriptorEmptyBasePath(t *testing.T) {
 	require.True(t, found)
 	require.Equal(t, "wpkh([01020304]"+xpub.String()+"/<0;1>/*)", payload)
 }
+
+func testMultisigCosigners(t *testing.T, count int) []KeyInfo {
+	t.Helper()
+	cosigners := make([]KeyInfo, count)
+	for i := range cosigners {
+		seed := make([]byte, 32)
+		seed[0] = byte(i + 1)
+		xprv, err := hdkeychain.NewMaster(seed, &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		xpub, err := xprv.Neuter()
+		require.NoError(t, err)
+		cosigners[i] = KeyInfo{
+			RootFingerprint:   []byte{byte(i + 1), 2, 3, 4},
+			AbsoluteKeypath:   mustKeypath("m/48'/1'/0'/2'"),
+			ExtendedPublicKey: xpub,
+		}
+	}
+	return cosigners
+}
+
+func TestNewBitcoinMultisigConfiguration(t *testing.T) {
+	cosigners := testMultisigCosigners(t, 3)
+
+	cfg, err := NewBitcoinMultisigConfiguration(2, cosigners, 1)
+	require.NoError(t, err)
+	require.Equal(t, ScriptTypeP2WSH, cfg.ScriptType())
+	require.Equal(t, cosigners[1].ExtendedPublicKey, cfg.ExtendedPublicKey())
+	require.Equal(t, "m/48'/1'/0'/2'", cfg.AbsoluteKeypath().Encode())
+	require.True(t, Configurations{cfg}.IsMultisig())
+	require.True(t, Configurations{cfg}.ContainsRootFingerprint([]byte{2, 2, 3, 4}))
+	require.False(t, Configurations{cfg}.ContainsRootFingerprint([]byte{1, 2, 3, 4}))
+	accountNumber, err := cfg.AccountNumber()
+	require.NoError(t, err)
+	require.Equal(t, uint16(0), accountNumber)
+
+	jsonBytes, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	var cfgDecoded Configuration
+	require.NoError(t, json.Unmarshal(jsonBytes, &cfgDecoded))
+	require.Nil(t, cfgDecoded.BitcoinSimple)
+	require.NotNil(t, cfgDecoded.BitcoinMultisig)
+	require.Equal(t, uint32(2), cfgDecoded.BitcoinMultisig.Threshold)
+	require.Equal(t, 1, cfgDecoded.BitcoinMultisig.OurCosignerIndex)
+	require.Len(t, cfgDecoded.BitcoinMultisig.Cosigners, 3)
+	require.Equal(t, cfg.String(), cfgDecoded.String())
+
+	_, err = NewBitcoinMultisigConfiguration(2, cosigners[:1], 0)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(0, cosigners, 0)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(4, cosigners, 0)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(2, cosigners, 3)
+	require.Error(t, err)
+	_, err = NewBitcoinMultisigConfiguration(2, append(cosigners, cosigners[0]), 0)
+	require.Error(t, err)
+}
+
+func TestBitcoinMultisigDescriptor(t *testing.T) {
+	cosigners := testMultisigCosigners(t, 2)
+	cfg, err := NewBitcoinMultisigConfiguration(1, cosigners, 0)
+	require.NoError(t, err)
+
+	descriptor, err := cfg.BitcoinMultisig.Descriptor(&chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	payload, checksum, found := strings.Cut(descriptor, "#")
+	require.True(t, found)
+	require.Equal(t,
+		"wsh(sortedmulti(1,"+
+			"[01020304/48'/1'/0'/2']"+cosigners[0].ExtendedPublicKey.String()+"/<0;1>/*,"+
+			"[02020304/48'/1'/0'/2']"+cosigners[1].ExtendedPublicKey.String()+"/<0;1>/*))",
+		payload)
+	expectedChecksum, err := descriptorChecksum(payload)
+	require.NoError(t, err)
+	require.Equal(t, expectedChecksum, checksum)
+
+	cfg.BitcoinMultisig.ScriptType = ScriptTypeP2WPKH
+	_, err = cfg.BitcoinMultisig.Descriptor(&chaincfg.TestNet3Params)
+	require.Error(t, err)
+}
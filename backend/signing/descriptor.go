@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package signing
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// slip132Versions maps the SLIP-132 extended public key version bytes to the script type they
+// encode. https://github.com/satoshilabs/slips/blob/master/slip-0132.md
+var slip132Versions = map[[4]byte]struct {
+	scriptType ScriptType
+	testnet    bool
+}{
+	{0x04, 0x88, 0xb2, 0x1e}: {ScriptTypeP2PKH, false},      // xpub
+	{0x04, 0x9d, 0x7c, 0xb2}: {ScriptTypeP2WPKHP2SH, false}, // ypub
+	{0x04, 0xb2, 0x47, 0x46}: {ScriptTypeP2WPKH, false},     // zpub
+	{0x04, 0x35, 0x87, 0xcf}: {ScriptTypeP2PKH, true},       // tpub
+	{0x04, 0x4a, 0x52, 0x62}: {ScriptTypeP2WPKHP2SH, true},  // upub
+	{0x04, 0x5f, 0x1c, 0xf6}: {ScriptTypeP2WPKH, true},      // vpub
+}
+
+// isTestnet returns true if the network uses testnet extended key versions (tpub).
+func isTestnet(net *chaincfg.Params) bool {
+	return net.HDPublicKeyID == chaincfg.TestNet3Params.HDPublicKeyID
+}
+
+// keyFingerprint returns the fingerprint of an extended key, i.e. the first 32 bits of the hash160
+// of its public key. It is used as the root fingerprint of keys imported without key origin.
+func keyFingerprint(extendedPublicKey *hdkeychain.ExtendedKey) ([]byte, error) {
+	publicKey, err := extendedPublicKey.ECPubKey()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return btcutil.Hash160(publicKey.SerializeCompressed())[:4], nil
+}
+
+// NewConfigurationFromExtendedPublicKey creates a single-sig configuration from an extended public
+// key in the xpub/ypub/zpub (tpub/upub/vpub on testnet) format. If scriptType is empty, it is
+// determined by the version bytes as defined in SLIP-132, with xpub/tpub meaning P2PKH. As the key
+// origin is unknown, the key itself is treated as the root key.
+func NewConfigurationFromExtendedPublicKey(
+	encodedKey string, scriptType ScriptType, net *chaincfg.Params) (*Configuration, error) {
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, errp.Wrap(err, "Could not read an extended public key.")
+	}
+	if extendedPublicKey.IsPrivate() {
+		return nil, errp.New("An extended key is private! Only extended public keys are accepted.")
+	}
+	var version [4]byte
+	copy(version[:], extendedPublicKey.Version())
+	slip132, ok := slip132Versions[version]
+	if !ok {
+		return nil, errp.New("unknown extended public key version")
+	}
+	if slip132.testnet != isTestnet(net) {
+		return nil, errp.New("extended public key is for the wrong network")
+	}
+	if scriptType == "" {
+		scriptType = slip132.scriptType
+	}
+	switch scriptType {
+	case ScriptTypeP2PKH, ScriptTypeP2WPKHP2SH, ScriptTypeP2WPKH, ScriptTypeP2TR:
+	default:
+		return nil, errp.Newf("unsupported script type: %s", scriptType)
+	}
+	extendedPublicKey.SetNet(net)
+	rootFingerprint, err := keyFingerprint(extendedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewBitcoinConfiguration(
+		scriptType, rootFingerprint, NewEmptyAbsoluteKeypath(), extendedPublicKey), nil
+}
+
+// parseDescriptorKey parses a key expression of the form [fingerprint/path]xpub/<0;1>/*. The key
+// origin is optional. The receive chain /0/* is accepted as well, as some wallets export the
+// receive and change descriptors separately.
+func parseDescriptorKey(expression string, net *chaincfg.Params) (KeyInfo, error) {
+	var keyInfo KeyInfo
+	if strings.HasPrefix(expression, "[") {
+		end := strings.Index(expression, "]")
+		if end == -1 {
+			return keyInfo, errp.New("unterminated key origin in descriptor")
+		}
+		fingerprint, path, _ := strings.Cut(expression[1:end], "/")
+		rootFingerprint, err := hex.DecodeString(fingerprint)
+		if err != nil || len(rootFingerprint) != 4 {
+			return keyInfo, errp.New("invalid key origin fingerprint in descriptor")
+		}
+		keypath, err := NewAbsoluteKeypath("m/" + strings.ReplaceAll(path, "h", hardenedKeySymbol))
+		if err != nil {
+			return keyInfo, err
+		}
+		keyInfo.RootFingerprint = rootFingerprint
+		keyInfo.AbsoluteKeypath = keypath
+		expression = expression[end+1:]
+	}
+	encodedKey, found := strings.CutSuffix(expression, "/<0;1>/*")
+	if !found {
+		encodedKey, found = strings.CutSuffix(expression, "/0/*")
+	}
+	if !found {
+		return keyInfo, errp.New("descriptor keys must end in /<0;1>/* or /0/*")
+	}
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(encodedKey)
+	if err != nil {
+		return keyInfo, errp.Wrap(err, "Could not read an extended public key.")
+	}
+	if extendedPublicKey.IsPrivate() {
+		return keyInfo, errp.New("An extended key is private! Only extended public keys are accepted.")
+	}
+	if !extendedPublicKey.IsForNet(net) {
+		return keyInfo, errp.New("extended public key is for the wrong network")
+	}
+	keyInfo.ExtendedPublicKey = extendedPublicKey
+	if keyInfo.RootFingerprint == nil {
+		keyInfo.RootFingerprint, err = keyFingerprint(extendedPublicKey)
+		if err != nil {
+			return keyInfo, err
+		}
+		keyInfo.AbsoluteKeypath = NewEmptyAbsoluteKeypath()
+	}
+	return keyInfo, nil
+}
+
+// NewConfigurationFromDescriptor parses an output descriptor (BIP-380) into a configuration. The
+// descriptors produced by BitcoinSimple.Descriptor() and BitcoinMultisig.Descriptor() are
+// supported: pkh(), sh(wpkh()), wpkh() and tr() with a single key, and wsh(sortedmulti()). If the
+// descriptor has a checksum, it is validated.
+func NewConfigurationFromDescriptor(descriptor string, net *chaincfg.Params) (*Configuration, error) {
+	payload, checksum, hasChecksum := strings.Cut(strings.TrimSpace(descriptor), "#")
+	expectedChecksum, err := descriptorChecksum(payload)
+	if err != nil {
+		return nil, err
+	}
+	if hasChecksum && checksum != expectedChecksum {
+		return nil, errp.New("invalid descriptor checksum")
+	}
+
+	if keys, ok := unwrapDescriptor(payload, "wsh(sortedmulti(", "))"); ok {
+		threshold, keys, found := strings.Cut(keys, ",")
+		if !found {
+			return nil, errp.New("invalid multisig descriptor")
+		}
+		parsedThreshold, err := strconv.ParseUint(threshold, 10, 32)
+		if err != nil {
+			return nil, errp.New("invalid multisig threshold in descriptor")
+		}
+		var cosigners []KeyInfo
+		for _, key := range strings.Split(keys, ",") {
+			keyInfo, err := parseDescriptorKey(key, net)
+			if err != nil {
+				return nil, err
+			}
+			cosigners = append(cosigners, keyInfo)
+		}
+		return NewBitcoinMultisigConfiguration(uint32(parsedThreshold), cosigners, 0)
+	}
+
+	for _, wrapper := range []struct {
+		prefix, suffix string
+		scriptType     ScriptType
+	}{
+		{"pkh(", ")", ScriptTypeP2PKH},
+		{"sh(wpkh(", "))", ScriptTypeP2WPKHP2SH},
+		{"wpkh(", ")", ScriptTypeP2WPKH},
+		{"tr(", ")", ScriptTypeP2TR},
+	} {
+		key, ok := unwrapDescriptor(payload, wrapper.prefix, wrapper.suffix)
+		if !ok {
+			continue
+		}
+		keyInfo, err := parseDescriptorKey(key, net)
+		if err != nil {
+			return nil, err
+		}
+		return NewBitcoinConfiguration(
+			wrapper.scriptType,
+			keyInfo.RootFingerprint,
+			keyInfo.AbsoluteKeypath,
+			keyInfo.ExtendedPublicKey,
+		), nil
+	}
+	return nil, errp.New("unsupported descriptor")
+}
+
+// unwrapDescriptor returns the inner part of the descriptor if it is enclosed by prefix and suffix.
+func unwrapDescriptor(descriptor, prefix, suffix string) (string, bool) {
+	if !strings.HasPrefix(descriptor, prefix) || !strings.HasSuffix(descriptor, suffix) ||
+		len(descriptor) < len(prefix)+len(suffix) {
+		return "", false
+	}
+	return descriptor[len(prefix) : len(descriptor)-len(suffix)], true
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package signing
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigurationFromDescriptor(t *testing.T) {
+	const testnetTPub = "tpubD6NzVbkrYhZ4XVPn5tSFsjZbYcAnoDizTifqiqFEU18GcLzJMMPeYkBL1tkPT94oxPpuaWeTrMnCoXqFcAwRUn83HeM9SSwZyeAg3J62ssn"
+	net := &chaincfg.TestNet3Params
+
+	for _, test := range []struct {
+		descriptor string
+		scriptType ScriptType
+		keypath    string
+	}{
+		{"pkh([deadbeef/44'/1'/0']" + testnetTPub + "/<0;1>/*)#67jw2hwm", ScriptTypeP2PKH, "m/44'/1'/0'"},
+		{"sh(wpkh([deadbeef/49'/1'/0']" + testnetTPub + "/<0;1>/*))#pdregzl2", ScriptTypeP2WPKHP2SH, "m/49'/1'/0'"},
+		{"wpkh([deadbeef/84'/1'/0']" + testnetTPub + "/<0;1>/*)#8r4fxrzh", ScriptTypeP2WPKH, "m/84'/1'/0'"},
+		{"tr([deadbeef/86'/1'/0']" + testnetTPub + "/<0;1>/*)#7kheukj2", ScriptTypeP2TR, "m/86'/1'/0'"},
+		// Without checksum, with h as the hardened marker and only the receive chain.
+		{"wpkh([deadbeef/84h/1h/0h]" + testnetTPub + "/0/*)", ScriptTypeP2WPKH, "m/84'/1'/0'"},
+	} {
+		t.Run(test.descriptor, func(t *testing.T) {
+			cfg, err := NewConfigurationFromDescriptor(test.descriptor, net)
+			require.NoError(t, err)
+			require.NotNil(t, cfg.BitcoinSimple)
+			require.Equal(t, test.scriptType, cfg.ScriptType())
+			require.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, cfg.BitcoinSimple.KeyInfo.RootFingerprint)
+			require.Equal(t, test.keypath, cfg.AbsoluteKeypath().Encode())
+			require.Equal(t, testnetTPub, cfg.ExtendedPublicKey().String())
+		})
+	}
+
+	// Round trip of a multisig descriptor.
+	multisig, err := NewBitcoinMultisigConfiguration(2, testMultisigCosigners(t, 3), 0)
+	require.NoError(t, err)
+	descriptor, err := multisig.BitcoinMultisig.Descriptor(net)
+	require.NoError(t, err)
+	cfg, err := NewConfigurationFromDescriptor(descriptor, net)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.BitcoinMultisig)
+	parsedDescriptor, err := cfg.BitcoinMultisig.Descriptor(net)
+	require.NoError(t, err)
+	require.Equal(t, descriptor, parsedDescriptor)
+
+	// Without key origin, the key is its own root.
+	cfg, err = NewConfigurationFromDescriptor("wpkh("+testnetTPub+"/<0;1>/*)", net)
+	require.NoError(t, err)
+	require.Len(t, cfg.BitcoinSimple.KeyInfo.RootFingerprint, 4)
+	require.Equal(t, "m/", cfg.AbsoluteKeypath().Encode())
+
+	for _, invalid := range []string{
+		// Wrong checksum.
+		"wpkh([deadbeef/84'/1'/0']" + testnetTPub + "/<0;1>/*)#8r4fxrzq",
+		// Unsupported script.
+		"wsh(multi(1,[deadbeef/84'/1'/0']" + testnetTPub + "/<0;1>/*))",
+		// Missing derivation.
+		"wpkh([deadbeef/84'/1'/0']" + testnetTPub + ")",
+		// Bad fingerprint.
+		"wpkh([deadbe/84'/1'/0']" + testnetTPub + "/<0;1>/*)",
+		"",
+	} {
+		_, err := NewConfigurationFromDescriptor(invalid, net)
+		require.Error(t, err, invalid)
+	}
+
+	// Wrong network.
+	_, err = NewConfigurationFromDescriptor(
+		"wpkh([deadbeef/84'/1'/0']"+testnetTPub+"/<0;1>/*)", &chaincfg.MainNetParams)
+	require.Error(t, err)
+
+	// Private keys are rejected.
+	xprv, err := hdkeychain.NewMaster(make([]byte, 32), net)
+	require.NoError(t, err)
+	_, err = NewConfigurationFromDescriptor("wpkh("+xprv.String()+"/<0;1>/*)", net)
+	require.Error(t, err)
+}
+
+func TestNewConfigurationFromExtendedPublicKey(t *testing.T) {
+	xpub := mustXPub(t, &chaincfg.MainNetParams)
+	zpub, err := xpub.CloneWithVersion([]byte{0x04, 0xb2, 0x47, 0x46})
+	require.NoError(t, err)
+
+	cfg, err := NewConfigurationFromExtendedPublicKey(zpub.String(), "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, ScriptTypeP2WPKH, cfg.ScriptType())
+	require.Equal(t, xpub.String(), cfg.ExtendedPublicKey().String())
+	require.Equal(t, "m/", cfg.AbsoluteKeypath().Encode())
+
+	cfg, err = NewConfigurationFromExtendedPublicKey(" "+xpub.String()+"\n", "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, ScriptTypeP2PKH, cfg.ScriptType())
+
+	cfg, err = NewConfigurationFromExtendedPublicKey(xpub.String(), ScriptTypeP2TR, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, ScriptTypeP2TR, cfg.ScriptType())
+
+	_, err = NewConfigurationFromExtendedPublicKey(xpub.String(), ScriptTypeP2WSH, &chaincfg.MainNetParams)
+	require.Error(t, err)
+	_, err = NewConfigurationFromExtendedPublicKey(zpub.String(), "", &chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = NewConfigurationFromExtendedPublicKey("zpub", "", &chaincfg.MainNetParams)
+	require.Error(t, err)
+	xprv, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	_, err = NewConfigurationFromExtendedPublicKey(xprv.String(), "", &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
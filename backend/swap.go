@@ -391,7 +391,7 @@ func validateSwapAccountSupported(account accounts.Interface) error {
 	case coinpkg.CodeBTC, coinpkg.CodeLTC, coinpkg.CodeETH:
 		return nil
 	}
-	for _, token := range ERC20Tokens() {
+	for _, token := range ERC20Tokens(coinpkg.CodeETH) {
 		if coinCode == token.Code {
 			return nil
 		}
@@ -434,7 +434,7 @@ func (backend *Backend) appendERC20SwapAccounts(
 	keystore config.Keystore,
 	persistedAccount *config.Account,
 ) ([]SwapAccount, []SwapAccount) {
-	for _, token := range ERC20Tokens() {
+	for _, token := range ERC20Tokens(coinpkg.CodeETH) {
 		tokenCoin, err := backend.Coin(token.Code)
 		if err != nil {
 			backend.log.WithField("tokenCode", token.Code).WithError(err).Error("could not find ERC20 coin")
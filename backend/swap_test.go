@@ -123,8 +123,8 @@ func TestSwapBuyAccountsSortOrder(t *testing.T) {
 	ethAccount2Code, err := b.CreateAndPersistAccountConfig(coinpkg.CodeETH, "Ethereum account name 2", ks)
 	require.NoError(t, err)
 
-	tokenCodes := make([]string, 0, len(ERC20Tokens()))
-	for _, token := range ERC20Tokens() {
+	tokenCodes := make([]string, 0, len(ERC20Tokens(coinpkg.CodeETH)))
+	for _, token := range ERC20Tokens(coinpkg.CodeETH) {
 		tokenCodes = append(tokenCodes, string(token.Code))
 	}
 	slices.Sort(tokenCodes)
@@ -166,8 +166,8 @@ func TestSwapSellAccountsIncludeOnlyActiveERC20Accounts(t *testing.T) {
 	b.registerKeystore(ks)
 
 	ethAccountCode := accountsTypes.Code("v0-55555555-eth-0")
-	activeTokenCode := string(ERC20Tokens()[0].Code)
-	inactiveTokenCode := string(ERC20Tokens()[1].Code)
+	activeTokenCode := string(ERC20Tokens(coinpkg.CodeETH)[0].Code)
+	inactiveTokenCode := string(ERC20Tokens(coinpkg.CodeETH)[1].Code)
 
 	require.NoError(t, b.SetTokenActive(ethAccountCode, activeTokenCode, true))
 	require.NoError(t, b.SetTokenActive(ethAccountCode, inactiveTokenCode, false))
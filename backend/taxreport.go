@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	accountsTypes "github.com/BitBoxSwiss/bitbox-wallet-app/backend/accounts/types"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/reporting"
+	utilConfig "github.com/BitBoxSwiss/bitbox-wallet-app/util/config"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
+)
+
+// reportingAccounts returns the accounts with the given codes for reports and exports. If no codes
+// are given, all active accounts are returned, except those hidden because they are unused.
+func (backend *Backend) reportingAccounts(accountCodes []accountsTypes.Code) ([]*reporting.Account, error) {
+	var result []*reporting.Account
+	for _, account := range backend.Accounts() {
+		if len(accountCodes) == 0 {
+			if account.Config().Config.Inactive || account.Config().Config.HiddenBecauseUnused {
+				continue
+			}
+		} else if !slices.Contains(accountCodes, account.Config().Config.Code) {
+			continue
+		}
+		if account.FatalError() {
+			continue
+		}
+		if err := account.Initialize(); err != nil {
+			return nil, err
+		}
+		txs, err := account.Transactions()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &reporting.Account{
+			Code:         account.Config().Config.Code,
+			Name:         account.Config().Config.Name,
+			CoinCode:     account.Coin().Code(),
+			Unit:         account.Coin().Unit(false),
+			Decimals:     account.Coin().Decimals(false),
+			Transactions: txs,
+			Notes:        account.Notes(),
+		})
+	}
+	return result, nil
+}
+
+// taxReport computes the gains of all active accounts in the given calendar year (local time) in
+// the main fiat currency.
+func (backend *Backend) taxReport(year int, method reporting.Method) (*reporting.Report, error) {
+	if year < 2009 {
+		return nil, errp.Newf("invalid year %d", year)
+	}
+	reportingAccounts, err := backend.reportingAccounts(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fiat := backend.Config().AppConfig().Backend.MainFiat
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	until := from.AddDate(1, 0, 0)
+	// The holdings of a year that has not ended yet are valued at the latest available rates.
+	valuedAt := until
+	latest := backend.RatesUpdater().HistoryLatestTimestampFiat(backend.allCoinCodes(), fiat)
+	if !latest.IsZero() && latest.Before(valuedAt) {
+		valuedAt = latest
+	}
+	return reporting.NewReport(reportingAccounts, backend.RatesUpdater(), reporting.Config{
+		Fiat:     fiat,
+		Method:   method,
+		From:     from,
+		Until:    until,
+		ValuedAt: valuedAt,
+	})
+}
+
+// TaxReport returns the realized and unrealized gains of all active accounts in the given year,
+// using the given cost basis method.
+func (backend *Backend) TaxReport(year int, method reporting.Method) (*reporting.Summary, error) {
+	report, err := backend.taxReport(year, method)
+	if err != nil {
+		return nil, err
+	}
+	return report.Summary(), nil
+}
+
+// ExportTaxReport exports the realized and unrealized gains per lot of all active accounts in the
+// given year to a CSV file. Returns errp.ErrUserAbort if the user did not choose a file.
+func (backend *Backend) ExportTaxReport(year int, method reporting.Method) error {
+	report, err := backend.taxReport(year, method)
+	if err != nil {
+		return err
+	}
+	exportsDir, err := utilConfig.ExportsDir()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-tax-report-%d-%s.csv", time.Now().Format("2006-01-02-at-15-04-05"), year, method)
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := backend.Environment().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return errp.ErrUserAbort
+	}
+	backend.log.Infof("Export tax report to %s.", path)
+	file, err := os.Create(path)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if err := report.WriteCSV(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errp.WithStack(err)
+	}
+	return backend.Environment().SystemOpen(path)
+}
+
+// ExportTransactions exports the transactions of the given accounts, or of all active accounts if
+// none are given, to a single file in the given format. Only transactions confirmed at or after from
+// and before until are exported. A zero from or until means no bound. Returns errp.ErrUserAbort if
+// the user did not choose a file.
+func (backend *Backend) ExportTransactions(
+	format reporting.ExportFormat,
+	accountCodes []accountsTypes.Code,
+	from, until time.Time,
+) error {
+	exporter, err := reporting.LookupExporter(format)
+	if err != nil {
+		return err
+	}
+	reportingAccounts, err := backend.reportingAccounts(accountCodes)
+	if err != nil {
+		return err
+	}
+	if len(reportingAccounts) == 0 {
+		return errp.New("no accounts to export")
+	}
+	exportsDir, err := utilConfig.ExportsDir()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-export.%s",
+		time.Now().Format("2006-01-02-at-15-04-05"), format, exporter.Extension())
+	suggestedPath := filepath.Join(exportsDir, name)
+	path := backend.Environment().GetSaveFilename(suggestedPath)
+	if path == "" {
+		return errp.ErrUserAbort
+	}
+	backend.log.Infof("Export transactions to %s.", path)
+	file, err := os.Create(path)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if err := exporter.Export(file, reporting.NewEntries(reportingAccounts, from, until)); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return errp.WithStack(err)
+	}
+	return backend.Environment().SystemOpen(path)
+}
@@ -94,7 +94,13 @@ func formatETHAddress(s string) string {
 
 // FormatAddress formats an address-like string for display based on the coin code.
 func FormatAddress(code coinpkg.Code, s string) string {
-	if code == coinpkg.CodeETH || code == coinpkg.CodeSEPETH || strings.HasPrefix(string(code), "eth-erc20-") {
+	switch code {
+	case coinpkg.CodeETH, coinpkg.CodeSEPETH,
+		coinpkg.CodeARBETH, coinpkg.CodeOPETH, coinpkg.CodeBASEETH, coinpkg.CodePOL:
+		return formatETHAddress(s)
+	}
+	// ERC20 tokens, e.g. "eth-erc20-usdt" or "arbeth-erc20-usdc".
+	if strings.Contains(string(code), "-erc20-") {
 		return formatETHAddress(s)
 	}
 	return formatAddress(s)
@@ -15,19 +15,21 @@ import (
 	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/electrum"
 	btcHeaders "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/btc/headers"
 	coinpkg "github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/coin"
+	"github.com/BitBoxSwiss/bitbox-wallet-app/backend/coins/ltc"
 	"github.com/BitBoxSwiss/bitbox-wallet-app/util/errp"
 	"github.com/BitBoxSwiss/block-client-go/electrum/types"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
 )
 
-func fetchTipCheckpoint(ctx context.Context, code coinpkg.Code, log *logrus.Entry) (int, string, error) {
+func fetchTipCheckpoint(ctx context.Context, code coinpkg.Code, net *chaincfg.Params, log *logrus.Entry) (int, string, error) {
 	serverInfos := backendPkg.DefaultDevServers(code)
 	if len(serverInfos) == 0 {
 		return 0, "", errp.Newf("no dev servers for %s", code)
 	}
 
-	client := electrum.NewElectrumConnection(serverInfos, log, proxy.Direct)
+	client := electrum.NewElectrumConnection(serverInfos, net, log, proxy.Direct, false)
 	defer client.Close()
 
 	heightChan := make(chan int, 1)
@@ -100,29 +102,29 @@ func main() {
 		fatalf("failed to parse %s: %v", jsonFile, err)
 	}
 
-	update := func(code coinpkg.Code) (int, string) {
+	update := func(code coinpkg.Code, net *chaincfg.Params) (int, string) {
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
-		height, hash, err := fetchTipCheckpoint(ctx, code, log.WithField("coin", code))
+		height, hash, err := fetchTipCheckpoint(ctx, code, net, log.WithField("coin", code))
 		if err != nil {
 			fatalf("failed to fetch tip checkpoint for %s: %v", code, err)
 		}
 		return height, hash
 	}
 
-	height, hash := update(coinpkg.CodeBTC)
+	height, hash := update(coinpkg.CodeBTC, &chaincfg.MainNetParams)
 	file.BTC.Mainnet.Height = int32(height)
 	file.BTC.Mainnet.Hash = hash
 
-	height, hash = update(coinpkg.CodeTBTC)
+	height, hash = update(coinpkg.CodeTBTC, &chaincfg.TestNet3Params)
 	file.BTC.Testnet3.Height = int32(height)
 	file.BTC.Testnet3.Hash = hash
 
-	height, hash = update(coinpkg.CodeLTC)
+	height, hash = update(coinpkg.CodeLTC, &ltc.MainNetParams)
 	file.LTC.Mainnet.Height = int32(height)
 	file.LTC.Mainnet.Hash = hash
 
-	height, hash = update(coinpkg.CodeTLTC)
+	height, hash = update(coinpkg.CodeTLTC, &ltc.TestNet4Params)
 	file.LTC.Testnet4.Height = int32(height)
 	file.LTC.Testnet4.Hash = hash
 
@@ -7,7 +7,7 @@ import type { SuccessResponse } from './response';
 import type { NonEmptyArray } from '@/utils/types';
 import { apiGet, apiPost } from '@/utils/request';
 
-export type NativeCoinCode = 'btc' | 'tbtc' | 'rbtc' | 'ltc' | 'tltc' | 'eth' | 'sepeth';
+export type NativeCoinCode = 'btc' | 'tbtc' | 'rbtc' | 'ltc' | 'tltc' | 'eth' | 'sepeth' | 'arbeth' | 'opeth' | 'baseeth' | 'pol';
 
 export type AccountCode = string;
 
@@ -15,7 +15,7 @@ export type Fiat = 'AUD' | 'BRL' | 'BTC' | 'CAD' | 'CHF' | 'CNY' | 'CZK' | 'EUR'
 
 export type ConversionUnit = Fiat | 'sat';
 
-export type NativeCoinUnit = 'BTC' | 'sat' | 'LTC' | 'ETH' | 'TBTC' | 'RBTC' | 'tsat' | 'TLTC' | 'SEPETH';
+export type NativeCoinUnit = 'BTC' | 'sat' | 'LTC' | 'ETH' | 'TBTC' | 'RBTC' | 'tsat' | 'TLTC' | 'SEPETH' | 'POL';
 
 export type CoinCode = NativeCoinCode | ERC20CoinCode;
 
@@ -226,6 +226,8 @@ export const getBalance = (code: AccountCode): Promise<TBalanceResponse> => {
 export type TTransactionStatus = 'complete' | 'pending' | 'failed';
 export type TTransactionType = 'send' | 'receive' | 'send_to_self';
 
+export type TTxVerification = 'unverified' | 'verified' | 'failed';
+
 export type TTransaction = {
   addresses: string[];
   amount: TAmountWithConversions;
@@ -235,6 +237,9 @@ export type TTransaction = {
   deductedAmountAtTime: TAmountWithConversions;
   gas: number;
   nonce: number | null;
+  ensNames?: string[];
+  verification?: TTxVerification;
+  verifiedHeight?: number;
   internalID: string;
   note: string;
   numConfirmations: number;
@@ -339,6 +344,7 @@ export type TTxInput = {
   sendAll: 'yes' | 'no';
   selectedUTXOs: string[];
   paymentRequest: Slip24 | null;
+  payjoinURL?: string;
 } & (
   {
     useHighestFee: false;
@@ -353,6 +359,7 @@ export type TTxProposalResult = {
   amount: TAmountWithConversions;
   fee: TAmountWithConversions;
   recipientDisplayAddress: string;
+  recipientENSName?: string;
   success: true;
   total: TAmountWithConversions;
 } | {
@@ -391,6 +398,7 @@ export type FeeTargetCode = 'custom' | 'low' | 'economy' | 'normal' | 'high' | '
 export type TFeeTarget = {
   code: FeeTargetCode;
   feeRateInfo: string;
+  expectedConfirmationMinutes?: number;
 };
 
 export type TFeeTargetList = {
@@ -423,6 +431,16 @@ export const getUTXOs = (code: AccountCode): Promise<TUTXO[]> => {
   return apiGet(`account/${code}/utxos`);
 };
 
+export type TSPVVerification = {
+  verified: number;
+  unverified: number;
+  failedTxIDs: string[];
+};
+
+export const getSPVVerification = (code: AccountCode): Promise<TSPVVerification> => {
+  return apiGet(`account/${code}/spv-verification`);
+};
+
 type TSecureOutput = {
   hasSecureOutput: boolean;
   optional: boolean;
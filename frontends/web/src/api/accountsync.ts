@@ -50,3 +50,15 @@ export const syncdone = (
 ): TUnsubscribe => {
   return subscribeEndpoint(`account/${code}/sync-done`, cb);
 };
+
+/**
+ * Fired when the server provided a merkle proof for a transaction which
+ * does not match our headers. Receives the transaction ID.
+ * Returns a method to unsubscribe.
+ */
+export const spvVerificationFailed = (
+  code: AccountCode,
+  cb: TSubscriptionCallback<string>,
+): TUnsubscribe => {
+  return subscribeEndpoint(`account/${code}/spv-verification-failed`, cb);
+};
@@ -1,6 +1,6 @@
 // SPDX-License-Identifier: Apache-2.0
 
-import type { AccountCode, CoinCode, ScriptType } from './account';
+import type { AccountCode, ChartData, CoinCode, Fiat, ScriptType } from './account';
 import type { ERC20CoinCode } from './erc20';
 import type { FailResponse, SuccessResponse } from './response';
 import { apiGet, apiPost } from '@/utils/request';
@@ -148,6 +148,7 @@ export const exportNotes = (): Promise<(FailResponse & { aborted: boolean }) | S
 type TImportNotes = {
   accountCount: number;
   transactionCount: number;
+  skippedCount: number;
 };
 
 export const importNotes = (fileContents: ArrayBuffer): Promise<FailResponse | (SuccessResponse & { data: TImportNotes })> => {
@@ -156,3 +157,114 @@ export const importNotes = (fileContents: ArrayBuffer): Promise<FailResponse | (
     .join('');
   return apiPost('notes/import', hexString);
 };
+
+export type TCostBasisMethod = 'fifo' | 'lifo' | 'hifo';
+
+export type TTaxReportCoin = {
+  coinCode: CoinCode;
+  unit: string;
+  disposed: string;
+  proceeds: string;
+  costBasis: string;
+  realizedGain: string;
+  fees: string;
+  holdings: string;
+  holdingsCostBasis: string;
+  holdingsValue: string;
+  unrealizedGain: string;
+};
+
+export type TTaxReport = {
+  fiat: string;
+  method: TCostBasisMethod;
+  from: number;
+  until: number;
+  valuedAt: number;
+  coins: TTaxReportCoin[];
+  realizedGain: string;
+  unrealizedGain: string;
+  pricesMissing: boolean;
+};
+
+export const getTaxReport = (year: number, method: TCostBasisMethod): Promise<FailResponse | (SuccessResponse & { data: TTaxReport })> => {
+  return apiGet(`tax-report?year=${year}&method=${method}`);
+};
+
+export const exportTaxReport = (year: number, method: TCostBasisMethod): Promise<(FailResponse & { aborted: boolean }) | SuccessResponse> => {
+  return apiPost('tax-report/export', { year, method });
+};
+
+export type TExportFormat = 'koinly' | 'cointracking' | 'hledger' | 'beancount';
+
+export const getExportTransactionsFormats = (): Promise<TExportFormat[]> => {
+  return apiGet('export-transactions/formats');
+};
+
+export type TExportTransactionsArgs = {
+  format: TExportFormat;
+  // Empty exports all active accounts.
+  accountCodes: AccountCode[];
+  // Dates in the format YYYY-MM-DD, both inclusive. Empty means no bound.
+  from: string;
+  until: string;
+};
+
+export const exportTransactions = (args: TExportTransactionsArgs): Promise<(FailResponse & { aborted: boolean }) | SuccessResponse> => {
+  return apiPost('export-transactions', args);
+};
+
+export type TPortfolioGranularity = 'hour' | 'day' | 'week';
+
+export type TPortfolioSeries = {
+  coinCode?: CoinCode;
+  accountCode?: AccountCode;
+  // Account name, or the unit of the coin.
+  name: string;
+  value: ChartData;
+  // Only set for coins and the total.
+  costBasis?: ChartData;
+};
+
+export type TPortfolioChart = {
+  dataMissing: boolean;
+  fiat: Fiat;
+  method: TCostBasisMethod;
+  coins: TPortfolioSeries[];
+  accounts: TPortfolioSeries[];
+  total: TPortfolioSeries;
+};
+
+export type TPortfolioChartArgs = {
+  // Dates in the format YYYY-MM-DD, both inclusive. Empty means the whole history.
+  from: string;
+  until: string;
+  granularity: TPortfolioGranularity;
+  // Empty means the main fiat currency.
+  fiat: Fiat | '';
+  method: TCostBasisMethod;
+};
+
+export const getPortfolioChart = ({ from, until, granularity, fiat, method }: TPortfolioChartArgs): Promise<FailResponse | (SuccessResponse & { data: TPortfolioChart })> => {
+  const params = new URLSearchParams({ from, until, granularity, fiat, method });
+  return apiGet(`portfolio-chart?${params.toString()}`);
+};
+
+export type TRatesHistoryFormat = 'csv' | 'json';
+
+export type TRatesHistoryArgs = {
+  // Coin code, e.g. 'btc'.
+  coin: CoinCode;
+  fiat: Fiat;
+  format: TRatesHistoryFormat;
+};
+
+export const importRatesHistory = (args: TRatesHistoryArgs, fileContents: ArrayBuffer): Promise<FailResponse | (SuccessResponse & { count: number })> => {
+  const contents = Array.from(new Uint8Array(fileContents))
+    .map(byte => byte.toString(16).padStart(2, '0'))
+    .join('');
+  return apiPost('rates/history/import', { ...args, contents });
+};
+
+export const exportRatesHistory = (args: TRatesHistoryArgs): Promise<(FailResponse & { aborted: boolean }) | SuccessResponse> => {
+  return apiPost('rates/history/export', args);
+};
@@ -10,6 +10,7 @@ export type TKeystores = TKeystore[];
 
 export type TKeystoreFeatures = {
   supportsSendToSelf: boolean;
+  supportsForeignInputs: boolean;
 };
 
 export type TKeystoreFeaturesResponse = {
@@ -1,7 +1,8 @@
 // SPDX-License-Identifier: Apache-2.0
 
 import type { SuccessResponse } from './response';
-import { apiPost } from '@/utils/request';
+import type { CoinCode } from './account';
+import { apiGet, apiPost } from '@/utils/request';
 
 type TCertResponse = {
   success: true;
@@ -29,3 +30,32 @@ type TCheckElectrumResponse = SuccessResponse | {
 export const checkElectrum = (server: TElectrumServer): Promise<TCheckElectrumResponse> => {
   return apiPost('electrum/check', server);
 };
+
+export type TElectrumTipStatus = 'unknown' | 'ok' | 'behind' | 'ahead';
+
+export type TElectrumServerHealth = {
+  server: string;
+  tls: boolean;
+  discovered: boolean;
+  connected: boolean;
+  reachable: boolean;
+  latencyMs: number;
+  requests: number;
+  errors: number;
+  tipHeight: number;
+  tipStatus: TElectrumTipStatus;
+  lastError?: string;
+  healthy: boolean;
+};
+
+type TElectrumHealthResponse = {
+  success: true;
+  servers?: TElectrumServerHealth[];
+} | {
+  success: false;
+  errorMessage?: string;
+};
+
+export const getElectrumHealth = (code: CoinCode): Promise<TElectrumHealthResponse> => {
+  return apiGet(`electrum/health/${code}`);
+};
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+
+import type { AccountCode } from './account';
+import type { TUnsubscribe } from '@/utils/transport-common';
+import { apiGet, apiPost } from '@/utils/request';
+import { subscribeEndpoint } from './subscribe';
+
+export type TPaymentURI = {
+  accounts: {
+    name: string;
+    code: AccountCode;
+  }[] | null;
+  // set if exactly one account can pay the request
+  accountCode: AccountCode;
+  address: string;
+  // in the default unit of the coin, empty if not specified
+  amount: string;
+  note: string;
+  message: string;
+  lightning?: string;
+  payjoinURL?: string;
+};
+
+export const getPaymentURI = (): Promise<TPaymentURI | null> => {
+  return apiGet('payment-uri');
+};
+
+export const cancelPaymentURI = (): Promise<null> => {
+  return apiPost('payment-uri/cancel');
+};
+
+export const subscribePaymentURI = (
+  cb: (paymentURI: TPaymentURI | null) => void
+): TUnsubscribe => {
+  return subscribeEndpoint('payment-uri', cb);
+};
@@ -21,6 +21,7 @@ import { ConnectedApp } from './connected';
 import { Alert } from './components/alert/Alert';
 import { Aopp } from './components/aopp/aopp';
 import { Confirm } from './components/confirm/Confirm';
+import { PaymentURI } from './components/paymenturi/paymenturi';
 import { KeystoreConnectPrompt } from './components/keystoreconnectprompt';
 import { Sidebar } from './components/sidebar/sidebar';
 import { RouterWatcher } from './utils/route';
@@ -185,6 +186,7 @@ export const App = () => {
           `}>
             <WCSigningRequest />
             <Aopp />
+            <PaymentURI />
             <KeystoreConnectPrompt />
             {
               Object.entries(devices).map(([deviceID, platformName]) => {
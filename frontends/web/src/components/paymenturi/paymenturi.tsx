@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+
+import React, { useCallback, useEffect, useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { useTranslation } from 'react-i18next';
+import type { AccountCode } from '@/api/account';
+import * as paymentURIAPI from '@/api/paymenturi';
+import { View, ViewHeader, ViewContent, ViewButtons } from '@/components/view/view';
+import { Message } from '@/components/message/message';
+import { Button, Select } from '@/components/forms';
+
+/**
+ * Handles clicked payment links (BIP-21 and EIP-681 URIs) by opening the send screen of the
+ * account paying the request, pre-filled with the request. If multiple accounts can pay it, the
+ * user chooses one first.
+ */
+export const PaymentURI = () => {
+  const { t } = useTranslation();
+  const navigate = useNavigate();
+  const [paymentURI, setPaymentURI] = useState<paymentURIAPI.TPaymentURI | null>(null);
+  const [accountCode, setAccountCode] = useState<AccountCode>('');
+
+  useEffect(() => {
+    paymentURIAPI.getPaymentURI().then(setPaymentURI);
+    return paymentURIAPI.subscribePaymentURI(setPaymentURI);
+  }, []);
+
+  const openSend = useCallback((code: AccountCode, request: paymentURIAPI.TPaymentURI) => {
+    // The send screen consumes the request passed in the location state.
+    navigate(`/account/${code}/send`, { state: { paymentURI: request } });
+    paymentURIAPI.cancelPaymentURI();
+  }, [navigate]);
+
+  useEffect(() => {
+    if (paymentURI?.accountCode) {
+      openSend(paymentURI.accountCode, paymentURI);
+    } else if (paymentURI?.accounts?.length) {
+      setAccountCode(paymentURI.accounts[0].code);
+    }
+  }, [openSend, paymentURI]);
+
+  if (!paymentURI || paymentURI.accountCode) {
+    return null;
+  }
+  if (!paymentURI.accounts?.length) {
+    return (
+      <View
+        fullscreen
+        textCenter
+        verticallyCentered
+        width="580px">
+        <ViewHeader title={t('paymentURI.title')}>
+          <p>{paymentURI.address}</p>
+        </ViewHeader>
+        <ViewContent>
+          <Message type="error">
+            {t('paymentURI.noAccounts')}
+          </Message>
+        </ViewContent>
+        <ViewButtons>
+          <Button danger onClick={paymentURIAPI.cancelPaymentURI}>{t('button.dismiss')}</Button>
+        </ViewButtons>
+      </View>
+    );
+  }
+
+  const chooseAccount = (e: React.SyntheticEvent) => {
+    if (accountCode) {
+      openSend(accountCode, paymentURI);
+    }
+    e.preventDefault();
+  };
+
+  const options = paymentURI.accounts.map(account => ({
+    text: account.name,
+    value: account.code,
+  }));
+  return (
+    <form onSubmit={chooseAccount}>
+      <View
+        fullscreen
+        textCenter
+        verticallyCentered
+        width="580px">
+        <ViewHeader title={t('paymentURI.title')}>
+          <p>{paymentURI.address}</p>
+        </ViewHeader>
+        <ViewContent>
+          <Select
+            label={t('buy.info.selectLabel')}
+            options={options}
+            value={accountCode}
+            onChange={e => setAccountCode((e.target as HTMLSelectElement)?.value)}
+            id="paymentURIAccount" />
+        </ViewContent>
+        <ViewButtons>
+          <Button primary type="submit">{t('button.next')}</Button>
+          <Button secondary onClick={paymentURIAPI.cancelPaymentURI}>{t('dialog.cancel')}</Button>
+        </ViewButtons>
+      </View>
+    </form>
+  );
+};
@@ -20,6 +20,7 @@ import { useScrollIntoView } from '@/hooks/scroll-into-view';
 import { HideAmountsButton } from '@/components/hideamountsbutton/hideamountsbutton';
 import { ActionButtons } from './actionButtons';
 import { Insured } from './components/insuredtag';
+import { SPVVerificationFailed } from './components/spv-verification-failed';
 import { AccountGuide } from './guide';
 import { BuyReceiveCTA } from './info/buy-receive-cta';
 import { isBitcoinBased } from './utils';
@@ -220,6 +221,9 @@ const RemountAccount = ({
               {t('account.initializing')}
               {notSyncedText}
             </Message>
+            {isBitcoinBased(account.coinCode) && (
+              <SPVVerificationFailed className={style.status} code={code} />
+            )}
           </ContentWrapper>
           <Dialog
             open={insured && uncoveredFunds.length !== 0}
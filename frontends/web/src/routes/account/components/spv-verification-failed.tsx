@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+import { useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import { AccountCode, getSPVVerification } from '@/api/account';
+import { spvVerificationFailed } from '@/api/accountsync';
+import { Message } from '@/components/message/message';
+
+type Props = {
+  className?: string;
+  code: AccountCode;
+};
+
+/**
+ * Warns about the transactions of a BTC/LTC account for which the server
+ * provided a merkle proof that does not match the block headers.
+ */
+export const SPVVerificationFailed = ({ className, code }: Props) => {
+  const { t } = useTranslation();
+  const [failedTxIDs, setFailedTxIDs] = useState<string[]>([]);
+
+  useEffect(() => {
+    const addFailedTxIDs = (txIDs: string[]) => {
+      setFailedTxIDs(prev => [...prev, ...txIDs.filter(txID => !prev.includes(txID))]);
+    };
+    getSPVVerification(code)
+      .then(({ failedTxIDs }) => addFailedTxIDs(failedTxIDs))
+      .catch(console.error);
+    return spvVerificationFailed(code, txID => addFailedTxIDs([txID]));
+  }, [code]);
+
+  if (failedTxIDs.length === 0) {
+    return null;
+  }
+  return (
+    <Message type="warning" className={className}>
+      <strong>{t('account.spvVerificationFailed.title')}</strong>
+      <p>{t('account.spvVerificationFailed.description')}</p>
+      <ul>
+        {failedTxIDs.map(txID => (
+          <li key={txID}><code>{txID}</code></li>
+        ))}
+      </ul>
+    </Message>
+  );
+};
@@ -2,10 +2,12 @@
 
 import { useState, useRef, useEffect, useCallback, useContext } from 'react';
 import { useTranslation } from 'react-i18next';
+import { useLocation, useNavigate } from 'react-router-dom';
 import type { TSelectedUTXOs } from './utxos';
 import { useMountedRef } from '@/hooks/mount';
 import { usePrevious } from '@/hooks/previous';
 import * as accountApi from '@/api/account';
+import type { TPaymentURI } from '@/api/paymenturi';
 import { syncdone } from '@/api/accountsync';
 import { convertFromCurrency, convertToCurrency, parseExternalBtcAmount, type BtcUnit } from '@/api/coins';
 import { View, ViewContent } from '@/components/view/view';
@@ -64,6 +66,8 @@ export const Send = ({
   activeAccounts,
 }: TProps) => {
   const { t } = useTranslation();
+  const location = useLocation();
+  const navigate = useNavigate();
   const { btcUnit, defaultCurrency } = useContext(RatesContext);
   const selectedUTXOsRef = useRef<TSelectedUTXOs>({});
   const [utxoDialogActive, setUtxoDialogActive] = useState(false);
@@ -82,6 +86,8 @@ export const Send = ({
   const [isConfirming, setIsConfirming] = useState<boolean>(false);
   const [isUpdatingProposal, setIsUpdatingProposal] = useState<boolean>(false);
   const [note, setNote] = useState<string>('');
+  // BIP-78 payjoin endpoint of the recipient, from the `pj` parameter of a BIP-21 URI
+  const [payjoinURL, setPayjoinURL] = useState<string>('');
   const [customFee, setCustomFee] = useState<string>('');
   const [errorHandling, setErrorHandling] = useState<TProposalError>({});
 
@@ -110,6 +116,7 @@ export const Send = ({
     setFiatAmount('');
     setAmount('');
     setNote('');
+    setPayjoinURL('');
     setCustomFee('');
     setSendResult(undefined);
     selectedUTXOsRef.current = {};
@@ -154,9 +161,10 @@ export const Send = ({
       sendAll: (sendAll ? 'yes' : 'no'),
       selectedUTXOs: Object.keys(selectedUTXOsRef.current),
       paymentRequest: null,
+      payjoinURL,
       useHighestFee: false
     };
-  }, [recipientInput, feeTarget, sendAll, amount, customFee]);
+  }, [recipientInput, feeTarget, sendAll, amount, customFee, payjoinURL]);
 
   const convertToFiat = useCallback(async (amount: string) => {
     if (amount) {
@@ -346,54 +354,73 @@ export const Send = ({
   const handleRecipientInputChange = (input: string) => {
     setRecipientInput(input.replace(/\s/g, ''));
     setRecipientDisplayAddress('');
+    setPayjoinURL('');
     setUpdateFiat(true);
     setSelectedReceiverAccount(null);
   };
 
 
-  const parseQRResult = async (uri: string) => {
-    let qrAddress;
-    let qrAmount = '';
-    try {
-      const url = new URL(uri);
-      if (url.protocol !== 'bitcoin:' && url.protocol !== 'litecoin:' && url.protocol !== 'ethereum:') {
-        alertUser(t('invalidFormat'));
-        return;
-      }
-      qrAddress = url.pathname;
-      if (isBitcoinBased(account.coinCode)) {
-        qrAmount = url.searchParams.get('amount') || '';
-      }
-    } catch {
-      qrAddress = uri;
-    }
-    qrAddress = qrAddress.replace(/\s/g, '');
-
-    if (qrAmount) {
+  // pre-fills the form with a payment request, i.e. a scanned QR code or a clicked payment link
+  const applyPaymentRequest = async (address: string, requestAmount: string, requestPayjoinURL: string) => {
+    setPayjoinURL(requestPayjoinURL);
+    if (requestAmount) {
       if (account.coinCode === 'btc' || account.coinCode === 'tbtc') {
-        const result = await parseExternalBtcAmount(qrAmount);
+        const result = await parseExternalBtcAmount(requestAmount);
         if (result.success) {
           setAmount(result.amount);
         } else {
-          setRecipientInput(qrAddress);
+          setRecipientInput(address);
           setSendAll(false);
           setFiatAmount('');
           setErrorHandling({ amountError: t('send.error.invalidAmount') });
           return;
         }
       } else {
-        setAmount(qrAmount);
+        setAmount(requestAmount);
       }
     }
-    setRecipientInput(qrAddress);
+    setRecipientInput(address);
     setRecipientDisplayAddress('');
     setSelectedReceiverAccount(null);
     setSendAll(false);
     setFiatAmount('');
-    convertToFiat(qrAmount);
+    convertToFiat(requestAmount);
     setUpdateFiat(true);
   };
 
+  const parseQRResult = async (uri: string) => {
+    let qrAddress;
+    let qrAmount = '';
+    let qrPayjoinURL = '';
+    try {
+      const url = new URL(uri);
+      if (url.protocol !== 'bitcoin:' && url.protocol !== 'litecoin:' && url.protocol !== 'ethereum:') {
+        alertUser(t('invalidFormat'));
+        return;
+      }
+      qrAddress = url.pathname;
+      if (isBitcoinBased(account.coinCode)) {
+        qrAmount = url.searchParams.get('amount') || '';
+        qrPayjoinURL = url.searchParams.get('pj') || url.searchParams.get('req-pj') || '';
+      }
+    } catch {
+      qrAddress = uri;
+    }
+    await applyPaymentRequest(qrAddress.replace(/\s/g, ''), qrAmount, qrPayjoinURL);
+  };
+
+  // a clicked payment link, see components/paymenturi
+  const paymentURI = (location.state as { paymentURI?: TPaymentURI } | null)?.paymentURI;
+  useEffect(() => {
+    if (paymentURI) {
+      applyPaymentRequest(paymentURI.address, paymentURI.amount, paymentURI.payjoinURL || '');
+      setNote(paymentURI.note);
+      // consume the request so that it is not applied again, e.g. when navigating back
+      navigate(location.pathname, { replace: true, state: null });
+    }
+  // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [paymentURI]);
+
   const handleCoinAmountChange = (amount: string) => {
     convertToFiat(amount);
     setAmount(amount);
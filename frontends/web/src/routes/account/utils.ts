@@ -59,7 +59,16 @@ export const isBitcoinBased = (coinCode: CoinCode): boolean => {
 };
 
 export const isEthereumBased = (coinCode: CoinCode): boolean => {
-  return coinCode === 'eth' || coinCode === 'sepeth' || coinCode.startsWith('eth-erc20-');
+  switch (coinCode) {
+  case 'eth':
+  case 'sepeth':
+  case 'arbeth':
+  case 'opeth':
+  case 'baseeth':
+  case 'pol':
+    return true;
+  }
+  return coinCode.includes('-erc20-');
 };
 
 export const isMessageSigningSupported = (coinCode: CoinCode): boolean => {
@@ -42,13 +42,14 @@ export const NotesImport = () => {
 
             const result = await importNotes(await file.arrayBuffer());
             if (result.success) {
-              const { accountCount, transactionCount } = result.data;
+              const { accountCount, transactionCount, skippedCount } = result.data;
               alertUser(`${t('settings.notes.import.accountNames', {
                 count: accountCount
               })}
     ${t('settings.notes.import.transactionNotes', {
       count: transactionCount
-    })}`);
+    })}${skippedCount > 0 ? `
+    ${t('settings.notes.import.skipped', { count: skippedCount })}` : ''}`);
               fileInput.value = '';
             } else if (result.message) {
               alertUser(result.message);
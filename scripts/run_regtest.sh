@@ -52,6 +52,7 @@ docker run -v "$BITCOIN_DATADIR:/bitcoin/.bitcoin" --name="$BITCOIND_CONTAINER_N
        bitcoin/bitcoin:30.0 \
        -regtest \
        -fallbackfee=0.00001 \
+       -blockfilterindex=1 \
        -port=${BITCOIND_PORT} \
        -rpcport=${BITCOIND_RPC_PORT} \
        -rpcuser=dbb \
@@ -100,6 +101,8 @@ echo "    docker exec --user=`id -u` -it $BITCOIND_CONTAINER_NAME bitcoin-cli -r
 echo "    docker exec --user=`id -u` -it $BITCOIND_CONTAINER_NAME bitcoin-cli -regtest -datadir=/bitcoin -rpcuser=dbb -rpcpassword=dbb -rpcport=$BITCOIND_RPC_PORT getnewaddress"
 echo "    docker exec --user=`id -u` -it $BITCOIND_CONTAINER_NAME bitcoin-cli -regtest -datadir=/bitcoin -rpcuser=dbb -rpcpassword=dbb -rpcport=$BITCOIND_RPC_PORT generatetoaddress 101 <newaddress>"
 echo "    docker exec --user=`id -u` -it $BITCOIND_CONTAINER_NAME bitcoin-cli -regtest -datadir=/bitcoin -rpcuser=dbb -rpcpassword=dbb -rpcport=$BITCOIND_RPC_PORT sendtoaddress <address> <amount>"
+echo "To sync from bitcoind instead of Electrs, set backend.rbtc.bitcoind in config.json to:"
+echo "    {\"url\": \"http://127.0.0.1:${BITCOIND_RPC_PORT}\", \"user\": \"dbb\", \"password\": \"dbb\"}"
 echo "Delete headers-rbtc.bin in the app cache folder before running the BitBoxApp, otherwise it can conflict the fresh regtest chain."
 echo "Also delete all rbtc account caches in the app cache folder before running the BitBoxApp."
 echo "You may need to disable VPN, as it can prevent Electrs/bitcoin-cli from connecting to bitcoind."